@@ -26,9 +26,40 @@ var (
 	pluginDirectory = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "plugins")
 	cacheDirectory  = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "cache")
 
+	// pluginCacheDirectory and pluginDataDirectory hold each plugin's
+	// own cache and data directories. See plugins.Plugin.SetStateDirs.
+	pluginCacheDirectory = filepath.Join(cacheDirectory, "plugins")
+	pluginDataDirectory  = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "data")
+	// historyDirectory holds the history of each plugin's last runs.
+	historyDirectory = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "history")
+	// logDirectory holds the log of each plugin's runs, with all
+	// of their output.
+	logDirectory = filepath.Join(os.Getenv("HOME"), "Library", "Logs", "xbar", "plugins")
+	// globalVariablesFile holds the variables given to every plugin.
+	// See plugins.GlobalVariablePrefix.
+	globalVariablesFile = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "global.vars.json")
+	// installsFile records where installed plugins came from, so
+	// they can be checked for updates. See plugins.CheckForUpdates.
+	installsFile = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "installs.json")
+	// orderFile holds the order of the plugins in the menu bar.
+	// See plugins.LoadOrder.
+	orderFile = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "order.json")
+	// profilesFile holds the profiles, which switch between sets of
+	// plugins. See plugins.Profile.
+	profilesFile = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "profiles.json")
+
 	// concurrentIncomingURLs is the number of concurrent incoming URLs to handle at
 	// the same time.
 	concurrentIncomingURLs int = 1
+
+	// pluginStartupStagger is how long to wait between starting each
+	// plugin, so they don't all start at once.
+	pluginStartupStagger = 100 * time.Millisecond
+
+	// defaultWebviewWidth and defaultWebviewHeight are the size of
+	// webviews that don't set webvieww or webviewh.
+	defaultWebviewWidth  = 400
+	defaultWebviewHeight = 300
 )
 
 type app struct {
@@ -41,6 +72,26 @@ type app struct {
 	plugins         plugins.Plugins
 	pluginTrays     map[string]*menu.TrayMenu
 	menuParser      *MenuParser
+	// imageFetcher downloads images that plugins specify
+	// by URL.
+	imageFetcher *plugins.ImageFetcher
+	// shortcuts keeps track of the keyboard shortcuts used by
+	// plugins, and those reserved by xbar menus.
+	shortcuts *plugins.ShortcutRegistry
+	// globalShortcuts makes the plugins' shortcuts work while the
+	// menus are closed.
+	globalShortcuts *globalShortcuts
+	// settings are the user's preferences, reloaded by RefreshAll.
+	// They are replaced rather than changed, and protected by lock.
+	settings *settings
+	// updateSettingsChanged receives a signal when the settings are
+	// changed, so the update checks can use the new interval.
+	updateSettingsChanged chan struct{}
+	// notifier shows the notifications plugins ask for.
+	notifier *notifier
+	// profiles are the profiles to switch between in the menu,
+	// reloaded by RefreshAll.
+	profiles []plugins.Profile
 
 	// Verbose gets whether verbose output will be printed
 	// or not.
@@ -58,12 +109,21 @@ type app struct {
 	// lock protects menu items when RefreshAll
 	// is called.
 	// Also protects stopPluginsFunc, pluginsStoppedSignal,
-	// menuIsOpen and isDarkMode.
+	// menuIsOpen, isDarkMode and settings.
 	lock            sync.Mutex
 	stopPluginsFunc context.CancelFunc
 	// menuIsOpen keeps track of whether menus are open or not.
 	// If they're open, they will not be updated.
 	menuIsOpen bool
+	// pendingRefreshes are the plugins that refreshed while a menu
+	// was open, to be updated when it closes.
+	pendingRefreshes map[*plugins.Plugin]context.Context
+	// pausedPlugins are the Commands of the plugins the user has
+	// paused, so they stay paused when plugins are reloaded.
+	pausedPlugins map[string]bool
+	// savingPower is whether the Mac is on battery power, or in Low
+	// Power Mode, in which case plugins are throttled.
+	savingPower bool
 	// pluginsStoppedSignal is closed when plugins have stopped running.
 	pluginsStoppedSignal  chan struct{}
 	defaultTrayMenuActive bool
@@ -75,9 +135,12 @@ type app struct {
 // newApp makes a new app.
 func newApp() *app {
 	app := &app{
-		Verbose:              true,
-		menuParser:           NewMenuParser(),
-		incomingURLSemaphore: make(chan struct{}, concurrentIncomingURLs),
+		Verbose:               true,
+		menuParser:            NewMenuParser(),
+		incomingURLSemaphore:  make(chan struct{}, concurrentIncomingURLs),
+		pausedPlugins:         make(map[string]bool),
+		notifier:              newNotifier(),
+		updateSettingsChanged: make(chan struct{}, 1),
 	}
 	app.appMenu = menu.NewMenuFromItems(
 		menu.AppMenu(),
@@ -99,17 +162,46 @@ func newApp() *app {
 			menu.Text("Clear Cache", nil, app.onClearCacheMenuClicked),
 		)),
 	}
+	s, err := loadSettings(settingsFile)
+	if err != nil {
+		log.Println("failed to load settings (using defaults):", err)
+	}
+	transport, err := newNetworkTransport(*s, scutilProxySettings())
+	if err != nil {
+		log.Println("failed to set up the network (using defaults):", err)
+	} else {
+		networkTransport = transport
+	}
 	// client-side caching to cacheDirectory
-	tp := httpcache.NewTransport(diskcache.New(cacheDirectory))
+	cache := diskcache.New(cacheDirectory)
+	tp := httpcache.NewTransport(cache)
+	tp.Transport = networkTransport
+	tp.MarkCachedResponses = true
 	client := &http.Client{
-		Transport: tp,
-		Timeout:   3 * time.Minute,
+		Transport: &repositoryTransport{
+			transport: tp,
+			cached: func(req *http.Request) (*http.Response, error) {
+				return httpcache.CachedResponse(cache, req)
+			},
+			changed: app.onRepositoryChanged,
+		},
+		Timeout: 3 * time.Minute,
 	}
+	app.imageFetcher = plugins.NewImageFetcher(&http.Client{
+		Transport: networkTransport,
+		Timeout:   1 * time.Minute,
+	}, filepath.Join(cacheDirectory, "images"))
+	// reserve the shortcuts from newXbarMenu
+	app.shortcuts = plugins.NewShortcutRegistry("cmd+r", "cmd+shift+r", "cmd+e", "cmd+p", "cmd+q")
+	app.globalShortcuts = newGlobalShortcuts(app.onShortcut)
 	app.CategoriesService = NewCategoriesService(client)
 	app.PersonService = NewPersonService(client)
 	app.CommandService = NewCommandService(app.RefreshAll)
 	app.PluginsService = NewPluginsService(client, "https://xbarapp.com/docs/plugins/")
 	app.PluginsService.OnRefresh = app.RefreshAll
+	app.PluginsService.setPaused = app.setPluginPausedByPath
+	app.PluginsService.variablesChanged = app.onVariablesChanged
+	app.PluginsService.pluginStats = app.pluginStats
 	app.defaultTrayMenu = &menu.TrayMenu{
 		Label: "xbar",
 		Menu:  app.newXbarMenu(nil, false),
@@ -120,27 +212,97 @@ func newApp() *app {
 func (app *app) Start(runtime *wails.Runtime) {
 	app.setDarkMode(runtime.System.IsDarkMode())
 	runtime.Events.OnThemeChange(func(darkMode bool) {
-		// keep track of dark mode changing, and refresh all
-		// plugins if it does.
+		// keep track of dark mode changing, and refresh the
+		// plugins that use it
 		app.setDarkMode(darkMode)
-		app.RefreshAll()
+		app.onAppearanceChanged()
 	})
 	app.runtime = runtime
 	app.PluginsService.runtime = runtime
 	app.CommandService.runtime = runtime
 	app.CommandService.clearCache = app.clearCache
+	app.CommandService.getUpdateInfo = app.getUpdateInfo
 	// ensure the plugin directory is there
 	if err := os.MkdirAll(pluginDirectory, 0777); err != nil {
 		log.Println("failed to create plugin directory:", err)
 	}
 	app.RefreshAll()
+	// plugins are likely to be out of date after waking up, or
+	// moving to another network
+	go newSystemEvents(func(what string) {
+		log.Println(what + ": refreshing plugins")
+		app.requestRefreshAll()
+	}).run(context.Background())
+	go newPowerMonitor(app.setSavingPower).run(context.Background())
+	go func() {
+		control := &controlServer{
+			refresh: func(installedPluginPath string) error {
+				plugin := app.findPlugin(installedPluginPath)
+				if plugin == nil {
+					return errPluginNotFound
+				}
+				log.Println("control server: refreshing", installedPluginPath)
+				plugin.RequestRefresh()
+				return nil
+			},
+			refreshAll: func() {
+				log.Println("control server: refreshing plugins")
+				app.requestRefreshAll()
+			},
+		}
+		if err := control.listen(context.Background(), controlSocket); err != nil {
+			log.Println("control server:", err)
+		}
+	}()
 	go func() {
 		// wait before checking for updates
 		time.Sleep(10 * time.Second)
-		app.checkForUpdates(true)
+		for {
+			app.checkForUpdates(true)
+			app.waitForNextUpdateCheck(time.Now())
+		}
 	}()
 }
 
+// waitForNextUpdateCheck waits until it's time to check for updates
+// again after the check at checked, which is sooner or later if the
+// update settings change meanwhile.
+func (app *app) waitForNextUpdateCheck(checked time.Time) {
+	for {
+		wait := app.updateCheckInterval() - time.Since(checked)
+		if wait <= 0 {
+			return
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+			return
+		case <-app.updateSettingsChanged:
+			// the interval might have changed
+			timer.Stop()
+		}
+	}
+}
+
+// updateCheckInterval gets how often to check for updates.
+func (app *app) updateCheckInterval() time.Duration {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if app.settings == nil {
+		return defaultUpdateCheckInterval
+	}
+	return app.settings.updateCheckInterval()
+}
+
+// settingsChanged tells the update checks the settings have changed.
+func (app *app) settingsChanged() {
+	select {
+	case app.updateSettingsChanged <- struct{}{}:
+	default:
+		// already told
+	}
+}
+
 func (app *app) RefreshAll() {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -157,19 +319,45 @@ func (app *app) RefreshAll() {
 		app.defaultTrayMenuActive = false
 	}
 	for _, plugin := range app.plugins {
-		m, ok := app.pluginTrays[plugin.Command]
-		if !ok {
-			continue
-		}
+		app.shortcuts.Unregister(plugin)
+	}
+	app.globalShortcuts.set(app.shortcuts.Shortcuts())
+	for _, m := range app.pluginTrays {
+		// including the trays of any splits
 		app.runtime.Menu.DeleteTrayMenu(m)
 	}
 	var err error
-	app.plugins, err = plugins.Dir(pluginDirectory)
+	app.settings, err = loadSettings(settingsFile)
+	if err != nil {
+		log.Println("failed to load settings (using defaults):", err)
+	}
+	app.settingsChanged()
+	env, err := pluginEnv(*app.settings, os.Environ(), loginShellEnv)
+	if err != nil {
+		log.Println("failed to set up the environment for plugins:", err)
+	}
+	globalVariables, err := plugins.LoadGlobalVariables(globalVariablesFile)
+	if err != nil {
+		log.Println("failed to load global variables:", err)
+	}
+	// plugins' own variables take precedence over global ones
+	env = append(env, plugins.GlobalVariablesEnv(globalVariables)...)
+	app.plugins, err = plugins.Dirs(app.settings.pluginDirectories(pluginDirectory)...)
 	if err != nil {
 		app.onErr(err.Error())
 		return
 	}
+	order, err := plugins.LoadOrder(orderFile)
+	if err != nil {
+		log.Println("failed to load the order of the plugins:", err)
+	}
+	app.plugins.Sort(order)
+	app.profiles, err = plugins.LoadProfiles(profilesFile)
+	if err != nil {
+		log.Println("failed to load profiles:", err)
+	}
 	app.pluginTrays = make(map[string]*menu.TrayMenu)
+	app.pendingRefreshes = make(map[*plugins.Plugin]context.Context)
 	if len(app.plugins) == 0 {
 		// no plugins - use default
 		app.runtime.Menu.SetTrayMenu(app.defaultTrayMenu)
@@ -180,6 +368,24 @@ func (app *app) RefreshAll() {
 		// Setup plugin
 		plugin.OnCycle = app.onCycle
 		plugin.OnRefresh = app.onRefresh
+		plugin.OnWebview = app.onWebview
+		plugin.OnConfirm = app.onConfirm
+		plugin.OnConfigRequired = app.onConfigRequired
+		plugin.OnNotify = app.onNotify
+		plugin.ImageFetcher = app.imageFetcher
+		plugin.Shortcuts = app.shortcuts
+		plugin.TerminalApp = app.settings.Terminal
+		plugin.Jitter = float64(app.settings.Jitter) / 100
+		plugin.SetStateDirs(pluginCacheDirectory, pluginDataDirectory)
+		plugin.HistoryFile = plugins.HistoryFilename(historyDirectory, plugin.Command)
+		plugin.LogFile = plugins.LogFilename(logDirectory, plugin.Command)
+		plugin.LogMaxBytes = app.settings.LogMaxBytes
+		plugin.LogMaxFiles = app.settings.LogMaxFiles
+		plugin.SetThrottle(app.throttle())
+		plugin.Env = env
+		if app.pausedPlugins[plugin.Command] {
+			plugin.Pause()
+		}
 		if app.Verbose {
 			//plugin.Stdout = os.Stdout
 			plugin.Stderr = os.Stderr
@@ -188,7 +394,7 @@ func (app *app) RefreshAll() {
 		app.pluginTrays[plugin.Command] = &menu.TrayMenu{
 			Label:   " ",
 			Menu:    app.newXbarMenu(plugin, false),
-			OnOpen:  app.onMenuWillOpen,
+			OnOpen:  app.onPluginMenuWillOpen(plugin),
 			OnClose: app.onMenuDidClose,
 		}
 		app.runtime.Menu.SetTrayMenu(app.pluginTrays[plugin.Command])
@@ -199,7 +405,7 @@ func (app *app) RefreshAll() {
 	go func() {
 		// use stopPluginsFunc to allow the context to
 		// be canceled - which will kill all running plugin subprocesses.
-		app.plugins.Run(ctx)
+		app.plugins.RunStaggered(ctx, app.settings.startupConcurrency(), pluginStartupStagger)
 		close(app.pluginsStoppedSignal)
 	}()
 }
@@ -215,14 +421,29 @@ func (app *app) onMenuWillOpen() {
 	app.menuIsOpen = true
 }
 
+// onPluginMenuWillOpen gets the OnOpen callback for the plugin's
+// menus, which also lets the plugin refresh (if it refreshes on open).
+func (app *app) onPluginMenuWillOpen(p *plugins.Plugin) func() {
+	return func() {
+		app.onMenuWillOpen()
+		p.MenuOpened()
+	}
+}
+
 func (app *app) onMenuDidClose() {
 	app.lock.Lock()
 	defer app.lock.Unlock()
 	app.menuIsOpen = false
+	// catch up on refreshes that happened while it was open
+	for p, ctx := range app.pendingRefreshes {
+		app.updatePluginTrays(ctx, p)
+	}
+	app.pendingRefreshes = make(map[*plugins.Plugin]context.Context)
 }
 
 // onErr adds a single menu showing the specified error
 // string.
+// The lock must be held.
 func (app *app) onErr(err string) {
 	if app.defaultTrayMenuActive {
 		app.runtime.Menu.DeleteTrayMenu(app.defaultTrayMenu)
@@ -249,6 +470,9 @@ func (app *app) Shutdown() {
 	}
 }
 
+// newXbarMenu makes the xbar menu items, shown after the items of
+// the plugin (if there is one).
+// The lock must be held.
 func (app *app) newXbarMenu(plugin *plugins.Plugin, asSubmenu bool) *menu.Menu {
 	var items []*menu.MenuItem
 	if plugin != nil {
@@ -260,6 +484,14 @@ func (app *app) newXbarMenu(plugin *plugins.Plugin, asSubmenu bool) *menu.Menu {
 				app.onPluginsRefreshMenuClicked(ctx, plugin)
 			},
 		})
+		items = append(items, &menu.MenuItem{
+			Type:    menu.CheckboxType,
+			Label:   "Pause",
+			Checked: plugin.Paused(),
+			Click: func(_ *menu.CallbackData) {
+				app.setPluginPaused(plugin, !plugin.Paused())
+			},
+		})
 	}
 	items = append(items, &menu.MenuItem{
 		Type:        menu.TextType,
@@ -274,15 +506,7 @@ func (app *app) newXbarMenu(plugin *plugins.Plugin, asSubmenu bool) *menu.Menu {
 			Label:       "Open plugin…",
 			Accelerator: keys.CmdOrCtrl("e"),
 			Click: func(_ *menu.CallbackData) {
-				app.runtime.Window.Show()
-				rel, err := filepath.Rel(pluginDirectory, plugin.Command)
-				if err != nil {
-					log.Println(err)
-					return
-				}
-				app.runtime.Events.Emit("xbar.browser.openInstalledPlugin", map[string]string{
-					"path": rel,
-				})
+				app.openInstalledPlugin(plugin)
 			},
 		})
 	}
@@ -297,6 +521,9 @@ func (app *app) newXbarMenu(plugin *plugins.Plugin, asSubmenu bool) *menu.Menu {
 		Label: "Open plugin folder…",
 		Click: app.onOpenPluginsFolderClicked,
 	})
+	if len(app.profiles) > 0 {
+		items = append(items, app.newProfileMenuItem())
+	}
 	items = append(items, menu.Separator())
 	items = append(items, &menu.MenuItem{
 		Type:     menu.TextType,
@@ -308,6 +535,14 @@ func (app *app) newXbarMenu(plugin *plugins.Plugin, asSubmenu bool) *menu.Menu {
 		Label: "Check for updates…",
 		Click: app.onCheckForUpdatesMenuClick,
 	})
+	items = append(items, app.newUpdateChannelMenuItem())
+	if previousVersion, err := app.newUpdater().PreviousVersion(); err == nil && previousVersion != "" {
+		items = append(items, &menu.MenuItem{
+			Type:  menu.TextType,
+			Label: fmt.Sprintf("Roll back to %s…", previousVersion),
+			Click: app.onRollbackUpdateMenuClick,
+		})
+	}
 	items = append(items, menu.Separator())
 	items = append(items, &menu.MenuItem{
 		Type:        menu.TextType,
@@ -334,6 +569,42 @@ func (app *app) createDefaultMenus() {
 	}
 }
 
+// openInstalledPlugin shows the plugin in the app, where its
+// variables can be edited.
+func (app *app) openInstalledPlugin(p *plugins.Plugin) {
+	app.runtime.Window.Show()
+	rel, err := filepath.Rel(pluginDirectory, p.Command)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if strings.HasPrefix(rel, "..") {
+		// the app only manages the plugins in the plugin directory
+		log.Println(p.CleanFilename(), "is in another plugin directory:", filepath.Dir(p.Command))
+		return
+	}
+	app.runtime.Events.Emit("xbar.browser.openInstalledPlugin", map[string]string{
+		"path": rel,
+	})
+}
+
+// onNotify is fired when a plugin wants to show a notification.
+func (app *app) onNotify(_ context.Context, p *plugins.Plugin, notification plugins.Notification) {
+	title := notification.Title
+	if title == "" {
+		title = p.CleanFilename()
+	}
+	if err := app.notifier.notify(p.Command, title, notification.Body); err != nil {
+		log.Println(p.CleanFilename(), "notification:", err)
+	}
+}
+
+// onConfigRequired is fired when a plugin needs configuring.
+func (app *app) onConfigRequired(_ context.Context, p *plugins.Plugin) {
+	log.Println(p.CleanFilename(), "needs configuring")
+	app.openInstalledPlugin(p)
+}
+
 func (app *app) onPluginsMenuClicked(_ *menu.CallbackData) {
 	app.runtime.Window.Show()
 }
@@ -350,6 +621,116 @@ func (app *app) onPluginsRefreshMenuClicked(_ *menu.CallbackData, p *plugins.Plu
 	p.TriggerRefresh()
 }
 
+// setPluginPaused pauses or resumes the plugin, keeping its menu as
+// it is while it is paused.
+func (app *app) setPluginPaused(p *plugins.Plugin, paused bool) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if paused {
+		app.pausedPlugins[p.Command] = true
+		p.Pause()
+	} else {
+		delete(app.pausedPlugins, p.Command)
+		p.Resume()
+	}
+	// update the Pause item
+	if app.menuIsOpen {
+		app.pendingRefreshes[p] = context.Background()
+		return
+	}
+	app.updatePluginTrays(context.Background(), p)
+}
+
+// setPluginPausedByPath pauses or resumes the installed plugin at
+// the path (relative to the plugin directory).
+func (app *app) setPluginPausedByPath(installedPluginPath string, paused bool) error {
+	plugin := app.findPlugin(installedPluginPath)
+	if plugin == nil {
+		return fmt.Errorf("plugin %s is not running", installedPluginPath)
+	}
+	app.setPluginPaused(plugin, paused)
+	return nil
+}
+
+// onVariablesChanged refreshes the plugin (if it's running) with its
+// new variables, leaving the other plugins alone.
+func (app *app) onVariablesChanged(installedPluginPath string) {
+	plugin := app.findPlugin(installedPluginPath)
+	if plugin == nil {
+		return
+	}
+	plugin.VariablesChanged()
+	plugin.RequestRefresh()
+}
+
+// pluginStats gets the stats of the running plugins.
+func (app *app) pluginStats() []PluginStats {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	stats := make([]PluginStats, 0, len(app.plugins))
+	for _, p := range app.plugins {
+		path, err := filepath.Rel(pluginDirectory, p.Command)
+		if err != nil || strings.HasPrefix(path, "..") {
+			// in another plugin directory
+			path = p.Command
+		}
+		stats = append(stats, PluginStats{
+			Path:  path,
+			Stats: p.Stats(),
+		})
+	}
+	return stats
+}
+
+// findPlugin gets the running plugin with the path (relative to the
+// plugin directory), or nil if there isn't one.
+func (app *app) findPlugin(installedPluginPath string) *plugins.Plugin {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	for _, p := range app.plugins {
+		rel, err := filepath.Rel(pluginDirectory, p.Command)
+		if err == nil && rel == installedPluginPath {
+			return p
+		}
+	}
+	return nil
+}
+
+// setSavingPower throttles the plugins when the Mac should save power.
+func (app *app) setSavingPower(saving bool) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if saving {
+		log.Println("saving power: throttling plugins")
+	}
+	app.savingPower = saving
+	for _, plugin := range app.plugins {
+		plugin.SetThrottle(app.throttle())
+	}
+}
+
+// throttle gets how many times slower plugins should refresh.
+// The lock must be held.
+func (app *app) throttle() float64 {
+	if !app.savingPower || app.settings == nil {
+		return 1
+	}
+	return app.settings.batteryThrottle()
+}
+
+// requestRefreshAll asks all the running plugins (apart from paused
+// ones) to refresh, without reloading them like RefreshAll does.
+func (app *app) requestRefreshAll() {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	for _, plugin := range app.plugins {
+		if plugin.Paused() {
+			continue
+		}
+		plugin.RequestRefresh()
+	}
+}
+
 func (app *app) onPluginsRefreshAllMenuClicked(_ *menu.CallbackData) {
 	app.RefreshAll()
 }
@@ -358,6 +739,16 @@ func (app *app) onBrowserRefreshMenuClicked(_ *menu.CallbackData) {
 	app.runtime.Events.Emit("xbar.browser.refresh")
 }
 
+// onRepositoryChanged refreshes the plugin browser when the plugin
+// repository has changed since it was cached.
+func (app *app) onRepositoryChanged() {
+	if app.runtime == nil {
+		// not started yet
+		return
+	}
+	app.runtime.Events.Emit("xbar.browser.refresh")
+}
+
 func (app *app) onBrowserHardRefreshMenuClicked(_ *menu.CallbackData) {
 	app.clearCache(true)
 	app.runtime.Events.Emit("xbar.browser.refresh")
@@ -367,6 +758,164 @@ func (app *app) onCheckForUpdatesMenuClick(_ *menu.CallbackData) {
 	app.CheckForUpdates()
 }
 
+func (app *app) onRollbackUpdateMenuClick(_ *menu.CallbackData) {
+	app.RollbackUpdate()
+}
+
+// RollbackUpdate puts back the version of xbar from before the last
+// update, and restarts.
+func (app *app) RollbackUpdate() {
+	app.lock.Lock()
+	u := app.newUpdater()
+	app.lock.Unlock()
+	previousVersion, err := u.PreviousVersion()
+	if err != nil || previousVersion == "" {
+		if err == nil {
+			err = update.ErrNoPreviousVersion
+		}
+		app.runtime.Dialog.Message(&dialog.MessageDialog{
+			Type:         dialog.ErrorDialog,
+			Title:        "Roll back failed",
+			Message:      err.Error(),
+			Buttons:      []string{"OK"},
+			CancelButton: "OK",
+		})
+		return
+	}
+	switch app.runtime.Dialog.Message(&dialog.MessageDialog{
+		Type:          dialog.QuestionDialog,
+		Title:         "Roll back xbar?",
+		Message:       fmt.Sprintf("Go back to xbar %s (you have %s)?\n\nxbar will restart.", previousVersion, version),
+		Buttons:       []string{"Roll back", "Cancel"},
+		DefaultButton: "Roll back",
+		CancelButton:  "Cancel",
+	}) {
+	case "Roll back":
+		// continue
+	case "Cancel":
+		return
+	}
+	if err := u.Rollback(); err != nil {
+		app.runtime.Dialog.Message(&dialog.MessageDialog{
+			Type:         dialog.ErrorDialog,
+			Title:        "Roll back failed",
+			Message:      err.Error(),
+			Buttons:      []string{"OK"},
+			CancelButton: "OK",
+		})
+		return
+	}
+	// don't offer this version again automatically
+	app.saveUpdateSettings(func(s *settings) {
+		s.SkipUpdate = version
+	})
+	if err := u.Restart(); err != nil {
+		app.runtime.Dialog.Message(&dialog.MessageDialog{
+			Type:         dialog.InfoDialog,
+			Title:        "Roll back successful",
+			Message:      "Please restart xbar for the changes to take effect.",
+			Buttons:      []string{"OK"},
+			CancelButton: "OK",
+		})
+	}
+}
+
+// newUpdateChannelMenuItem makes the menu to pick which releases xbar
+// updates to.
+// The lock must be held.
+func (app *app) newUpdateChannelMenuItem() *menu.MenuItem {
+	current := app.updateChannel()
+	channelMenu := &menu.Menu{}
+	for _, channel := range update.Channels {
+		channel := channel
+		channelMenu.Items = append(channelMenu.Items, &menu.MenuItem{
+			Type:    menu.CheckboxType,
+			Label:   strings.Title(string(channel)),
+			Checked: channel == current,
+			Click: func(_ *menu.CallbackData) {
+				app.setUpdateChannel(channel)
+			},
+		})
+	}
+	return &menu.MenuItem{
+		Type:    menu.TextType,
+		Label:   "Update channel",
+		SubMenu: channelMenu,
+	}
+}
+
+// newProfileMenuItem makes the menu to switch between profiles.
+// The lock must be held.
+func (app *app) newProfileMenuItem() *menu.MenuItem {
+	var current string
+	if app.settings != nil {
+		current = app.settings.Profile
+	}
+	profileMenu := &menu.Menu{}
+	for _, profile := range app.profiles {
+		name := profile.Name
+		profileMenu.Items = append(profileMenu.Items, &menu.MenuItem{
+			Type:    menu.CheckboxType,
+			Label:   name,
+			Checked: name == current,
+			Click: func(_ *menu.CallbackData) {
+				if err := app.PluginsService.ApplyProfile(name); err != nil {
+					app.lock.Lock()
+					app.onErr(err.Error())
+					app.lock.Unlock()
+				}
+			},
+		})
+	}
+	return &menu.MenuItem{
+		Type:    menu.TextType,
+		Label:   "Profile",
+		SubMenu: profileMenu,
+	}
+}
+
+// saveUpdateSettings changes and saves the settings, logging if it
+// fails.
+func (app *app) saveUpdateSettings(fn func(s *settings)) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	s, err := updateSettings(settingsFile, fn)
+	if err != nil {
+		log.Println("failed to save settings:", err)
+		return
+	}
+	app.settings = s
+	app.settingsChanged()
+}
+
+// updateChannel gets the channel xbar updates from.
+// The lock must be held.
+func (app *app) updateChannel() update.Channel {
+	if app.settings == nil {
+		return update.ChannelStable
+	}
+	return app.settings.updateChannel()
+}
+
+// updateFeed gets the URL of the appcast to check for updates in, or
+// empty to use GitHub releases.
+// The lock must be held.
+func (app *app) updateFeed() string {
+	if app.settings == nil {
+		return ""
+	}
+	return app.settings.UpdateFeed
+}
+
+// setUpdateChannel saves the channel to update from, and checks for
+// updates in it, so switching back to stable can roll back a beta.
+func (app *app) setUpdateChannel(channel update.Channel) {
+	app.saveUpdateSettings(func(s *settings) {
+		s.UpdateChannel = string(channel)
+	})
+	app.checkForUpdates(false)
+}
+
 func (app *app) onClearCacheMenuClicked(_ *menu.CallbackData) {
 	app.clearCache(false)
 }
@@ -423,6 +972,21 @@ func (app *app) handleIncomingURL(url string) {
 	}
 	switch incomingURL.Action {
 	case "openPlugin":
+		// links can come from anywhere, so check the plugin is
+		// wanted before showing it
+		switch app.runtime.Dialog.Message(&dialog.MessageDialog{
+			Type:          dialog.QuestionDialog,
+			Title:         "Open plugin?",
+			Message:       fmt.Sprintf("A link wants to open the plugin %s from:\n\n%s\n\nOnly install plugins you trust.", incomingURL.Params.Get("path"), incomingURL.pluginSource()),
+			Buttons:       []string{"Open", "Cancel"},
+			DefaultButton: "Open",
+			CancelButton:  "Cancel",
+		}) {
+		case "Open":
+			// continue
+		case "Cancel":
+			return
+		}
 		app.runtime.Window.Show()
 		app.runtime.Events.Emit("xbar.incomingURL.openPlugin", map[string]string{
 			"path": incomingURL.Params.Get("path"),
@@ -434,11 +998,23 @@ func (app *app) handleIncomingURL(url string) {
 				log.Println("incoming URL: rel for this failed", err)
 				continue
 			}
-			if rel == incomingURL.Params.Get("path") {
+			if incomingURL.matchesPlugin(rel) {
 				plugin.TriggerRefresh()
 				return
 			}
 		}
+		log.Println("incoming URL: refreshPlugin: no matching plugin")
+	case "notify":
+		title := incomingURL.Params.Get("title")
+		if title == "" {
+			title = "xbar"
+		}
+		// limited like the plugin it says it's from, or together
+		// with the other links that don't say
+		source := "xbar://notify/" + incomingURL.Params.Get("plugin")
+		if err := app.notifier.notify(source, title, incomingURL.Params.Get("body")); err != nil {
+			log.Println("incoming URL: notify:", err)
+		}
 	default:
 		log.Printf("incoming URL: skipping, unknown action %q\n", incomingURL.Action)
 	}
@@ -446,28 +1022,134 @@ func (app *app) handleIncomingURL(url string) {
 
 // onRefresh is fired when a plugin needs to refresh.
 func (app *app) onRefresh(ctx context.Context, p *plugins.Plugin, _ error) {
+	// the plugin's shortcuts were registered when it refreshed
+	app.globalShortcuts.set(app.shortcuts.Shortcuts())
 	app.lock.Lock()
 	defer app.lock.Unlock()
 	if app.menuIsOpen {
 		// don't update while the menu is open
 		// as this can cause a crash
+		if app.pendingRefreshes != nil {
+			app.pendingRefreshes[p] = ctx
+		}
 		return
 	}
+	app.updatePluginTrays(ctx, p)
+}
+
+// onShortcut is called when the global shortcut of a plugin item is
+// pressed, and runs the item's action.
+func (app *app) onShortcut(shortcut string) {
+	app.lock.Lock()
+	menuIsOpen := app.menuIsOpen
+	app.lock.Unlock()
+	if menuIsOpen {
+		// the menu item's key equivalent handles it
+		return
+	}
+	item := app.shortcuts.Item(shortcut)
+	if item == nil {
+		return
+	}
+	if action := item.Action(); action != nil {
+		action(context.Background())
+	}
+}
+
+// updatePluginTrays updates the menu bar items for the plugin with
+// its latest Items.
+// The lock must be held.
+func (app *app) updatePluginTrays(ctx context.Context, p *plugins.Plugin) {
 	tray, ok := app.pluginTrays[p.Command]
 	if !ok {
 		log.Println("no item - probably refreshing", tray.Label)
 		return
 	}
 	app.updateLabel(tray, p)
-	pluginMenu := app.menuParser.ParseItems(ctx, p.Items.ExpandedItems)
+	tray.Menu = app.pluginMenu(ctx, p, p.Items.ExpandedItems)
+	app.runtime.Menu.SetTrayMenu(tray)
+	app.updateSplitTrays(ctx, p)
+}
+
+// pluginMenu makes the dropdown menu for a plugin's items, along
+// with the xbar menu items.
+func (app *app) pluginMenu(ctx context.Context, p *plugins.Plugin, items []*plugins.Item) *menu.Menu {
+	pluginMenu := app.menuParser.ParseItems(ctx, items)
 	if pluginMenu == nil {
-		pluginMenu = app.newXbarMenu(p, false)
-	} else {
-		pluginMenu.Append(menu.Separator())
-		pluginMenu.Merge(app.newXbarMenu(p, true))
+		return app.newXbarMenu(p, false)
+	}
+	pluginMenu.Append(menu.Separator())
+	pluginMenu.Merge(app.newXbarMenu(p, true))
+	return pluginMenu
+}
+
+// splitTrayKey gets the pluginTrays key for one of the plugin's
+// Items.Splits.
+func splitTrayKey(p *plugins.Plugin, split int) string {
+	return fmt.Sprintf("%s#split%d", p.Command, split)
+}
+
+// updateSplitTrays adds, updates and removes the menu bar items for
+// the plugin's Items.Splits.
+func (app *app) updateSplitTrays(ctx context.Context, p *plugins.Plugin) {
+	for i, split := range p.Items.Splits {
+		key := splitTrayKey(p, i)
+		tray, ok := app.pluginTrays[key]
+		if !ok {
+			tray = &menu.TrayMenu{
+				Label:   " ",
+				OnOpen:  app.onPluginMenuWillOpen(p),
+				OnClose: app.onMenuDidClose,
+			}
+			app.pluginTrays[key] = tray
+		}
+		if cycleItem := p.SplitCycleItem(i); cycleItem != nil {
+			app.menuParser.ParseTitle(tray, cycleItem)
+		}
+		tray.Menu = app.pluginMenu(ctx, p, split.ExpandedItems)
+		app.runtime.Menu.SetTrayMenu(tray)
+	}
+	// remove trays for splits that are no longer in the output
+	for i := len(p.Items.Splits); ; i++ {
+		key := splitTrayKey(p, i)
+		tray, ok := app.pluginTrays[key]
+		if !ok {
+			break
+		}
+		app.runtime.Menu.DeleteTrayMenu(tray)
+		delete(app.pluginTrays, key)
 	}
-	tray.Menu = pluginMenu
-	app.runtime.Menu.SetTrayMenu(tray)
+}
+
+// onWebview is fired when an item with a webview is clicked, and
+// opens the page in a popover window under the mouse.
+func (app *app) onWebview(_ context.Context, url string, width, height int) {
+	if width <= 0 {
+		width = defaultWebviewWidth
+	}
+	if height <= 0 {
+		height = defaultWebviewHeight
+	}
+	if err := openWebview(url, width, height); err != nil {
+		log.Println("webview:", err, "(opening in the browser instead)")
+		if err := app.CommandService.OpenURL(url); err != nil {
+			log.Println("webview:", err)
+		}
+	}
+}
+
+// onConfirm is fired when an item with confirm=true is clicked,
+// and asks the user whether to continue.
+func (app *app) onConfirm(_ context.Context, title, message string) bool {
+	answer := app.runtime.Dialog.Message(&dialog.MessageDialog{
+		Type:          dialog.QuestionDialog,
+		Title:         title,
+		Message:       message,
+		Buttons:       []string{"Continue", "Cancel"},
+		DefaultButton: "Cancel",
+		CancelButton:  "Cancel",
+	})
+	return answer == "Continue"
 }
 
 func (app *app) onCycle(_ context.Context, p *plugins.Plugin) {
@@ -487,6 +1169,16 @@ func (app *app) onCycle(_ context.Context, p *plugins.Plugin) {
 	if app.updateLabel(tray, p) {
 		app.runtime.Menu.UpdateTrayMenuLabel(tray)
 	}
+	for i := range p.Items.Splits {
+		splitTray, ok := app.pluginTrays[splitTrayKey(p, i)]
+		if !ok {
+			continue
+		}
+		if cycleItem := p.SplitCycleItem(i); cycleItem != nil {
+			app.menuParser.ParseTitle(splitTray, cycleItem)
+			app.runtime.Menu.UpdateTrayMenuLabel(splitTray)
+		}
+	}
 }
 
 func (app *app) updateLabel(tray *menu.TrayMenu, p *plugins.Plugin) bool {
@@ -494,35 +1186,78 @@ func (app *app) updateLabel(tray *menu.TrayMenu, p *plugins.Plugin) bool {
 	if cycleItem == nil {
 		return false
 	}
-	tray.Label = cycleItem.DisplayText()
-	tray.Image = cycleItem.Params.Image
-	tray.FontName = cycleItem.Params.Font
-	tray.FontSize = cycleItem.Params.Size
-	tray.RGBA = cycleItem.Params.Color
-	tray.Disabled = cycleItem.Params.Disabled
-	if cycleItem.Params.TemplateImage != "" {
-		tray.Image = cycleItem.Params.TemplateImage
-		tray.MacTemplateImage = true
-	}
-
+	app.menuParser.ParseTitle(tray, cycleItem)
 	return true
 }
 
-// checkForUpdates looks to see if there's a newer version of xbar,
-// downloads it and installs it.
-// If passive is true, it won't complain if it fails.
-func (app *app) checkForUpdates(passive bool) {
-	u := update.Updater{
+// newUpdater makes the Updater that updates xbar.
+// The lock must be held.
+func (app *app) newUpdater() *update.Updater {
+	return &update.Updater{
 		CurrentVersion: version,
 		//LatestReleaseGitHubEndpoint: "https://api.github.com/repos/matryer/xbar/releases/latest",
 		LatestReleaseGitHubEndpoint: "https://api.github.com/repos/matryer/xbar/releases/latest",
-		Client:                      &http.Client{Timeout: 10 * time.Minute},
+		ReleasesGitHubEndpoint:      "https://api.github.com/repos/matryer/xbar/releases",
+		AppcastURL:                  app.updateFeed(),
+		Channel:                     app.updateChannel(),
+		PublicKey:                   updatePublicKey,
+		Client:                      &http.Client{Transport: networkTransport, Timeout: 10 * time.Minute},
 		SelectAsset: func(release update.Release, asset update.Asset) bool {
 			// get the .tar.gz file
 			return strings.HasSuffix(asset.Name, ".tar.gz")
 		},
 		DownloadBytesLimit: 10_741_824, // 10MB
+		KeepPrevious:       true,
+	}
+}
+
+// getUpdateInfo checks for a newer version of xbar, and gets the
+// release notes since this version.
+func (app *app) getUpdateInfo() (*UpdateInfo, error) {
+	app.lock.Lock()
+	u := app.newUpdater()
+	app.lock.Unlock()
+	latest, hasUpdate, err := u.HasUpdate()
+	if err != nil {
+		return nil, err
 	}
+	info := &UpdateInfo{
+		CurrentVersion: u.CurrentVersion,
+		LatestVersion:  latest.TagName,
+		HasUpdate:      hasUpdate,
+	}
+	if hasUpdate {
+		info.Releases, err = u.ReleaseNotes(*latest)
+		if err != nil {
+			// just the latest release notes are shown
+			log.Println("failed to get release notes:", err)
+		}
+	}
+	return info, nil
+}
+
+// releaseNotesSummary gets the notes of the releases for the update
+// prompt, cut short so it fits on screen.
+func releaseNotesSummary(releases []update.Release) string {
+	var notes []string
+	for _, release := range releases {
+		body := update.PlainReleaseNotes(release.Body, 400)
+		if body == "" {
+			continue
+		}
+		notes = append(notes, release.TagName+"\n"+body)
+	}
+	return update.PlainReleaseNotes(strings.Join(notes, "\n\n"), 1200)
+}
+
+// checkForUpdates looks to see if there's a newer version of xbar,
+// downloads it and installs it.
+// If passive is true, it won't complain if it fails.
+func (app *app) checkForUpdates(passive bool) {
+	app.lock.Lock()
+	u := app.newUpdater()
+	current := app.settings
+	app.lock.Unlock()
 	latest, hasUpdate, err := u.HasUpdate()
 	if err != nil {
 		log.Println("failed to check for updates:", err)
@@ -537,6 +1272,10 @@ func (app *app) checkForUpdates(passive bool) {
 		}
 		return
 	}
+	if passive && current != nil && !current.offerUpdate(latest.TagName, time.Now()) {
+		// they skipped this version, or want reminding later
+		return
+	}
 	if !hasUpdate {
 		// they are using the latest version
 		if !passive {
@@ -550,17 +1289,59 @@ func (app *app) checkForUpdates(passive bool) {
 		}
 		return
 	}
+	managed, err := u.Managed()
+	if err != nil {
+		log.Println("failed to check how xbar was installed:", err)
+	}
+	if managed != nil {
+		// xbar can't update itself, so just say how to
+		switch app.runtime.Dialog.Message(&dialog.MessageDialog{
+			Type:          dialog.InfoDialog,
+			Title:         "xbar update available",
+			Message:       fmt.Sprintf("xbar %s is now available (you have %s).\n\n%s\n\n%s", latest.TagName, u.CurrentVersion, managed.Reason, managed.Instructions),
+			Buttons:       []string{"OK", "Skip this version"},
+			DefaultButton: "OK",
+			CancelButton:  "OK",
+		}) {
+		case "OK":
+			app.saveUpdateSettings(func(s *settings) {
+				s.RemindUpdateAfter = time.Now().Add(s.updateCheckInterval())
+			})
+		case "Skip this version":
+			app.saveUpdateSettings(func(s *settings) {
+				s.SkipUpdate = latest.TagName
+			})
+		}
+		return
+	}
+	message := fmt.Sprintf("xbar %s is now available (you have %s).", latest.TagName, u.CurrentVersion)
+	releases, err := u.ReleaseNotes(*latest)
+	if err != nil {
+		// just the latest release notes are shown
+		log.Println("failed to get release notes:", err)
+	}
+	if notes := releaseNotesSummary(releases); notes != "" {
+		message += "\n\nWhat's new:\n\n" + notes
+	}
 	switch app.runtime.Dialog.Message(&dialog.MessageDialog{
 		Type:          dialog.QuestionDialog,
 		Title:         "Update xbar?",
-		Message:       fmt.Sprintf("xbar %s is now available (you have %s).\n\nWould you like to update?", latest.TagName, u.CurrentVersion),
-		Buttons:       []string{"Update", "Later"},
+		Message:       message + "\n\nWould you like to update?",
+		Buttons:       []string{"Update", "Later", "Skip this version"},
 		DefaultButton: "Update",
 		CancelButton:  "Later",
 	}) {
 	case "Update":
 		// continue
 	case "Later":
+		app.saveUpdateSettings(func(s *settings) {
+			s.RemindUpdateAfter = time.Now().Add(s.updateCheckInterval())
+		})
+		return
+	case "Skip this version":
+		app.saveUpdateSettings(func(s *settings) {
+			s.SkipUpdate = latest.TagName
+		})
 		return
 	}
 	_, err = u.Update()
@@ -598,29 +1379,43 @@ func tickOS() {
 
 // setDarkMode sets the current dark mode state.
 // It updates app.isDarkMode and also sets the
-// appropriate environment variables.
+// appropriate environment variables (see appearanceEnv).
 func (app *app) setDarkMode(darkmode bool) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
 	app.isDarkMode = darkmode
-	var err error
-	if darkmode {
-		err = os.Setenv("BitBarDarkMode", "true") // backwards compatibility
-		if err != nil {
+	app.menuParser.DarkMode = darkmode
+	for _, keyValue := range appearanceEnv(darkmode, readDefault, os.Environ()) {
+		kv := strings.SplitN(keyValue, "=", 2)
+		if err := os.Setenv(kv[0], kv[1]); err != nil {
 			log.Println("os.Setenv", err)
 		}
-		err = os.Setenv("XBARDarkMode", "true")
-		if err != nil {
-			log.Println("os.Setenv", err)
+	}
+}
+
+// onAppearanceChanged refreshes the plugins that use the system
+// appearance, and redraws the others, for their light and dark
+// colors.
+func (app *app) onAppearanceChanged() {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	ctx := context.Background()
+	for _, p := range app.plugins {
+		if p.AppearanceSensitive {
+			p.AppearanceChanged()
+			p.RequestRefresh()
+			continue
 		}
-	} else {
-		err = os.Setenv("BitBarDarkMode", "false") // backwards compatibility
-		if err != nil {
-			log.Println("os.Setenv", err)
+		if _, ok := app.pluginTrays[p.Command]; !ok {
+			// not shown yet
+			continue
 		}
-		err = os.Setenv("XBARDarkMode", "false")
-		if err != nil {
-			log.Println("os.Setenv", err)
+		if app.menuIsOpen {
+			if app.pendingRefreshes != nil {
+				app.pendingRefreshes[p] = ctx
+			}
+			continue
 		}
+		app.updatePluginTrays(ctx, p)
 	}
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// accentColors are the names of the macOS accent colors, by their
+// AppleAccentColor default.
+var accentColors = map[string]string{
+	"-1": "graphite",
+	"0":  "red",
+	"1":  "orange",
+	"2":  "yellow",
+	"3":  "green",
+	"4":  "blue",
+	"5":  "purple",
+	"6":  "pink",
+}
+
+// appearanceEnv gets the environment variables that describe the
+// system appearance to plugins, using readDefault to read the user's
+// defaults, on top of the environment in base (like os.Environ()).
+// LANG is only set if base doesn't have it, since apps opened from
+// the Finder don't get one.
+func appearanceEnv(darkMode bool, readDefault func(domain, key string) (string, error), base []string) []string {
+	env := []string{
+		"XBAR_DARK_MODE=" + strconv.FormatBool(darkMode),
+		"XBARDarkMode=" + strconv.FormatBool(darkMode),
+		"BitBarDarkMode=" + strconv.FormatBool(darkMode), // backwards compatibility
+	}
+	// without the default, the accent color is multicolor, which
+	// is blue
+	accentColor := "blue"
+	if value, err := readDefault("-g", "AppleAccentColor"); err == nil && accentColors[value] != "" {
+		accentColor = accentColors[value]
+	}
+	env = append(env, "XBAR_ACCENT_COLOR="+accentColor)
+	locale, err := readDefault("-g", "AppleLocale")
+	if err != nil || locale == "" {
+		return env
+	}
+	// like en_GB@rg=usz
+	locale = strings.SplitN(locale, "@", 2)[0]
+	env = append(env, "XBAR_LOCALE="+locale)
+	if lookupEnv(base, "LANG") == "" {
+		env = append(env, "LANG="+locale+".UTF-8")
+	}
+	return env
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestAppearanceEnv(t *testing.T) {
+	is := is.New(t)
+
+	defaults := map[string]string{
+		"AppleAccentColor": "5",
+		"AppleLocale":      "en_GB@rg=usz",
+	}
+	readDefault := func(domain, key string) (string, error) {
+		is.Equal(domain, "-g")
+		value, ok := defaults[key]
+		if !ok {
+			return "", errors.New("does not exist")
+		}
+		return value, nil
+	}
+	env := appearanceEnv(true, readDefault, nil)
+	is.Equal(lookupEnv(env, "XBAR_DARK_MODE"), "true")
+	is.Equal(lookupEnv(env, "XBARDarkMode"), "true")
+	is.Equal(lookupEnv(env, "BitBarDarkMode"), "true")
+	is.Equal(lookupEnv(env, "XBAR_ACCENT_COLOR"), "purple")
+	is.Equal(lookupEnv(env, "XBAR_LOCALE"), "en_GB")
+	is.Equal(lookupEnv(env, "LANG"), "en_GB.UTF-8")
+
+	// LANG is left alone if there is one
+	env = appearanceEnv(false, readDefault, []string{"LANG=fr_FR.UTF-8"})
+	is.Equal(lookupEnv(env, "XBAR_DARK_MODE"), "false")
+	is.Equal(lookupEnv(env, "LANG"), "")
+
+	delete(defaults, "AppleAccentColor")
+	delete(defaults, "AppleLocale")
+	env = appearanceEnv(false, readDefault, nil)
+	is.Equal(lookupEnv(env, "XBAR_ACCENT_COLOR"), "blue") // multicolor
+	is.Equal(lookupEnv(env, "XBAR_LOCALE"), "")
+}
@@ -7,5 +7,6 @@ echo ""
 echo "  xbar ${VERSION}..."
 echo ""
 echo -n $VERSION > .version
+echo -n "${XBAR_UPDATE_PUBLIC_KEY}" > .update-public-key
 
 wails build
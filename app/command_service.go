@@ -6,15 +6,30 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"github.com/matryer/xbar/pkg/update"
 	"github.com/pkg/errors"
 	wails "github.com/wailsapp/wails/v2"
 )
 
 // CommandService provides window service.
 type CommandService struct {
-	runtime    *wails.Runtime
-	OnRefresh  func()
-	clearCache func(passive bool)
+	runtime       *wails.Runtime
+	OnRefresh     func()
+	clearCache    func(passive bool)
+	getUpdateInfo func() (*UpdateInfo, error)
+}
+
+// UpdateInfo describes the latest version of xbar.
+type UpdateInfo struct {
+	// CurrentVersion is the version that is running.
+	CurrentVersion string `json:"currentVersion"`
+	// LatestVersion is the newest version in the update channel.
+	LatestVersion string `json:"latestVersion"`
+	// HasUpdate is whether LatestVersion should be installed.
+	HasUpdate bool `json:"hasUpdate"`
+	// Releases are the releases since CurrentVersion, newest first,
+	// with their notes.
+	Releases []update.Release `json:"releases,omitempty"`
 }
 
 // NewCommandService makes a new CommandService.
@@ -29,6 +44,12 @@ func (c *CommandService) ClearCache() {
 	c.clearCache(false)
 }
 
+// GetUpdateInfo checks for a newer version of xbar, and gets what's
+// new in it.
+func (c *CommandService) GetUpdateInfo() (*UpdateInfo, error) {
+	return c.getUpdateInfo()
+}
+
 // RefreshAllPlugins refreshes all plugins.
 func (c *CommandService) RefreshAllPlugins() {
 	c.OnRefresh()
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// controlSocket is the unix socket that the control server listens
+// on. Only the current user can use it.
+var controlSocket = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "xbar.sock")
+
+// errPluginNotFound is returned by controlServer.refresh when there
+// is no such plugin.
+var errPluginNotFound = errors.New("plugin not found")
+
+// controlServer lets other programs (like scripts and git hooks)
+// control xbar over HTTP:
+//
+//	POST /refresh           - refreshes all plugins
+//	POST /refresh/{path}    - refreshes the plugin (path is relative
+//	                          to the plugin directory)
+type controlServer struct {
+	// refresh refreshes the plugin at the path, returning
+	// errPluginNotFound if there isn't one.
+	refresh func(installedPluginPath string) error
+	// refreshAll refreshes all plugins.
+	refreshAll func()
+}
+
+func (s *controlServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "refresh":
+		s.refreshAll()
+	case strings.HasPrefix(path, "refresh/"):
+		installedPluginPath := strings.TrimPrefix(path, "refresh/")
+		err := s.refresh(installedPluginPath)
+		if err == errPluginNotFound {
+			http.Error(w, fmt.Sprintf("plugin %s not found", installedPluginPath), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// listen serves the control server on the unix socket until ctx is
+// done.
+func (s *controlServer) listen(ctx context.Context, socket string) error {
+	// clean up after a previous run
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove old socket")
+	}
+	if err := os.MkdirAll(filepath.Dir(socket), 0777); err != nil {
+		return errors.Wrap(err, "make socket directory")
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return errors.Wrap(err, "listen")
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		l.Close()
+		return errors.Wrap(err, "chmod socket")
+	}
+	srv := &http.Server{Handler: s}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Close(); err != nil {
+			log.Println("control server: close:", err)
+		}
+	}()
+	if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestControlServer(t *testing.T) {
+	is := is.New(t)
+
+	var refreshed []string
+	var refreshedAll int
+	s := &controlServer{
+		refresh: func(installedPluginPath string) error {
+			if installedPluginPath != "weather.1m.sh" {
+				return errPluginNotFound
+			}
+			refreshed = append(refreshed, installedPluginPath)
+			return nil
+		},
+		refreshAll: func() {
+			refreshedAll++
+		},
+	}
+	for _, test := range []struct {
+		method, path string
+		status       int
+	}{
+		{method: http.MethodPost, path: "/refresh/weather.1m.sh", status: http.StatusAccepted},
+		{method: http.MethodPost, path: "/refresh/missing.1m.sh", status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/refresh", status: http.StatusAccepted},
+		{method: http.MethodGet, path: "/refresh", status: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: "/unknown", status: http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		is.Equal(w.Code, test.status) // test.path
+	}
+	is.Equal(refreshed, []string{"weather.1m.sh"})
+	is.Equal(refreshedAll, 1)
+}
+
+func TestControlServerListen(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-control-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	socket := filepath.Join(dir, "xbar.sock")
+	refreshed := make(chan string, 1)
+	s := &controlServer{
+		refresh: func(installedPluginPath string) error {
+			refreshed <- installedPluginPath
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		is.NoErr(s.listen(ctx, socket))
+	}()
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		resp, err = client.Post("http://xbar/refresh/todo.10s.sh", "", nil)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond) // not listening yet
+	}
+	is.NoErr(err)
+	resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusAccepted)
+	is.Equal(<-refreshed, "todo.10s.sh")
+	info, err := os.Stat(socket)
+	is.NoErr(err)
+	is.Equal(info.Mode().Perm(), os.FileMode(0600))
+}
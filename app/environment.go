@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultPluginPaths are added to the end of the PATH for plugins,
+// because apps opened from the Finder get a minimal PATH, without
+// the places Homebrew installs things.
+var defaultPluginPaths = []string{
+	"/usr/local/bin",
+	"/opt/homebrew/bin",
+	"/opt/homebrew/sbin",
+}
+
+// pluginEnv builds the environment variables for plugins from the
+// settings, on top of the environment in base (like os.Environ()).
+// loginShellEnv gets the environment from the user's login shell, and
+// is only called if the settings ask for it. If it fails, the error
+// is returned along with the rest of the environment.
+func pluginEnv(s settings, base []string, loginShellEnv func() ([]string, error)) ([]string, error) {
+	var env []string
+	var err error
+	if s.LoginShell {
+		var shellEnv []string
+		shellEnv, err = loginShellEnv()
+		if err != nil {
+			err = errors.Wrap(err, "login shell")
+		}
+		env = append(env, shellEnv...)
+		base = append(base, shellEnv...)
+	}
+	path := append([]string{}, s.Path...)
+	path = append(path, filepath.SplitList(lookupEnv(base, "PATH"))...)
+	path = append(path, defaultPluginPaths...)
+	env = append(env, "PATH="+strings.Join(uniquePaths(path), string(filepath.ListSeparator)))
+	keys := make([]string, 0, len(s.Env))
+	for key := range s.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+s.Env[key])
+	}
+	return env, err
+}
+
+// lookupEnv gets the last value of the variable in env, or an
+// empty string if it isn't there.
+func lookupEnv(env []string, key string) string {
+	var value string
+	for _, keyValue := range env {
+		if strings.HasPrefix(keyValue, key+"=") {
+			value = strings.TrimPrefix(keyValue, key+"=")
+		}
+	}
+	return value
+}
+
+// uniquePaths gets the paths, with ~ expanded, and without empty
+// paths or repeats.
+func uniquePaths(paths []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, path := range paths {
+		if strings.HasPrefix(path, "~/") {
+			path = filepath.Join(os.Getenv("HOME"), path[2:])
+		}
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		unique = append(unique, path)
+	}
+	return unique
+}
+
+var (
+	loginShellEnvOnce   sync.Once
+	loginShellEnvResult []string
+	loginShellEnvErr    error
+)
+
+// loginShellEnv gets the environment variables set up by the user's
+// login shell (like in ~/.zprofile), running it the first time only.
+func loginShellEnv() ([]string, error) {
+	loginShellEnvOnce.Do(func() {
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/zsh"
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, shell, "-l", "-c", "env -0").Output()
+		if err != nil {
+			loginShellEnvErr = err
+			return
+		}
+		for _, keyValue := range bytes.Split(out, []byte{0}) {
+			if bytes.IndexByte(keyValue, '=') > 0 {
+				loginShellEnvResult = append(loginShellEnvResult, string(keyValue))
+			}
+		}
+	})
+	return loginShellEnvResult, loginShellEnvErr
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestPluginEnv(t *testing.T) {
+	is := is.New(t)
+
+	base := []string{"HOME=/Users/mat", "PATH=/usr/bin:/bin"}
+	noLoginShell := func() ([]string, error) {
+		t.Fatal("login shell should not be used")
+		return nil, nil
+	}
+	env, err := pluginEnv(settings{}, base, noLoginShell)
+	is.NoErr(err)
+	is.Equal(env, []string{
+		"PATH=/usr/bin:/bin:/usr/local/bin:/opt/homebrew/bin:/opt/homebrew/sbin",
+	})
+
+	s := settings{
+		Path: []string{"~/.asdf/shims", "/usr/local/bin"},
+		Env: map[string]string{
+			"LANG":   "en_GB.UTF-8",
+			"GITHUB": "matryer",
+		},
+	}
+	env, err = pluginEnv(s, base, noLoginShell)
+	is.NoErr(err)
+	home := os.Getenv("HOME")
+	is.Equal(env, []string{
+		"PATH=" + filepath.Join(home, ".asdf/shims") + ":/usr/local/bin:/usr/bin:/bin:/opt/homebrew/bin:/opt/homebrew/sbin",
+		"GITHUB=matryer",
+		"LANG=en_GB.UTF-8",
+	})
+
+	s = settings{LoginShell: true}
+	env, err = pluginEnv(s, base, func() ([]string, error) {
+		return []string{"PATH=/opt/homebrew/bin:/usr/bin", "EDITOR=vim"}, nil
+	})
+	is.NoErr(err)
+	is.Equal(env, []string{
+		"PATH=/opt/homebrew/bin:/usr/bin",
+		"EDITOR=vim",
+		"PATH=/opt/homebrew/bin:/usr/bin:/usr/local/bin:/opt/homebrew/sbin",
+	})
+
+	// the rest of the environment still works without the login shell
+	env, err = pluginEnv(s, base, func() ([]string, error) {
+		return nil, errors.New("no shell")
+	})
+	is.True(err != nil)
+	is.Equal(env, []string{
+		"PATH=/usr/bin:/bin:/usr/local/bin:/opt/homebrew/bin:/opt/homebrew/sbin",
+	})
+}
@@ -21,6 +21,13 @@ const backend = {
       "ClearCache": () => {
         return window.backend.main.CommandService.ClearCache();
       },
+      /**
+       * GetUpdateInfo
+       * @returns {Promise<any|Error>}  - Go Type: *main.UpdateInfo
+       */
+      "GetUpdateInfo": () => {
+        return window.backend.main.CommandService.GetUpdateInfo();
+      },
       /**
        * OpenFile
        * @param {string} arg1 - Go Type: string
@@ -78,6 +85,45 @@ const backend = {
       },
     }
     "PluginsService": {
+      /**
+       * ApplyProfile
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "ApplyProfile": (arg1) => {
+        return window.backend.main.PluginsService.ApplyProfile(arg1);
+      },
+      /**
+       * CheckForUpdates
+       * @returns {Promise<Array.<any>|Error>}  - Go Type: []plugins.PluginUpdate
+       */
+      "CheckForUpdates": () => {
+        return window.backend.main.PluginsService.CheckForUpdates();
+      },
+      /**
+       * DeleteGlobalVariable
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "DeleteGlobalVariable": (arg1) => {
+        return window.backend.main.PluginsService.DeleteGlobalVariable(arg1);
+      },
+      /**
+       * DeleteProfile
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "DeleteProfile": (arg1) => {
+        return window.backend.main.PluginsService.DeleteProfile(arg1);
+      },
+      /**
+       * ExportBackup
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "ExportBackup": (arg1) => {
+        return window.backend.main.PluginsService.ExportBackup(arg1);
+      },
       /**
        * GetFeaturedPlugins
        * @returns {Promise<Array.<any>|Error>}  - Go Type: []metadata.Plugin
@@ -85,6 +131,13 @@ const backend = {
       "GetFeaturedPlugins": () => {
         return window.backend.main.PluginsService.GetFeaturedPlugins();
       },
+      /**
+       * GetGlobalVariables
+       * @returns {Promise<any|Error>}  - Go Type: map[string]string
+       */
+      "GetGlobalVariables": () => {
+        return window.backend.main.PluginsService.GetGlobalVariables();
+      },
       /**
        * GetInstalledPluginMetadata
        * @param {string} arg1 - Go Type: string
@@ -108,6 +161,37 @@ const backend = {
       "GetPlugin": (arg1) => {
         return window.backend.main.PluginsService.GetPlugin(arg1);
       },
+      /**
+       * GetPluginDirectories
+       * @returns {Promise<Array.<string>|Error>}  - Go Type: []string
+       */
+      "GetPluginDirectories": () => {
+        return window.backend.main.PluginsService.GetPluginDirectories();
+      },
+      /**
+       * GetPluginHistory
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Array.<any>|Error>}  - Go Type: []plugins.Run
+       */
+      "GetPluginHistory": (arg1) => {
+        return window.backend.main.PluginsService.GetPluginHistory(arg1);
+      },
+      /**
+       * GetPluginLog
+       * @param {string} arg1 - Go Type: string
+       * @param {number} arg2 - Go Type: int
+       * @returns {Promise<string|Error>}  - Go Type: string
+       */
+      "GetPluginLog": (arg1, arg2) => {
+        return window.backend.main.PluginsService.GetPluginLog(arg1, arg2);
+      },
+      /**
+       * GetPluginStats
+       * @returns {Promise<Array.<any>|Error>}  - Go Type: []main.PluginStats
+       */
+      "GetPluginStats": () => {
+        return window.backend.main.PluginsService.GetPluginStats();
+      },
       /**
        * GetPlugins
        * @param {string} arg1 - Go Type: string
@@ -116,6 +200,29 @@ const backend = {
       "GetPlugins": (arg1) => {
         return window.backend.main.PluginsService.GetPlugins(arg1);
       },
+      /**
+       * GetProfiles
+       * @returns {Promise<Array.<any>|Error>}  - Go Type: []plugins.Profile
+       */
+      "GetProfiles": () => {
+        return window.backend.main.PluginsService.GetProfiles();
+      },
+      /**
+       * ImportBackup
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "ImportBackup": (arg1) => {
+        return window.backend.main.PluginsService.ImportBackup(arg1);
+      },
+      /**
+       * ImportPlugins
+       * @param {boolean} arg1 - Go Type: bool
+       * @returns {Promise<any|Error>}  - Go Type: *plugins.MigrationReport
+       */
+      "ImportPlugins": (arg1) => {
+        return window.backend.main.PluginsService.ImportPlugins(arg1);
+      },
       /**
        * InstallPlugin
        * @param {any} arg1 - Go Type: metadata.Plugin
@@ -132,15 +239,71 @@ const backend = {
       "LoadVariableValues": (arg1) => {
         return window.backend.main.PluginsService.LoadVariableValues(arg1);
       },
+      /**
+       * MovePluginDown
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "MovePluginDown": (arg1) => {
+        return window.backend.main.PluginsService.MovePluginDown(arg1);
+      },
+      /**
+       * MovePluginUp
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "MovePluginUp": (arg1) => {
+        return window.backend.main.PluginsService.MovePluginUp(arg1);
+      },
+      /**
+       * PausePlugin
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "PausePlugin": (arg1) => {
+        return window.backend.main.PluginsService.PausePlugin(arg1);
+      },
+      /**
+       * ReplacePlugin
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<string|Error>}  - Go Type: string
+       */
+      "ReplacePlugin": (arg1) => {
+        return window.backend.main.PluginsService.ReplacePlugin(arg1);
+      },
+      /**
+       * ResumePlugin
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "ResumePlugin": (arg1) => {
+        return window.backend.main.PluginsService.ResumePlugin(arg1);
+      },
+      /**
+       * SaveProfile
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "SaveProfile": (arg1) => {
+        return window.backend.main.PluginsService.SaveProfile(arg1);
+      },
       /**
        * SaveVariableValues
        * @param {string} arg1 - Go Type: string
        * @param {any} arg2 - Go Type: map[string]interface {}
-       * @returns {Promise<Error>}  - Go Type: error
+       * @returns {Promise<Array.<any>|Error>}  - Go Type: []metadata.ValueError
        */
       "SaveVariableValues": (arg1, arg2) => {
         return window.backend.main.PluginsService.SaveVariableValues(arg1, arg2);
       },
+      /**
+       * SearchPlugins
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Array.<any>|Error>}  - Go Type: []metadata.Plugin
+       */
+      "SearchPlugins": (arg1) => {
+        return window.backend.main.PluginsService.SearchPlugins(arg1);
+      },
       /**
        * SetEnabled
        * @param {string} arg1 - Go Type: string
@@ -150,6 +313,23 @@ const backend = {
       "SetEnabled": (arg1, arg2) => {
         return window.backend.main.PluginsService.SetEnabled(arg1, arg2);
       },
+      /**
+       * SetGlobalVariable
+       * @param {string} arg1 - Go Type: string
+       * @param {string} arg2 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "SetGlobalVariable": (arg1, arg2) => {
+        return window.backend.main.PluginsService.SetGlobalVariable(arg1, arg2);
+      },
+      /**
+       * SetPluginDirectories
+       * @param {Array.<string>} arg1 - Go Type: []string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "SetPluginDirectories": (arg1) => {
+        return window.backend.main.PluginsService.SetPluginDirectories(arg1);
+      },
       /**
        * SetRefreshInterval
        * @param {string} arg1 - Go Type: string
@@ -167,6 +347,14 @@ const backend = {
       "UninstallPlugin": (arg1) => {
         return window.backend.main.PluginsService.UninstallPlugin(arg1);
       },
+      /**
+       * UpgradePlugin
+       * @param {string} arg1 - Go Type: string
+       * @returns {Promise<Error>}  - Go Type: error
+       */
+      "UpgradePlugin": (arg1) => {
+        return window.backend.main.PluginsService.UpgradePlugin(arg1);
+      },
     }
   }
 
@@ -6,6 +6,7 @@ import PluginsList from './PluginsList.svelte'
 import PluginView from './PluginView.svelte'
 import PeopleView from './PersonView.svelte'
 import InstalledPluginView from './InstalledPluginView.svelte'
+import SearchView from './SearchView.svelte'
 
 let app;
 
@@ -15,6 +16,7 @@ routes.set({
 	'/installed-plugins/*': InstalledPluginView,
 	'/plugin-details/*': PluginView,
 	'/people/:username': PeopleView,
+	'/search/*': SearchView,
 })
 
 ready(() => {
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// globalShortcuts registers the keyboard shortcuts of plugin items
+// (shortcut=) with the system, so they work while the menus are
+// closed, not just while they are open.
+type globalShortcuts struct {
+	// register registers the shortcuts (in the normalized form, see
+	// plugins.ShortcutRegistry), replacing any registered before, and
+	// calls pressed with the index of the shortcut when one is
+	// pressed. It returns the shortcuts that were registered (in
+	// index order), and an error if any couldn't be.
+	register func(shortcuts []string, pressed func(i int)) ([]string, error)
+	// onPress is called when a shortcut is pressed.
+	onPress func(shortcut string)
+
+	lock sync.Mutex
+	// shortcuts are those registered, by index.
+	shortcuts []string
+	// requested are the shortcuts last asked for, so they are only
+	// registered again when they change.
+	requested []string
+}
+
+// newGlobalShortcuts makes a new globalShortcuts that calls onPress.
+func newGlobalShortcuts(onPress func(shortcut string)) *globalShortcuts {
+	return &globalShortcuts{
+		register: registerHotKeys,
+		onPress:  onPress,
+	}
+}
+
+// set registers the shortcuts, replacing the ones registered before.
+func (g *globalShortcuts) set(shortcuts []string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if equalStrings(shortcuts, g.requested) {
+		return
+	}
+	g.requested = shortcuts
+	registered, err := g.register(shortcuts, g.pressed)
+	if err != nil {
+		log.Println("global shortcuts:", err)
+	}
+	g.shortcuts = registered
+}
+
+// pressed is called when the shortcut with index i is pressed.
+func (g *globalShortcuts) pressed(i int) {
+	g.lock.Lock()
+	if i < 0 || i >= len(g.shortcuts) {
+		g.lock.Unlock()
+		return
+	}
+	shortcut := g.shortcuts[i]
+	g.lock.Unlock()
+	g.onPress(shortcut)
+}
+
+// equalStrings gets whether a and b contain the same strings in
+// the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+#include <Carbon/Carbon.h>
+#include <dispatch/dispatch.h>
+#include <stdlib.h>
+#include <string.h>
+
+// defined in global_shortcuts_darwin.go
+extern void xbarHotKeyPressed(UInt32 i);
+
+// xbarHotKeySignature identifies xbar's hot keys ('xbar').
+#define xbarHotKeySignature 0x78626172
+#define xbarMaxHotKeys 256
+
+static EventHotKeyRef xbarHotKeyRefs[xbarMaxHotKeys];
+static int xbarHotKeyCount = 0;
+static int xbarHotKeyHandlerInstalled = 0;
+
+typedef struct {
+	UInt32 *keyCodes;
+	UInt32 *modifiers;
+	int count;
+} xbarHotKeys;
+
+static OSStatus xbarHotKeyHandler(EventHandlerCallRef next, EventRef event, void *data) {
+	EventHotKeyID hotKeyID;
+	OSStatus err = GetEventParameter(event, kEventParamDirectObject, typeEventHotKeyID, NULL, sizeof(hotKeyID), NULL, &hotKeyID);
+	if (err != noErr) {
+		return err;
+	}
+	if (hotKeyID.signature != xbarHotKeySignature) {
+		return eventNotHandledErr;
+	}
+	xbarHotKeyPressed(hotKeyID.id);
+	return noErr;
+}
+
+// xbarApplyHotKeys replaces the registered hot keys. It runs on the
+// main thread.
+static void xbarApplyHotKeys(void *context) {
+	xbarHotKeys *hotKeys = (xbarHotKeys *)context;
+	if (!xbarHotKeyHandlerInstalled) {
+		EventTypeSpec eventType = {kEventClassKeyboard, kEventHotKeyPressed};
+		InstallApplicationEventHandler(NewEventHandlerUPP(xbarHotKeyHandler), 1, &eventType, NULL, NULL);
+		xbarHotKeyHandlerInstalled = 1;
+	}
+	for (int i = 0; i < xbarHotKeyCount; i++) {
+		if (xbarHotKeyRefs[i] != NULL) {
+			UnregisterEventHotKey(xbarHotKeyRefs[i]);
+			xbarHotKeyRefs[i] = NULL;
+		}
+	}
+	for (int i = 0; i < hotKeys->count; i++) {
+		EventHotKeyID hotKeyID = {xbarHotKeySignature, (UInt32)i};
+		RegisterEventHotKey(hotKeys->keyCodes[i], hotKeys->modifiers[i], hotKeyID, GetApplicationEventTarget(), 0, &xbarHotKeyRefs[i]);
+	}
+	xbarHotKeyCount = hotKeys->count;
+	free(hotKeys->keyCodes);
+	free(hotKeys->modifiers);
+	free(hotKeys);
+}
+
+// xbarSetHotKeys registers the hot keys (replacing any registered
+// before), with ids that are their index.
+void xbarSetHotKeys(UInt32 *keyCodes, UInt32 *modifiers, int count) {
+	if (count > xbarMaxHotKeys) {
+		count = xbarMaxHotKeys;
+	}
+	xbarHotKeys *hotKeys = malloc(sizeof(xbarHotKeys));
+	hotKeys->keyCodes = malloc(sizeof(UInt32) * (count > 0 ? count : 1));
+	hotKeys->modifiers = malloc(sizeof(UInt32) * (count > 0 ? count : 1));
+	hotKeys->count = count;
+	if (count > 0) {
+		memcpy(hotKeys->keyCodes, keyCodes, sizeof(UInt32) * count);
+		memcpy(hotKeys->modifiers, modifiers, sizeof(UInt32) * count);
+	}
+	// Carbon hot keys must be registered on the main thread
+	dispatch_async_f(dispatch_get_main_queue(), hotKeys, xbarApplyHotKeys);
+}
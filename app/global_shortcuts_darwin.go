@@ -0,0 +1,123 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+/*
+#cgo LDFLAGS: -framework Carbon
+#include <Carbon/Carbon.h>
+
+// defined in global_shortcuts_darwin.c
+void xbarSetHotKeys(UInt32 *keyCodes, UInt32 *modifiers, int count);
+*/
+import "C"
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// hotKeyCodes are the macOS virtual key codes of the keys that
+// can be used in global shortcuts.
+var hotKeyCodes = map[string]C.UInt32{
+	"a": C.kVK_ANSI_A, "b": C.kVK_ANSI_B, "c": C.kVK_ANSI_C, "d": C.kVK_ANSI_D,
+	"e": C.kVK_ANSI_E, "f": C.kVK_ANSI_F, "g": C.kVK_ANSI_G, "h": C.kVK_ANSI_H,
+	"i": C.kVK_ANSI_I, "j": C.kVK_ANSI_J, "k": C.kVK_ANSI_K, "l": C.kVK_ANSI_L,
+	"m": C.kVK_ANSI_M, "n": C.kVK_ANSI_N, "o": C.kVK_ANSI_O, "p": C.kVK_ANSI_P,
+	"q": C.kVK_ANSI_Q, "r": C.kVK_ANSI_R, "s": C.kVK_ANSI_S, "t": C.kVK_ANSI_T,
+	"u": C.kVK_ANSI_U, "v": C.kVK_ANSI_V, "w": C.kVK_ANSI_W, "x": C.kVK_ANSI_X,
+	"y": C.kVK_ANSI_Y, "z": C.kVK_ANSI_Z,
+	"0": C.kVK_ANSI_0, "1": C.kVK_ANSI_1, "2": C.kVK_ANSI_2, "3": C.kVK_ANSI_3,
+	"4": C.kVK_ANSI_4, "5": C.kVK_ANSI_5, "6": C.kVK_ANSI_6, "7": C.kVK_ANSI_7,
+	"8": C.kVK_ANSI_8, "9": C.kVK_ANSI_9,
+	"-": C.kVK_ANSI_Minus, "=": C.kVK_ANSI_Equal, ",": C.kVK_ANSI_Comma,
+	".": C.kVK_ANSI_Period, "/": C.kVK_ANSI_Slash, ";": C.kVK_ANSI_Semicolon,
+	"'": C.kVK_ANSI_Quote, "[": C.kVK_ANSI_LeftBracket, "]": C.kVK_ANSI_RightBracket,
+	"\\": C.kVK_ANSI_Backslash, "`": C.kVK_ANSI_Grave,
+	"f1": C.kVK_F1, "f2": C.kVK_F2, "f3": C.kVK_F3, "f4": C.kVK_F4,
+	"f5": C.kVK_F5, "f6": C.kVK_F6, "f7": C.kVK_F7, "f8": C.kVK_F8,
+	"f9": C.kVK_F9, "f10": C.kVK_F10, "f11": C.kVK_F11, "f12": C.kVK_F12,
+	"space": C.kVK_Space, "tab": C.kVK_Tab, "return": C.kVK_Return,
+	"enter": C.kVK_Return, "escape": C.kVK_Escape, "backspace": C.kVK_Delete,
+	"delete": C.kVK_ForwardDelete, "home": C.kVK_Home, "end": C.kVK_End,
+	"left": C.kVK_LeftArrow, "right": C.kVK_RightArrow,
+	"up": C.kVK_UpArrow, "down": C.kVK_DownArrow,
+}
+
+// hotKeyModifiers are the Carbon modifier flags for the modifiers
+// in normalized shortcuts.
+var hotKeyModifiers = map[string]C.UInt32{
+	"cmdorctrl":   C.cmdKey,
+	"optionoralt": C.optionKey,
+	"ctrl":        C.controlKey,
+	"shift":       C.shiftKey,
+	"super":       C.cmdKey,
+}
+
+var (
+	hotKeyLock sync.Mutex
+	// hotKeyPressed is called with the index of a hot key when it
+	// is pressed.
+	hotKeyPressed func(i int)
+)
+
+// registerHotKeys registers the shortcuts as Carbon hot keys, which
+// work whichever app is active.
+func registerHotKeys(shortcuts []string, pressed func(i int)) ([]string, error) {
+	var (
+		registered []string
+		keyCodes   []C.UInt32
+		modifiers  []C.UInt32
+		unknown    []string
+	)
+	for _, shortcut := range shortcuts {
+		keyCode, mods, ok := hotKey(shortcut)
+		if !ok {
+			unknown = append(unknown, shortcut)
+			continue
+		}
+		registered = append(registered, shortcut)
+		keyCodes = append(keyCodes, keyCode)
+		modifiers = append(modifiers, mods)
+	}
+	hotKeyLock.Lock()
+	hotKeyPressed = pressed
+	hotKeyLock.Unlock()
+	var keyCodesPtr, modifiersPtr *C.UInt32
+	if len(registered) > 0 {
+		keyCodesPtr, modifiersPtr = &keyCodes[0], &modifiers[0]
+	}
+	// (copied by xbarSetHotKeys)
+	C.xbarSetHotKeys(keyCodesPtr, modifiersPtr, C.int(len(registered)))
+	if len(unknown) > 0 {
+		return registered, errors.Errorf("unsupported keys, these only work while the menu is open: %s", strings.Join(unknown, ", "))
+	}
+	return registered, nil
+}
+
+// hotKey gets the key code and modifiers of a normalized shortcut.
+func hotKey(shortcut string) (C.UInt32, C.UInt32, bool) {
+	parts := strings.Split(shortcut, "+")
+	keyCode, ok := hotKeyCodes[parts[len(parts)-1]]
+	if !ok {
+		return 0, 0, false
+	}
+	var mods C.UInt32
+	for _, part := range parts[:len(parts)-1] {
+		mods |= hotKeyModifiers[part]
+	}
+	return keyCode, mods, true
+}
+
+//export xbarHotKeyPressed
+func xbarHotKeyPressed(i C.UInt32) {
+	hotKeyLock.Lock()
+	pressed := hotKeyPressed
+	hotKeyLock.Unlock()
+	if pressed != nil {
+		// don't hold up the main thread
+		go pressed(int(i))
+	}
+}
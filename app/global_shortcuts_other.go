@@ -0,0 +1,10 @@
+//go:build !darwin
+// +build !darwin
+
+package main
+
+// registerHotKeys does nothing on this platform, so shortcuts only
+// work while the menus are open.
+func registerHotKeys(shortcuts []string, pressed func(i int)) ([]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestGlobalShortcuts(t *testing.T) {
+	is := is.New(t)
+
+	var pressed []string
+	g := newGlobalShortcuts(func(shortcut string) {
+		pressed = append(pressed, shortcut)
+	})
+	var registers int
+	var press func(i int)
+	g.register = func(shortcuts []string, pressedFunc func(i int)) ([]string, error) {
+		registers++
+		press = pressedFunc
+		return shortcuts, nil
+	}
+
+	g.set([]string{"cmdorctrl+shift+k", "cmdorctrl+shift+o"})
+	is.Equal(registers, 1)
+	press(1)
+	is.Equal(pressed, []string{"cmdorctrl+shift+o"})
+
+	// unchanged shortcuts aren't registered again
+	g.set([]string{"cmdorctrl+shift+k", "cmdorctrl+shift+o"})
+	is.Equal(registers, 1)
+
+	g.set([]string{"cmdorctrl+shift+n"})
+	is.Equal(registers, 2)
+	press(0)
+	press(1) // no longer registered
+	is.Equal(pressed, []string{"cmdorctrl+shift+o", "cmdorctrl+shift+n"})
+}
@@ -2,11 +2,17 @@ package main
 
 import (
 	"net/url"
+	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
+// pluginSourceBaseURL is where the plugins that xbar:// URLs open
+// come from.
+const pluginSourceBaseURL = "https://github.com/matryer/xbar-plugins/blob/main/"
+
 type incomingURL struct {
 	// Action is the action to take.
 	Action string
@@ -14,7 +20,10 @@ type incomingURL struct {
 	Params url.Values
 }
 
-// parseIncomingURL parses an incoming xbar:// URL.
+// parseIncomingURL parses an incoming xbar:// URL, like
+// xbar://app.xbarapp.com/openPlugin?path=IoT/homebridge.10s.py or
+// xbar://app.xbarapp.com/refreshPlugin?name=homebridge or
+// xbar://app.xbarapp.com/notify?title=Homebridge&body=Door%20open.
 func parseIncomingURL(urlStr string) (incomingURL, error) {
 	var incomingURL incomingURL
 	u, err := url.Parse(urlStr)
@@ -28,9 +37,46 @@ func parseIncomingURL(urlStr string) (incomingURL, error) {
 	incomingURL.Params = u.Query()
 	switch incomingURL.Action {
 	case "openPlugin":
+		if incomingURL.Params.Get("path") == "" {
+			return incomingURL, errors.New("openPlugin: missing path")
+		}
 	case "refreshPlugin":
+		if incomingURL.Params.Get("path") == "" && incomingURL.Params.Get("name") == "" {
+			return incomingURL, errors.New("refreshPlugin: missing name or path")
+		}
+	case "notify":
+		if incomingURL.Params.Get("body") == "" {
+			return incomingURL, errors.New("notify: missing body")
+		}
 	default: // not ok
 		return incomingURL, errors.Errorf("unsupported action %q", incomingURL.Action)
 	}
+	if p := incomingURL.Params.Get("path"); p != "" {
+		if path.IsAbs(p) || path.Clean(p) != p || strings.HasPrefix(p, "../") || p == ".." {
+			return incomingURL, errors.Errorf("%s: invalid path %q", incomingURL.Action, p)
+		}
+	}
 	return incomingURL, nil
 }
+
+// pluginSource gets the URL of the source of the plugin an
+// openPlugin URL opens.
+func (u incomingURL) pluginSource() string {
+	return pluginSourceBaseURL + u.Params.Get("path")
+}
+
+// matchesPlugin gets whether a refreshPlugin URL is for the
+// plugin at the path (relative to the plugin directory).
+// The name matches the filename, with or without the refresh time
+// and extension (homebridge matches homebridge.10s.py).
+func (u incomingURL) matchesPlugin(rel string) bool {
+	if p := u.Params.Get("path"); p != "" {
+		return filepath.ToSlash(rel) == p
+	}
+	name := u.Params.Get("name")
+	filename := filepath.Base(rel)
+	if filename == name {
+		return true
+	}
+	return strings.SplitN(filename, ".", 2)[0] == name
+}
@@ -22,4 +22,35 @@ func TestParseIncomingURL(t *testing.T) {
 	result, err = parseIncomingURL(`xbar://app.xbarapp.com/nope?path=cycle_text_and_detail`)
 	is.True(err != nil)
 
+	result, err = parseIncomingURL(`xbar://app/refreshPlugin?name=homebridge`)
+	is.NoErr(err)
+	is.Equal(result.Action, "refreshPlugin")
+	is.True(result.matchesPlugin("homebridge.10s.py"))
+	is.True(result.matchesPlugin("IoT/homebridge.10s.py"))
+	is.True(!result.matchesPlugin("homebridge-lights.10s.py"))
+
+	result, err = parseIncomingURL(`xbar://app/refreshPlugin?path=IoT/homebridge.10s.py`)
+	is.NoErr(err)
+	is.True(result.matchesPlugin("IoT/homebridge.10s.py"))
+	is.True(!result.matchesPlugin("homebridge.10s.py"))
+
+	result, err = parseIncomingURL(`xbar://app/openPlugin?path=IoT/homebridge.10s.py`)
+	is.NoErr(err)
+	is.Equal(result.pluginSource(), "https://github.com/matryer/xbar-plugins/blob/main/IoT/homebridge.10s.py")
+
+	result, err = parseIncomingURL(`xbar://app/notify?plugin=homebridge&title=Homebridge&body=Door%20open`)
+	is.NoErr(err)
+	is.Equal(result.Action, "notify")
+	is.Equal(result.Params.Get("body"), "Door open")
+	_, err = parseIncomingURL(`xbar://app/notify?title=Homebridge`)
+	is.True(err != nil) // missing body
+
+	_, err = parseIncomingURL(`xbar://app/openPlugin`)
+	is.True(err != nil) // missing path
+	_, err = parseIncomingURL(`xbar://app/refreshPlugin`)
+	is.True(err != nil) // missing name or path
+	_, err = parseIncomingURL(`xbar://app/openPlugin?path=../../etc/passwd`)
+	is.True(err != nil) // outside the plugins
+	_, err = parseIncomingURL(`xbar://app/openPlugin?path=/etc/passwd`)
+	is.True(err != nil) // absolute
 }
@@ -14,6 +14,12 @@ import (
 //go:embed .version
 var version string
 
+// updatePublicKey is the minisign public key that updates must be
+// signed with, written by build.sh from XBAR_UPDATE_PUBLIC_KEY.
+//
+//go:embed .update-public-key
+var updatePublicKey string
+
 func main() {
 	println("xbar", version)
 	if err := run(); err != nil {
@@ -10,7 +10,11 @@ import (
 )
 
 // MenuParser translates xbar items into Wails menu items.
-type MenuParser struct{}
+type MenuParser struct {
+	// DarkMode indicates whether the menus are shown in dark mode,
+	// and is used to pick item colors.
+	DarkMode bool
+}
 
 // NewMenuParser makes a new MenuParser.
 func NewMenuParser() *MenuParser {
@@ -23,11 +27,15 @@ func (m MenuParser) ParseItems(ctx context.Context, items []*plugins.Item) *menu
 		return nil
 	}
 	theMenu := menu.NewMenu()
-	for _, item := range items {
+	for i, item := range items {
 		if item.Params.Separator {
 			theMenu.Append(menu.Separator())
 			continue
 		}
+		if item.Params.Header && i > 0 {
+			// headers start a new section
+			theMenu.Append(menu.Separator())
+		}
 		menuItem := m.ParseMenuItem(ctx, item)
 		theMenu.Append(menuItem)
 		if item.Alternate != nil {
@@ -48,28 +56,43 @@ func (m MenuParser) ParseMenuItem(ctx context.Context, item *plugins.Item) *menu
 		}
 		itemAction(ctx)
 	})
-	if item.Text != displayText {
+	if item.Params.Tooltip != "" {
+		menuItem.Tooltip = item.Params.Tooltip
+	} else if item.FullText != "" {
+		// truncated when parsed
+		menuItem.Tooltip = item.FullText
+	} else if item.Text != displayText {
 		menuItem.Tooltip = item.Text
 	}
-	if item.Params.Key != "" {
-		acc, err := keys.Parse(item.Params.Key)
+	accelerator := item.Params.Key
+	if accelerator == "" {
+		accelerator = item.Params.Shortcut
+	}
+	if accelerator != "" {
+		acc, err := keys.Parse(accelerator)
 		if err != nil {
 			// show the error in the menu
 			menuItem.Label = fmt.Sprintf("error: %s", err)
 		}
 		menuItem.Accelerator = acc
 	}
-	menuItem.Image = item.Params.Image
+	// template images adapt to dark and light menus on all macOS versions
+	menuItem.Image, menuItem.MacTemplateImage = item.Params.DisplayImage()
 	menuItem.FontName = item.Params.Font
+	if menuItem.FontName == "" {
+		menuItem.FontName = segmentsFont(item.Segments)
+	}
 	menuItem.FontSize = item.Params.Size
-	menuItem.RGBA = item.Params.Color
-	// Check for template image
-	if item.Params.TemplateImage != "" {
-		menuItem.Image = item.Params.TemplateImage
-		// get template images working on all macOS versions
-		menuItem.MacTemplateImage = true
+	menuItem.RGBA = item.Params.DisplayColor(m.DarkMode)
+	if menuItem.RGBA == "" {
+		// no explicit color, so use any from ANSI codes
+		menuItem.RGBA = segmentsColor(item.Segments)
 	}
 	menuItem.MacAlternate = item.Params.Alternate
+	if item.Params.Checked {
+		menuItem.Type = menu.CheckboxType
+		menuItem.Checked = true
+	}
 	if item.Params.Dropdown == false {
 		menuItem.Hidden = true
 	}
@@ -86,3 +109,52 @@ func (m MenuParser) ParseMenuItem(ctx context.Context, item *plugins.Item) *menu
 	}
 	return menuItem
 }
+
+// ParseTitle updates the tray with the title item (one of the
+// plugin's CycleItems), which may have text, an image, or both.
+// Unlike items in the dropdown, titles can have a badge, and
+// template images so they suit both light and dark menu bars.
+func (m MenuParser) ParseTitle(tray *menu.TrayMenu, item *plugins.Item) {
+	tray.Label = item.TitleText()
+	tray.Tooltip = item.Params.Tooltip
+	tray.Image, tray.MacTemplateImage = item.Params.DisplayImage()
+	if tray.Label == "" && tray.Image == "" {
+		// keep the item visible (and clickable)
+		tray.Label = " "
+	}
+	tray.FontName = item.Params.Font
+	if tray.FontName == "" {
+		tray.FontName = segmentsFont(item.Segments)
+	}
+	tray.FontSize = item.Params.Size
+	tray.RGBA = item.Params.DisplayColor(m.DarkMode)
+	if tray.RGBA == "" {
+		tray.RGBA = segmentsColor(item.Segments)
+	}
+	tray.Disabled = item.Params.Disabled
+}
+
+// segmentsColor gets the first foreground color from the styled
+// segments, or an empty string if there isn't one.
+func segmentsColor(segments []*plugins.TextSegment) string {
+	for _, segment := range segments {
+		if segment.Color != "" {
+			return segment.Color
+		}
+	}
+	return ""
+}
+
+// segmentsFont gets a fixed width font if all of the text is code
+// (like `code` in Markdown), or an empty string for the default font.
+func segmentsFont(segments []*plugins.TextSegment) string {
+	if len(segments) == 0 {
+		return ""
+	}
+	for _, segment := range segments {
+		if !segment.Monospace && segment.Text != "" {
+			return ""
+		}
+	}
+	return "Menlo"
+}
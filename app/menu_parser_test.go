@@ -114,15 +114,218 @@ func TestMenuParser(t *testing.T) {
 
 	is.Equal(menuitems.Items[7].Label, "Template Image")
 	is.Equal(menuitems.Items[7].Image, "base64stuff")
-	//is.Equal(menuitems.Items[7].MacTemplateImage, true)
+	is.Equal(menuitems.Items[7].MacTemplateImage, true)
 
 	is.Equal(menuitems.Items[8].Label, "Non Alternate")
 	is.Equal(menuitems.Items[9].Label, "Alternate")
 	is.Equal(menuitems.Items[9].MacAlternate, true)
 }
 
+func TestMenuParserANSI(t *testing.T) {
+	is := is.New(t)
+
+	items := []*plugins.Item{
+		{
+			Text: "red",
+			Segments: []*plugins.TextSegment{
+				{Text: "red", Color: "#cd0000"},
+			},
+		},
+		{
+			Text: "explicit",
+			Segments: []*plugins.TextSegment{
+				{Text: "explicit", Color: "#cd0000"},
+			},
+			Params: plugins.ItemParams{
+				Color: "#0000ff",
+			},
+		},
+	}
+	menuitems := NewMenuParser().ParseItems(context.Background(), items)
+	is.Equal(len(menuitems.Items), 2)
+	is.Equal(menuitems.Items[0].Label, "red")
+	is.Equal(menuitems.Items[0].RGBA, "#cd0000")
+	is.Equal(menuitems.Items[1].RGBA, "#0000ff") // explicit color wins
+}
+
+func TestMenuParserMarkdownCode(t *testing.T) {
+	is := is.New(t)
+
+	items := []*plugins.Item{
+		{
+			Text: "git status",
+			Segments: []*plugins.TextSegment{
+				{Text: "git status", Monospace: true},
+			},
+		},
+		{
+			Text: "run git status",
+			Segments: []*plugins.TextSegment{
+				{Text: "run "},
+				{Text: "git status", Monospace: true},
+			},
+		},
+	}
+	menuitems := NewMenuParser().ParseItems(context.Background(), items)
+	is.Equal(len(menuitems.Items), 2)
+	is.Equal(menuitems.Items[0].FontName, "Menlo") // all code
+	is.Equal(menuitems.Items[1].FontName, "")      // mixed text keeps the menu font
+}
+
+func TestMenuParserDarkMode(t *testing.T) {
+	is := is.New(t)
+
+	items := []*plugins.Item{
+		{
+			Text: "pair",
+			Params: plugins.ItemParams{
+				Color:     "#000000",
+				DarkColor: "#ffffff",
+			},
+		},
+		{
+			Text: "single",
+			Params: plugins.ItemParams{
+				Color: "#ff0000",
+			},
+		},
+	}
+	menuitems := NewMenuParser().ParseItems(context.Background(), items)
+	is.Equal(menuitems.Items[0].RGBA, "#000000")
+	is.Equal(menuitems.Items[1].RGBA, "#ff0000")
+	darkParser := &MenuParser{DarkMode: true}
+	menuitems = darkParser.ParseItems(context.Background(), items)
+	is.Equal(menuitems.Items[0].RGBA, "#ffffff")
+	is.Equal(menuitems.Items[1].RGBA, "#ff0000")
+}
+
+func TestMenuParserChecked(t *testing.T) {
+	is := is.New(t)
+
+	items := []*plugins.Item{
+		{
+			Text: "VPN connected",
+			Params: plugins.ItemParams{
+				Checked: true,
+			},
+		},
+		{
+			Text: "Not checked",
+		},
+	}
+	menuitems := NewMenuParser().ParseItems(context.Background(), items)
+	is.Equal(len(menuitems.Items), 2)
+	is.Equal(menuitems.Items[0].Type, menu.CheckboxType)
+	is.Equal(menuitems.Items[0].Checked, true)
+	is.Equal(menuitems.Items[1].Type, menu.TextType)
+	is.Equal(menuitems.Items[1].Checked, false)
+}
+
+func TestMenuParserHeaders(t *testing.T) {
+	is := is.New(t)
+
+	items := []*plugins.Item{
+		{
+			Text:   "Servers",
+			Params: plugins.ItemParams{Header: true, Disabled: true},
+		},
+		{Text: "web1"},
+		{
+			Text:   "Databases",
+			Params: plugins.ItemParams{Header: true, Disabled: true},
+		},
+		{Text: "db1"},
+	}
+	menuitems := NewMenuParser().ParseItems(context.Background(), items)
+	is.Equal(len(menuitems.Items), 5)
+	is.Equal(menuitems.Items[0].Label, "Servers")
+	is.Equal(menuitems.Items[0].Disabled, true)
+	is.Equal(menuitems.Items[2].Type, menu.SeparatorType) // starts a new section
+	is.Equal(menuitems.Items[3].Label, "Databases")
+	is.Equal(menuitems.Items[3].Disabled, true)
+}
+
+func TestMenuParserTooltip(t *testing.T) {
+	is := is.New(t)
+
+	items := []*plugins.Item{
+		{
+			Text: "CPU 12%",
+			Params: plugins.ItemParams{
+				Tooltip: "4 cores, 2.3GHz",
+			},
+		},
+		{
+			Text:     "truncat…",
+			FullText: "truncated text",
+			Params: plugins.ItemParams{
+				Length: 8,
+			},
+		},
+		{
+			Text: "plain",
+		},
+	}
+	menuitems := NewMenuParser().ParseItems(context.Background(), items)
+	is.Equal(len(menuitems.Items), 3)
+	is.Equal(menuitems.Items[0].Tooltip, "4 cores, 2.3GHz")
+	is.Equal(menuitems.Items[1].Tooltip, "truncated text")
+	is.Equal(menuitems.Items[2].Tooltip, "")
+}
+
+func TestMenuParserShortcut(t *testing.T) {
+	is := is.New(t)
+
+	items := []*plugins.Item{
+		{
+			Text: "Connect",
+			Params: plugins.ItemParams{
+				Shortcut: "cmdorctrl+shift+k",
+				Href:     "https://xbarapp.com",
+			},
+		},
+	}
+	menuitems := NewMenuParser().ParseItems(context.Background(), items)
+	is.Equal(len(menuitems.Items), 1)
+	is.True(menuitems.Items[0].Accelerator != nil)
+	is.Equal(menuitems.Items[0].Accelerator.Key, "k")
+	is.Equal(len(menuitems.Items[0].Accelerator.Modifiers), 2)
+}
+
 func JSON(menu *menu.Menu, is *is.I) string {
 	data, err := json.Marshal(menu)
 	is.NoErr(err)
 	return string(data)
 }
+
+func TestMenuParserTitle(t *testing.T) {
+	is := is.New(t)
+
+	parser := NewMenuParser()
+	tray := &menu.TrayMenu{}
+	parser.ParseTitle(tray, &plugins.Item{
+		Text: "CPU",
+		Params: plugins.ItemParams{
+			TemplateImage: "dGVtcGxhdGU",
+			Badge:         2,
+		},
+	})
+	is.Equal(tray.Label, "CPU ²")
+	is.Equal(tray.Image, "dGVtcGxhdGU")
+	is.Equal(tray.MacTemplateImage, true)
+
+	// image instead of text
+	parser.ParseTitle(tray, &plugins.Item{
+		Params: plugins.ItemParams{
+			Image: "aW1hZ2U",
+		},
+	})
+	is.Equal(tray.Label, "")
+	is.Equal(tray.Image, "aW1hZ2U")
+	is.Equal(tray.MacTemplateImage, false)
+
+	// nothing at all
+	parser.ParseTitle(tray, &plugins.Item{})
+	is.Equal(tray.Label, " ")
+	is.Equal(tray.Image, "")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/matryer/xbar/pkg/plugins"
+)
+
+// migrationApps are the other apps whose plugins can be imported,
+// and where they keep the path to their plugin folder in the
+// user's defaults.
+var migrationApps = []struct {
+	app, domain, key string
+}{
+	{app: "BitBar", domain: "com.matryer.BitBar", key: "pluginsDirectory"},
+	{app: "SwiftBar", domain: "com.ameba.SwiftBar", key: "PluginDirectory"},
+}
+
+// migrationSources gets the plugin folders of the other apps that
+// have one, using readDefault to read the user's defaults.
+func migrationSources(readDefault func(domain, key string) (string, error)) []plugins.MigrationSource {
+	var sources []plugins.MigrationSource
+	for _, migrationApp := range migrationApps {
+		dir, err := readDefault(migrationApp.domain, migrationApp.key)
+		if err != nil || dir == "" {
+			// not installed, or never set up
+			continue
+		}
+		if strings.HasPrefix(dir, "~/") {
+			dir = filepath.Join(os.Getenv("HOME"), dir[2:])
+		}
+		if dir == pluginDirectory {
+			continue
+		}
+		sources = append(sources, plugins.MigrationSource{
+			App:       migrationApp.app,
+			PluginDir: dir,
+		})
+	}
+	return sources
+}
+
+// readDefault reads a value from the user's defaults.
+func readDefault(domain, key string) (string, error) {
+	out, err := exec.Command("defaults", "read", domain, key).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
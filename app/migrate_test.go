@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestMigrationSources(t *testing.T) {
+	is := is.New(t)
+
+	defaults := map[string]string{
+		"com.matryer.BitBar/pluginsDirectory": "~/BitBar",
+		"com.ameba.SwiftBar/PluginDirectory":  "/Users/mat/SwiftBar Plugins",
+	}
+	readDefault := func(domain, key string) (string, error) {
+		value, ok := defaults[domain+"/"+key]
+		if !ok {
+			return "", errors.New("does not exist")
+		}
+		return value, nil
+	}
+	sources := migrationSources(readDefault)
+	is.Equal(len(sources), 2)
+	is.Equal(sources[0].App, "BitBar")
+	is.Equal(sources[0].PluginDir, filepath.Join(os.Getenv("HOME"), "BitBar"))
+	is.Equal(sources[1].App, "SwiftBar")
+	is.Equal(sources[1].PluginDir, "/Users/mat/SwiftBar Plugins")
+
+	// apps that aren't set up are left out, and so is xbar's own
+	// plugin folder
+	delete(defaults, "com.matryer.BitBar/pluginsDirectory")
+	defaults["com.ameba.SwiftBar/PluginDirectory"] = pluginDirectory
+	sources = migrationSources(readDefault)
+	is.Equal(len(sources), 0)
+}
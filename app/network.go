@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// networkTransport is used for all of xbar's requests (updates, the
+// plugin repository and images), so they go through the proxy and
+// trust the CA certificates in the settings. It is set up by newApp.
+var networkTransport http.RoundTripper = http.DefaultTransport
+
+// newNetworkTransport makes the transport for xbar's requests.
+// Requests go through the proxy in the settings, or else the one in
+// the environment (like HTTPS_PROXY), or else the macOS system one.
+func newNetworkTransport(s settings, systemProxy proxySettings) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if s.Proxy != "" {
+		proxyURL, err := url.Parse(s.Proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "proxy")
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	} else {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			proxyURL, err := http.ProxyFromEnvironment(req)
+			if err != nil || proxyURL != nil {
+				return proxyURL, err
+			}
+			return systemProxy.proxy(req)
+		}
+	}
+	if len(s.CACertificates) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, filename := range s.CACertificates {
+			b, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return nil, errors.Wrap(err, "CA certificates")
+			}
+			if !pool.AppendCertsFromPEM(b) {
+				return nil, errors.Errorf("CA certificates: no PEM certificates in %s", filename)
+			}
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport, nil
+}
+
+// proxySettings are the macOS system proxy settings.
+type proxySettings struct {
+	// HTTP and HTTPS are the host:port of the proxies for http and
+	// https requests, or empty if there isn't one.
+	HTTP, HTTPS string
+	// Exceptions are hosts (like *.local) that don't go through the
+	// proxy.
+	Exceptions []string
+}
+
+// proxy gets the proxy for the request.
+func (p proxySettings) proxy(req *http.Request) (*url.URL, error) {
+	proxy := p.HTTP
+	if req.URL.Scheme == "https" {
+		proxy = p.HTTPS
+	}
+	if proxy == "" {
+		return nil, nil
+	}
+	host := req.URL.Hostname()
+	for _, exception := range p.Exceptions {
+		if strings.HasPrefix(exception, "*.") && strings.HasSuffix(host, exception[1:]) {
+			return nil, nil
+		}
+		if strings.EqualFold(host, exception) {
+			return nil, nil
+		}
+	}
+	return &url.URL{Scheme: "http", Host: proxy}, nil
+}
+
+// scutilProxySettings uses scutil to get the macOS system proxy
+// settings. If scutil fails, there are no proxies.
+func scutilProxySettings() proxySettings {
+	out, err := exec.Command("scutil", "--proxy").Output()
+	if err != nil {
+		log.Println("failed to get the system proxy settings:", err)
+		return proxySettings{}
+	}
+	return parseScutilProxy(string(out))
+}
+
+// parseScutilProxy parses the output of scutil --proxy.
+func parseScutilProxy(out string) proxySettings {
+	values := make(map[string]string)
+	var p proxySettings
+	var inExceptions bool
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "}" {
+			inExceptions = false
+			continue
+		}
+		parts := strings.SplitN(line, " : ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if inExceptions {
+			p.Exceptions = append(p.Exceptions, value)
+			continue
+		}
+		if key == "ExceptionsList" {
+			inExceptions = true
+			continue
+		}
+		values[key] = value
+	}
+	if values["HTTPEnable"] == "1" && values["HTTPProxy"] != "" {
+		p.HTTP = net.JoinHostPort(values["HTTPProxy"], valueOr(values["HTTPPort"], "80"))
+	}
+	if values["HTTPSEnable"] == "1" && values["HTTPSProxy"] != "" {
+		p.HTTPS = net.JoinHostPort(values["HTTPSProxy"], valueOr(values["HTTPSPort"], "443"))
+	}
+	return p
+}
+
+// valueOr gets value, or def if it is empty.
+func valueOr(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseScutilProxy(t *testing.T) {
+	is := is.New(t)
+
+	p := parseScutilProxy(`<dictionary> {
+  ExceptionsList : <array> {
+    0 : *.local
+    1 : intranet.example.com
+  }
+  FTPPassive : 1
+  HTTPEnable : 1
+  HTTPPort : 8080
+  HTTPProxy : proxy.example.com
+  HTTPSEnable : 1
+  HTTPSProxy : secure-proxy.example.com
+}
+`)
+	is.Equal(p.HTTP, "proxy.example.com:8080")
+	is.Equal(p.HTTPS, "secure-proxy.example.com:443")
+	is.Equal(p.Exceptions, []string{"*.local", "intranet.example.com"})
+
+	proxy := func(rawurl string) string {
+		u, err := url.Parse(rawurl)
+		is.NoErr(err)
+		proxyURL, err := p.proxy(&http.Request{URL: u})
+		is.NoErr(err)
+		if proxyURL == nil {
+			return ""
+		}
+		return proxyURL.String()
+	}
+	is.Equal(proxy("https://xbarapp.com/docs/plugins/"), "http://secure-proxy.example.com:443")
+	is.Equal(proxy("http://xbarapp.com/"), "http://proxy.example.com:8080")
+	is.Equal(proxy("https://printer.local/"), "")
+	is.Equal(proxy("https://intranet.example.com/"), "")
+
+	is.Equal(parseScutilProxy(`<dictionary> {
+  HTTPEnable : 0
+}
+`), proxySettings{})
+}
+
+func TestNewNetworkTransport(t *testing.T) {
+	is := is.New(t)
+
+	transport, err := newNetworkTransport(settings{Proxy: "http://proxy.example.com:8080"}, proxySettings{})
+	is.NoErr(err)
+	u, err := url.Parse("https://xbarapp.com/")
+	is.NoErr(err)
+	proxyURL, err := transport.Proxy(&http.Request{URL: u})
+	is.NoErr(err)
+	is.Equal(proxyURL.String(), "http://proxy.example.com:8080")
+
+	_, err = newNetworkTransport(settings{CACertificates: []string{"testdata/missing.pem"}}, proxySettings{})
+	is.True(err != nil) // missing CA certificates
+}
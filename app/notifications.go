@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultNotificationInterval is the least time between notifications
+// from the same plugin, so a misbehaving one can't flood the
+// Notification Centre.
+const defaultNotificationInterval = 1 * time.Minute
+
+// errNotificationRateLimited is returned by notifier.notify when the
+// source showed a notification too recently.
+var errNotificationRateLimited = errors.New("too many notifications")
+
+// notifier shows macOS notifications for plugins, limiting how often
+// each one can show them.
+type notifier struct {
+	// interval is the least time between notifications from the
+	// same source.
+	interval time.Duration
+	// show shows a notification.
+	show func(title, body string) error
+	// now gets the current time.
+	now func() time.Time
+
+	// lock protects last.
+	lock sync.Mutex
+	// last is when each source last showed a notification.
+	last map[string]time.Time
+}
+
+// newNotifier makes a new notifier that shows notifications with
+// osascript.
+func newNotifier() *notifier {
+	return &notifier{
+		interval: defaultNotificationInterval,
+		show:     osascriptNotification,
+		now:      time.Now,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// notify shows a notification from the source (like the plugin's
+// command), unless it showed one less than interval ago.
+func (n *notifier) notify(source, title, body string) error {
+	n.lock.Lock()
+	now := n.now()
+	if last, ok := n.last[source]; ok && now.Sub(last) < n.interval {
+		n.lock.Unlock()
+		return errNotificationRateLimited
+	}
+	n.last[source] = now
+	n.lock.Unlock()
+	return n.show(title, body)
+}
+
+// osascriptNotification shows a notification with osascript.
+func osascriptNotification(title, body string) error {
+	script := "display notification " + appleScriptString(body) + " with title " + appleScriptString(title)
+	if out, err := exec.Command("osascript", "-e", script).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "osascript: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string.
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
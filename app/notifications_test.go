@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestNotifier(t *testing.T) {
+	is := is.New(t)
+
+	now := time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+	var shown []string
+	n := newNotifier()
+	n.now = func() time.Time { return now }
+	n.show = func(title, body string) error {
+		shown = append(shown, title+": "+body)
+		return nil
+	}
+	is.NoErr(n.notify("build.1m.sh", "CI", "Build failed"))
+	is.Equal(n.notify("build.1m.sh", "CI", "Build failed again"), errNotificationRateLimited)
+	is.NoErr(n.notify("weather.1h.sh", "Weather", "Rain")) // other plugins aren't limited
+	now = now.Add(defaultNotificationInterval)
+	is.NoErr(n.notify("build.1m.sh", "CI", "Build fixed"))
+	is.Equal(shown, []string{"CI: Build failed", "Weather: Rain", "CI: Build fixed"})
+}
+
+func TestAppleScriptString(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(appleScriptString(`Build "main" failed \o/`), `"Build \"main\" failed \\o/"`)
+}
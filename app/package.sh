@@ -14,6 +14,13 @@ echo "  xbar ${VERSION}..."
 echo ""
 echo -n $VERSION > .version
 
+# updates are only installed if they're signed with this key
+if [ -z "${XBAR_UPDATE_PUBLIC_KEY}" ]; then
+	echo "XBAR_UPDATE_PUBLIC_KEY must be set to the minisign public key"
+	exit 1
+fi
+echo -n "${XBAR_UPDATE_PUBLIC_KEY}" > .update-public-key
+
 # run all tests
 ./test.sh
 
@@ -22,6 +29,10 @@ CGO_LDFLAGS=-mmacosx-version-min=10.13 wails build -package -production
 cd ./build/darwin/desktop
 create-dmg ./xbar.app --overwrite --dmg-title "Install xbar"
 tar -czvf xbar.${VERSION}.tar.gz ./xbar.app
+# upload checksums.txt with the release
+shasum -a 256 xbar.${VERSION}.tar.gz > checksums.txt
+# upload xbar.${VERSION}.tar.gz.minisig with the release
+minisign -S -l -m xbar.${VERSION}.tar.gz
 #rm -rf ./xbar.app
 
 open .
@@ -5,10 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,8 +38,30 @@ type PluginsService struct {
 	// OnRefresh is called whenever the menus should
 	// be updated.
 	OnRefresh func()
+	// setPaused pauses or resumes a running plugin.
+	setPaused func(installedPluginPath string, paused bool) error
+	// variablesChanged is called when the variables of a plugin
+	// have been saved.
+	variablesChanged func(installedPluginPath string)
+	// pluginStats gets the stats of the running plugins.
+	pluginStats func() []PluginStats
+
+	// searchLock protects searchIndex and searchIndexLoaded.
+	searchLock sync.Mutex
+	// searchIndex is every plugin in the repository, for
+	// SearchPlugins.
+	searchIndex       []metadata.Plugin
+	searchIndexLoaded time.Time
 }
 
+const (
+	// searchIndexMaxAge is how long the plugins SearchPlugins looks
+	// through are kept before they are loaded again.
+	searchIndexMaxAge = 10 * time.Minute
+	// maxSearchResults is the most plugins SearchPlugins finds.
+	maxSearchResults = 50
+)
+
 // NewPluginsService makes a new PluginsService.
 func NewPluginsService(client *http.Client, baseURL string) *PluginsService {
 	return &PluginsService{
@@ -70,7 +96,7 @@ func (p *PluginsService) GetPlugins(categoryPath string) ([]metadata.Plugin, err
 	if err != nil {
 		return nil, err
 	}
-	return payload.Plugins, nil
+	return localizePlugins(payload.Plugins), nil
 }
 
 // GetPlugin gets the plugin metadata for a plugin.
@@ -99,6 +125,9 @@ func (p *PluginsService) GetPlugin(pluginPath string) (*metadata.Plugin, error)
 	if err != nil {
 		return nil, err
 	}
+	if payload.Plugin != nil {
+		*payload.Plugin = payload.Plugin.Localized(userLocale())
+	}
 	return payload.Plugin, nil
 }
 
@@ -128,14 +157,99 @@ func (p *PluginsService) GetFeaturedPlugins() ([]metadata.Plugin, error) {
 	if err != nil {
 		return nil, err
 	}
-	return payload.Plugins, nil
+	return localizePlugins(payload.Plugins), nil
+}
+
+// SearchPlugins finds the plugins in the repository whose title,
+// filename, authors or description match the query (see
+// metadata.Search), best matches first.
+func (p *PluginsService) SearchPlugins(query string) ([]metadata.Plugin, error) {
+	index, err := p.getSearchIndex()
+	if err != nil {
+		return nil, err
+	}
+	results := metadata.Search(index, query)
+	if len(results) > maxSearchResults {
+		results = results[:maxSearchResults]
+	}
+	return results, nil
+}
+
+// getSearchIndex gets every plugin in the repository, loading them if
+// they haven't been loaded lately.
+func (p *PluginsService) getSearchIndex() ([]metadata.Plugin, error) {
+	p.searchLock.Lock()
+	defer p.searchLock.Unlock()
+	if p.searchIndex != nil && time.Since(p.searchIndexLoaded) < searchIndexMaxAge {
+		return p.searchIndex, nil
+	}
+	req, err := http.NewRequest("GET", p.baseURL+"all-plugins.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	timeout := 30 * time.Second
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	var payload struct {
+		Plugins []metadata.Plugin
+	}
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, err
+	}
+	for i := range payload.Plugins {
+		// the code isn't searched, and takes up a lot of room
+		payload.Plugins[i].Files = nil
+	}
+	p.searchIndex = localizePlugins(payload.Plugins)
+	p.searchIndexLoaded = time.Now()
+	return p.searchIndex, nil
 }
 
-// GetInstalledPlugins gets the installed plugins.
+// GetInstalledPlugins gets the installed plugins, in the order they
+// are in the menu bar.
 func (p *PluginsService) GetInstalledPlugins() ([]plugins.InstalledPlugin, error) {
 	p.osLock.Lock()
 	defer p.osLock.Unlock()
-	return plugins.GetInstalledPlugins(pluginDirectory)
+	installedPlugins, err := plugins.GetInstalledPlugins(pluginDirectory)
+	if err != nil {
+		return nil, err
+	}
+	order, err := plugins.LoadOrder(orderFile)
+	if err != nil {
+		return nil, err
+	}
+	plugins.SortInstalledPlugins(installedPlugins, order)
+	return installedPlugins, nil
+}
+
+// MovePluginUp moves the plugin one place earlier in the menu bar.
+func (p *PluginsService) MovePluginUp(installedPluginPath string) error {
+	return p.movePlugin(installedPluginPath, -1)
+}
+
+// MovePluginDown moves the plugin one place later in the menu bar.
+func (p *PluginsService) MovePluginDown(installedPluginPath string) error {
+	return p.movePlugin(installedPluginPath, 1)
+}
+
+// movePlugin moves the plugin in the menu bar, and refreshes the
+// menus to show it.
+func (p *PluginsService) movePlugin(installedPluginPath string, by int) error {
+	defer p.OnRefresh()
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	return plugins.MovePlugin(pluginDirectory, orderFile, installedPluginPath, by)
 }
 
 // InstallPlugin installs the plugin described by the provided metadata.
@@ -160,9 +274,13 @@ func (p *PluginsService) InstallPlugin(plugin metadata.Plugin) (string, error) {
 	}
 	installer := &plugins.Installer{
 		Client: &http.Client{
-			Timeout: 1 * time.Minute,
+			Transport: networkTransport,
+			Timeout:   1 * time.Minute,
 		},
-		PluginDir: pluginDirectory,
+		PluginDir:    pluginDirectory,
+		AppVersion:   version,
+		OSVersion:    macOSVersion(),
+		InstallsFile: installsFile,
 	}
 	pluginPath := "https://xbarapp.com/docs/plugins/" + plugin.Path + ".json"
 	pluginPathURL, err := url.Parse(pluginPath)
@@ -177,6 +295,108 @@ func (p *PluginsService) InstallPlugin(plugin metadata.Plugin) (string, error) {
 	return installedPluginPath, nil
 }
 
+// CheckForUpdates gets the installed plugins that have newer versions
+// on xbarapp.com.
+func (p *PluginsService) CheckForUpdates() ([]plugins.PluginUpdate, error) {
+	return plugins.CheckForUpdates(pluginDirectory, installsFile, p.GetPlugin)
+}
+
+// UpgradePlugin installs the latest version of the installed plugin
+// from xbarapp.com over it (see CheckForUpdates), keeping its
+// variable values.
+func (p *PluginsService) UpgradePlugin(installedPluginPath string) error {
+	defer p.OnRefresh()
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	record, err := plugins.LoadInstallRecord(installsFile, installedPluginPath)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return errors.Errorf("%s was not installed from xbarapp.com", installedPluginPath)
+	}
+	installer := &plugins.Installer{
+		Client: &http.Client{
+			Transport: networkTransport,
+			Timeout:   1 * time.Minute,
+		},
+		PluginDir:    pluginDirectory,
+		AppVersion:   version,
+		OSVersion:    macOSVersion(),
+		InstallsFile: installsFile,
+	}
+	pluginPath := "https://xbarapp.com/docs/plugins/" + record.Path + ".json"
+	pluginPathURL, err := url.Parse(pluginPath)
+	if err != nil {
+		return errors.Wrapf(err, "parse URL: %s", pluginPath)
+	}
+	if err := installer.Upgrade(installedPluginPath, pluginPathURL); err != nil {
+		return errors.Wrap(err, "Upgrade")
+	}
+	tickOS() // wait a beat
+	return nil
+}
+
+// ReplacePlugin installs the plugin that replaces a deprecated installed
+// plugin (see metadata.Plugin.ReplacedBy), keeping its variable values,
+// and uninstalls it.
+// Returns the path of the replacement, or an empty string if the
+// user cancels.
+func (p *PluginsService) ReplacePlugin(installedPluginPath string) (string, error) {
+	defer p.OnRefresh()
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	md, err := metadata.ParseFile(metadata.DebugfNoop, filepath.Join(pluginDirectory, installedPluginPath))
+	if tagErrs, ok := err.(metadata.TagErrors); ok {
+		// the rest of the metadata is still fine
+		log.Printf("%s: %s", installedPluginPath, tagErrs)
+	} else if err != nil {
+		return "", err
+	}
+	if md.ReplacedBy == "" {
+		return "", errors.Errorf("%s has not been replaced", installedPluginPath)
+	}
+	if p.runtime != nil {
+		switch p.runtime.Dialog.Message(&dialog.MessageDialog{
+			Type:          "Question",
+			Title:         "Replace plugin",
+			Message:       fmt.Sprintf("Are you sure you want to replace %s with %s?\n\nYour settings will be kept where they can be.", md.Title, md.ReplacedBy),
+			Buttons:       []string{"Replace", "Cancel"},
+			DefaultButton: "Replace",
+			CancelButton:  "Cancel",
+		}) {
+		case "Replace":
+			// continue
+		case "Cancel":
+			return "", nil
+		}
+	}
+	installer := &plugins.Installer{
+		Client: &http.Client{
+			Transport: networkTransport,
+			Timeout:   1 * time.Minute,
+		},
+		PluginDir:    pluginDirectory,
+		CacheDir:     pluginCacheDirectory,
+		DataDir:      pluginDataDirectory,
+		HistoryDir:   historyDirectory,
+		AppVersion:   version,
+		OSVersion:    macOSVersion(),
+		InstallsFile: installsFile,
+	}
+	pluginPath := "https://xbarapp.com/docs/plugins/" + md.ReplacedBy + ".json"
+	pluginPathURL, err := url.Parse(pluginPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse URL: %s", pluginPath)
+	}
+	replacementPluginPath, err := installer.Replace(installedPluginPath, pluginPathURL)
+	if err != nil {
+		return "", errors.Wrap(err, "Replace")
+	}
+	tickOS() // wait a beat
+	return replacementPluginPath, nil
+}
+
 // UninstallPluginRequest is the object to send when uninstalling an
 // installed plugin.
 type UninstallPluginRequest struct {
@@ -205,7 +425,11 @@ func (p *PluginsService) UninstallPlugin(installedPluginInfo UninstallPluginRequ
 		}
 	}
 	installer := &plugins.Installer{
-		PluginDir: pluginDirectory,
+		PluginDir:    pluginDirectory,
+		CacheDir:     pluginCacheDirectory,
+		DataDir:      pluginDataDirectory,
+		HistoryDir:   historyDirectory,
+		InstallsFile: installsFile,
 	}
 	err := installer.Uninstall(installedPluginInfo.Path)
 	if err != nil {
@@ -222,6 +446,12 @@ type InstalledPluginMetadata struct {
 	Enabled         bool                    `json:"enabled"`
 	RefreshInterval plugins.RefreshInterval `json:"refreshInterval"`
 	Error           string                  `json:"error,omitempty"`
+	// Dependencies are whether the programs the plugin needs are
+	// installed, and how to install them if they aren't.
+	Dependencies []plugins.DependencyStatus `json:"dependencies,omitempty"`
+	// Warnings are the problems with the plugin's metadata, like
+	// tags that are invalid (and so are ignored).
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // GetInstalledPluginMetadata loads the plugin metadata from a plugin file.
@@ -229,23 +459,35 @@ func (p *PluginsService) GetInstalledPluginMetadata(installedPluginPath string)
 	p.osLock.Lock()
 	defer p.osLock.Unlock()
 	filename := filepath.Base(installedPluginPath)
-	b, err := os.ReadFile(filepath.Join(pluginDirectory, installedPluginPath))
-	if err != nil {
-		return nil, err
-	}
-	md, err := metadata.Parse(metadata.DebugfNoop, filename, string(b))
-	if err != nil {
+	md, err := metadata.ParseFile(metadata.DebugfNoop, filepath.Join(pluginDirectory, installedPluginPath))
+	tagErrs, ok := err.(metadata.TagErrors)
+	if !ok && err != nil {
 		return nil, err
 	}
 	md.Path = installedPluginPath
+	md = md.Localized(userLocale())
 	response := &InstalledPluginMetadata{
 		Plugin:  md,
 		Enabled: plugins.IsPluginEnabled(installedPluginPath),
 	}
+	for _, tagErr := range tagErrs {
+		response.Warnings = append(response.Warnings, tagErr.Error())
+	}
 	response.RefreshInterval, err = plugins.ParseFilenameInterval(filename)
 	if err != nil {
 		response.Error = err.Error()
 	}
+	if len(md.DependencyDetails) > 0 {
+		s, err := loadSettings(settingsFile)
+		if err != nil {
+			log.Println("failed to load settings (using defaults):", err)
+		}
+		env, err := pluginEnv(*s, os.Environ(), loginShellEnv)
+		if err != nil {
+			log.Println("failed to set up the environment for plugins:", err)
+		}
+		response.Dependencies = plugins.CheckDependencies(context.Background(), md.DependencyDetails, env)
+	}
 	return response, nil
 }
 
@@ -257,12 +499,24 @@ func (p *PluginsService) LoadVariableValues(installedPluginPath string) (map[str
 	return plugins.LoadVariableValues(pluginDirectory, installedPluginPath)
 }
 
-// SaveVariableValues saves the values for an installed plugin.
-func (p *PluginsService) SaveVariableValues(installedPluginPath string, values map[string]interface{}) error {
+// SaveVariableValues saves the values for an installed plugin, and
+// refreshes it.
+// If any values aren't valid, nothing is saved, and the problem with
+// each one is returned for the UI to show.
+func (p *PluginsService) SaveVariableValues(installedPluginPath string, values map[string]interface{}) ([]metadata.ValueError, error) {
 	p.osLock.Lock()
 	defer p.osLock.Unlock()
 	defer tickOS() // wait a beat
-	return plugins.SaveVariableValues(pluginDirectory, installedPluginPath, values)
+	err := plugins.SaveVariableValues(pluginDirectory, installedPluginPath, values)
+	var valueErrs metadata.ValueErrors
+	if errors.As(err, &valueErrs) {
+		return valueErrs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.variablesChanged(installedPluginPath)
+	return nil, nil
 }
 
 // SetEnabled sets a plugin to enabled or disabled state, depending on the value of
@@ -279,6 +533,262 @@ func (p *PluginsService) SetEnabled(installedPluginPath string, enabled bool) (s
 	return newPath, err
 }
 
+// GetPluginDirectories gets the folders plugins run from, in order of
+// priority.
+func (p *PluginsService) GetPluginDirectories() ([]string, error) {
+	s, err := loadSettings(settingsFile)
+	if err != nil {
+		return nil, err
+	}
+	return s.pluginDirectories(pluginDirectory), nil
+}
+
+// SetPluginDirectories saves the folders plugins run from, in order of
+// priority, and restarts the plugins.
+func (p *PluginsService) SetPluginDirectories(dirs []string) error {
+	if _, err := updateSettings(settingsFile, func(s *settings) {
+		s.PluginDirectories = dirs
+	}); err != nil {
+		return err
+	}
+	p.OnRefresh()
+	return nil
+}
+
+// PausePlugin stops a running plugin from refreshing, keeping its
+// menu as it is, until ResumePlugin is called.
+func (p *PluginsService) PausePlugin(installedPluginPath string) error {
+	return p.setPaused(installedPluginPath, true)
+}
+
+// ResumePlugin resumes a plugin paused with PausePlugin, and
+// refreshes it.
+func (p *PluginsService) ResumePlugin(installedPluginPath string) error {
+	return p.setPaused(installedPluginPath, false)
+}
+
+// GetPluginHistory gets the last runs of a plugin, oldest first, for
+// working out why it isn't working.
+func (p *PluginsService) GetPluginHistory(installedPluginPath string) ([]plugins.Run, error) {
+	runs, err := plugins.ReadHistory(plugins.HistoryFilename(historyDirectory, installedPluginPath))
+	if err != nil {
+		return nil, errors.Wrap(err, "read history")
+	}
+	return runs, nil
+}
+
+// GetPluginLog gets the last lines of a plugin's log, which has the
+// whole output of its recent runs, for the log viewer.
+// Zero lines gets as much as there is.
+func (p *PluginsService) GetPluginLog(installedPluginPath string, lines int) (string, error) {
+	tail, err := plugins.TailLog(plugins.LogFilename(logDirectory, installedPluginPath), lines)
+	if err != nil {
+		return "", errors.Wrap(err, "read log")
+	}
+	return tail, nil
+}
+
+// PluginStats are the statistics of a running plugin.
+type PluginStats struct {
+	// Path is the path of the plugin, relative to the plugin
+	// directory if it is in it.
+	Path string `json:"path"`
+	plugins.Stats
+}
+
+// GetPluginStats gets statistics about the runs of the running
+// plugins, those using the most CPU time first, for finding out
+// which plugins slow the Mac down.
+func (p *PluginsService) GetPluginStats() ([]PluginStats, error) {
+	stats := p.pluginStats()
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].CPUTime > stats[j].CPUTime
+	})
+	return stats, nil
+}
+
+// ImportPlugins copies the plugins (and their variables) from BitBar
+// and SwiftBar, if they're installed, skipping any that xbar already
+// has.
+// If dryRun is true, nothing is changed, and the report describes
+// what would be imported.
+func (p *PluginsService) ImportPlugins(dryRun bool) (*plugins.MigrationReport, error) {
+	if !dryRun {
+		defer p.OnRefresh()
+	}
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	report, err := plugins.Migrate(pluginDirectory, migrationSources(readDefault), dryRun)
+	if err != nil {
+		return nil, errors.Wrap(err, "import plugins")
+	}
+	if !dryRun {
+		tickOS() // wait a beat
+	}
+	return report, nil
+}
+
+// GetProfiles gets the profiles, which switch between sets of
+// plugins.
+func (p *PluginsService) GetProfiles() ([]plugins.Profile, error) {
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	return plugins.LoadProfiles(profilesFile)
+}
+
+// SaveProfile saves which plugins are enabled, and their variables,
+// as the profile called name, replacing it if it's already there.
+func (p *PluginsService) SaveProfile(name string) error {
+	defer p.OnRefresh()
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	profile, err := plugins.CaptureProfile(pluginDirectory, name)
+	if err != nil {
+		return errors.Wrap(err, "save profile")
+	}
+	if err := plugins.SaveProfile(profilesFile, profile); err != nil {
+		return errors.Wrap(err, "save profile")
+	}
+	if _, err := updateSettings(settingsFile, func(s *settings) {
+		s.Profile = name
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteProfile removes the profile called name.
+func (p *PluginsService) DeleteProfile(name string) error {
+	defer p.OnRefresh()
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	if err := plugins.DeleteProfile(profilesFile, name); err != nil {
+		return errors.Wrap(err, "delete profile")
+	}
+	return nil
+}
+
+// ApplyProfile enables and disables plugins, and sets their
+// variables, to match the profile called name, and refreshes the
+// plugins.
+func (p *PluginsService) ApplyProfile(name string) error {
+	defer p.OnRefresh()
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	profile, err := plugins.FindProfile(profilesFile, name)
+	if err != nil {
+		return errors.Wrap(err, "apply profile")
+	}
+	if err := plugins.ApplyProfile(pluginDirectory, profile); err != nil {
+		return errors.Wrap(err, "apply profile")
+	}
+	if _, err := updateSettings(settingsFile, func(s *settings) {
+		s.Profile = name
+	}); err != nil {
+		return err
+	}
+	tickOS() // wait a beat
+	return nil
+}
+
+// ExportBackup saves the installed plugins (with their variables),
+// their order and xbar's settings to a zip file, so they can be
+// restored on another Mac with ImportBackup.
+// The values of secret variables are kept in the Keychain, so they
+// aren't included.
+func (p *PluginsService) ExportBackup(filename string) error {
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrap(err, "export backup")
+	}
+	if err := plugins.Backup(f, pluginDirectory, backupConfigFiles()); err != nil {
+		f.Close()
+		return errors.Wrap(err, "export backup")
+	}
+	return f.Close()
+}
+
+// ImportBackup restores the plugins and settings from a backup made
+// with ExportBackup, replacing any that are already installed, and
+// refreshes the plugins.
+func (p *PluginsService) ImportBackup(filename string) error {
+	defer p.OnRefresh()
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	f, err := os.Open(filename)
+	if err != nil {
+		return errors.Wrap(err, "import backup")
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "import backup")
+	}
+	if err := plugins.Restore(f, info.Size(), pluginDirectory, backupConfigFiles()); err != nil {
+		return errors.Wrap(err, "import backup")
+	}
+	tickOS() // wait a beat
+	return nil
+}
+
+// backupConfigFiles gets the configuration files that go in a
+// backup, by their name in the backup.
+func backupConfigFiles() map[string]string {
+	return map[string]string{
+		"xbar.config.json": settingsFile,
+		"global.vars.json": globalVariablesFile,
+		"installs.json":    installsFile,
+		"order.json":       orderFile,
+		"profiles.json":    profilesFile,
+	}
+}
+
+// GetGlobalVariables gets the variables given to every plugin, by
+// name (without the XBAR_GLOBAL_ prefix).
+func (p *PluginsService) GetGlobalVariables() (map[string]string, error) {
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	return plugins.LoadGlobalVariables(globalVariablesFile)
+}
+
+// SetGlobalVariable adds or updates a variable given to every plugin,
+// and refreshes the plugins.
+func (p *PluginsService) SetGlobalVariable(name, value string) error {
+	if err := plugins.ValidateGlobalVariableName(name); err != nil {
+		return err
+	}
+	return p.updateGlobalVariables(func(values map[string]string) {
+		values[name] = value
+	})
+}
+
+// DeleteGlobalVariable removes a variable given to every plugin, and
+// refreshes the plugins.
+func (p *PluginsService) DeleteGlobalVariable(name string) error {
+	return p.updateGlobalVariables(func(values map[string]string) {
+		delete(values, name)
+	})
+}
+
+// updateGlobalVariables loads the global variables, calls fn to change
+// them, and saves them.
+func (p *PluginsService) updateGlobalVariables(fn func(values map[string]string)) error {
+	defer p.OnRefresh()
+	p.osLock.Lock()
+	defer p.osLock.Unlock()
+	values, err := plugins.LoadGlobalVariables(globalVariablesFile)
+	if err != nil {
+		return errors.Wrap(err, "load global variables")
+	}
+	fn(values)
+	if err := plugins.SaveGlobalVariables(globalVariablesFile, values); err != nil {
+		return errors.Wrap(err, "save global variables")
+	}
+	return nil
+}
+
 // SetRefreshIntervalResult is the refresh interval result returned from SetRefreshInterval.
 type SetRefreshIntervalResult struct {
 	InstalledPluginPath string                  `json:"installedPluginPath"`
@@ -301,3 +811,41 @@ func (p *PluginsService) SetRefreshInterval(installedPluginPath string, refreshI
 	tickOS() // wait a beat
 	return result, nil
 }
+
+// macOSVersion gets the version of macOS, like 11.6, or an empty
+// string if it can't be found.
+func macOSVersion() string {
+	out, err := exec.Command("sw_vers", "-productVersion").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// localizePlugins gets the plugins in the user's language, where
+// they have translations.
+func localizePlugins(plugins []metadata.Plugin) []metadata.Plugin {
+	locale := userLocale()
+	for i := range plugins {
+		plugins[i] = plugins[i].Localized(locale)
+	}
+	return plugins
+}
+
+var (
+	userLocaleOnce   sync.Once
+	userLocaleResult string
+)
+
+// userLocale gets the user's locale (like en_GB) from their macOS
+// settings, or the LANG environment variable.
+func userLocale() string {
+	userLocaleOnce.Do(func() {
+		locale, err := readDefault("-g", "AppleLocale")
+		if err != nil || locale == "" {
+			locale = os.Getenv("LANG")
+		}
+		userLocaleResult = locale
+	})
+	return userLocaleResult
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// powerMonitor watches whether the Mac should save power (because it
+// is on battery power, or in Low Power Mode), and calls onChange when
+// that changes.
+// On macOS, it is told about changes by the system (see
+// watchNativePower); otherwise it checks every interval.
+type powerMonitor struct {
+	// interval is how often to check, when polling.
+	interval time.Duration
+	// saving gets whether the Mac should save power.
+	saving func() bool
+	// watchNative starts watching for changes from the system,
+	// returning false if it can't.
+	watchNative func(changed func()) bool
+	// onChange is called with whether the Mac should save power,
+	// once at the start, and again whenever it changes.
+	onChange func(saving bool)
+
+	// changes receives a signal when the system reports a change.
+	changes chan struct{}
+}
+
+// newPowerMonitor makes a new powerMonitor that calls onChange.
+func newPowerMonitor(onChange func(saving bool)) *powerMonitor {
+	return &powerMonitor{
+		interval:    30 * time.Second,
+		saving:      savingPower,
+		watchNative: watchNativePower,
+		onChange:    onChange,
+		changes:     make(chan struct{}, 1),
+	}
+}
+
+// run watches the power until ctx is done.
+func (m *powerMonitor) run(ctx context.Context) {
+	last := m.saving()
+	m.onChange(last)
+	var tick <-chan time.Time
+	if !m.watchNative(m.changed) {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+		case <-m.changes:
+		case <-ctx.Done():
+			return
+		}
+		if saving := m.saving(); saving != last {
+			last = saving
+			m.onChange(saving)
+		}
+	}
+}
+
+// changed is called when the system reports a change to the power.
+func (m *powerMonitor) changed() {
+	select {
+	case m.changes <- struct{}{}:
+	default:
+		// a check is already pending
+	}
+}
@@ -0,0 +1,46 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+/*
+#cgo LDFLAGS: -framework Foundation -framework IOKit
+
+// defined in power_darwin.m
+int xbarSavingPower(void);
+int xbarWatchPower(void);
+*/
+import "C"
+
+import "sync"
+
+var (
+	nativePowerChangedLock sync.Mutex
+	nativePowerChanged     func()
+)
+
+// savingPower gets whether the Mac is on battery power, or in Low
+// Power Mode.
+func savingPower() bool {
+	return C.xbarSavingPower() == 1
+}
+
+// watchNativePower subscribes to IOPowerSources notifications, and
+// to Low Power Mode changes, calling changed when they happen.
+// It should only be called once.
+func watchNativePower(changed func()) bool {
+	nativePowerChangedLock.Lock()
+	nativePowerChanged = changed
+	nativePowerChangedLock.Unlock()
+	return C.xbarWatchPower() == 1
+}
+
+//export xbarPowerChanged
+func xbarPowerChanged() {
+	nativePowerChangedLock.Lock()
+	changed := nativePowerChanged
+	nativePowerChangedLock.Unlock()
+	if changed != nil {
+		changed()
+	}
+}
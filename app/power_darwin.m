@@ -0,0 +1,49 @@
+#import <Foundation/Foundation.h>
+#import <IOKit/ps/IOPowerSources.h>
+#import <IOKit/ps/IOPSKeys.h>
+
+// defined in power_darwin.go
+extern void xbarPowerChanged(void);
+
+int xbarSavingPower(void) {
+	int saving = 0;
+	CFTypeRef info = IOPSCopyPowerSourcesInfo();
+	if (info != NULL) {
+		CFStringRef source = IOPSGetProvidingPowerSourceType(info);
+		if (source != NULL && CFStringCompare(source, CFSTR(kIOPMBatteryPowerKey), 0) == kCFCompareEqualTo) {
+			saving = 1;
+		}
+		CFRelease(info);
+	}
+	if (@available(macOS 12.0, *)) {
+		if ([[NSProcessInfo processInfo] isLowPowerModeEnabled]) {
+			saving = 1;
+		}
+	}
+	return saving;
+}
+
+static void xbarPowerSourcesChanged(void *context) {
+	xbarPowerChanged();
+}
+
+int xbarWatchPower(void) {
+	CFRunLoopSourceRef source = IOPSNotificationCreateRunLoopSource(xbarPowerSourcesChanged, NULL);
+	if (source == NULL) {
+		return 0;
+	}
+	dispatch_async(dispatch_get_main_queue(), ^{
+		// the run loop keeps the source
+		CFRunLoopAddSource(CFRunLoopGetMain(), source, kCFRunLoopCommonModes);
+		CFRelease(source);
+	});
+	if (@available(macOS 12.0, *)) {
+		[[NSNotificationCenter defaultCenter] addObserverForName:NSProcessInfoPowerStateDidChangeNotification
+			object:nil
+			queue:nil
+			usingBlock:^(NSNotification *notification) {
+				xbarPowerChanged();
+			}];
+	}
+	return 1;
+}
@@ -0,0 +1,15 @@
+//go:build !darwin
+// +build !darwin
+
+package main
+
+// savingPower can't tell on this platform, so assumes not.
+func savingPower() bool {
+	return false
+}
+
+// watchNativePower isn't supported on this platform, so powerMonitor
+// polls instead.
+func watchNativePower(changed func()) bool {
+	return false
+}
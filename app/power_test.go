@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestPowerMonitor(t *testing.T) {
+	is := is.New(t)
+
+	var lock sync.Mutex
+	saving := false
+	var changes []bool
+	m := &powerMonitor{
+		interval: 10 * time.Millisecond,
+		saving: func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return saving
+		},
+		onChange: func(saving bool) {
+			lock.Lock()
+			defer lock.Unlock()
+			changes = append(changes, saving)
+		},
+		watchNative: func(func()) bool {
+			return false
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.run(ctx)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	lock.Lock()
+	saving = true
+	lock.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+	is.Equal(changes, []bool{false, true})
+}
+
+func TestPowerMonitorNative(t *testing.T) {
+	is := is.New(t)
+
+	var lock sync.Mutex
+	saving, checks := false, 0
+	changes := make(chan bool, 10)
+	watching := make(chan func(), 1)
+	m := newPowerMonitor(func(saving bool) {
+		changes <- saving
+	})
+	m.saving = func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		checks++
+		return saving
+	}
+	m.watchNative = func(changed func()) bool {
+		watching <- changed
+		return true
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.run(ctx)
+	changed := <-watching
+	is.Equal(<-changes, false)
+
+	// unplugged
+	lock.Lock()
+	saving = true
+	lock.Unlock()
+	changed()
+	is.Equal(<-changes, true)
+
+	// something else changed
+	changed()
+	select {
+	case saving := <-changes:
+		t.Fatalf("unexpected change: %v", saving)
+	case <-time.After(50 * time.Millisecond):
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	is.Equal(checks, 3) // only when told about a change
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// fromCacheHeader is set (by httpcache, with MarkCachedResponses) on
+// responses that came from the cache, including ones revalidated
+// with an ETag.
+const fromCacheHeader = "X-From-Cache"
+
+// repositoryTransport serves the plugin repository (categories,
+// featured plugins and plugins) from the cache straight away, so the
+// plugin browser loads instantly and works offline, and revalidates
+// it in the background.
+type repositoryTransport struct {
+	// transport makes requests through the cache, which revalidates
+	// them with ETags.
+	transport http.RoundTripper
+	// cached gets the cached response to the request, or nil if
+	// there isn't one.
+	cached func(req *http.Request) (*http.Response, error)
+	// changed is called when revalidating finds that something in
+	// the repository has changed.
+	changed func()
+
+	lock sync.Mutex
+	// revalidating are the URLs being revalidated, so they're only
+	// revalidated once at a time.
+	revalidating map[string]bool
+}
+
+// RoundTrip serves the request from the cache, if it's there,
+// revalidating it in the background, or else makes the request.
+func (t *repositoryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.transport.RoundTrip(req)
+	}
+	resp, err := t.cached(req)
+	if err != nil || resp == nil {
+		return t.transport.RoundTrip(req)
+	}
+	go t.revalidate(req.Clone(context.Background()))
+	return resp, nil
+}
+
+// revalidate makes the request through the cache, which updates it.
+func (t *repositoryTransport) revalidate(req *http.Request) {
+	url := req.URL.String()
+	t.lock.Lock()
+	if t.revalidating[url] {
+		t.lock.Unlock()
+		return
+	}
+	if t.revalidating == nil {
+		t.revalidating = make(map[string]bool)
+	}
+	t.revalidating[url] = true
+	t.lock.Unlock()
+	defer func() {
+		t.lock.Lock()
+		delete(t.revalidating, url)
+		t.lock.Unlock()
+	}()
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		// probably offline - keep using the cache
+		log.Println("revalidate:", url, err)
+		return
+	}
+	// the cache is updated once the body has been read
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		log.Println("revalidate:", url, err)
+		return
+	}
+	if resp.StatusCode == http.StatusOK && resp.Header.Get(fromCacheHeader) == "" && t.changed != nil {
+		t.changed()
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// roundTripperFunc is a http.RoundTripper made from a func.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRepositoryTransport(t *testing.T) {
+	is := is.New(t)
+
+	response := func(body string, header http.Header) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}
+	}
+	requests := make(chan string, 10)
+	online := true
+	changed := make(chan struct{}, 10)
+	transport := &repositoryTransport{
+		transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			isOnline := online // read before the request is seen
+			requests <- req.URL.Path
+			if !isOnline {
+				return nil, errors.New("offline")
+			}
+			if req.URL.Path == "/unchanged.json" {
+				return response("cached", http.Header{fromCacheHeader: []string{"1"}}), nil
+			}
+			return response("fresh", http.Header{}), nil
+		}),
+		cached: func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/new.json" {
+				return nil, nil
+			}
+			return response("cached", http.Header{fromCacheHeader: []string{"1"}}), nil
+		},
+		changed: func() {
+			changed <- struct{}{}
+		},
+	}
+	client := &http.Client{Transport: transport}
+	get := func(path string) (string, error) {
+		resp, err := client.Get("https://xbarapp.com" + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		return string(b), err
+	}
+	waitFor := func(path string) {
+		select {
+		case got := <-requests:
+			is.Equal(got, path)
+		case <-time.After(time.Second):
+			t.Fatalf("no request for %s", path)
+		}
+	}
+
+	body, err := get("/new.json")
+	is.NoErr(err)
+	is.Equal(body, "fresh") // not cached yet
+	waitFor("/new.json")
+
+	body, err = get("/changed.json")
+	is.NoErr(err)
+	is.Equal(body, "cached") // straight from the cache
+	waitFor("/changed.json")
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("changed not called")
+	}
+
+	body, err = get("/unchanged.json")
+	is.NoErr(err)
+	is.Equal(body, "cached")
+	waitFor("/unchanged.json")
+
+	online = false
+	body, err = get("/offline.json")
+	is.NoErr(err)
+	is.Equal(body, "cached") // works offline
+	waitFor("/offline.json")
+	_, err = get("/new.json")
+	is.True(err != nil) // offline, and not cached
+
+	time.Sleep(10 * time.Millisecond)
+	is.Equal(len(changed), 0) // only the changed response
+}
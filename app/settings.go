@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/matryer/xbar/pkg/plugins"
+	"github.com/matryer/xbar/pkg/update"
+	"github.com/pkg/errors"
+)
+
+// settingsFile is where the user's settings are stored.
+var settingsFile = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar", "xbar.config.json")
+
+// settings are the user's preferences for xbar.
+type settings struct {
+	// Terminal is the terminal app that items with terminal=true
+	// run in. One of plugins.TerminalApps, or empty for Terminal.
+	Terminal string `json:"terminal"`
+	// Jitter is the percentage (0-100) to randomly vary the time
+	// between plugin refreshes by, so they don't all run at once.
+	// Plugins can override it with xbar.jitter.
+	Jitter int `json:"jitter"`
+	// StartupConcurrency is how many plugins may run at once when
+	// xbar starts, or zero for defaultStartupConcurrency.
+	StartupConcurrency int `json:"startupConcurrency"`
+	// BatteryThrottle is how many times slower plugins refresh on
+	// battery power, or in Low Power Mode, or zero for
+	// defaultBatteryThrottle. 1 turns throttling off.
+	BatteryThrottle float64 `json:"batteryThrottle"`
+	// Path are extra directories to look for programs in, added to
+	// the start of the PATH for plugins.
+	Path []string `json:"path"`
+	// PluginDirectories are the folders to run plugins from, in
+	// order of priority (like a personal folder, then one managed by
+	// dotfiles). xbar's plugin directory comes first, unless it is
+	// in the list.
+	PluginDirectories []string `json:"pluginDirectories,omitempty"`
+	// Profile is the name of the profile that was applied last
+	// (see plugins.Profile), which is checked in the menu.
+	Profile string `json:"profile,omitempty"`
+	// LogMaxBytes is how big each plugin's log file gets before it
+	// is rotated, or zero for the default (1MB).
+	LogMaxBytes int64 `json:"logMaxBytes,omitempty"`
+	// LogMaxFiles is how many rotated log files are kept for each
+	// plugin, or zero for the default (3).
+	LogMaxFiles int `json:"logMaxFiles,omitempty"`
+	// LoginShell is whether plugins get the environment variables
+	// set up by the user's login shell (like in ~/.zprofile).
+	LoginShell bool `json:"loginShell"`
+	// Env are environment variables for all plugins.
+	Env map[string]string `json:"env"`
+	// UpdateChannel is which releases xbar updates to: stable (the
+	// default), beta or nightly.
+	UpdateChannel string `json:"updateChannel,omitempty"`
+	// UpdateFeed is the URL of a Sparkle appcast to check for updates
+	// in, instead of GitHub releases.
+	UpdateFeed string `json:"updateFeed,omitempty"`
+	// SkipUpdate is a version of xbar that automatic update checks
+	// don't offer again, because it was skipped, or rolled back from.
+	SkipUpdate string `json:"skipUpdate,omitempty"`
+	// RemindUpdateAfter is when automatic update checks can offer
+	// updates again, after choosing Later.
+	RemindUpdateAfter time.Time `json:"remindUpdateAfter,omitempty"`
+	// UpdateCheckInterval is how often to check for updates (like
+	// 12h), or empty for defaultUpdateCheckInterval.
+	UpdateCheckInterval string `json:"updateCheckInterval,omitempty"`
+	// Proxy is the URL of the proxy for xbar's requests (like
+	// http://proxy.example.com:8080), or empty to use the system one.
+	Proxy string `json:"proxy,omitempty"`
+	// CACertificates are PEM files of extra certificate authorities
+	// to trust, for networks that intercept HTTPS.
+	CACertificates []string `json:"caCertificates,omitempty"`
+}
+
+// pluginDirectories gets the folders to run plugins from, in order
+// of priority, including xbar's pluginDir.
+// A plugin in an earlier folder hides one with the same name in a
+// later one.
+func (s settings) pluginDirectories(pluginDir string) []string {
+	dirs := uniquePaths(s.PluginDirectories)
+	for _, dir := range dirs {
+		if dir == pluginDir {
+			return dirs
+		}
+	}
+	return append([]string{pluginDir}, dirs...)
+}
+
+// defaultBatteryThrottle is the default settings.BatteryThrottle.
+const defaultBatteryThrottle = 2
+
+// batteryThrottle gets how many times slower plugins refresh on
+// battery power.
+func (s settings) batteryThrottle() float64 {
+	if s.BatteryThrottle == 0 {
+		return defaultBatteryThrottle
+	}
+	return s.BatteryThrottle
+}
+
+// defaultStartupConcurrency is the default
+// settings.StartupConcurrency.
+const defaultStartupConcurrency = 4
+
+// startupConcurrency gets how many plugins may run at once when xbar
+// starts.
+func (s settings) startupConcurrency() int {
+	if s.StartupConcurrency == 0 {
+		return defaultStartupConcurrency
+	}
+	return s.StartupConcurrency
+}
+
+// defaultUpdateCheckInterval is the default
+// settings.UpdateCheckInterval.
+const defaultUpdateCheckInterval = 24 * time.Hour
+
+// minUpdateCheckInterval is the shortest settings.UpdateCheckInterval.
+const minUpdateCheckInterval = 1 * time.Hour
+
+// updateCheckInterval gets how often to check for updates.
+func (s settings) updateCheckInterval() time.Duration {
+	interval, err := time.ParseDuration(s.UpdateCheckInterval)
+	if err != nil || interval < minUpdateCheckInterval {
+		return defaultUpdateCheckInterval
+	}
+	return interval
+}
+
+// offerUpdate gets whether automatic update checks should offer the
+// version, which they don't if it was skipped, or if Later was chosen
+// recently.
+func (s settings) offerUpdate(version string, now time.Time) bool {
+	if version == s.SkipUpdate {
+		return false
+	}
+	return !now.Before(s.RemindUpdateAfter)
+}
+
+// updateChannel gets the channel xbar updates from.
+func (s settings) updateChannel() update.Channel {
+	channel, err := update.ParseChannel(s.UpdateChannel)
+	if err != nil {
+		return update.ChannelStable
+	}
+	return channel
+}
+
+// loadSettings loads the settings from filename.
+// If the file doesn't exist, the default settings are returned.
+// Settings that are invalid are logged, and reset to their defaults,
+// keeping the rest.
+func loadSettings(filename string) (*settings, error) {
+	s := &settings{}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return s, errors.Wrap(err, "read settings")
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return &settings{}, errors.Wrap(err, "json.Unmarshal")
+	}
+	for _, err := range s.fix() {
+		log.Printf("%s (ignoring it)", err)
+	}
+	return s, nil
+}
+
+// validate checks the settings, returning the first problem.
+func (s settings) validate() error {
+	// fix changes a copy, so s is left alone
+	if errs := s.fix(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// fix checks each of the settings, and resets any that are invalid
+// to their defaults (or leaves out the invalid parts), returning the
+// problems.
+// Maps and slices are replaced rather than changed.
+func (s *settings) fix() []error {
+	var errs []error
+	if s.Jitter < 0 || s.Jitter > 100 {
+		errs = append(errs, errors.Errorf("settings: jitter should be a percentage (0-100), not %d", s.Jitter))
+		if s.Jitter < 0 {
+			s.Jitter = 0
+		} else {
+			s.Jitter = 100
+		}
+	}
+	if s.BatteryThrottle != 0 && s.BatteryThrottle < 1 {
+		errs = append(errs, errors.Errorf("settings: batteryThrottle should be 1 or more, not %v", s.BatteryThrottle))
+		s.BatteryThrottle = 0
+	}
+	var env map[string]string
+	for key, value := range s.Env {
+		if key == "" || strings.ContainsAny(key, "= ") {
+			errs = append(errs, errors.Errorf("settings: env: invalid variable name %q", key))
+			continue
+		}
+		if env == nil {
+			env = make(map[string]string, len(s.Env))
+		}
+		env[key] = value
+	}
+	if len(env) != len(s.Env) {
+		s.Env = env
+	}
+	if _, err := update.ParseChannel(s.UpdateChannel); err != nil {
+		errs = append(errs, errors.Wrap(err, "settings: updateChannel"))
+		s.UpdateChannel = ""
+	}
+	var dirs []string
+	for _, dir := range s.PluginDirectories {
+		if !filepath.IsAbs(dir) && !strings.HasPrefix(dir, "~/") {
+			errs = append(errs, errors.Errorf("settings: pluginDirectories should be full paths (like ~/dotfiles/xbar), not %q", dir))
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) != len(s.PluginDirectories) {
+		s.PluginDirectories = dirs
+	}
+	if s.UpdateFeed != "" {
+		if u, err := url.Parse(s.UpdateFeed); err != nil || u.Host == "" {
+			errs = append(errs, errors.Errorf("settings: updateFeed should be a URL (like https://example.com/appcast.xml), not %q", s.UpdateFeed))
+			s.UpdateFeed = ""
+		}
+	}
+	if s.Proxy != "" {
+		if u, err := url.Parse(s.Proxy); err != nil || u.Host == "" {
+			errs = append(errs, errors.Errorf("settings: proxy should be a URL (like http://proxy.example.com:8080), not %q", s.Proxy))
+			s.Proxy = ""
+		}
+	}
+	if s.UpdateCheckInterval != "" {
+		interval, err := time.ParseDuration(s.UpdateCheckInterval)
+		if err != nil || interval < minUpdateCheckInterval {
+			errs = append(errs, errors.Errorf("settings: updateCheckInterval should be a duration of %s or more (like 12h), not %q", minUpdateCheckInterval, s.UpdateCheckInterval))
+			s.UpdateCheckInterval = ""
+		}
+	}
+	if s.StartupConcurrency < 0 {
+		errs = append(errs, errors.Errorf("settings: startupConcurrency should be zero or more, not %d", s.StartupConcurrency))
+		s.StartupConcurrency = 0
+	}
+	if s.LogMaxBytes < 0 {
+		errs = append(errs, errors.Errorf("settings: logMaxBytes should be zero or more, not %d", s.LogMaxBytes))
+		s.LogMaxBytes = 0
+	}
+	if s.LogMaxFiles < 0 {
+		errs = append(errs, errors.Errorf("settings: logMaxFiles should be zero or more, not %d", s.LogMaxFiles))
+		s.LogMaxFiles = 0
+	}
+	if s.Terminal != "" && !containsString(plugins.TerminalApps, s.Terminal) {
+		errs = append(errs, errors.Errorf("settings: unsupported terminal %q", s.Terminal))
+		s.Terminal = ""
+	}
+	return errs
+}
+
+// updateSettings loads the settings from filename, changes them with
+// fn, and saves them, returning the new settings.
+func updateSettings(filename string, fn func(s *settings)) (*settings, error) {
+	s, err := loadSettings(filename)
+	if err != nil {
+		return nil, err
+	}
+	fn(s)
+	if err := s.save(filename); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// save writes the settings to filename.
+func (s settings) save(filename string) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "json.MarshalIndent")
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return errors.Wrap(err, "make settings directory")
+	}
+	if err := ioutil.WriteFile(filename, b, 0666); err != nil {
+		return errors.Wrap(err, "write settings")
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/matryer/xbar/pkg/update"
+)
+
+func TestSettings(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-settings-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	filename := filepath.Join(dir, "xbar.config.json")
+
+	// defaults when there is no file
+	s, err := loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.Terminal, "")
+
+	s.Terminal = "iTerm2"
+	is.NoErr(s.save(filename))
+	s, err = loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.Terminal, "iTerm2")
+
+	s.Terminal = "Hyper"
+	is.True(s.save(filename) != nil) // unsupported
+
+	is.NoErr(os.WriteFile(filename, []byte(`{"terminal":"Hyper"}`), 0666))
+	s, err = loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.Terminal, "") // the default
+
+	s.Jitter = 10
+	is.NoErr(s.save(filename))
+	s, err = loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.Jitter, 10)
+
+	s.Jitter = 150
+	is.True(s.save(filename) != nil) // not a percentage
+	s.Jitter = 10
+
+	is.Equal(s.startupConcurrency(), defaultStartupConcurrency)
+	s.StartupConcurrency = 8
+	is.NoErr(s.save(filename))
+	s, err = loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.startupConcurrency(), 8)
+
+	s.StartupConcurrency = -1
+	is.True(s.save(filename) != nil) // negative
+	s.StartupConcurrency = 0
+
+	is.Equal(s.batteryThrottle(), float64(defaultBatteryThrottle))
+	s.BatteryThrottle = 1.5
+	is.NoErr(s.save(filename))
+	s, err = loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.batteryThrottle(), 1.5)
+
+	s.BatteryThrottle = 0.5
+	is.True(s.save(filename) != nil) // would refresh faster
+	s.BatteryThrottle = 0
+
+	s.Env = map[string]string{"NOT VALID": "value"}
+	is.True(s.save(filename) != nil) // invalid name
+	s.Env = nil
+
+	is.Equal(s.pluginDirectories("/xbar/plugins"), []string{"/xbar/plugins"})
+	s.PluginDirectories = []string{"/dotfiles/xbar", "/dotfiles/xbar"}
+	is.NoErr(s.save(filename))
+	s, err = loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.pluginDirectories("/xbar/plugins"), []string{"/xbar/plugins", "/dotfiles/xbar"})
+	s.PluginDirectories = []string{"/dotfiles/xbar", "/xbar/plugins"}
+	is.Equal(s.pluginDirectories("/xbar/plugins"), []string{"/dotfiles/xbar", "/xbar/plugins"}) // in the list, so not first
+	s.PluginDirectories = []string{"plugins"}
+	is.True(s.save(filename) != nil) // not a full path
+	s.PluginDirectories = nil
+
+	s.LogMaxBytes = -1
+	is.True(s.save(filename) != nil) // negative
+	s.LogMaxBytes = 0
+	s.LogMaxFiles = -1
+	is.True(s.save(filename) != nil) // negative
+	s.LogMaxFiles = 0
+
+	is.Equal(s.updateChannel(), update.ChannelStable)
+	s.UpdateChannel = "beta"
+	is.NoErr(s.save(filename))
+	s, err = loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.updateChannel(), update.ChannelBeta)
+
+	s.UpdateChannel = "canary"
+	is.True(s.save(filename) != nil) // unsupported
+	s.UpdateChannel = ""
+
+	s.UpdateFeed = "https://example.com/appcast.xml"
+	is.NoErr(s.save(filename))
+	s.UpdateFeed = "appcast.xml"
+	is.True(s.save(filename) != nil) // not a URL
+}
+
+func TestLoadInvalidSettings(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-settings-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	filename := filepath.Join(dir, "xbar.config.json")
+	is.NoErr(os.WriteFile(filename, []byte(`{
+	"terminal": "iTerm2",
+	"jitter": 150,
+	"env": {"GOOD": "1", "NOT VALID": "2"},
+	"pluginDirectories": ["/dotfiles/xbar", "plugins"],
+	"updateChannel": "beta",
+	"proxy": "nope",
+	"logMaxFiles": -1
+}`), 0666))
+
+	// only the invalid settings are left out
+	s, err := loadSettings(filename)
+	is.NoErr(err)
+	is.Equal(s.Terminal, "iTerm2")
+	is.Equal(s.Jitter, 100) // clamped
+	is.Equal(s.Env, map[string]string{"GOOD": "1"})
+	is.Equal(s.PluginDirectories, []string{"/dotfiles/xbar"})
+	is.Equal(s.updateChannel(), update.ChannelBeta)
+	is.Equal(s.Proxy, "")
+	is.Equal(s.LogMaxFiles, 0)
+	is.NoErr(s.validate())
+
+	// validating doesn't change the settings
+	s.Env["NOT VALID"] = "2"
+	is.True(s.validate() != nil)
+	is.Equal(len(s.Env), 2)
+}
+
+func TestOfferUpdate(t *testing.T) {
+	is := is.New(t)
+
+	now := time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+	s := settings{}
+	is.True(s.offerUpdate("v2.1.0", now))
+	is.Equal(s.updateCheckInterval(), defaultUpdateCheckInterval)
+
+	s.SkipUpdate = "v2.1.0"
+	is.True(!s.offerUpdate("v2.1.0", now)) // skipped
+	is.True(s.offerUpdate("v2.1.1", now))
+
+	s.RemindUpdateAfter = now.Add(1 * time.Hour)
+	is.True(!s.offerUpdate("v2.1.1", now))                 // later
+	is.True(s.offerUpdate("v2.1.1", now.Add(1*time.Hour))) // time to remind
+
+	s.UpdateCheckInterval = "12h"
+	is.NoErr(s.validate())
+	is.Equal(s.updateCheckInterval(), 12*time.Hour)
+	s.UpdateCheckInterval = "1m"
+	is.True(s.validate() != nil) // too often
+	s.UpdateCheckInterval = "daily"
+	is.True(s.validate() != nil) // not a duration
+}
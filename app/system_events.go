@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// systemEvents watches for the computer waking up from sleep, and
+// for the network changing.
+// On macOS, it is told about them by the system (see
+// watchNativeSystemEvents); elsewhere it checks every interval.
+// Events that happen close together (like waking up, and then
+// reconnecting to the network) are merged, so there is only one
+// call to onChange.
+type systemEvents struct {
+	// interval is how often to check, when polling.
+	interval time.Duration
+	// wakeThreshold is how much longer than interval the time
+	// between checks must be to count as the computer sleeping.
+	wakeThreshold time.Duration
+	// settle is how long to wait after an event for others, before
+	// calling onChange.
+	settle time.Duration
+	// now gets the current time.
+	now func() time.Time
+	// network gets a description of the network, which changes
+	// when the network does.
+	network func() string
+	// watchNative starts watching for events from the system,
+	// returning false if it can't.
+	watchNative func(event func(what string)) bool
+
+	// onChange is called with what happened (like "woke from sleep")
+	// when the computer wakes up, or the network changes.
+	onChange func(what string)
+
+	// events receives the events, to be merged.
+	events chan string
+}
+
+const (
+	eventWoke           = "woke from sleep"
+	eventNetworkChanged = "network changed"
+)
+
+// newSystemEvents makes a new systemEvents that calls onChange.
+func newSystemEvents(onChange func(what string)) *systemEvents {
+	return &systemEvents{
+		interval:      5 * time.Second,
+		wakeThreshold: 30 * time.Second,
+		settle:        5 * time.Second,
+		now:           time.Now,
+		network:       networkAddresses,
+		watchNative:   watchNativeSystemEvents,
+		onChange:      onChange,
+		events:        make(chan string, 10),
+	}
+}
+
+// run watches for events until ctx is done.
+func (s *systemEvents) run(ctx context.Context) {
+	if !s.watchNative(s.event) {
+		go s.poll(ctx)
+	}
+	var (
+		pending []string
+		settled <-chan time.Time
+	)
+	for {
+		select {
+		case what := <-s.events:
+			if !containsString(pending, what) {
+				pending = append(pending, what)
+			}
+			if settled == nil {
+				settled = time.After(s.settle)
+			}
+		case <-settled:
+			s.onChange(strings.Join(pending, " and "))
+			pending, settled = nil, nil
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// event is called when something happens.
+func (s *systemEvents) event(what string) {
+	select {
+	case s.events <- what:
+	default:
+		// plenty pending already, and they are merged anyway
+	}
+}
+
+// poll checks for events every interval until ctx is done.
+func (s *systemEvents) poll(ctx context.Context) {
+	last, lastNetwork := s.now(), s.network()
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			last, lastNetwork = s.check(last, lastNetwork)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// check looks for events since the last check, returning the time
+// and network to compare with next time.
+func (s *systemEvents) check(last time.Time, lastNetwork string) (time.Time, string) {
+	now := s.now()
+	// timers don't run while asleep, so the wall clock will have
+	// jumped ahead (Round(0) strips the monotonic clock reading)
+	if now.Round(0).Sub(last.Round(0)) > s.interval+s.wakeThreshold {
+		s.event(eventWoke)
+	}
+	network := s.network()
+	if network != lastNetwork {
+		s.event(eventNetworkChanged)
+	}
+	return now, network
+}
+
+// containsString gets whether the strings contain s.
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+// networkAddresses gets the addresses of the network interfaces that
+// are up (apart from loopback), as a string.
+func networkAddresses() string {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	var addresses []string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			addresses = append(addresses, iface.Name+"="+addr.String())
+		}
+	}
+	sort.Strings(addresses)
+	return strings.Join(addresses, ",")
+}
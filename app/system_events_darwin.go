@@ -0,0 +1,44 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+/*
+#cgo LDFLAGS: -framework Cocoa -framework SystemConfiguration
+
+// defined in system_events_darwin.m
+int xbarWatchSystemEvents(void);
+*/
+import "C"
+
+import "sync"
+
+var (
+	nativeSystemEventLock sync.Mutex
+	nativeSystemEvent     func(what string)
+)
+
+// watchNativeSystemEvents subscribes to NSWorkspaceDidWakeNotification,
+// and to SCNetworkReachability changes, calling event when they happen.
+// It should only be called once.
+func watchNativeSystemEvents(event func(what string)) bool {
+	nativeSystemEventLock.Lock()
+	nativeSystemEvent = event
+	nativeSystemEventLock.Unlock()
+	return C.xbarWatchSystemEvents() == 1
+}
+
+//export xbarSystemEvent
+func xbarSystemEvent(woke C.int) {
+	nativeSystemEventLock.Lock()
+	event := nativeSystemEvent
+	nativeSystemEventLock.Unlock()
+	if event == nil {
+		return
+	}
+	if woke == 1 {
+		event(eventWoke)
+		return
+	}
+	event(eventNetworkChanged)
+}
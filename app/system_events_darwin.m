@@ -0,0 +1,38 @@
+#import <Cocoa/Cocoa.h>
+#import <SystemConfiguration/SystemConfiguration.h>
+#import <netinet/in.h>
+
+// defined in system_events_darwin.go
+extern void xbarSystemEvent(int woke);
+
+static void xbarReachabilityChanged(SCNetworkReachabilityRef target, SCNetworkReachabilityFlags flags, void *info) {
+	xbarSystemEvent(0);
+}
+
+int xbarWatchSystemEvents(void) {
+	// watching the zero address reports changes to the default route
+	struct sockaddr_in zero;
+	memset(&zero, 0, sizeof(zero));
+	zero.sin_len = sizeof(zero);
+	zero.sin_family = AF_INET;
+	// kept for the life of the app
+	SCNetworkReachabilityRef reachability = SCNetworkReachabilityCreateWithAddress(kCFAllocatorDefault, (const struct sockaddr *)&zero);
+	if (reachability == NULL) {
+		return 0;
+	}
+	SCNetworkReachabilityContext context = {0, NULL, NULL, NULL, NULL};
+	if (!SCNetworkReachabilitySetCallback(reachability, xbarReachabilityChanged, &context) ||
+		!SCNetworkReachabilitySetDispatchQueue(reachability, dispatch_get_global_queue(DISPATCH_QUEUE_PRIORITY_DEFAULT, 0))) {
+		CFRelease(reachability);
+		return 0;
+	}
+	dispatch_async(dispatch_get_main_queue(), ^{
+		[[[NSWorkspace sharedWorkspace] notificationCenter] addObserverForName:NSWorkspaceDidWakeNotification
+			object:nil
+			queue:nil
+			usingBlock:^(NSNotification *notification) {
+				xbarSystemEvent(1);
+			}];
+	});
+	return 1;
+}
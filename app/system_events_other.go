@@ -0,0 +1,10 @@
+//go:build !darwin
+// +build !darwin
+
+package main
+
+// watchNativeSystemEvents isn't supported on this platform, so
+// systemEvents polls instead.
+func watchNativeSystemEvents(event func(what string)) bool {
+	return false
+}
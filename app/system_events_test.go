@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestSystemEventsCheck(t *testing.T) {
+	is := is.New(t)
+
+	s := newSystemEvents(func(string) {})
+	network := "en0=192.168.1.2/24"
+	s.network = func() string {
+		return network
+	}
+	start := time.Date(2021, time.March, 19, 9, 0, 0, 0, time.UTC)
+	now := start
+	s.now = func() time.Time {
+		return now
+	}
+	events := func() []string {
+		var events []string
+		for {
+			select {
+			case what := <-s.events:
+				events = append(events, what)
+			default:
+				return events
+			}
+		}
+	}
+
+	last, lastNetwork := s.now(), s.network()
+	now = now.Add(5 * time.Second)
+	last, lastNetwork = s.check(last, lastNetwork)
+	is.Equal(len(events()), 0)
+
+	// laptop closed for an hour
+	now = now.Add(1 * time.Hour)
+	last, lastNetwork = s.check(last, lastNetwork)
+	is.Equal(events(), []string{eventWoke})
+
+	// different wifi
+	network = "en0=10.0.0.5/24"
+	now = now.Add(5 * time.Second)
+	last, lastNetwork = s.check(last, lastNetwork)
+	is.Equal(events(), []string{eventNetworkChanged})
+
+	now = now.Add(5 * time.Second)
+	_, _ = s.check(last, lastNetwork)
+	is.Equal(len(events()), 0)
+}
+
+func TestSystemEventsMerged(t *testing.T) {
+	is := is.New(t)
+
+	changes := make(chan string, 10)
+	s := newSystemEvents(func(what string) {
+		changes <- what
+	})
+	s.settle = 50 * time.Millisecond
+	watching := make(chan func(what string), 1)
+	s.watchNative = func(event func(what string)) bool {
+		watching <- event
+		return true
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.run(ctx)
+	event := <-watching
+
+	// waking up is usually followed by the network reconnecting
+	event(eventWoke)
+	event(eventNetworkChanged)
+	event(eventNetworkChanged)
+	is.Equal(<-changes, "woke from sleep and network changed")
+	select {
+	case what := <-changes:
+		t.Fatalf("unexpected second change: %s", what)
+	case <-time.After(2 * s.settle):
+	}
+
+	event(eventNetworkChanged)
+	is.Equal(<-changes, "network changed")
+}
@@ -0,0 +1,26 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+/*
+#cgo LDFLAGS: -framework Cocoa -framework WebKit
+#include <stdlib.h>
+
+// defined in webview_darwin.m
+void xbarOpenWebview(const char *url, int width, int height);
+*/
+import "C"
+
+import "unsafe"
+
+// openWebview opens the URL in a popover window under the mouse,
+// which closes when it loses focus.
+// Unlike an iframe in the xbar window, pages that forbid framing
+// (with X-Frame-Options or CSP frame-ancestors) load fine.
+func openWebview(url string, width, height int) error {
+	curl := C.CString(url)
+	defer C.free(unsafe.Pointer(curl))
+	C.xbarOpenWebview(curl, C.int(width), C.int(height))
+	return nil
+}
@@ -0,0 +1,53 @@
+#import <Cocoa/Cocoa.h>
+#import <WebKit/WebKit.h>
+
+// xbarOpenWebview opens the URL in a floating panel under the mouse,
+// which closes (like a popover) when it loses focus.
+void xbarOpenWebview(const char *url, int width, int height) {
+	// copied, since url is freed once this returns
+	NSString *urlString = [[NSString alloc] initWithUTF8String:url];
+	dispatch_async(dispatch_get_main_queue(), ^{
+		NSURL *pageURL = [NSURL URLWithString:urlString];
+		[urlString release];
+		if (pageURL == nil) {
+			return;
+		}
+		NSPoint mouse = [NSEvent mouseLocation];
+		NSRect frame = NSMakeRect(mouse.x - width / 2.0, mouse.y - height, width, height);
+		NSPanel *panel = [[NSPanel alloc] initWithContentRect:frame
+			styleMask:(NSWindowStyleMaskTitled | NSWindowStyleMaskClosable | NSWindowStyleMaskResizable)
+			backing:NSBackingStoreBuffered
+			defer:NO];
+		[panel setTitle:[pageURL host] ?: @"xbar"];
+		[panel setLevel:NSPopUpMenuWindowLevel];
+		[panel setHidesOnDeactivate:NO];
+		// (the panel releases itself when it closes)
+		[panel setReleasedWhenClosed:YES];
+		WKWebViewConfiguration *configuration = [[WKWebViewConfiguration alloc] init];
+		WKWebView *webView = [[WKWebView alloc] initWithFrame:[[panel contentView] bounds] configuration:configuration];
+		[configuration release];
+		[webView setAutoresizingMask:(NSViewWidthSizable | NSViewHeightSizable)];
+		[panel setContentView:webView];
+		[webView release];
+		[webView loadRequest:[NSURLRequest requestWithURL:pageURL]];
+		// close it when it loses focus, like a popover
+		NSNotificationCenter *center = [NSNotificationCenter defaultCenter];
+		__block id resignObserver = nil;
+		__block id closeObserver = nil;
+		resignObserver = [center addObserverForName:NSWindowDidResignKeyNotification
+			object:panel
+			queue:nil
+			usingBlock:^(NSNotification *note) {
+				[panel close];
+			}];
+		closeObserver = [center addObserverForName:NSWindowWillCloseNotification
+			object:panel
+			queue:nil
+			usingBlock:^(NSNotification *note) {
+				[center removeObserver:resignObserver];
+				[center removeObserver:closeObserver];
+			}];
+		[NSApp activateIgnoringOtherApps:YES];
+		[panel makeKeyAndOrderFront:nil];
+	});
+}
@@ -0,0 +1,11 @@
+//go:build !darwin
+// +build !darwin
+
+package main
+
+import "github.com/pkg/errors"
+
+// openWebview isn't supported on this platform.
+func openWebview(url string, width, height int) error {
+	return errors.New("webviews are not supported on this platform")
+}
@@ -0,0 +1,57 @@
+package metadata
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ChangelogEntry is a line from a plugin's changelog.
+type ChangelogEntry struct {
+	// Version is the version of the plugin the changes are in, like
+	// v1.2.0, or empty if the entry doesn't say.
+	Version string `json:"version,omitempty"`
+	// Changes describes what changed.
+	Changes string `json:"changes"`
+}
+
+// changelogEntryRegexp matches changelog entries that start with
+// a version, like v1.2.0: Added dark mode.
+var changelogEntryRegexp = regexp.MustCompile(`^(v?\d+(?:\.\d+)*)\s*[:-]\s*(.*)$`)
+
+// parseChangelog adds the value of an xbar.changelog tag to the
+// plugin, which is either a URL (to a page listing the changes), or
+// an entry describing the changes in a version.
+func (p *Plugin) parseChangelog(value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	if u, err := url.Parse(value); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		p.ChangelogURL = value
+		return
+	}
+	entry := ChangelogEntry{Changes: value}
+	if match := changelogEntryRegexp.FindStringSubmatch(value); match != nil {
+		entry.Version, entry.Changes = match[1], match[2]
+	}
+	for _, existing := range p.Changelog {
+		if existing == entry {
+			return
+		}
+	}
+	p.Changelog = append(p.Changelog, entry)
+}
+
+// ChangesSince gets the changelog entries for versions newer than
+// version, along with entries that don't say which version they are
+// for.
+func (p Plugin) ChangesSince(version string) []ChangelogEntry {
+	var changes []ChangelogEntry
+	for _, entry := range p.Changelog {
+		if entry.Version == "" || IsNewerVersion(entry.Version, version) {
+			changes = append(changes, entry)
+		}
+	}
+	return changes
+}
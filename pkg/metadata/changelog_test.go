@@ -0,0 +1,33 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestChangelog(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+	<xbar.version>v1.3.0</xbar.version>
+	<xbar.changelog>https://github.com/matryer/xbar-plugins/commits/main</xbar.changelog>
+	<xbar.changelog>v1.3.0: Added dark mode</xbar.changelog>
+	<xbar.changelog>v1.2.0 - Fixed the weather in London</xbar.changelog>
+	<xbar.changelog>Now with fewer bugs</xbar.changelog>
+	<xbar.changelog>v1.0: First version</xbar.changelog>
+	`)
+	is.NoErr(err)
+	is.Equal(md.ChangelogURL, "https://github.com/matryer/xbar-plugins/commits/main")
+	is.Equal(len(md.Changelog), 4)
+	is.Equal(md.Changelog[0], ChangelogEntry{Version: "v1.3.0", Changes: "Added dark mode"})
+	is.Equal(md.Changelog[1], ChangelogEntry{Version: "v1.2.0", Changes: "Fixed the weather in London"})
+	is.Equal(md.Changelog[2], ChangelogEntry{Changes: "Now with fewer bugs"})
+	is.Equal(md.Changelog[3], ChangelogEntry{Version: "v1.0", Changes: "First version"})
+
+	changes := md.ChangesSince("v1.1.0")
+	is.Equal(len(changes), 3)
+	is.Equal(changes[0].Version, "v1.3.0")
+	is.Equal(changes[1].Version, "v1.2.0")
+	is.Equal(changes[2].Version, "")
+}
@@ -0,0 +1,51 @@
+package metadata
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// minVersionRegexp matches the versions in xbar.minVersion and
+// xbar.minOSVersion, like v2.1.0 or 11.0.
+var minVersionRegexp = regexp.MustCompile(`^v?\d+(?:\.\d+)*$`)
+
+// CheckCompatible checks the plugin works with the version of xbar
+// (like v2.1.0) and macOS (like 11.6), returning an error describing
+// which is too old if it doesn't.
+// Empty versions are not checked.
+func (p Plugin) CheckCompatible(appVersion, osVersion string) error {
+	if p.MinVersion != "" && appVersion != "" && olderThan(appVersion, p.MinVersion) {
+		return errors.Errorf("%s needs xbar %s or later (this is %s)", p.displayName(), p.MinVersion, appVersion)
+	}
+	if p.MinOSVersion != "" && osVersion != "" && olderThan(osVersion, p.MinOSVersion) {
+		return errors.Errorf("%s needs macOS %s or later (this is %s)", p.displayName(), p.MinOSVersion, osVersion)
+	}
+	return nil
+}
+
+// IsNewerVersion gets whether version (like v1.2.3) is newer than
+// the other one.
+// Versions that can't be understood are not newer.
+func IsNewerVersion(version, than string) bool {
+	return olderThan(than, version)
+}
+
+// olderThan gets whether version is older than min.
+// Versions that can't be understood are not older.
+func olderThan(version, min string) bool {
+	version, min = versionRegexp.FindString(version), versionRegexp.FindString(min)
+	if version == "" || min == "" {
+		return false
+	}
+	return compareVersions(version, min) < 0
+}
+
+// displayName gets the Title of the plugin, or its Filename if it
+// doesn't have one.
+func (p Plugin) displayName() string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return p.Filename
+}
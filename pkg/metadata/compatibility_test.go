@@ -0,0 +1,40 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCheckCompatible(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Modern</xbar.title>
+# <xbar.minVersion>v2.1.0</xbar.minVersion>
+# <xbar.minOSVersion>11.0</xbar.minOSVersion>
+	`)
+	is.NoErr(err)
+	is.Equal(md.MinVersion, "v2.1.0")
+	is.Equal(md.MinOSVersion, "11.0")
+
+	is.NoErr(md.CheckCompatible("v2.1.0", "11.0"))
+	is.NoErr(md.CheckCompatible("v2.10.1-beta", "12.3.1"))
+	is.NoErr(md.CheckCompatible("", "")) // unknown versions aren't checked
+	err = md.CheckCompatible("v2.0.9", "12.0")
+	is.Equal(err.Error(), "Modern needs xbar v2.1.0 or later (this is v2.0.9)")
+	err = md.CheckCompatible("v2.1.0", "10.15.7")
+	is.Equal(err.Error(), "Modern needs macOS 11.0 or later (this is 10.15.7)")
+
+	md.MinVersion, md.MinOSVersion = "", ""
+	is.NoErr(md.CheckCompatible("v1.0.0", "10.0"))
+}
+
+func TestIsNewerVersion(t *testing.T) {
+	is := is.New(t)
+	is.True(IsNewerVersion("v1.10.0", "v1.9.2"))
+	is.True(IsNewerVersion("2.0", "v1.9"))
+	is.True(!IsNewerVersion("1.0.0", "1.0"))
+	is.True(!IsNewerVersion("1.0", "1.1"))
+	is.True(!IsNewerVersion("latest", "1.0"))
+}
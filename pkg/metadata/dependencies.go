@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Dependency describes something a plugin needs to run, and how to
+// install it.
+type Dependency struct {
+	// Name is the name of the program, like jq.
+	Name string `json:"name"`
+	// Version is the version of the program the plugin needs, like
+	// >=1.6, if it matters.
+	// One of >=, >, <=, < or = is followed by a version, and a
+	// version on its own means that version or later.
+	Version string `json:"version,omitempty"`
+	// Brew is the Homebrew formula that installs the program, if
+	// there is one.
+	Brew string `json:"brew,omitempty"`
+	// Install is how to install the program otherwise, like a
+	// command or a URL.
+	Install string `json:"install,omitempty"`
+}
+
+// InstallHint describes how to install the Dependency, or is empty
+// if the plugin doesn't say.
+func (d Dependency) InstallHint() string {
+	if d.Brew != "" {
+		return "brew install " + d.Brew
+	}
+	return d.Install
+}
+
+// versionConstraintRegexp matches version constraints, like >=1.6.
+var versionConstraintRegexp = regexp.MustCompile(`^(>=|>|<=|<|=)?\s*v?(\d+(?:\.\d+)*)$`)
+
+// versionRegexp finds versions in the output of programs,
+// like jq-1.6 or Python 3.9.1.
+var versionRegexp = regexp.MustCompile(`\d+(?:\.\d+)+|\d+`)
+
+// Satisfied gets whether the version (like the output of
+// jq --version) is one the plugin can use.
+// Any version will do if the Dependency has no Version constraint.
+// If the version can't be found, it is assumed to be fine.
+func (d Dependency) Satisfied(version string) bool {
+	if d.Version == "" {
+		return true
+	}
+	match := versionConstraintRegexp.FindStringSubmatch(strings.TrimSpace(d.Version))
+	if match == nil {
+		return true // checked when parsing
+	}
+	found := versionRegexp.FindString(version)
+	if found == "" {
+		return true
+	}
+	cmp := compareVersions(found, match[2])
+	switch match[1] {
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	case "=":
+		return cmp == 0
+	}
+	return cmp >= 0
+}
+
+// compareVersions compares dotted versions, like 1.10 and 1.9,
+// returning -1, 0 or 1 if a is less than, equal to or greater
+// than b.
+// Missing parts count as zero, so 1.6 is equal to 1.6.0.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// isDependencyList gets whether xbar.dependencies is a JSON array
+// of Dependency objects (see parseDependencies), rather than a comma
+// separated list like python,ruby,node.
+// The comma separated lists are free-form (like "python (with the
+// requests module)"), so they are only shown to the user, and not
+// checked.
+func isDependencyList(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), "[")
+}
+
+// parseDependencies parses xbar.dependencies when it is a JSON array
+// of Dependency objects, like:
+//
+//	[{"name": "jq", "version": ">=1.6", "brew": "jq"}]
+func parseDependencies(s string) ([]Dependency, error) {
+	var deps []Dependency
+	if err := json.Unmarshal([]byte(strings.TrimSpace(s)), &deps); err != nil {
+		return nil, errors.Wrap(err, "expected a comma separated list, or a JSON array")
+	}
+	for _, dep := range deps {
+		if dep.Name == "" {
+			return nil, errors.New("expected a name for every dependency")
+		}
+		if dep.Version != "" && !versionConstraintRegexp.MatchString(strings.TrimSpace(dep.Version)) {
+			return nil, errors.Errorf(`%s: expected a version (like >=1.6), not "%s"`, dep.Name, dep.Version)
+		}
+	}
+	return deps, nil
+}
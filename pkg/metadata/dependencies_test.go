@@ -0,0 +1,60 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDependencies(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.dependencies>[{"name": "jq", "version": ">=1.6", "brew": "jq"}, {"name": "gh", "install": "https://cli.github.com"}]</xbar.dependencies>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Dependencies, []string{"jq", "gh"})
+	is.Equal(len(md.DependencyDetails), 2)
+	jq := md.DependencyDetails[0]
+	is.Equal(jq.Version, ">=1.6")
+	is.Equal(jq.InstallHint(), "brew install jq")
+	is.Equal(md.DependencyDetails[1].InstallHint(), "https://cli.github.com")
+
+	md, err = Parse(DebugfNoop, "test.txt", `
+# <xbar.dependencies>python,ruby</xbar.dependencies>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Dependencies, []string{"python", "ruby"})
+	is.Equal(len(md.DependencyDetails), 0) // free-form, so not checked
+
+	md, err = Parse(DebugfNoop, "test.txt", `
+# <xbar.dependencies>python (with requests module),node.js</xbar.dependencies>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Dependencies, []string{"python (with requests module)", "node.js"})
+	is.Equal(len(md.DependencyDetails), 0)
+}
+
+func TestDependencySatisfied(t *testing.T) {
+	is := is.New(t)
+
+	for _, test := range []struct {
+		constraint, version string
+		satisfied           bool
+	}{
+		{"", "jq-1.5", true},
+		{">=1.6", "jq-1.6", true},
+		{">=1.6", "jq-1.10", true},
+		{">=1.6", "jq-1.5", false},
+		{"1.6", "jq-1.6.1", true},
+		{">3", "Python 3.9.1", true},
+		{">3", "Python 3", false},
+		{"<2", "v1.9.9", true},
+		{"<=2.0", "2", true},
+		{"=1.6", "jq-1.6.0", true},
+		{">=1.6", "no version here", true},
+	} {
+		dep := Dependency{Name: "test", Version: test.constraint}
+		is.Equal(dep.Satisfied(test.version), test.satisfied) // test.constraint, test.version
+	}
+}
@@ -0,0 +1,287 @@
+package metadata
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SidecarExts are the extensions of the files that can hold a
+// plugin's metadata instead of comments in the plugin itself (which
+// suits compiled plugins), like weather.1m.exe.yaml.
+var SidecarExts = []string{".yaml", ".yml", ".toml"}
+
+// maxPluginFileSize is the most ParseFile reads from a plugin.
+const maxPluginFileSize = 1_000_000
+
+// ParseFile parses the metadata of the plugin file at path, along
+// with its sidecar file (see SidecarExts) if it has one.
+// Metadata in the sidecar file takes precedence.
+func ParseFile(debugf DebugFunc, path string) (Plugin, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Plugin{}, errors.Wrap(err, "open plugin")
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(f, maxPluginFileSize))
+	if err != nil {
+		return Plugin{}, errors.Wrap(err, "read plugin")
+	}
+	var sidecarTags string
+	for _, ext := range SidecarExts {
+		sidecar, err := ioutil.ReadFile(path + ext)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return Plugin{}, errors.Wrap(err, "read sidecar")
+		}
+		format := "yaml"
+		if ext == ".toml" {
+			format = "toml"
+		}
+		lines := strings.Split(string(sidecar), "\n")
+		if found, _, ok := findFrontMatter(string(sidecar)); ok {
+			lines = found
+		}
+		sidecarTags, err = frontMatterTags(format, lines)
+		if err != nil {
+			return Plugin{}, errors.Wrap(err, filepath.Base(path+ext))
+		}
+		break
+	}
+	return parse(debugf, filepath.Base(path), string(b), sidecarTags)
+}
+
+// frontMatterDelimiters are the lines that start and end front matter
+// blocks, and the format of the block.
+var frontMatterDelimiters = map[string]string{
+	"---": "yaml",
+	"+++": "toml",
+}
+
+// commentPrefixRegexp matches the start of comment lines in the
+// languages plugins are commonly written in.
+var commentPrefixRegexp = regexp.MustCompile(`^\s*(?:#|//|--|;)? ?`)
+
+// frontMatterLine gets the line without any comment prefix.
+func frontMatterLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if _, ok := frontMatterDelimiters[trimmed]; ok {
+		return trimmed
+	}
+	return strings.TrimRight(commentPrefixRegexp.ReplaceAllString(line, ""), " \t\r")
+}
+
+// findFrontMatter finds a front matter block at the start of s (after
+// any #! line), which may be in comments, like:
+//
+//	#!/bin/bash
+//	# ---
+//	# title: Weather
+//	# ---
+//
+// It returns the lines in the block, and its format (yaml or toml).
+func findFrontMatter(s string) ([]string, string, bool) {
+	lines := strings.Split(s, "\n")
+	start := -1
+	var delimiter string
+	for i, line := range lines {
+		if i == 0 && strings.HasPrefix(line, "#!") {
+			continue
+		}
+		line = strings.TrimSpace(frontMatterLine(line))
+		if line == "" {
+			continue
+		}
+		if _, ok := frontMatterDelimiters[line]; ok {
+			start, delimiter = i, line
+		}
+		break
+	}
+	if start < 0 {
+		return nil, "", false
+	}
+	var block []string
+	for _, line := range lines[start+1:] {
+		line = frontMatterLine(line)
+		if strings.TrimSpace(line) == delimiter {
+			return block, frontMatterDelimiters[delimiter], true
+		}
+		block = append(block, line)
+	}
+	return nil, "", false // never ended
+}
+
+// frontMatterKeyAliases are the plural names front matter may use for
+// metadata that can be given more than once.
+var frontMatterKeyAliases = map[string]string{
+	"authors":   "author",
+	"images":    "image",
+	"vars":      "var",
+	"variables": "var",
+}
+
+// frontMatterJoinedKeys are the metadata that take a comma separated
+// list, rather than being given more than once.
+var frontMatterJoinedKeys = map[string]bool{
+	"author.github": true,
+	"dependencies":  true,
+}
+
+// frontMatterTags converts the lines of YAML or TOML front matter into
+// the equivalent xbar tags, like <xbar.title>Weather</xbar.title>, so it
+// is parsed just like metadata in comments.
+// Only simple keys and values (and lists of them) are supported.
+func frontMatterTags(format string, lines []string) (string, error) {
+	var keys []string
+	values := make(map[string][]string)
+	var listKey string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		var key, value string
+		switch format {
+		case "toml":
+			if strings.HasPrefix(trimmed, "[") {
+				return "", errors.Errorf(`front matter: tables are not supported: "%s"`, trimmed)
+			}
+			segs := strings.SplitN(trimmed, "=", 2)
+			if len(segs) != 2 {
+				return "", errors.Errorf(`front matter: expected key = value, not "%s"`, trimmed)
+			}
+			key, value = segs[0], strings.TrimSpace(segs[1])
+		default:
+			if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+				if listKey == "" {
+					return "", errors.Errorf(`front matter: unexpected list item "%s"`, trimmed)
+				}
+				item, err := frontMatterValue(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+				if err != nil {
+					return "", errors.Wrap(err, "front matter: "+listKey)
+				}
+				values[listKey] = append(values[listKey], item)
+				continue
+			}
+			segs := strings.SplitN(trimmed, ":", 2)
+			if len(segs) != 2 {
+				return "", errors.Errorf(`front matter: expected key: value, not "%s"`, trimmed)
+			}
+			key, value = segs[0], strings.TrimSpace(segs[1])
+		}
+		key = frontMatterKey(key)
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+			values[key] = nil
+		}
+		listKey = ""
+		if value == "" {
+			listKey = key
+			continue
+		}
+		items, err := frontMatterValues(value)
+		if err != nil {
+			return "", errors.Wrap(err, "front matter: "+key)
+		}
+		values[key] = append(values[key], items...)
+	}
+	var tags strings.Builder
+	for _, key := range keys {
+		items := values[key]
+		if frontMatterJoinedKeys[key] {
+			items = []string{strings.Join(items, ",")}
+		}
+		for _, item := range items {
+			tags.WriteString("<xbar." + key + ">" + item + "</xbar." + key + ">\n")
+		}
+	}
+	return tags.String(), nil
+}
+
+// frontMatterKey gets the metadata name for a front matter key, like
+// title or author.github.
+func frontMatterKey(key string) string {
+	key = strings.ToLower(strings.Trim(strings.TrimSpace(key), `"'`))
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "xbar."), "bitbar.")
+	if alias, ok := frontMatterKeyAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// frontMatterValues parses a value, which may be a list like
+// ["one", "two"].
+func frontMatterValues(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") {
+		item, err := frontMatterValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{item}, nil
+	}
+	if !strings.HasSuffix(value, "]") {
+		return nil, errors.Errorf(`expected a list ending with ], not "%s"`, value)
+	}
+	var items []string
+	var item strings.Builder
+	var quote rune
+	for _, r := range value[1 : len(value)-1] {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, item.String())
+			item.Reset()
+			continue
+		}
+		item.WriteRune(r)
+	}
+	items = append(items, item.String())
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		value, err := frontMatterValue(item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// frontMatterValue parses a single value, which may be quoted.
+func frontMatterValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", errors.Errorf(`expected a quoted string, not %s`, value)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, `'`):
+		if len(value) < 2 || !strings.HasSuffix(value, `'`) {
+			return "", errors.Errorf(`expected a quoted string, not %s`, value)
+		}
+		return value[1 : len(value)-1], nil
+	}
+	// comments follow unquoted values, after a space
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
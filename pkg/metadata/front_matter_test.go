@@ -0,0 +1,117 @@
+package metadata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestYAMLFrontMatter(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "weather.1h.py", `#!/usr/bin/env python3
+# ---
+# title: Weather
+# version: v1.2.0
+# authors:
+#   - Mat Ryer
+#   - Lea Anthony
+# author.github: [matryer, leaanthony]
+# desc: "Shows the weather: rain or shine."
+# image: https://example.com/weather.png # the main one
+# dependencies: [python3, curl]
+# vars:
+#   - 'string(VAR_CITY="London"): The city'
+#   - 'boolean(VAR_CELSIUS=true): Use Celsius'
+# title.fr: Météo
+# ---
+print("hi")
+`)
+	is.NoErr(err)
+	is.Equal(md.Title, "Weather")
+	is.Equal(md.Version, "v1.2.0")
+	is.Equal(len(md.Authors), 2)
+	is.Equal(md.Authors[1].Name, "Lea Anthony")
+	is.Equal(md.Authors[1].GitHubUsername, "leaanthony")
+	is.Equal(md.Desc, "Shows the weather: rain or shine.")
+	is.Equal(md.ImageURL, "https://example.com/weather.png")
+	is.Equal(md.Dependencies, []string{"python3", "curl"})
+	is.Equal(len(md.Vars), 2)
+	is.Equal(md.Vars[0].Name, "VAR_CITY")
+	is.Equal(md.Vars[0].Default, "London")
+	is.Equal(md.Vars[1].Type, "boolean")
+	is.Equal(md.Localizations["fr"].Title, "Météo")
+}
+
+func TestTOMLFrontMatter(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "weather.1h.js", `#!/usr/bin/env node
+// +++
+// title = "Weather"
+// "xbar.author" = 'Mat Ryer'
+// images = ["https://example.com/one.png", "https://example.com/two.png"]
+// refreshOnOpen = true
+// +++
+console.log("hi")
+`)
+	is.NoErr(err)
+	is.Equal(md.Title, "Weather")
+	is.Equal(md.Author, "Mat Ryer")
+	is.Equal(md.ImageURLs, []string{"https://example.com/one.png", "https://example.com/two.png"})
+	is.True(md.RefreshOnOpen)
+
+	_, err = Parse(DebugfNoop, "weather.1h.js", `// +++
+// [xbar]
+// title = "Weather"
+// +++
+`)
+	is.Equal(err.Error(), `front matter: tables are not supported: "[xbar]"`)
+}
+
+func TestFrontMatterOnlyAtStart(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "menu.1h.sh", `#!/bin/bash
+# <xbar.title>Menu</xbar.title>
+echo "Menu"
+# ---
+# title: Not this
+# ---
+`)
+	is.NoErr(err)
+	is.Equal(md.Title, "Menu")
+}
+
+func TestParseFileSidecar(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-sidecar-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	plugin := filepath.Join(dir, "weather.1h.bin")
+	is.NoErr(ioutil.WriteFile(plugin, []byte("\x00\x01binary<xbar.title>Old</xbar.title>"), 0755))
+
+	md, err := ParseFile(DebugfNoop, plugin)
+	is.NoErr(err)
+	is.Equal(md.Title, "Old")
+	is.Equal(md.Filename, "weather.1h.bin")
+
+	is.NoErr(ioutil.WriteFile(plugin+".yaml", []byte(`
+title: Weather
+desc: Shows the weather.
+`), 0666))
+	md, err = ParseFile(DebugfNoop, plugin)
+	is.NoErr(err)
+	is.Equal(md.Title, "Weather") // the sidecar takes precedence
+	is.Equal(md.Desc, "Shows the weather.")
+
+	is.NoErr(ioutil.WriteFile(plugin+".yaml", []byte("title Weather"), 0666))
+	_, err = ParseFile(DebugfNoop, plugin)
+	is.Equal(err.Error(), `weather.1h.bin.yaml: front matter: expected key: value, not "title Weather"`)
+}
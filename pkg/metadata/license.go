@@ -0,0 +1,140 @@
+package metadata
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// spdxLicenses are the SPDX identifiers (https://spdx.org/licenses/)
+// of the licenses plugins commonly use.
+var spdxLicenses = []string{
+	"0BSD",
+	"AFL-3.0",
+	"AGPL-3.0-only",
+	"AGPL-3.0-or-later",
+	"Apache-1.1",
+	"Apache-2.0",
+	"Artistic-2.0",
+	"BSD-1-Clause",
+	"BSD-2-Clause",
+	"BSD-3-Clause",
+	"BSD-3-Clause-Clear",
+	"BSD-4-Clause",
+	"BSL-1.0",
+	"CC-BY-4.0",
+	"CC-BY-SA-4.0",
+	"CC-BY-NC-4.0",
+	"CC-BY-NC-SA-4.0",
+	"CC0-1.0",
+	"CDDL-1.0",
+	"ECL-2.0",
+	"EPL-1.0",
+	"EPL-2.0",
+	"EUPL-1.1",
+	"EUPL-1.2",
+	"GPL-2.0-only",
+	"GPL-2.0-or-later",
+	"GPL-3.0-only",
+	"GPL-3.0-or-later",
+	"ISC",
+	"LGPL-2.1-only",
+	"LGPL-2.1-or-later",
+	"LGPL-3.0-only",
+	"LGPL-3.0-or-later",
+	"MIT",
+	"MIT-0",
+	"MPL-1.1",
+	"MPL-2.0",
+	"MS-PL",
+	"MS-RL",
+	"NCSA",
+	"ODbL-1.0",
+	"OFL-1.1",
+	"OSL-3.0",
+	"PostgreSQL",
+	"Python-2.0",
+	"Ruby",
+	"Unlicense",
+	"UPL-1.0",
+	"Vim",
+	"WTFPL",
+	"X11",
+	"Zlib",
+	"ZPL-2.1",
+}
+
+// deprecatedSPDXLicenses are old SPDX identifiers that are still
+// seen in the wild, and what they are now.
+var deprecatedSPDXLicenses = map[string]string{
+	"AGPL-3.0":  "AGPL-3.0-only",
+	"GPL-2.0":   "GPL-2.0-only",
+	"GPL-2.0+":  "GPL-2.0-or-later",
+	"GPL-3.0":   "GPL-3.0-only",
+	"GPL-3.0+":  "GPL-3.0-or-later",
+	"LGPL-2.1":  "LGPL-2.1-only",
+	"LGPL-2.1+": "LGPL-2.1-or-later",
+	"LGPL-3.0":  "LGPL-3.0-only",
+	"LGPL-3.0+": "LGPL-3.0-or-later",
+}
+
+// ParseLicense parses an SPDX license identifier (like MIT), or an
+// expression combining them with OR and AND (like MIT OR Apache-2.0),
+// returning it with the identifiers spelled as SPDX does.
+// Custom licenses can be described with LicenseRef-, like
+// LicenseRef-Proprietary.
+func ParseLicense(s string) (string, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", errors.New("expected an SPDX license identifier (like MIT)")
+	}
+	for i, field := range fields {
+		if i%2 == 1 {
+			operator := strings.ToUpper(field)
+			if operator != "OR" && operator != "AND" {
+				return "", errors.Errorf(`expected OR or AND, not "%s"`, field)
+			}
+			fields[i] = operator
+			continue
+		}
+		id, ok := spdxLicense(field)
+		if !ok {
+			return "", errors.Errorf(`unknown SPDX license identifier "%s" (see https://spdx.org/licenses/)`, field)
+		}
+		fields[i] = id
+	}
+	if len(fields)%2 == 0 {
+		return "", errors.Errorf(`expected a license after "%s"`, fields[len(fields)-1])
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// spdxLicense gets the SPDX identifier for id, ignoring case.
+func spdxLicense(id string) (string, bool) {
+	if strings.HasPrefix(strings.ToLower(id), "licenseref-") && len(id) > len("LicenseRef-") {
+		return "LicenseRef-" + id[len("LicenseRef-"):], true
+	}
+	for old, current := range deprecatedSPDXLicenses {
+		if strings.EqualFold(id, old) {
+			return current, true
+		}
+	}
+	for _, license := range spdxLicenses {
+		if strings.EqualFold(id, license) {
+			return license, true
+		}
+	}
+	return "", false
+}
+
+// Licenses gets the SPDX identifiers in the plugin's License, like
+// [MIT Apache-2.0] for MIT OR Apache-2.0.
+func (p Plugin) Licenses() []string {
+	var licenses []string
+	for i, field := range strings.Fields(p.License) {
+		if i%2 == 0 {
+			licenses = append(licenses, field)
+		}
+	}
+	return licenses
+}
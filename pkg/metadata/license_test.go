@@ -0,0 +1,45 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseLicense(t *testing.T) {
+	is := is.New(t)
+
+	for s, expected := range map[string]string{
+		"MIT":                     "MIT",
+		" mit ":                   "MIT",
+		"apache-2.0":              "Apache-2.0",
+		"GPL-3.0":                 "GPL-3.0-only",
+		"GPL-2.0+":                "GPL-2.0-or-later",
+		"MIT or Apache-2.0":       "MIT OR Apache-2.0",
+		"MIT AND BSD-3-Clause":    "MIT AND BSD-3-Clause",
+		"licenseref-MyOwnLicense": "LicenseRef-MyOwnLicense",
+	} {
+		license, err := ParseLicense(s)
+		is.NoErr(err)
+		is.Equal(license, expected)
+	}
+
+	for s, expected := range map[string]string{
+		"":                "expected an SPDX license identifier (like MIT)",
+		"Made up":         `unknown SPDX license identifier "Made" (see https://spdx.org/licenses/)`,
+		"MIT WITH Apache": `expected OR or AND, not "WITH"`,
+		"MIT OR":          `expected a license after "OR"`,
+		"LicenseRef-":     `unknown SPDX license identifier "LicenseRef-" (see https://spdx.org/licenses/)`,
+	} {
+		_, err := ParseLicense(s)
+		is.True(err != nil)
+		is.Equal(err.Error(), expected)
+	}
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.license>mit OR apache-2.0</xbar.license>
+	`)
+	is.NoErr(err)
+	is.Equal(md.License, "MIT OR Apache-2.0")
+	is.Equal(md.Licenses(), []string{"MIT", "Apache-2.0"})
+}
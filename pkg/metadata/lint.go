@@ -0,0 +1,197 @@
+package metadata
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity is how bad a Diagnostic is.
+type Severity string
+
+const (
+	// SeverityError is for problems that stop the plugin working, or
+	// being listed.
+	SeverityError Severity = "error"
+	// SeverityWarning is for things that should be fixed, but don't
+	// stop the plugin working.
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem Lint found with a plugin's metadata.
+type Diagnostic struct {
+	// Line is the line (starting at 1) the problem is on, or zero if
+	// it isn't about a particular line (like missing metadata).
+	Line int `json:"line,omitempty"`
+	// Severity is how bad the problem is.
+	Severity Severity `json:"severity"`
+	// Tag is the metadata the problem is with, like xbar.var.
+	Tag string `json:"tag,omitempty"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// String gets the Diagnostic like 3: error: xbar.var: malformed xbar.var format.
+func (d Diagnostic) String() string {
+	s := string(d.Severity) + ": "
+	if d.Line > 0 {
+		s = fmt.Sprintf("%d: %s", d.Line, s)
+	}
+	if d.Tag != "" && !strings.Contains(d.Message, d.Tag) {
+		s += d.Tag + ": "
+	}
+	return s + d.Message
+}
+
+// lintTagRegexp matches the metadata tags Lint checks, like
+// <xbar.var secret="true">...</xbar.var>.
+var lintTagRegexp = regexp.MustCompile(`<((?:xbar|bitbar|swiftbar)\.[^\s>]*)([^>]*)>(.*?)</(?:xbar|bitbar|swiftbar)\.[^>]*>`)
+
+// lintKnownTags are the xbar.* tags Parse understands (besides
+// localized ones like xbar.title.fr).
+var lintKnownTags = map[string]bool{
+	"title": true, "version": true, "author": true, "author.github": true,
+	"desc": true, "image": true, "dependencies": true, "abouturl": true,
+	"license": true, "changelog": true, "deprecated": true, "replacedby": true,
+	"minversion": true, "minosversion": true,
+	"cycle": true, "maxoutput": true, "schedule": true, "jitter": true,
+	"timeout": true, "refreshonopen": true, "streamable": true,
+	"keepoutputonerror": true, "overlap": true, "throttle": true,
+	"cwd": true, "interpreter": true, "watch": true, "var": true,
+}
+
+// lintBitBarTags are the bitbar.* tags Parse understands, from the
+// days of BitBar.
+var lintBitBarTags = map[string]bool{
+	"title": true, "version": true, "author": true, "author.github": true,
+	"desc": true, "image": true, "dependencies": true, "abouturl": true,
+}
+
+// Lint checks the metadata in the source of a plugin, returning the
+// problems it finds (like missing metadata, malformed xbar.var tags or
+// unknown tags), ordered by line.
+// A plugin with any SeverityError problems won't work, or can't be
+// listed on xbarapp.com.
+func Lint(filename, s string) []Diagnostic {
+	var diagnostics []Diagnostic
+	for i, line := range strings.Split(s, "\n") {
+		for _, match := range lintTagRegexp.FindAllStringSubmatch(line, -1) {
+			diagnostics = append(diagnostics, lintTag(i+1, match[1], match[2], match[3])...)
+		}
+	}
+	md, err := Parse(DebugfNoop, filename, s)
+	if err != nil {
+		// problems with particular tags have been found above, so
+		// only add problems with the whole thing (like front matter)
+		if !hasErrors(diagnostics) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityError,
+				Message:  err.Error(),
+			})
+		}
+		return sortDiagnostics(diagnostics)
+	}
+	if md.Title == "" {
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: SeverityError,
+			Tag:      "xbar.title",
+			Message:  "missing xbar.title",
+		})
+	}
+	for _, missing := range []struct {
+		tag     string
+		missing bool
+	}{
+		{"xbar.desc", md.Desc == ""},
+		{"xbar.author", len(md.Authors) == 0},
+		{"xbar.image", !HasImage(md.ImageURL)},
+	} {
+		if missing.missing {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityWarning,
+				Tag:      missing.tag,
+				Message:  "missing " + missing.tag,
+			})
+		}
+	}
+	return sortDiagnostics(diagnostics)
+}
+
+// lintTag checks a single tag.
+func lintTag(line int, tag, attrs, value string) []Diagnostic {
+	lowerTag := strings.ToLower(tag)
+	var diagnostics []Diagnostic
+	switch {
+	case strings.HasPrefix(lowerTag, "xbar."):
+		name := strings.TrimPrefix(lowerTag, "xbar.")
+		if _, _, localized, _ := parseLocalizedTag(lowerTag); !lintKnownTags[name] && !localized {
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:     line,
+				Severity: SeverityWarning,
+				Tag:      tag,
+				Message:  "unknown tag, so it is ignored",
+			})
+		}
+	case strings.HasPrefix(lowerTag, "bitbar."):
+		name := strings.TrimPrefix(lowerTag, "bitbar.")
+		if _, _, localized, _ := parseLocalizedTag(lowerTag); !lintBitBarTags[name] && !localized {
+			message := "unknown tag, so it is ignored"
+			if lintKnownTags[name] {
+				message = "not supported, use xbar." + name
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:     line,
+				Severity: SeverityWarning,
+				Tag:      tag,
+				Message:  message,
+			})
+		}
+	}
+	// parse the tag on its own, to find problems with its value
+	if _, err := Parse(DebugfNoop, "lint", "<"+tag+attrs+">"+value+"</"+tag+">"); err != nil {
+		diagnostics = append(diagnostics, Diagnostic{
+			Line:     line,
+			Severity: SeverityError,
+			Tag:      tag,
+			Message:  err.Error(),
+		})
+	}
+	switch lowerTag {
+	case "xbar.image", "bitbar.image", "xbar.abouturl", "bitbar.abouturl":
+		value = strings.TrimSpace(value)
+		if u, err := url.Parse(value); value != "" && (err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https")) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:     line,
+				Severity: SeverityWarning,
+				Tag:      tag,
+				Message:  fmt.Sprintf(`expected an absolute http or https URL, not "%s"`, value),
+			})
+		}
+	}
+	return diagnostics
+}
+
+// hasErrors gets whether any of the diagnostics are SeverityError.
+func hasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// sortDiagnostics sorts the diagnostics by line, with those that
+// aren't about a particular line last.
+func sortDiagnostics(diagnostics []Diagnostic) []Diagnostic {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i].Line, diagnostics[j].Line
+		if a == 0 || b == 0 {
+			return b == 0 && a != 0
+		}
+		return a < b
+	})
+	return diagnostics
+}
@@ -0,0 +1,54 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLint(t *testing.T) {
+	is := is.New(t)
+
+	diagnostics := Lint("test.sh", `#!/bin/bash
+# <xbar.title>Lint</xbar.title>
+# <xbar.author>Mat Ryer</xbar.author>
+# <xbar.var>nope</xbar.var>
+# <xbar.colour>red</xbar.colour>
+# <bitbar.var>string(VAR_NAME=""): Name</bitbar.var>
+# <xbar.image>screenshot.png</xbar.image>
+# <xbar.title.fr>Peluche</xbar.title.fr>
+# <swiftbar.hideRunInTerminal>true</swiftbar.hideRunInTerminal>
+echo <a href="https://xbarapp.com">xbar</a>
+`)
+	is.Equal(len(diagnostics), 4) // diagnostics
+	is.Equal(diagnostics[0].Line, 4)
+	is.Equal(diagnostics[0].Severity, SeverityError)
+	is.Equal(diagnostics[0].Tag, "xbar.var")
+	is.Equal(diagnostics[1].Line, 5)
+	is.Equal(diagnostics[1].Severity, SeverityWarning)
+	is.Equal(diagnostics[1].Tag, "xbar.colour")
+	is.Equal(diagnostics[2].Line, 6)
+	is.Equal(diagnostics[2].Message, "not supported, use xbar.var")
+	is.Equal(diagnostics[3].Line, 7)
+	is.Equal(diagnostics[3].String(), `7: warning: xbar.image: expected an absolute http or https URL, not "screenshot.png"`)
+}
+
+func TestLintMissingMetadata(t *testing.T) {
+	is := is.New(t)
+
+	diagnostics := Lint("test.sh", `#!/bin/bash
+# <xbar.desc>No title</xbar.desc>
+`)
+	is.Equal(len(diagnostics), 3) // diagnostics
+	is.Equal(diagnostics[0].String(), "error: missing xbar.title")
+	is.Equal(diagnostics[1].String(), "warning: missing xbar.author")
+	is.Equal(diagnostics[2].String(), "warning: missing xbar.image")
+
+	diagnostics = Lint("test.sh", `#!/bin/bash
+# <xbar.title>Good</xbar.title>
+# <xbar.desc>Nothing wrong here.</xbar.desc>
+# <xbar.author>Mat Ryer</xbar.author>
+# <xbar.image>https://xbarapp.com/screenshot.png</xbar.image>
+`)
+	is.Equal(len(diagnostics), 0) // diagnostics
+}
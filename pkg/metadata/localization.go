@@ -0,0 +1,76 @@
+package metadata
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Localization is the title and description of a plugin in another
+// language, from tags like <xbar.title.fr> and <xbar.desc.fr>.
+type Localization struct {
+	Title string `json:"title,omitempty"`
+	Desc  string `json:"desc,omitempty"`
+}
+
+// localeRegexp matches normalized locales, like fr or pt-br.
+var localeRegexp = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLocale gets the locale (like pt_BR) in the form used for
+// the keys of Plugin.Localizations (like pt-br).
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	locale = strings.ReplaceAll(locale, "_", "-")
+	// drop any encoding or modifier, like en_GB.UTF-8
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// parseLocalizedTag parses tags like xbar.title.fr and xbar.desc.pt-BR,
+// returning the field (title or desc) and the normalized locale.
+func parseLocalizedTag(tag string) (field, locale string, ok bool, err error) {
+	tag = strings.ToLower(tag)
+	for _, field := range []string{"title", "desc"} {
+		for _, prefix := range []string{"xbar.", "bitbar."} {
+			if !strings.HasPrefix(tag, prefix+field+".") {
+				continue
+			}
+			locale := NormalizeLocale(strings.TrimPrefix(tag, prefix+field+"."))
+			if !localeRegexp.MatchString(locale) {
+				return "", "", false, errors.Errorf(`xbar.%s: expected a language code (like fr or pt-BR), not "%s"`, field, strings.TrimPrefix(tag, prefix+field+"."))
+			}
+			return field, locale, true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// Localized gets the plugin with its Title and Desc in the language
+// that best matches the locale (like fr-CA, or fr_CA), falling back
+// to the language (like fr), and then to English (the plain xbar.title
+// and xbar.desc).
+func (p Plugin) Localized(locale string) Plugin {
+	locale = NormalizeLocale(locale)
+	candidates := []string{locale}
+	if i := strings.Index(locale, "-"); i > 0 {
+		// the language on its own is a worse match, so goes first
+		// and gets overridden
+		candidates = []string{locale[:i], locale}
+	}
+	for _, candidate := range candidates {
+		localization, ok := p.Localizations[candidate]
+		if !ok {
+			continue
+		}
+		if localization.Title != "" {
+			p.Title = localization.Title
+		}
+		if localization.Desc != "" {
+			p.Desc = localization.Desc
+		}
+	}
+	return p
+}
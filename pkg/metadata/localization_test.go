@@ -0,0 +1,46 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLocalized(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Weather</xbar.title>
+# <xbar.desc>Shows the weather.</xbar.desc>
+# <xbar.title.fr>Météo</xbar.title.fr>
+# <xbar.desc.fr>Affiche la météo.</xbar.desc.fr>
+# <xbar.desc.fr_CA>Affiche la météo au Canada.</xbar.desc.fr_CA>
+# <xbar.title.pt-BR>Clima</xbar.title.pt-BR>
+	`)
+	is.NoErr(err)
+	is.Equal(len(md.Localizations), 3)
+	is.Equal(md.Localizations["fr"].Title, "Météo")
+	is.Equal(md.Localizations["pt-br"].Title, "Clima")
+
+	fr := md.Localized("fr")
+	is.Equal(fr.Title, "Météo")
+	is.Equal(fr.Desc, "Affiche la météo.")
+	frCA := md.Localized("fr_CA")
+	is.Equal(frCA.Title, "Météo") // from fr
+	is.Equal(frCA.Desc, "Affiche la météo au Canada.")
+	ptBR := md.Localized("pt-BR")
+	is.Equal(ptBR.Title, "Clima")
+	is.Equal(ptBR.Desc, "Shows the weather.") // English fallback
+	de := md.Localized("de_DE.UTF-8")
+	is.Equal(de.Title, "Weather")
+	is.Equal(de.Desc, "Shows the weather.")
+	is.Equal(md.Title, "Weather") // unchanged
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	is := is.New(t)
+	is.Equal(NormalizeLocale("en_GB"), "en-gb")
+	is.Equal(NormalizeLocale(" pt-BR "), "pt-br")
+	is.Equal(NormalizeLocale("en_GB.UTF-8"), "en-gb")
+	is.Equal(NormalizeLocale("sr_RS@latin"), "sr-rs")
+}
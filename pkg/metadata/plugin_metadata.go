@@ -47,10 +47,41 @@ type Plugin struct {
 	Authors []Person `json:"authors"`
 	// Desc is a short description of this plugin.
 	Desc string `json:"desc"`
+	// Localizations are the Title and Desc in other languages, by
+	// locale (like fr or pt-br). See Localized.
+	Localizations map[string]Localization `json:"localizations,omitempty"`
 	// ImageURL is a public URL containing the preview image for this plugin.
 	ImageURL string `json:"imageURL"`
+	// ImageURLs are the public URLs of all the screenshots of this
+	// plugin, from each xbar.image. The first is the ImageURL.
+	ImageURLs []string `json:"imageURLs,omitempty"`
 	// Dependencies are a list of explicit dependencies this plugin requires to run.
 	Dependencies []string `json:"dependencies"`
+	// DependencyDetails describe the Dependencies, including the
+	// versions the plugin needs and how to install them, when the
+	// plugin lists them as a JSON array. Only these are checked
+	// before the plugin runs.
+	DependencyDetails []Dependency `json:"dependencyDetails,omitempty"`
+	// MinVersion is the oldest version of xbar the plugin works with,
+	// like v2.1.0.
+	MinVersion string `json:"minVersion,omitempty"`
+	// MinOSVersion is the oldest version of macOS the plugin works
+	// with, like 11.0.
+	MinOSVersion string `json:"minOSVersion,omitempty"`
+	// License is the SPDX license identifier of the plugin (like MIT),
+	// or an expression combining them (like MIT OR Apache-2.0).
+	License string `json:"license,omitempty"`
+	// ChangelogURL is the public URL of a page describing the changes
+	// in each version of the plugin.
+	ChangelogURL string `json:"changelogURL,omitempty"`
+	// Changelog describes the changes in each version of the plugin,
+	// newest first.
+	Changelog []ChangelogEntry `json:"changelog,omitempty"`
+	// Deprecated is whether the plugin should no longer be used.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// ReplacedBy is the path of the plugin to use instead of this one
+	// (like Weather/weather.15m.py), if it is Deprecated.
+	ReplacedBy string `json:"replacedBy,omitempty"`
 	// AboutURL is the public URL to learn more about the plugin, including
 	// to contact the author.
 	AboutURL string `json:"aboutURL"`
@@ -58,6 +89,46 @@ type Plugin struct {
 	LastUpdated time.Time `json:"lastUpdated"`
 	// Vars are the configurable values for this Plugin.
 	Vars []PluginVar `json:"vars"`
+	// Cycle is how long each title is shown for when the plugin
+	// outputs more than one (like 5s).
+	Cycle string `json:"cycle"`
+	// MaxOutput is the most output (like 2MB) xbar will read from
+	// the plugin each time it runs.
+	MaxOutput string `json:"maxOutput"`
+	// Schedule is a cron schedule (like */5 9-17 * * 1-5) for
+	// refreshing the plugin, instead of the interval in its filename.
+	Schedule string `json:"schedule"`
+	// Jitter is how much (like 10%) to randomly vary the time
+	// between refreshes by, so plugins don't all run at once.
+	Jitter string `json:"jitter"`
+	// Timeout is how long the plugin may run for (like 30s) before
+	// it is stopped.
+	Timeout string `json:"timeout"`
+	// RefreshOnOpen indicates that the plugin only runs when its
+	// menu is opened, rather than on a timer.
+	RefreshOnOpen bool `json:"refreshOnOpen"`
+	// Watch are files or directories that cause the plugin to
+	// refresh when they change.
+	Watch []string `json:"watch"`
+	// Streamable indicates that the plugin keeps running, and
+	// updates its menu each time it outputs a ~~~ line.
+	Streamable bool `json:"streamable"`
+	// KeepOutputOnError indicates that the output from the last
+	// successful run is kept (and marked stale) when the plugin
+	// fails, instead of showing the error.
+	KeepOutputOnError bool `json:"keepOutputOnError"`
+	// Overlap is what to do when the plugin is asked to refresh
+	// while it is still running; "queue" or "skip".
+	Overlap string `json:"overlap"`
+	// NeverThrottle indicates that the plugin keeps refreshing as
+	// often as usual on battery power, from <xbar.throttle>false</xbar.throttle>.
+	NeverThrottle bool `json:"neverThrottle"`
+	// Cwd is the directory to run the plugin in (relative to the
+	// plugin, or starting with ~).
+	Cwd string `json:"cwd"`
+	// Interpreter is the program (and any arguments) to run the
+	// plugin with, like python3.
+	Interpreter string `json:"interpreter"`
 
 	// ProcessingNotes is a list of errors/warnings/notes that are set during
 	// the processing of this plugin.
@@ -113,6 +184,18 @@ type PluginVar struct {
 	Desc string `json:"desc"`
 	// Options are the available options for "select" types.
 	Options []string `json:"options"`
+	// Min is the smallest allowed value for "number" types, if any.
+	Min *float64 `json:"min,omitempty"`
+	// Max is the largest allowed value for "number" types, if any.
+	Max *float64 `json:"max,omitempty"`
+	// Secret is whether the value is kept in the Keychain, instead
+	// of with the other values.
+	Secret bool `json:"secret,omitempty"`
+	// Pattern is a regular expression that "string" values must
+	// match, if any.
+	Pattern string `json:"pattern,omitempty"`
+	// Required is whether the value may be empty.
+	Required bool `json:"required,omitempty"`
 }
 
 // DefaultValue gets the Default value in the correct type.
@@ -137,6 +220,165 @@ func (p PluginVar) DefaultValue() interface{} {
 	return p.Default
 }
 
+// ValueError describes a value that isn't valid for a variable.
+type ValueError struct {
+	// Name is the name of the variable.
+	Name string `json:"name"`
+	// Label is the display text for the variable.
+	Label string `json:"label"`
+	// Message describes what is wrong with the value.
+	Message string `json:"message"`
+}
+
+func (e ValueError) Error() string {
+	return e.Label + ": " + e.Message
+}
+
+// ValueErrors are the ValueError for each variable with a value that
+// isn't valid.
+type ValueErrors []ValueError
+
+func (e ValueErrors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// valueErrorf makes a ValueError for this variable.
+func (p PluginVar) valueErrorf(format string, args ...interface{}) ValueError {
+	return ValueError{
+		Name:    p.Name,
+		Label:   p.Label,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Value checks v is a valid value for this variable, and gets it in
+// the correct type; a string for "string" and "select" types, a float64
+// for "number" types, and a bool for "boolean" types.
+// Numbers and booleans may also be given as strings.
+// Invalid values get a ValueError.
+func (p PluginVar) Value(v interface{}) (interface{}, error) {
+	switch p.Type {
+	case "number":
+		var f float64
+		switch val := v.(type) {
+		case float64:
+			f = val
+		case int:
+			f = float64(val)
+		case string:
+			var err error
+			f, err = strconv.ParseFloat(strings.TrimSpace(val), 64)
+			if err != nil {
+				return nil, p.valueErrorf("expected a number, not %q", val)
+			}
+		default:
+			return nil, p.valueErrorf("expected a number, not %v", v)
+		}
+		if (p.Min != nil && f < *p.Min) || (p.Max != nil && f > *p.Max) {
+			return nil, p.valueErrorf("expected a number %s, not %v", p.rangeText(), f)
+		}
+		return f, nil
+	case "boolean":
+		switch val := v.(type) {
+		case bool:
+			return val, nil
+		case string:
+			switch val {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+		}
+		return nil, p.valueErrorf("expected true or false, not %v", v)
+	case "select":
+		s, ok := v.(string)
+		if ok {
+			for _, option := range p.Options {
+				if option == s {
+					return s, nil
+				}
+			}
+		}
+		return nil, p.valueErrorf("expected one of %s, not %v", strings.Join(p.Options, ", "), v)
+	}
+	s, ok := v.(string)
+	if !ok {
+		s = fmt.Sprint(v)
+	}
+	if s == "" {
+		if p.Required {
+			return nil, p.valueErrorf("required")
+		}
+		return s, nil
+	}
+	if p.Pattern != "" {
+		// the pattern is checked when parsing
+		if !regexp.MustCompile(anchorPattern(p.Pattern)).MatchString(s) {
+			return nil, p.valueErrorf("expected a value matching %s, not %q", p.Pattern, s)
+		}
+	}
+	return s, nil
+}
+
+// anchorPattern makes the pattern match whole values, like the
+// pattern attribute of HTML inputs.
+func anchorPattern(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
+
+// rangeText describes the Min and Max of a "number" variable.
+func (p PluginVar) rangeText() string {
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	switch {
+	case p.Min != nil && p.Max != nil:
+		return fmt.Sprintf("from %s to %s", format(*p.Min), format(*p.Max))
+	case p.Min != nil:
+		return "of at least " + format(*p.Min)
+	case p.Max != nil:
+		return "of at most " + format(*p.Max)
+	}
+	return ""
+}
+
+// Values checks values against the variables, and gets them in the
+// correct types.
+// Values for which there is no variable are kept as they are.
+// If any values are invalid, or required variables are missing,
+// the error is ValueErrors.
+func Values(vars []PluginVar, values map[string]interface{}) (map[string]interface{}, error) {
+	typed := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		typed[k] = v
+	}
+	var errs ValueErrors
+	for _, pluginVar := range vars {
+		v, ok := values[pluginVar.Name]
+		if !ok {
+			if pluginVar.Required && pluginVar.Default == "" {
+				errs = append(errs, pluginVar.valueErrorf("required"))
+			}
+			continue
+		}
+		val, err := pluginVar.Value(v)
+		if err != nil {
+			errs = append(errs, err.(ValueError))
+			continue
+		}
+		typed[pluginVar.Name] = val
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return typed, nil
+}
+
 // LastUpdatedFormatted is a formatted string.
 func (p Plugin) LastUpdatedFormatted() string {
 	return p.LastUpdated.Format(time.RFC822)
@@ -190,8 +432,16 @@ func (p Plugin) Complete() error {
 	return nil
 }
 
-// Parse parses the s input extracting bitbar or xbar metadata.
+// Parse parses the s input extracting bitbar or xbar metadata, from
+// tags in comments, or a YAML (---) or TOML (+++) front matter block
+// at the start.
 func Parse(debugf DebugFunc, filename, s string) (Plugin, error) {
+	return parse(debugf, filename, s, "")
+}
+
+// parse parses the metadata in s, along with the extra tags (from
+// a sidecar file), which come last so take precedence.
+func parse(debugf DebugFunc, filename, s, extraTags string) (Plugin, error) {
 	var p Plugin
 	p.LastUpdated = time.Now()
 	p.Files = []File{
@@ -213,65 +463,387 @@ func Parse(debugf DebugFunc, filename, s string) (Plugin, error) {
 	if err != nil {
 		return p, err
 	}
-	submatchall := append(bitbarMatches.FindAllStringSubmatch(s, -1), xbarMatches.FindAllStringSubmatch(s, -1)...)
+	swiftBarMatches, err := regexp.Compile(`<(swiftbar\..*?)>(.*)</swiftbar\..*?>`)
+	if err != nil {
+		return p, err
+	}
+	tagSource := s
+	if lines, format, ok := findFrontMatter(s); ok {
+		frontMatter, err := frontMatterTags(format, lines)
+		if err != nil {
+			return p, err
+		}
+		tagSource += "\n" + frontMatter
+	}
+	tagSource += "\n" + extraTags
+	submatchall := append(bitbarMatches.FindAllStringSubmatch(tagSource, -1), xbarMatches.FindAllStringSubmatch(tagSource, -1)...)
+	submatchall = append(submatchall, swiftBarMatches.FindAllStringSubmatch(tagSource, -1)...)
+	var errs TagErrors
 	for _, element := range submatchall {
 		debugf("%s: %s ", element[1], element[2])
-		switch strings.ToLower(element[1]) {
-		case "bitbar.title", "xbar.title":
-			p.Title = element[2]
-			debugf("✓\n")
-		case "bitbar.version", "xbar.version":
-			p.Version = element[2]
-			debugf("✓\n")
-		case "bitbar.author", "xbar.author":
-			authorNames := strings.Split(element[2], ",")
-			p.Author = strings.Join(authorNames, ", ")
-			for i, authorName := range authorNames {
-				authorName = strings.TrimSpace(authorName)
-				if len(p.Authors) < i+1 {
-					p.Authors = append(p.Authors, Person{})
-				}
-				p.Authors[i].Name = authorName
-			}
-			debugf("✓\n")
-		case "bitbar.author.github", "xbar.author.github":
-			authorUsernames := strings.Split(element[2], ",")
-			for i, authorUsername := range authorUsernames {
-				authorUsername = strings.TrimSpace(authorUsername)
-				if len(p.Authors) < i+1 {
-					p.Authors = append(p.Authors, Person{})
-				}
-				p.Authors[i].GitHubUsername = authorUsername
+		// attributes follow the tag name, like <xbar.var secret="true">
+		tag, attrs := element[1], ""
+		if i := strings.IndexAny(tag, " \t"); i >= 0 {
+			tag, attrs = tag[:i], tag[i+1:]
+		}
+		// tags that can't be parsed are skipped, so the rest still apply
+		if err := p.parseTag(debugf, tag, attrs, element); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(p.Authors) > 0 {
+		// the first author is the "primary" one
+		p.Authors[0].Primary = true
+		names := make([]string, 0, len(p.Authors))
+		for _, author := range p.Authors {
+			if author.Name != "" {
+				names = append(names, author.Name)
 			}
-			debugf("✓\n")
-		case "bitbar.desc", "xbar.desc":
-			p.Desc = element[2]
-			debugf("✓\n")
-		case "bitbar.image", "xbar.image":
-			p.ImageURL = element[2]
-			debugf("✓\n")
-		case "bitbar.abouturl", "xbar.abouturl":
-			p.AboutURL = element[2]
-			debugf("✓\n")
-		case "bitbar.dependencies", "xbar.dependencies":
+		}
+		p.Author = strings.Join(names, ", ")
+	}
+	if len(errs) > 0 {
+		return p, errs
+	}
+	return p, nil
+}
+
+// TagErrors are the errors for each tag that couldn't be parsed.
+// The Plugin returned with them has the tags that could be.
+type TagErrors []error
+
+func (e TagErrors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// parseTag parses a tag, with its attrs, from the element matched
+// in the source (the whole tag, the tag name and the value).
+func (p *Plugin) parseTag(debugf DebugFunc, tag, attrs string, element []string) error {
+	switch strings.ToLower(tag) {
+	case "bitbar.title", "xbar.title":
+		p.Title = element[2]
+		debugf("✓\n")
+	case "bitbar.version", "xbar.version":
+		p.Version = strings.TrimSpace(element[2])
+		debugf("✓\n")
+	case "bitbar.author", "xbar.author":
+		attributes, err := parseAttributes(attrs)
+		if err != nil {
+			return errors.Wrap(err, "xbar.author")
+		}
+		if err := p.addAuthors(splitList(element[2]), attributes); err != nil {
+			return errors.Wrap(err, "xbar.author")
+		}
+		debugf("✓\n")
+	case "bitbar.author.github", "xbar.author.github":
+		for _, username := range splitList(element[2]) {
+			p.addAuthorGitHubUsername(username)
+		}
+		debugf("✓\n")
+	case "bitbar.desc", "xbar.desc":
+		p.Desc = element[2]
+		debugf("✓\n")
+	case "bitbar.image", "xbar.image":
+		imageURL := strings.TrimSpace(element[2])
+		if imageURL != "" && !containsString(p.ImageURLs, imageURL) {
+			p.ImageURLs = append(p.ImageURLs, imageURL)
+			p.ImageURL = p.ImageURLs[0]
+		}
+		debugf("✓\n")
+	case "bitbar.abouturl", "xbar.abouturl":
+		p.AboutURL = element[2]
+		debugf("✓\n")
+	case "bitbar.dependencies", "xbar.dependencies":
+		if !isDependencyList(element[2]) {
 			p.Dependencies = splitList(element[2])
-			debugf("✓\n")
-		case "xbar.var":
-			v, err := parsePluginVar(element[2])
+		} else {
+			deps, err := parseDependencies(element[2])
 			if err != nil {
-				return p, err
+				return errors.Wrap(err, "xbar.dependencies")
 			}
-			p.Vars = append(p.Vars, v)
-			debugf("✓\n")
-		default:
+			p.Dependencies = make([]string, 0, len(deps))
+			for _, dep := range deps {
+				p.Dependencies = append(p.Dependencies, dep.Name)
+			}
+			p.DependencyDetails = deps
+		}
+		debugf("✓\n")
+	case "xbar.license":
+		license, err := ParseLicense(element[2])
+		if err != nil {
+			return errors.Wrap(err, "xbar.license")
+		}
+		p.License = license
+		debugf("✓\n")
+	case "xbar.deprecated":
+		deprecated, err := strconv.ParseBool(strings.TrimSpace(element[2]))
+		if err != nil {
+			return errors.Errorf(`xbar.deprecated: expected true or false, not "%s"`, strings.TrimSpace(element[2]))
+		}
+		p.Deprecated = deprecated
+		debugf("✓\n")
+	case "xbar.replacedby":
+		p.ReplacedBy = strings.Trim(strings.TrimSpace(element[2]), "/")
+		p.Deprecated = p.Deprecated || p.ReplacedBy != ""
+		debugf("✓\n")
+	case "xbar.changelog":
+		p.parseChangelog(element[2])
+		debugf("✓\n")
+	case "xbar.minversion":
+		minVersion := strings.TrimSpace(element[2])
+		if !minVersionRegexp.MatchString(minVersion) {
+			return errors.Errorf(`xbar.minVersion: expected a version (like v2.1.0), not "%s"`, minVersion)
+		}
+		p.MinVersion = minVersion
+		debugf("✓\n")
+	case "xbar.minosversion":
+		minOSVersion := strings.TrimSpace(element[2])
+		if !minVersionRegexp.MatchString(minOSVersion) {
+			return errors.Errorf(`xbar.minOSVersion: expected a macOS version (like 11.0), not "%s"`, minOSVersion)
+		}
+		p.MinOSVersion = minOSVersion
+		debugf("✓\n")
+	case "xbar.cycle":
+		cycle := strings.TrimSpace(element[2])
+		if d, err := time.ParseDuration(cycle); err != nil || d <= 0 {
+			return errors.Errorf(`xbar.cycle: expected a duration (like 5s), not "%s"`, cycle)
+		}
+		p.Cycle = cycle
+		debugf("✓\n")
+	case "xbar.maxoutput":
+		maxOutput := strings.TrimSpace(element[2])
+		if _, err := ParseSize(maxOutput); err != nil {
+			return errors.Wrap(err, "xbar.maxoutput")
+		}
+		p.MaxOutput = maxOutput
+		debugf("✓\n")
+	case "xbar.schedule":
+		schedule := strings.TrimSpace(element[2])
+		if _, err := ParseSchedule(schedule); err != nil {
+			return errors.Wrap(err, "xbar.schedule")
+		}
+		p.Schedule = schedule
+		debugf("✓\n")
+	case "xbar.jitter":
+		jitter := strings.TrimSpace(element[2])
+		if _, err := ParsePercent(jitter); err != nil {
+			return errors.Wrap(err, "xbar.jitter")
+		}
+		p.Jitter = jitter
+		debugf("✓\n")
+	case "xbar.timeout":
+		timeout := strings.TrimSpace(element[2])
+		if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+			return errors.Errorf(`xbar.timeout: expected a duration (like 30s), not "%s"`, timeout)
+		}
+		p.Timeout = timeout
+		debugf("✓\n")
+	case "xbar.refreshonopen":
+		refreshOnOpen, err := strconv.ParseBool(strings.TrimSpace(element[2]))
+		if err != nil {
+			return errors.Errorf(`xbar.refreshonopen: expected "true" or "false", not "%s"`, strings.TrimSpace(element[2]))
+		}
+		p.RefreshOnOpen = refreshOnOpen
+		debugf("✓\n")
+	case "xbar.streamable":
+		streamable, err := strconv.ParseBool(strings.TrimSpace(element[2]))
+		if err != nil {
+			return errors.Errorf(`xbar.streamable: expected "true" or "false", not "%s"`, strings.TrimSpace(element[2]))
+		}
+		p.Streamable = streamable
+		debugf("✓\n")
+	case "xbar.keepoutputonerror":
+		keepOutputOnError, err := strconv.ParseBool(strings.TrimSpace(element[2]))
+		if err != nil {
+			return errors.Errorf(`xbar.keepOutputOnError: expected "true" or "false", not "%s"`, strings.TrimSpace(element[2]))
+		}
+		p.KeepOutputOnError = keepOutputOnError
+		debugf("✓\n")
+	case "xbar.overlap":
+		overlap := strings.ToLower(strings.TrimSpace(element[2]))
+		if overlap != "queue" && overlap != "skip" {
+			return errors.Errorf(`xbar.overlap: expected "queue" or "skip", not "%s"`, strings.TrimSpace(element[2]))
+		}
+		p.Overlap = overlap
+		debugf("✓\n")
+	case "xbar.throttle":
+		throttle, err := strconv.ParseBool(strings.TrimSpace(element[2]))
+		if err != nil {
+			return errors.Errorf(`xbar.throttle: expected "true" or "false", not "%s"`, strings.TrimSpace(element[2]))
+		}
+		p.NeverThrottle = !throttle
+		debugf("✓\n")
+	case "xbar.cwd":
+		cwd := strings.TrimSpace(element[2])
+		if cwd == "" {
+			return errors.New("xbar.cwd: expected a directory")
+		}
+		p.Cwd = cwd
+		debugf("✓\n")
+	case "xbar.interpreter":
+		interpreter := strings.TrimSpace(element[2])
+		if interpreter == "" {
+			return errors.New("xbar.interpreter: expected a program (like python3)")
+		}
+		p.Interpreter = interpreter
+		debugf("✓\n")
+	case "xbar.watch":
+		watch := strings.TrimSpace(element[2])
+		if watch == "" {
+			return errors.New("xbar.watch: expected a file or directory")
+		}
+		p.Watch = append(p.Watch, watch)
+		debugf("✓\n")
+	case "xbar.var":
+		attributes, err := parseAttributes(attrs)
+		if err != nil {
+			return errors.Wrap(err, "xbar.var")
+		}
+		v, err := parsePluginVar(element[2], attributes)
+		if err != nil {
+			return err
+		}
+		p.Vars = append(p.Vars, v)
+		debugf("✓\n")
+	default:
+		if strings.HasPrefix(strings.ToLower(tag), "swiftbar.") {
+			p.parseSwiftBarTag(debugf, tag, element[2])
+			return nil
+		}
+		field, locale, ok, err := parseLocalizedTag(tag)
+		if err != nil {
+			return err
+		}
+		if !ok {
 			debugf("(skipping) unknown parameter %s\n", element[1])
+			return nil
 		}
+		if p.Localizations == nil {
+			p.Localizations = make(map[string]Localization)
+		}
+		localization := p.Localizations[locale]
+		switch field {
+		case "title":
+			localization.Title = strings.TrimSpace(element[2])
+		case "desc":
+			localization.Desc = strings.TrimSpace(element[2])
+		}
+		p.Localizations[locale] = localization
+		debugf("✓\n")
 	}
-	if len(p.Authors) > 0 {
-		// the first author is the "primary" one
-		p.Authors[0].Primary = true
+	return nil
+}
+
+// addAuthors adds the names from an xbar.author tag to the Authors.
+// Plugins can list their authors in one tag (separated by commas), or
+// have a tag for each.
+// The github attribute (like github="matryer") gives the GitHub
+// usernames of the authors in the tag, in the same order.
+// Authors that have already been added are skipped.
+func (p *Plugin) addAuthors(names []string, attributes map[string]string) error {
+	var usernames []string
+	for name, value := range attributes {
+		if name != "github" {
+			return errors.Errorf(`unknown attribute "%s"`, name)
+		}
+		usernames = splitList(value)
+		if len(usernames) != len(names) {
+			return errors.Errorf("expected a GitHub username for each of the %d authors, not %d", len(names), len(usernames))
+		}
 	}
-	return p, nil
+	for i, name := range names {
+		author := p.findAuthor(func(person Person) bool {
+			return strings.EqualFold(person.Name, name)
+		})
+		if author == nil {
+			author = p.findAuthor(func(person Person) bool {
+				return person.Name == ""
+			})
+		}
+		if author == nil {
+			p.Authors = append(p.Authors, Person{})
+			author = &p.Authors[len(p.Authors)-1]
+		}
+		author.Name = name
+		if usernames != nil {
+			author.GitHubUsername = usernames[i]
+		}
+	}
+	return nil
+}
+
+// addAuthorGitHubUsername gives the first author without one the
+// GitHub username from an xbar.author.github tag.
+func (p *Plugin) addAuthorGitHubUsername(username string) {
+	if p.findAuthor(func(person Person) bool {
+		return strings.EqualFold(person.GitHubUsername, username)
+	}) != nil {
+		return
+	}
+	author := p.findAuthor(func(person Person) bool {
+		return person.GitHubUsername == ""
+	})
+	if author == nil {
+		p.Authors = append(p.Authors, Person{})
+		author = &p.Authors[len(p.Authors)-1]
+	}
+	author.GitHubUsername = username
+}
+
+// findAuthor gets the first of the Authors that match, or nil if
+// none do.
+func (p *Plugin) findAuthor(match func(person Person) bool) *Person {
+	for i := range p.Authors {
+		if match(p.Authors[i]) {
+			return &p.Authors[i]
+		}
+	}
+	return nil
+}
+
+// sizeUnits are the units accepted by ParseSize.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	// longest first, so B doesn't match KB etc.
+	{suffix: "KB", multiplier: 1 << 10},
+	{suffix: "MB", multiplier: 1 << 20},
+	{suffix: "GB", multiplier: 1 << 30},
+	{suffix: "B", multiplier: 1},
+}
+
+// ParseSize parses a size in bytes, like 500KB or 2MB.
+// A number without units is bytes.
+func ParseSize(s string) (int64, error) {
+	value, multiplier := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errors.Errorf(`expected a size (like 2MB), not "%s"`, s)
+	}
+	return n * multiplier, nil
+}
+
+// ParsePercent parses a percentage between 0 and 100 (like 10%),
+// returning it as a fraction (like 0.1). The % is optional.
+func ParsePercent(s string) (float64, error) {
+	value := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, errors.Errorf(`expected a percentage (like 10%%), not "%s"`, s)
+	}
+	return percent / 100, nil
 }
 
 // PathItem is a path segment.
@@ -299,6 +871,16 @@ func CategoryPathSegments(categoryPath string) []PathItem {
 	return items
 }
 
+// containsString gets whether the strings include s.
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
 func splitList(s string) []string {
 	segs := strings.Split(s, ",")
 	cleanSegs := make([]string, 0, len(segs))
@@ -365,7 +947,37 @@ func RandomPlugins(pluginsByPath map[string][]Plugin, pathPrefix string, n int)
 	return pluginsWithImages[:n]
 }
 
-func parsePluginVar(s string) (PluginVar, error) {
+// numberRangeRegexp matches the range at the end of the description
+// of a "number" variable, like [1..10], [0..] or [..100].
+var numberRangeRegexp = regexp.MustCompile(`\[\s*(-?\d+(?:\.\d+)?)?\s*\.\.\s*(-?\d+(?:\.\d+)?)?\s*\]\s*$`)
+
+// attributeRegexp matches a name="value" attribute in a tag.
+var attributeRegexp = regexp.MustCompile(`(\w+)=("[^"]*"|'[^']*'|[^\s"']+)`)
+
+// parseAttributes parses the name="value" attributes in s.
+func parseAttributes(s string) (map[string]string, error) {
+	attributes := make(map[string]string)
+	for _, match := range attributeRegexp.FindAllStringSubmatch(s, -1) {
+		attributes[strings.ToLower(match[1])] = strings.Trim(match[2], `"'`)
+	}
+	if rest := strings.TrimSpace(attributeRegexp.ReplaceAllString(s, "")); rest != "" {
+		return nil, errors.Errorf(`expected attributes like secret="true", not "%s"`, rest)
+	}
+	return attributes, nil
+}
+
+// varLabel gets the display text for a variable called name, like
+// "Api key" for VAR_API_KEY.
+func varLabel(name string) string {
+	if !strings.HasPrefix(name, "VAR_") || name == "VAR_" {
+		return name
+	}
+	label := strings.ToLower(strings.TrimPrefix(name, "VAR_"))
+	label = strings.ToUpper(label[0:1]) + label[1:]
+	return strings.ReplaceAll(label, "_", " ")
+}
+
+func parsePluginVar(s string, attributes map[string]string) (PluginVar, error) {
 	var v PluginVar
 	varLineRegexp, err := regexp.Compile(`(.+)\((.+)\):\s(.+)`)
 	if err != nil {
@@ -393,16 +1005,85 @@ func parsePluginVar(s string) (PluginVar, error) {
 		v.Name = nameSegs[0]
 		v.Default = strings.Trim(nameSegs[1], `"'`)
 	}
-	v.Label = v.Name
-	if strings.HasPrefix(v.Name, "VAR_") {
-		v.Label = strings.ToLower(strings.TrimPrefix(v.Name, "VAR_"))
-		v.Label = strings.ToUpper(v.Label[0:1]) + v.Label[1:]
-		v.Label = strings.ReplaceAll(v.Label, "_", " ")
+	v.Label = varLabel(v.Name)
+	for name, value := range attributes {
+		switch name {
+		case "secret", "required":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return v, errors.Errorf(`xbar.var: %s: expected "true" or "false", not "%s"`, name, value)
+			}
+			if name == "secret" {
+				v.Secret = b
+			} else {
+				v.Required = b
+			}
+		case "pattern":
+			if v.Type != "string" {
+				return v, errors.New("xbar.var: pattern: only for string variables")
+			}
+			if _, err := regexp.Compile(anchorPattern(value)); err != nil {
+				return v, errors.Wrap(err, "xbar.var: pattern")
+			}
+			v.Pattern = value
+		case "min", "max":
+			if v.Type != "number" {
+				return v, errors.Errorf("xbar.var: %s: only for number variables", name)
+			}
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return v, errors.Errorf(`xbar.var: %s: expected a number, not "%s"`, name, value)
+			}
+			if name == "min" {
+				v.Min = &f
+			} else {
+				v.Max = &f
+			}
+		default:
+			return v, errors.Errorf(`xbar.var: unknown attribute "%s"`, name)
+		}
 	}
 	switch v.Type {
-	case "string", "number", "boolean":
-		// valid types - but no work to do
-	case "select":
+	case "string":
+		if v.Pattern != "" && v.Default != "" {
+			if _, err := v.Value(v.Default); err != nil {
+				return v, errParse{
+					src: s,
+					err: errors.New("malformed xbar.var format (default doesn't match pattern)"),
+				}
+			}
+		}
+	case "boolean":
+		// valid type - but no work to do
+	case "number":
+		// extract the optional range from description
+		if match := numberRangeRegexp.FindStringSubmatch(v.Desc); match != nil {
+			v.Desc = strings.TrimSpace(strings.TrimSuffix(v.Desc, match[0]))
+			if match[1] != "" {
+				min, _ := strconv.ParseFloat(match[1], 64)
+				v.Min = &min
+			}
+			if match[2] != "" {
+				max, _ := strconv.ParseFloat(match[2], 64)
+				v.Max = &max
+			}
+		}
+		if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+			return v, errParse{
+				src: s,
+				err: errors.New("malformed xbar.var format (min is greater than max)"),
+			}
+		}
+		if v.Min != nil || v.Max != nil {
+			if _, err := v.Value(v.Default); err != nil {
+				return v, errParse{
+					src: s,
+					err: errors.New("malformed xbar.var format (default not in range)"),
+				}
+			}
+		}
+	case "select", "list":
+		v.Type = "select"
 		// extract options from description
 		listSegs := strings.Split(v.Desc, `[`)
 		if len(listSegs) < 2 {
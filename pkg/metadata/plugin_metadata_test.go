@@ -96,6 +96,60 @@ func TestParse(t *testing.T) {
 	is.Equal(md.DocsCategory, "")
 }
 
+func TestMultipleAuthors(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.author github="matryer">Mat Ryer</xbar.author>
+# <xbar.author>David Hernandez, Lea Anthony</xbar.author>
+# <xbar.author.github>dhernandez</xbar.author.github>
+# <xbar.author.github>leaanthony</xbar.author.github>
+# <xbar.author github="someone">Someone Else</xbar.author>
+	`)
+	is.NoErr(err)
+	is.Equal(len(md.Authors), 4)
+	is.Equal(md.Author, "Mat Ryer, David Hernandez, Lea Anthony, Someone Else")
+	is.Equal(md.Authors[0].Name, "Mat Ryer")
+	is.Equal(md.Authors[0].GitHubUsername, "matryer")
+	is.True(md.Authors[0].Primary)
+	is.Equal(md.Authors[1].Name, "David Hernandez")
+	is.Equal(md.Authors[1].GitHubUsername, "dhernandez")
+	is.True(!md.Authors[1].Primary)
+	is.Equal(md.Authors[2].Name, "Lea Anthony")
+	is.Equal(md.Authors[2].GitHubUsername, "leaanthony")
+	is.Equal(md.Authors[3].Name, "Someone Else")
+	is.Equal(md.Authors[3].GitHubUsername, "someone")
+
+	// bitbar tags are still supported, and aren't added twice
+	md, err = Parse(DebugfNoop, "test.txt", `
+# <bitbar.author.github>matryer</bitbar.author.github>
+# <bitbar.author>Mat Ryer</bitbar.author>
+	`)
+	is.NoErr(err)
+	is.Equal(len(md.Authors), 1)
+	is.Equal(md.Authors[0].Name, "Mat Ryer")
+	is.Equal(md.Authors[0].GitHubUsername, "matryer")
+}
+
+func TestImages(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.image>https://example.com/one.png</xbar.image>
+# <xbar.image> https://example.com/two.png </xbar.image>
+# <xbar.image>https://example.com/one.png</xbar.image>
+	`)
+	is.NoErr(err)
+	is.Equal(md.ImageURL, "https://example.com/one.png")
+	is.Equal(md.ImageURLs, []string{"https://example.com/one.png", "https://example.com/two.png"})
+
+	md, err = Parse(DebugfNoop, "test.txt", `
+# <bitbar.image>https://example.com/one.png</bitbar.image>
+	`)
+	is.NoErr(err)
+	is.Equal(md.ImageURLs, []string{"https://example.com/one.png"})
+}
+
 func TestPluginCategoryPathSegments(t *testing.T) {
 	is := is.New(t)
 
@@ -175,6 +229,312 @@ func TestVariables(t *testing.T) {
 
 }
 
+func TestVariableTypes(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.var>number(VAR_COUNT=5): How many. [1..10]</xbar.var>
+# <xbar.var>number(VAR_OFFSET=0): The offset. [..100]</xbar.var>
+# <xbar.var>boolean(VAR_VERBOSE=true): Whether to be verbose or not.</xbar.var>
+# <xbar.var>list(VAR_STYLE="normal"): Which style to use. [small, normal, big]</xbar.var>
+# <xbar.var>string(VAR_NAME="Mat"): Your name.</xbar.var>
+	`)
+	is.NoErr(err)
+	is.Equal(len(md.Vars), 5)
+
+	count := md.Vars[0]
+	is.Equal(count.Desc, "How many.")
+	is.Equal(*count.Min, 1.0)
+	is.Equal(*count.Max, 10.0)
+	offset := md.Vars[1]
+	is.Equal(offset.Min, nil)
+	is.Equal(*offset.Max, 100.0)
+	is.Equal(md.Vars[3].Type, "select") // list is select
+
+	v, err := count.Value("7")
+	is.NoErr(err)
+	is.Equal(v, 7.0)
+	v, err = count.Value(3.0)
+	is.NoErr(err)
+	is.Equal(v, 3.0)
+	_, err = count.Value(11.0)
+	is.Equal(err.Error(), "Count: expected a number from 1 to 10, not 11")
+	_, err = count.Value("lots")
+	is.Equal(err.Error(), `Count: expected a number, not "lots"`)
+	_, err = offset.Value(101.0)
+	is.Equal(err.Error(), "Offset: expected a number of at most 100, not 101")
+
+	v, err = md.Vars[2].Value("false")
+	is.NoErr(err)
+	is.Equal(v, false)
+	_, err = md.Vars[2].Value("yes")
+	is.Equal(err.Error(), "Verbose: expected true or false, not yes")
+
+	v, err = md.Vars[3].Value("big")
+	is.NoErr(err)
+	is.Equal(v, "big")
+	_, err = md.Vars[3].Value("huge")
+	is.Equal(err.Error(), "Style: expected one of small, normal, big, not huge")
+
+	values, err := Values(md.Vars, map[string]interface{}{
+		"VAR_COUNT":   "2",
+		"VAR_VERBOSE": true,
+		"OTHER":       "kept",
+	})
+	is.NoErr(err)
+	is.Equal(values["VAR_COUNT"], 2.0)
+	is.Equal(values["VAR_VERBOSE"], true)
+	is.Equal(values["OTHER"], "kept")
+	_, err = Values(md.Vars, map[string]interface{}{
+		"VAR_COUNT": 0.0,
+	})
+	is.True(err != nil)
+}
+
+func TestSecretVariables(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.var secret="true">string(VAR_TOKEN=""): Your API token.</xbar.var>
+# <xbar.var secret=false>string(VAR_NAME=""): Your name.</xbar.var>
+	`)
+	is.NoErr(err)
+	is.Equal(len(md.Vars), 2)
+	is.Equal(md.Vars[0].Name, "VAR_TOKEN")
+	is.Equal(md.Vars[0].Secret, true)
+	is.Equal(md.Vars[1].Secret, false)
+}
+
+func TestVariableValidation(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.var pattern="[a-z]+" required="true">string(VAR_USERNAME=""): Your username.</xbar.var>
+# <xbar.var min="1" max=10>number(VAR_COUNT=5): How many.</xbar.var>
+# <xbar.var>string(VAR_NAME=""): Your name.</xbar.var>
+	`)
+	is.NoErr(err)
+	is.Equal(len(md.Vars), 3)
+	username := md.Vars[0]
+	is.Equal(username.Pattern, "[a-z]+")
+	is.Equal(username.Required, true)
+	count := md.Vars[1]
+	is.Equal(*count.Min, 1.0)
+	is.Equal(*count.Max, 10.0)
+
+	v, err := username.Value("matryer")
+	is.NoErr(err)
+	is.Equal(v, "matryer")
+	_, err = username.Value("Mat Ryer")
+	is.Equal(err.Error(), `Username: expected a value matching [a-z]+, not "Mat Ryer"`)
+	_, err = username.Value("")
+	is.Equal(err.Error(), "Username: required")
+	v, err = md.Vars[2].Value("")
+	is.NoErr(err)
+	is.Equal(v, "")
+
+	_, err = Values(md.Vars, map[string]interface{}{
+		"VAR_COUNT": 11.0,
+	})
+	valueErrs, ok := err.(ValueErrors)
+	is.True(ok)
+	is.Equal(len(valueErrs), 2)
+	is.Equal(valueErrs[0], ValueError{
+		Name:    "VAR_USERNAME",
+		Label:   "Username",
+		Message: "required",
+	})
+	is.Equal(valueErrs[1].Name, "VAR_COUNT")
+	is.Equal(valueErrs[1].Message, "expected a number from 1 to 10, not 11")
+	is.Equal(err.Error(), "Username: required; Count: expected a number from 1 to 10, not 11")
+}
+
+func TestCycle(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Rotating</xbar.title>
+# <xbar.cycle>10s</xbar.cycle>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Cycle, "10s")
+}
+
+func TestMaxOutput(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Chatty</xbar.title>
+# <xbar.maxoutput>2MB</xbar.maxoutput>
+	`)
+	is.NoErr(err)
+	is.Equal(md.MaxOutput, "2MB")
+
+	for s, expected := range map[string]int64{
+		"100":    100,
+		"100B":   100,
+		"500KB":  500 * 1024,
+		"2MB":    2 * 1024 * 1024,
+		"1 gb":   1024 * 1024 * 1024,
+		" 3 MB ": 3 * 1024 * 1024,
+	} {
+		n, err := ParseSize(s)
+		is.NoErr(err)
+		is.Equal(n, expected)
+	}
+	for _, s := range []string{"", "MB", "-1MB", "0", "2TB", "lots"} {
+		_, err := ParseSize(s)
+		is.True(err != nil)
+	}
+}
+
+func TestScheduleMetadata(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Work hours</xbar.title>
+# <xbar.schedule>*/5 9-17 * * 1-5</xbar.schedule>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Schedule, "*/5 9-17 * * 1-5")
+}
+
+func TestRefreshOnOpen(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Lazy</xbar.title>
+# <xbar.refreshonopen>true</xbar.refreshonopen>
+	`)
+	is.NoErr(err)
+	is.Equal(md.RefreshOnOpen, true)
+
+	_, err = Parse(DebugfNoop, "test.txt", `# <xbar.refreshonopen>sometimes</xbar.refreshonopen>`)
+	is.True(err != nil)
+}
+
+func TestKeepOutputOnError(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Flaky</xbar.title>
+# <xbar.keepOutputOnError>true</xbar.keepOutputOnError>
+	`)
+	is.NoErr(err)
+	is.Equal(md.KeepOutputOnError, true)
+}
+
+func TestOverlap(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Sync</xbar.title>
+# <xbar.overlap>Skip</xbar.overlap>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Overlap, "skip")
+}
+
+func TestThrottle(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Alarm</xbar.title>
+# <xbar.throttle>false</xbar.throttle>
+	`)
+	is.NoErr(err)
+	is.Equal(md.NeverThrottle, true)
+
+	md, err = Parse(DebugfNoop, "test.txt", `# <xbar.title>Weather</xbar.title>`)
+	is.NoErr(err)
+	is.Equal(md.NeverThrottle, false)
+}
+
+func TestCwdAndInterpreter(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Repo stats</xbar.title>
+# <xbar.cwd>~/code/repo</xbar.cwd>
+# <xbar.interpreter>python3 -u</xbar.interpreter>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Cwd, "~/code/repo")
+	is.Equal(md.Interpreter, "python3 -u")
+}
+
+func TestWatch(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Todos</xbar.title>
+# <xbar.watch>~/todo.txt</xbar.watch>
+# <xbar.watch>/var/log/app</xbar.watch>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Watch, []string{"~/todo.txt", "/var/log/app"})
+}
+
+func TestStreamable(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Live</xbar.title>
+# <xbar.streamable>true</xbar.streamable>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Streamable, true)
+	is.Equal(md.RefreshOnOpen, false)
+
+	md, err = Parse(DebugfNoop, "test.txt", `# <xbar.title>Not live</xbar.title>`)
+	is.NoErr(err)
+	is.Equal(md.Streamable, false)
+}
+
+func TestJitter(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+# <xbar.title>Jittery</xbar.title>
+# <xbar.jitter>10%</xbar.jitter>
+	`)
+	is.NoErr(err)
+	is.Equal(md.Jitter, "10%")
+
+	for s, expected := range map[string]float64{
+		"10%":   0.1,
+		"25":    0.25,
+		" 0 % ": 0,
+		"100%":  1,
+	} {
+		jitter, err := ParsePercent(s)
+		is.NoErr(err)
+		is.Equal(jitter, expected)
+	}
+	for _, s := range []string{"", "%", "-5%", "101%", "ten"} {
+		_, err := ParsePercent(s)
+		is.True(err != nil)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.txt", `
+	<xbar.deprecated>true</xbar.deprecated>
+	`)
+	is.NoErr(err)
+	is.True(md.Deprecated)
+	is.Equal(md.ReplacedBy, "")
+
+	md, err = Parse(DebugfNoop, "test.txt", `
+	<xbar.replacedBy>/Weather/weather.15m.py</xbar.replacedBy>
+	`)
+	is.NoErr(err)
+	is.True(md.Deprecated) // replaced plugins are deprecated
+	is.Equal(md.ReplacedBy, "Weather/weather.15m.py")
+}
+
 func TestErrors(t *testing.T) {
 	is := is.New(t)
 
@@ -188,9 +548,102 @@ func TestErrors(t *testing.T) {
 		"default not in select options": `
 			<xbar.var>select(VAR_STYLE="not-in-select"): Default not in the select of options. [one,two,three]</xbar.var>
 		`,
+		"default not in range": `
+			<xbar.var>number(VAR_COUNT=20): Out of range. [1..10]</xbar.var>
+		`,
+		"min is greater than max": `
+			<xbar.var>number(VAR_COUNT=5): Backwards. [10..1]</xbar.var>
+		`,
+		"xbar.var: secret: expected \"true\" or \"false\"": `
+			<xbar.var secret="maybe">string(VAR_TOKEN=""): Your API token.</xbar.var>
+		`,
+		"xbar.var: unknown attribute \"hidden\"": `
+			<xbar.var hidden="true">string(VAR_TOKEN=""): Your API token.</xbar.var>
+		`,
+		"xbar.var: expected attributes": `
+			<xbar.var secret>string(VAR_TOKEN=""): Your API token.</xbar.var>
+		`,
+		"xbar.var: required: expected \"true\" or \"false\"": `
+			<xbar.var required="yes">string(VAR_TOKEN=""): Your API token.</xbar.var>
+		`,
+		"xbar.var: pattern: only for string variables": `
+			<xbar.var pattern="[0-9]+">number(VAR_COUNT=1): How many.</xbar.var>
+		`,
+		"xbar.var: pattern: error parsing regexp": `
+			<xbar.var pattern="[0-9">string(VAR_CODE=""): Your code.</xbar.var>
+		`,
+		"xbar.var: min: only for number variables": `
+			<xbar.var min="1">string(VAR_NAME=""): Your name.</xbar.var>
+		`,
+		"xbar.var: max: expected a number": `
+			<xbar.var max="lots">number(VAR_COUNT=1): How many.</xbar.var>
+		`,
+		"default doesn't match pattern": `
+			<xbar.var pattern="[a-z]+">string(VAR_USERNAME="Mat Ryer"): Your username.</xbar.var>
+		`,
+		"xbar.dependencies: expected a comma separated list, or a JSON array": `
+			<xbar.dependencies>[{"name": "jq"</xbar.dependencies>
+		`,
+		"xbar.dependencies: jq: expected a version (like >=1.6)": `
+			<xbar.dependencies>[{"name": "jq", "version": "latest"}]</xbar.dependencies>
+		`,
+		"xbar.author: expected a GitHub username for each of the 2 authors, not 1": `
+			<xbar.author github="matryer">Mat Ryer, Lea Anthony</xbar.author>
+		`,
+		`xbar.author: unknown attribute "twitter"`: `
+			<xbar.author twitter="matryer">Mat Ryer</xbar.author>
+		`,
+		`xbar.license: unknown SPDX license identifier "Proprietary"`: `
+			<xbar.license>Proprietary</xbar.license>
+		`,
+		`xbar.desc: expected a language code (like fr or pt-BR), not "french"`: `
+			<xbar.desc.french>Affiche la météo.</xbar.desc.french>
+		`,
+		`xbar.deprecated: expected true or false, not "soon"`: `
+			<xbar.deprecated>soon</xbar.deprecated>
+		`,
+		"xbar.minVersion: expected a version (like v2.1.0)": `
+			<xbar.minVersion>latest</xbar.minVersion>
+		`,
+		"xbar.minOSVersion: expected a macOS version (like 11.0)": `
+			<xbar.minOSVersion>Big Sur</xbar.minOSVersion>
+		`,
 		"malformed": `
 			<xbar.var>select(VAR_STYLE="): Missing options.</xbar.var>
 		`,
+		"xbar.maxoutput: expected a size": `
+			<xbar.maxoutput>huge</xbar.maxoutput>
+		`,
+		"xbar.streamable: expected \"true\" or \"false\"": `
+			<xbar.streamable>yes please</xbar.streamable>
+		`,
+		"xbar.keepOutputOnError: expected \"true\" or \"false\"": `
+			<xbar.keepOutputOnError>yes please</xbar.keepOutputOnError>
+		`,
+		"xbar.overlap: expected \"queue\" or \"skip\"": `
+			<xbar.overlap>sometimes</xbar.overlap>
+		`,
+		"xbar.throttle: expected \"true\" or \"false\"": `
+			<xbar.throttle>never</xbar.throttle>
+		`,
+		"xbar.cwd: expected a directory": `
+			<xbar.cwd> </xbar.cwd>
+		`,
+		"xbar.interpreter: expected a program": `
+			<xbar.interpreter></xbar.interpreter>
+		`,
+		"xbar.schedule: minute: expected 0-59": `
+			<xbar.schedule>99 * * * *</xbar.schedule>
+		`,
+		"xbar.jitter: expected a percentage (like 10%)": `
+			<xbar.jitter>lots</xbar.jitter>
+		`,
+		"xbar.timeout: expected a duration (like 30s)": `
+			<xbar.timeout>-5s</xbar.timeout>
+		`,
+		"xbar.cycle: expected a duration": `
+			<xbar.cycle>five seconds</xbar.cycle>
+		`,
 	}
 	for expected, src := range errs {
 		t.Run(expected, func(t *testing.T) {
@@ -201,3 +654,25 @@ func TestErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestTagErrors(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "test.script", `
+		<xbar.title>Todo</xbar.title>
+		<xbar.cycle>five seconds</xbar.cycle>
+		<xbar.timeout>soon</xbar.timeout>
+		<xbar.var secret="true">string(VAR_TOKEN=""): Your API token.</xbar.var>
+		<xbar.cwd>/tmp</xbar.cwd>
+	`)
+	tagErrs, ok := err.(TagErrors)
+	is.True(ok)
+	is.Equal(len(tagErrs), 2)
+	is.True(strings.HasPrefix(tagErrs[0].Error(), "xbar.cycle:"))
+	is.True(strings.HasPrefix(tagErrs[1].Error(), "xbar.timeout:"))
+	// the other tags are still parsed
+	is.Equal(md.Title, "Todo")
+	is.Equal(md.Cycle, "")
+	is.Equal(len(md.Vars), 1)
+	is.Equal(md.Cwd, "/tmp")
+}
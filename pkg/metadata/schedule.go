@@ -0,0 +1,157 @@
+package metadata
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule is a cron schedule, like */5 9-17 * * 1-5.
+type Schedule struct {
+	minutes, hours, days, months, weekdays uint64
+	// anyDay and anyWeekday are whether the day of month or day of
+	// week fields are *, which changes how they combine.
+	anyDay, anyWeekday bool
+}
+
+// scheduleField describes one of the five fields of a Schedule.
+type scheduleField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var scheduleFields = []scheduleField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// 7 is also Sunday
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// ParseSchedule parses a cron schedule with five fields (minute,
+// hour, day of month, month and day of week). Each field may be *,
+// a value, a range (1-5), a step (*/5 or 9-17/2), or a comma
+// separated list of them. Months and days of the week may be names
+// (like jan or mon).
+func ParseSchedule(s string) (*Schedule, error) {
+	fields := strings.Fields(s)
+	if len(fields) != len(scheduleFields) {
+		return nil, errors.Errorf(`expected a cron schedule (like */5 9-17 * * 1-5), not "%s"`, s)
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		var err error
+		bits[i], err = scheduleFields[i].parse(field)
+		if err != nil {
+			return nil, errors.Wrap(err, scheduleFields[i].name)
+		}
+	}
+	if bits[4]&(1<<7) != 0 {
+		// 7 is Sunday
+		bits[4] |= 1
+	}
+	return &Schedule{
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}, nil
+}
+
+// parse parses the field, returning a bit set of the values.
+func (f scheduleField) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, errors.Errorf(`bad step "%s"`, part)
+			}
+			rangePart = part[:i]
+		}
+		start, end := f.min, f.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			start, err = f.value(bounds[0])
+			if err != nil {
+				return 0, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				end, err = f.value(bounds[1])
+				if err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// 5/10 means from 5, every 10
+				end = f.max
+			}
+			if end < start {
+				return 0, errors.Errorf(`bad range "%s"`, rangePart)
+			}
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single value (or name) for the field.
+func (f scheduleField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, errors.Errorf(`expected %d-%d, not "%s"`, f.min, f.max, s)
+	}
+	return v, nil
+}
+
+// Next gets the first time after t that matches the schedule, or
+// the zero time if there isn't one (like on the 31st of February).
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// no schedule repeats less than every few years
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay gets whether the day of t matches the schedule.
+// Like cron, if both the day of month and day of week are
+// restricted, either may match.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if !s.anyDay && !s.anyWeekday {
+		return day || weekday
+	}
+	return day && weekday
+}
@@ -0,0 +1,39 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestSchedule(t *testing.T) {
+	is := is.New(t)
+
+	// Friday
+	now := time.Date(2021, time.March, 19, 16, 58, 30, 0, time.UTC)
+	for schedule, expected := range map[string]time.Time{
+		"* * * * *":            time.Date(2021, time.March, 19, 16, 59, 0, 0, time.UTC),
+		"*/5 9-17 * * 1-5":     time.Date(2021, time.March, 19, 17, 0, 0, 0, time.UTC),
+		"*/5 9-16 * * mon-fri": time.Date(2021, time.March, 22, 9, 0, 0, 0, time.UTC),
+		"0 12 * * 0":           time.Date(2021, time.March, 21, 12, 0, 0, 0, time.UTC),
+		"0 12 * * 7":           time.Date(2021, time.March, 21, 12, 0, 0, 0, time.UTC),
+		"30 8 1 jan *":         time.Date(2022, time.January, 1, 8, 30, 0, 0, time.UTC),
+		"0 0 1,15 * *":         time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 31 * 1":           time.Date(2021, time.March, 22, 0, 0, 0, 0, time.UTC), // either day matches
+		"15/20 * * * *":        time.Date(2021, time.March, 19, 17, 15, 0, 0, time.UTC),
+	} {
+		s, err := ParseSchedule(schedule)
+		is.NoErr(err)
+		is.Equal(s.Next(now), expected) // schedule
+	}
+
+	s, err := ParseSchedule("0 0 31 2 *")
+	is.NoErr(err)
+	is.True(s.Next(now).IsZero()) // never
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		_, err := ParseSchedule(bad)
+		is.True(err != nil) // bad schedule
+	}
+}
@@ -0,0 +1,137 @@
+package metadata
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// searchFields are the fields of a plugin that Search looks in, and
+// how much a match in each counts for.
+var searchFields = []struct {
+	weight int
+	values func(p Plugin) []string
+}{
+	{4, func(p Plugin) []string { return []string{p.Title} }},
+	{3, func(p Plugin) []string { return []string{p.Filename} }},
+	{2, func(p Plugin) []string {
+		values := make([]string, 0, len(p.Authors)*2)
+		for _, author := range p.Authors {
+			values = append(values, author.Name, author.GitHubUsername)
+		}
+		return values
+	}},
+	{1, func(p Plugin) []string { return []string{p.Desc} }},
+}
+
+// Search finds the plugins that match the query, best matches first.
+// Each word in the query has to match the title, filename, authors
+// or description of the plugin, either exactly, or fuzzily (with the
+// letters in order, like wthr for weather).
+func Search(plugins []Plugin, query string) []Plugin {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+	type result struct {
+		plugin Plugin
+		score  int
+	}
+	var results []result
+	for _, plugin := range plugins {
+		score := searchScore(plugin, terms)
+		if score == 0 {
+			continue
+		}
+		results = append(results, result{plugin: plugin, score: score})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return strings.ToLower(results[i].plugin.Title) < strings.ToLower(results[j].plugin.Title)
+	})
+	matches := make([]Plugin, len(results))
+	for i := range results {
+		matches[i] = results[i].plugin
+	}
+	return matches
+}
+
+// searchScore gets how well the plugin matches the (lowercase) terms,
+// or zero if any of them don't match.
+func searchScore(plugin Plugin, terms []string) int {
+	var total int
+	for _, term := range terms {
+		var best int
+		for _, field := range searchFields {
+			for _, value := range field.values(plugin) {
+				if score := field.weight * fuzzyScore(strings.ToLower(value), term); score > best {
+					best = score
+				}
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// fuzzyScore gets how well the term matches the text: best if it's
+// the whole text, then the start of a word, then anywhere in it, and
+// least if its letters are in the text in order, and close together
+// (closer scores higher), or zero if it doesn't match.
+func fuzzyScore(text, term string) int {
+	if text == "" {
+		return 0
+	}
+	if text == term {
+		return 150
+	}
+	if i := strings.Index(text, term); i != -1 {
+		if i == 0 || !isWordRune(rune(text[i-1])) {
+			return 100
+		}
+		return 60
+	}
+	// the letters of the term, in order, as close together as they
+	// are anywhere in the text
+	termRunes, textRunes := []rune(term), []rune(text)
+	span := -1
+	for start := range textRunes {
+		if textRunes[start] != termRunes[0] {
+			continue
+		}
+		next := 1
+		end := start
+		for end+1 < len(textRunes) && next < len(termRunes) {
+			end++
+			if textRunes[end] == termRunes[next] {
+				next++
+			}
+		}
+		if next < len(termRunes) {
+			// no more matches further on
+			break
+		}
+		if n := end - start + 1; span == -1 || n < span {
+			span = n
+		}
+	}
+	if span == -1 || span > maxFuzzySpan*len(termRunes) {
+		// not there, or too far apart to be what was meant
+		return 0
+	}
+	return 40 * len(termRunes) / span
+}
+
+// maxFuzzySpan is how many times longer than the term the text it
+// fuzzily matches can be.
+const maxFuzzySpan = 3
+
+// isWordRune gets whether r is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
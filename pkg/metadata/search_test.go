@@ -0,0 +1,61 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSearch(t *testing.T) {
+	is := is.New(t)
+
+	plugins := []Plugin{
+		{
+			Title:    "Currency tracker",
+			Filename: "currency-tracker.1h.py",
+			Desc:     "Shows the exchange rate of your currencies.",
+			Authors:  []Person{{Name: "Mat Ryer", GitHubUsername: "matryer"}},
+		},
+		{
+			Title:    "Weather",
+			Filename: "weather.15m.sh",
+			Desc:     "The weather where you are.",
+			Authors:  []Person{{Name: "Jane Doe", GitHubUsername: "janedoe"}},
+		},
+		{
+			Title:    "Rain radar",
+			Filename: "rain.5m.sh",
+			Desc:     "Tells you if it will rain, from the weather forecast.",
+			Authors:  []Person{{GitHubUsername: "matryer"}},
+		},
+	}
+
+	titles := func(plugins []Plugin) []string {
+		var titles []string
+		for _, plugin := range plugins {
+			titles = append(titles, plugin.Title)
+		}
+		return titles
+	}
+
+	is.Equal(len(Search(plugins, "")), 0)
+	is.Equal(len(Search(plugins, "   ")), 0)
+	is.Equal(titles(Search(plugins, "weather")), []string{"Weather", "Rain radar"}) // title first
+	is.Equal(titles(Search(plugins, "WEATHER")), []string{"Weather", "Rain radar"})
+	is.Equal(titles(Search(plugins, "wthr")), []string{"Weather", "Rain radar"}) // fuzzy
+	is.Equal(titles(Search(plugins, "matryer")), []string{"Currency tracker", "Rain radar"})
+	is.Equal(titles(Search(plugins, "matryer rain")), []string{"Rain radar"}) // every word
+	is.Equal(titles(Search(plugins, "tracker.1h")), []string{"Currency tracker"})
+	is.Equal(len(Search(plugins, "nothing")), 0)
+}
+
+func TestFuzzyScore(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(fuzzyScore("weather", "weather"), 150)
+	is.Equal(fuzzyScore("the weather", "weather"), 100)
+	is.Equal(fuzzyScore("sunweather", "weather"), 60)
+	is.True(fuzzyScore("weather", "wthr") > fuzzyScore("what a great hour", "wthr"))
+	is.Equal(fuzzyScore("weather", "xyz"), 0)
+	is.Equal(fuzzyScore("", "weather"), 0)
+}
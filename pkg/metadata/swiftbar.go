@@ -0,0 +1,90 @@
+package metadata
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseSwiftBarTag parses metadata from plugins written for SwiftBar
+// (https://github.com/swiftbar/SwiftBar), mapping it to the xbar
+// equivalent where there is one.
+// SwiftBar metadata xbar doesn't support (like swiftbar.hideAbout) is
+// ignored, as are values it can't understand, so the plugins still
+// work.
+func (p *Plugin) parseSwiftBarTag(debugf DebugFunc, tag, value string) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(tag) {
+	case "swiftbar.environment":
+		for _, v := range SwiftBarEnvironment(value) {
+			if p.hasVar(v.Name) {
+				continue
+			}
+			p.Vars = append(p.Vars, v)
+		}
+		debugf("✓\n")
+	case "swiftbar.type":
+		if !strings.EqualFold(value, "streamable") {
+			debugf("(skipping) unknown SwiftBar type %s\n", value)
+			return
+		}
+		p.Streamable = true
+		debugf("✓\n")
+	case "swiftbar.refreshonopen":
+		refreshOnOpen, err := strconv.ParseBool(value)
+		if err != nil {
+			debugf("(skipping) %s\n", err)
+			return
+		}
+		p.RefreshOnOpen = refreshOnOpen
+		debugf("✓\n")
+	case "swiftbar.schedule":
+		// SwiftBar allows more than one schedule (separated by |),
+		// but xbar only has one
+		schedule := strings.TrimSpace(strings.Split(value, "|")[0])
+		if _, err := ParseSchedule(schedule); err != nil {
+			debugf("(skipping) %s\n", err)
+			return
+		}
+		p.Schedule = schedule
+		debugf("✓\n")
+	default:
+		debugf("(skipping) unsupported SwiftBar parameter %s\n", tag)
+	}
+}
+
+// SwiftBarEnvironment parses the value of SwiftBar's environment
+// metadata, like [VAR_NAME=Mat, VAR_CITY=London], into string
+// variables, with the values as their defaults.
+func SwiftBarEnvironment(value string) []PluginVar {
+	value = strings.TrimSpace(value)
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var vars []PluginVar
+	for _, pair := range strings.Split(value, ",") {
+		segs := strings.SplitN(pair, "=", 2)
+		if len(segs) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(segs[0])
+		if name == "" {
+			continue
+		}
+		vars = append(vars, PluginVar{
+			Type:    "string",
+			Name:    name,
+			Label:   varLabel(name),
+			Default: strings.TrimSpace(segs[1]),
+			Desc:    varLabel(name),
+		})
+	}
+	return vars
+}
+
+// hasVar gets whether the plugin already has a variable called name.
+func (p *Plugin) hasVar(name string) bool {
+	for _, v := range p.Vars {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
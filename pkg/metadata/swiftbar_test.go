@@ -0,0 +1,45 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSwiftBarMetadata(t *testing.T) {
+	is := is.New(t)
+
+	md, err := Parse(DebugfNoop, "clock.1s.sh", `#!/bin/bash
+# <bitbar.title>Clock</bitbar.title>
+# <xbar.var>string(VAR_ZONE="UTC"): The time zone.</xbar.var>
+# <swiftbar.hideAbout>true</swiftbar.hideAbout>
+# <swiftbar.runInBash>false</swiftbar.runInBash>
+# <swiftbar.type>streamable</swiftbar.type>
+# <swiftbar.refreshOnOpen>true</swiftbar.refreshOnOpen>
+# <swiftbar.schedule>*/5 9-17 * * 1-5|0 12 * * *</swiftbar.schedule>
+# <swiftbar.environment>[VAR_ZONE=Europe/London, VAR_FORMAT=%H:%M]</swiftbar.environment>
+date
+`)
+	is.NoErr(err)
+	is.Equal(md.Title, "Clock")
+	is.True(md.Streamable)
+	is.True(md.RefreshOnOpen)
+	is.Equal(md.Schedule, "*/5 9-17 * * 1-5")
+	is.Equal(len(md.Vars), 2)
+	is.Equal(md.Vars[0].Default, "UTC") // xbar.var takes precedence
+	is.Equal(md.Vars[1].Name, "VAR_FORMAT")
+	is.Equal(md.Vars[1].Type, "string")
+	is.Equal(md.Vars[1].Label, "Format")
+	is.Equal(md.Vars[1].Default, "%H:%M")
+
+	// values xbar doesn't understand are ignored
+	md, err = Parse(DebugfNoop, "clock.1s.sh", `
+# <swiftbar.type>unknown</swiftbar.type>
+# <swiftbar.refreshOnOpen>sometimes</swiftbar.refreshOnOpen>
+# <swiftbar.schedule>whenever</swiftbar.schedule>
+`)
+	is.NoErr(err)
+	is.True(!md.Streamable)
+	is.True(!md.RefreshOnOpen)
+	is.Equal(md.Schedule, "")
+}
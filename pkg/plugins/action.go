@@ -3,6 +3,7 @@ package plugins
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -25,22 +26,49 @@ const actionTimeout = 10 * time.Second
 // action should this item be clicked/selected.
 // nil response indicates no action, so you must check
 // for nil before calling.
+// Disabled items never have an action.
 // The following code should be called:
 //  actionFunc := item.Action()
 //  if actionFunc != nil {
 //  	actionFunc(ctx)
 //  }
 func (i *Item) Action() ActionFunc {
+	if i.Params.Disabled {
+		return nil
+	}
 	debugf := DebugfNoop
 	if i.Plugin != nil {
 		debugf = i.Plugin.Debugf
 	}
+	// shell commands run first, so that links etc. can open their
+	// results
 	var actions []ActionFunc
-	if i.Params.Href != "" {
+	if i.Params.Href != "" && i.Params.Webview == "" {
+		// webview takes precedence, so the page doesn't open twice
 		actions = append(actions, actionHref(debugf, i.Params.Href))
 	}
+	if i.Params.Open != "" {
+		actions = append(actions, actionOpen(debugf, i, i.Params.Open))
+	}
+	if i.Params.Webview != "" {
+		if i.Plugin != nil && i.Plugin.OnWebview != nil {
+			actions = append(actions, actionWebview(debugf, i.Plugin.OnWebview, i.Params))
+		} else {
+			actions = append(actions, actionHref(debugf, i.Params.Webview))
+		}
+	}
+	if i.Params.Copy != "" {
+		actions = append(actions, actionCopy(debugf, i.Params.Copy))
+	}
+	if i.Params.Sound != "" {
+		actions = append(actions, actionSound(debugf, i, i.Params.Sound))
+	}
 	if i.Params.Shell != "" {
-		actions = append(actions, actionShell(debugf, i, i.Params.Shell, i.Params.ShellParams))
+		var next ActionFunc
+		if len(actions) > 0 {
+			next = actionFuncs(actions...)
+		}
+		actions = []ActionFunc{actionShell(debugf, i, next)}
 	}
 	if i.Params.Refresh == true {
 		shouldDelayBeforeRefresh := false
@@ -60,9 +88,40 @@ func (i *Item) Action() ActionFunc {
 	if len(actions) == 0 {
 		return nil // no actions
 	}
+	if i.Params.Confirm {
+		var onConfirm ConfirmFunc
+		if i.Plugin != nil {
+			onConfirm = i.Plugin.OnConfirm
+		}
+		return actionConfirm(debugf, onConfirm, i, actionFuncs(actions...))
+	}
 	return actionFuncs(actions...)
 }
 
+// actionConfirm gets an ActionFunc that asks the user to confirm
+// before running the action.
+func actionConfirm(debugf DebugFunc, onConfirm ConfirmFunc, item *Item, action ActionFunc) ActionFunc {
+	return func(ctx context.Context) {
+		if onConfirm == nil {
+			debugf("ERR: action confirm: cannot confirm (no OnConfirm) - skipping")
+			return
+		}
+		title := item.Params.ConfirmTitle
+		if title == "" {
+			title = "Are you sure?"
+		}
+		message := item.Params.ConfirmText
+		if message == "" {
+			message = fmt.Sprintf("Do you want to continue with \"%s\"?", item.DisplayText())
+		}
+		if !onConfirm(ctx, title, message) {
+			debugf("action confirm: cancelled")
+			return
+		}
+		action(ctx)
+	}
+}
+
 // actionFuncs makes an ActionFunc that runs multuple functions
 // in order.
 func actionFuncs(actions ...ActionFunc) ActionFunc {
@@ -81,74 +140,207 @@ func actionFuncs(actions ...ActionFunc) ActionFunc {
 func actionHref(debugf DebugFunc, href string) ActionFunc {
 	return func(ctx context.Context) {
 		debugf("action href: %s", href)
+		if err := platformOpen(ctx, "", href); err != nil {
+			debugf("ERR: action href: %s", err)
+			return
+		}
+	}
+}
+
+// actionOpen gets an ActionFunc that opens a file, folder or
+// application.
+func actionOpen(debugf DebugFunc, item *Item, path string) ActionFunc {
+	return func(ctx context.Context) {
+		var pluginDir string
+		if item.Plugin != nil {
+			pluginDir = filepath.Dir(item.Plugin.Command)
+		}
+		target, err := resolveOpenPath(pluginDir, path)
+		if err != nil {
+			debugf("ERR: action open: %s", err)
+			return
+		}
+		debugf("action open: %s", target)
+		if err := platformOpen(ctx, pluginDir, target); err != nil {
+			debugf("ERR: action open: %s", err)
+			return
+		}
+	}
+}
+
+// resolveOpenPath gets the absolute path for the open parameter.
+// ~ is the home directory, and relative paths are relative to the
+// plugin's directory.
+func resolveOpenPath(pluginDir, path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, path[1:]), nil
+	}
+	if filepath.IsAbs(path) || pluginDir == "" {
+		return path, nil
+	}
+	return filepath.Join(pluginDir, path), nil
+}
+
+// platformOpen opens the URL, file, folder or application with the
+// platform's opener, running in dir.
+func platformOpen(ctx context.Context, dir, target string) error {
+	commandCtx, cancel := context.WithTimeout(ctx, actionTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.CommandContext(commandCtx, "xdg-open", target)
+	case "windows":
+		cmd = exec.CommandContext(commandCtx, "rundll32", "url.dll,FileProtocolHandler", target)
+	case "darwin":
+		cmd = exec.CommandContext(commandCtx, "open", target)
+	default:
+		return fmt.Errorf("unsupported platform")
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	cmd.Dir = dir
+	return cmd.Run()
+}
+
+// actionCopy gets an ActionFunc that copies text to the clipboard.
+func actionCopy(debugf DebugFunc, text string) ActionFunc {
+	return func(ctx context.Context) {
+		debugf("action copy: %s", text)
 		commandCtx, cancel := context.WithTimeout(ctx, actionTimeout)
 		defer cancel()
-		var err error
+		var cmd *exec.Cmd
 		switch runtime.GOOS {
 		case "linux":
-			cmd := exec.CommandContext(commandCtx, "xdg-open", href)
-			cmd.SysProcAttr = &syscall.SysProcAttr{
-				Setpgid: true,
-			}
-			cmd.Run()
+			cmd = exec.CommandContext(commandCtx, "xclip", "-selection", "clipboard")
 		case "windows":
-			cmd := exec.CommandContext(commandCtx, "rundll32", "url.dll,FileProtocolHandler", href)
-			cmd.SysProcAttr = &syscall.SysProcAttr{
-				Setpgid: true,
-			}
-			cmd.Run()
+			cmd = exec.CommandContext(commandCtx, "clip")
 		case "darwin":
-			cmd := exec.CommandContext(commandCtx, "open", href)
-			cmd.SysProcAttr = &syscall.SysProcAttr{
-				Setpgid: true,
-			}
-			cmd.Run()
+			cmd = exec.CommandContext(commandCtx, "pbcopy")
 		default:
-			err = fmt.Errorf("unsupported platform")
+			debugf("ERR: action copy: unsupported platform")
+			return
 		}
-		if err != nil {
-			debugf("ERR: action href: %s", err)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			debugf("ERR: action copy: %s", err)
 			return
 		}
 	}
 }
 
-// actionShell gets an ActionFunc that runs a shell command.
-func actionShell(debugf DebugFunc, item *Item, command string, params []string) ActionFunc {
+// actionWebview gets an ActionFunc that opens a URL in a webview.
+func actionWebview(debugf DebugFunc, onWebview WebviewFunc, params ItemParams) ActionFunc {
 	return func(ctx context.Context) {
-		var commandExec string
-		var commandArgs []string
+		debugf("action webview: %s", params.Webview)
+		onWebview(ctx, params.Webview, params.WebviewWidth, params.WebviewHeight)
+	}
+}
+
+// actionShell gets an ActionFunc that runs the item's shell command,
+// followed by any extra shell steps, and then the next action.
+// If a command fails, the remaining steps and the next action are
+// skipped.
+func actionShell(debugf DebugFunc, item *Item, next ActionFunc) ActionFunc {
+	return func(ctx context.Context) {
+		commandExec := item.Params.Shell
+		commandArgs := item.Params.ShellParams
+		steps := item.Params.ShellSteps
+		var scriptPath string
 		if item.Params.Terminal {
-			shell := os.Getenv("SHELL")
-			if shell == "" {
-				shell = "/bin/bash"
+			// open the terminal app, and run the command (and the
+			// steps) in it, in the same environment and directory as
+			// they would run in the background
+			script := terminalScript(item.Plugin.workingDir(), item.actionEnv(), commandExec, commandArgs, steps)
+			var err error
+			scriptPath, err = writeTerminalScript(script)
+			if err != nil {
+				debugf("ERR: action shell: %s", err)
+				return
 			}
-			commandExec = shell
-			commandArgs = append([]string{command}, params...)
-		} else {
-			commandExec = command
-			commandArgs = params
-		}
-		debugf("exec: %s %s", commandExec, strings.Join(commandArgs, " "))
-		cmd := exec.CommandContext(context.Background(), commandExec, commandArgs...)
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setpgid: true,
-		}
-		// wd should be where the plugin is running
-		cmd.Dir = filepath.Dir(item.Plugin.Command)
-		// and it can inherit the environment
-		cmd.Env = append(cmd.Env, os.Environ()...)
-		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
-		err := cmd.Run()
-		if err != nil {
-			debugf("ERR: action shell: %s", errExec{
-				err:    err,
-				Stderr: stderr.String(),
-			})
+			commandExec, commandArgs, err = terminalCommand(item.Plugin.TerminalApp, scriptPath)
+			if err != nil {
+				os.Remove(scriptPath)
+				debugf("ERR: action shell: %s", err)
+				return
+			}
+			steps = nil
+		}
+		if err := runShell(debugf, item, commandExec, commandArgs); err != nil {
+			if scriptPath != "" {
+				// the terminal didn't open, so the script won't remove itself
+				os.Remove(scriptPath)
+			}
+			debugf("ERR: action shell: %s", err)
 			return
 		}
+		for _, step := range steps {
+			if err := runShell(debugf, item, "/bin/sh", []string{"-c", step}); err != nil {
+				debugf("ERR: action shell: %s", err)
+				return
+			}
+		}
+		if next != nil {
+			next(ctx)
+		}
+	}
+}
+
+// actionEnv gets the environment variables for shell commands run
+// by this item: the plugin's variables, dark mode, and the item's text
+// and parameters.
+func (i *Item) actionEnv() []string {
+	var env []string
+	if i.Plugin != nil {
+		env = append(env, i.Plugin.Env...)
+		env = append(env, i.Plugin.expandVariables(append(os.Environ(), i.Plugin.Env...))...)
+		env = append(env, "XBAR_PLUGIN="+i.Plugin.Command)
+		env = append(env, i.Plugin.stateDirsEnv()...)
+	}
+	darkMode := os.Getenv("XBARDarkMode")
+	if darkMode == "" {
+		darkMode = "false"
+	}
+	env = append(env, "XBAR_DARK_MODE="+darkMode)
+	text := i.Text
+	if i.FullText != "" {
+		text = i.FullText
+	}
+	env = append(env, "XBAR_ITEM_TEXT="+text)
+	params, err := json.Marshal(i.Params)
+	if err == nil {
+		env = append(env, "XBAR_ITEM_PARAMS="+string(params))
+	}
+	return env
+}
+
+// runShell runs a command for an item, waiting for it to finish.
+func runShell(debugf DebugFunc, item *Item, commandExec string, commandArgs []string) error {
+	debugf("exec: %s %s", commandExec, strings.Join(commandArgs, " "))
+	cmd := exec.CommandContext(context.Background(), commandExec, commandArgs...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	// wd should be where the plugin is running
+	cmd.Dir = item.Plugin.workingDir()
+	// and it can inherit the environment
+	cmd.Env = append(cmd.Env, os.Environ()...)
+	// along with details about the item
+	cmd.Env = append(cmd.Env, item.actionEnv()...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errExec{
+			err:    err,
+			Stderr: stderr.String(),
+		}
 	}
+	return nil
 }
 
 // actionRefresh gets an ActionFunc that manually refreshes the
@@ -2,6 +2,9 @@ package plugins
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/matryer/is"
@@ -53,6 +56,176 @@ func OffTestItemAction(t *testing.T) {
 
 }
 
+func TestDisabledItemAction(t *testing.T) {
+	is := is.New(t)
+
+	item := Item{
+		Text: "Informational",
+		Params: ItemParams{
+			Href:     "https://xbarapp.com",
+			Disabled: true,
+		},
+	}
+	is.True(item.Action() == nil) // disabled items have no action
+
+	item.Params.Disabled = false
+	is.True(item.Action() != nil)
+}
+
+func TestWebviewItemAction(t *testing.T) {
+	is := is.New(t)
+
+	var gotURL string
+	var gotWidth, gotHeight int
+	p := NewPlugin("/dev/null")
+	p.OnWebview = func(ctx context.Context, url string, width, height int) {
+		gotURL, gotWidth, gotHeight = url, width, height
+	}
+	item := Item{
+		Plugin: p,
+		Text:   "Dashboard",
+		Params: ItemParams{
+			Webview:       "https://xbarapp.com",
+			WebviewWidth:  400,
+			WebviewHeight: 300,
+		},
+	}
+	action := item.Action()
+	is.True(action != nil)
+	action(context.Background())
+	is.Equal(gotURL, "https://xbarapp.com")
+	is.Equal(gotWidth, 400)
+	is.Equal(gotHeight, 300)
+
+	// webview takes precedence over href
+	gotURL = ""
+	item.Params.Href = "https://example.com"
+	item.Action()(context.Background())
+	is.Equal(gotURL, "https://xbarapp.com")
+}
+
+func TestConfirmItemAction(t *testing.T) {
+	is := is.New(t)
+
+	var gotTitle, gotMessage string
+	confirm := false
+	p := NewPlugin("/dev/null")
+	p.OnConfirm = func(ctx context.Context, title, message string) bool {
+		gotTitle, gotMessage = title, message
+		return confirm
+	}
+	opened := 0
+	p.OnWebview = func(ctx context.Context, url string, width, height int) {
+		opened++
+	}
+	item := Item{
+		Plugin: p,
+		Text:   "Delete everything",
+		Params: ItemParams{
+			Webview: "https://xbarapp.com",
+			Confirm: true,
+		},
+	}
+	action := item.Action()
+	action(context.Background())
+	is.Equal(opened, 0) // cancelled
+	is.Equal(gotTitle, "Are you sure?")
+	is.Equal(gotMessage, `Do you want to continue with "Delete everything"?`)
+
+	confirm = true
+	item.Params.ConfirmTitle = "Delete?"
+	item.Params.ConfirmText = "This cannot be undone."
+	action = item.Action()
+	action(context.Background())
+	is.Equal(opened, 1) // confirmed
+	is.Equal(gotTitle, "Delete?")
+	is.Equal(gotMessage, "This cannot be undone.")
+
+	// without OnConfirm, nothing runs
+	p.OnConfirm = nil
+	action = item.Action()
+	action(context.Background())
+	is.Equal(opened, 1)
+}
+
+func TestActionChain(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-action-chain-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	p := NewPlugin(filepath.Join(dir, "plugin.sh"))
+	var logAtWebview string
+	p.OnWebview = func(ctx context.Context, url string, width, height int) {
+		b, _ := os.ReadFile(filepath.Join(dir, "log.txt"))
+		logAtWebview = string(b)
+	}
+	items, err := p.parseOutput(context.Background(), "chain.txt", strings.NewReader(strings.TrimSpace(`
+Build | webview=https://xbarapp.com bash=/bin/sh param1=-c param2="echo one >> log.txt" bash="echo two >> log.txt"
+Broken | webview=https://xbarapp.com bash=/bin/sh param1=-c param2="exit 1"
+`)))
+	is.NoErr(err)
+	is.Equal(items.CycleItems[0].Params.ShellSteps, []string{"echo two >> log.txt"})
+
+	// shell steps run in order, then the webview opens
+	items.CycleItems[0].Action()(context.Background())
+	is.Equal(logAtWebview, "one\ntwo\n")
+
+	// a failing shell step stops the chain
+	logAtWebview = "not called"
+	items.CycleItems[1].Action()(context.Background())
+	is.Equal(logAtWebview, "not called")
+}
+
+func TestActionEnv(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-action-env-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	os.Setenv("XBARDarkMode", "true")
+	t.Cleanup(func() {
+		os.Unsetenv("XBARDarkMode")
+	})
+	p := NewPlugin(filepath.Join(dir, "plugin.sh"))
+	p.Variables = []string{"VAR_NAME=Mat"}
+	items, err := p.parseOutput(context.Background(), "env.txt", strings.NewReader(strings.TrimSpace(`
+A long item | length=6 bash=/bin/sh param1=-c param2="env > env.txt"
+`)))
+	is.NoErr(err)
+	items.CycleItems[0].Action()(context.Background())
+	b, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+	is.NoErr(err)
+	env := string(b)
+	is.True(strings.Contains(env, "VAR_NAME=Mat\n"))
+	is.True(strings.Contains(env, "XBAR_DARK_MODE=true\n"))
+	is.True(strings.Contains(env, "XBAR_PLUGIN="+p.Command+"\n"))
+	is.True(strings.Contains(env, "XBAR_ITEM_TEXT=A long item\n")) // untruncated
+	is.True(strings.Contains(env, `XBAR_ITEM_PARAMS={"disabled":false`))
+}
+
+func TestResolveOpenPath(t *testing.T) {
+	is := is.New(t)
+
+	home, err := os.UserHomeDir()
+	is.NoErr(err)
+	for path, expected := range map[string]string{
+		"/var/log/system.log":      "/var/log/system.log",
+		"~":                        home,
+		"~/Downloads":              filepath.Join(home, "Downloads"),
+		"logs/today.log":           "/plugins/logs/today.log",
+		"/Applications/Safari.app": "/Applications/Safari.app",
+	} {
+		actual, err := resolveOpenPath("/plugins", path)
+		is.NoErr(err)
+		is.Equal(actual, expected)
+	}
+}
+
 func TestTerminal(t *testing.T) {
 	p := NewPlugin("/dev/null")
 	p.Debugf = DebugfLog
@@ -0,0 +1,204 @@
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TextSegment is a run of text within an Item that shares the
+// same style, as described by ANSI escape codes or Markdown.
+type TextSegment struct {
+	// Text is the content of this segment.
+	Text string `json:"text"`
+	// Color is the foreground color (#RRGGBB), or empty for the
+	// default.
+	Color string `json:"color"`
+	// BackgroundColor is the background color (#RRGGBB), or empty
+	// for the default.
+	BackgroundColor string `json:"backgroundColor"`
+	// Bold indicates that the text is bold.
+	Bold bool `json:"bold"`
+	// Faint indicates that the text is dimmed.
+	Faint bool `json:"faint"`
+	// Italic indicates that the text is italic.
+	Italic bool `json:"italic"`
+	// Underline indicates that the text is underlined.
+	Underline bool `json:"underline"`
+	// Strikethrough indicates that the text is struck through.
+	Strikethrough bool `json:"strikethrough"`
+	// Monospace indicates that the text is code, and should be
+	// shown in a fixed width font.
+	Monospace bool `json:"monospace"`
+}
+
+// ansiEscape is the start of an ANSI control sequence.
+const ansiEscape = "\x1b["
+
+// ansiColors are the 16 standard ANSI colors (xterm palette).
+var ansiColors = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// parseANSI parses ANSI escape codes out of s.
+// It returns the plain text (with all escape sequences removed), and
+// the styled segments that make up the text.
+// If s contains no escape sequences, the segments will be nil.
+func parseANSI(s string) (string, []*TextSegment) {
+	if !strings.Contains(s, ansiEscape) {
+		return s, nil
+	}
+	var (
+		plain    strings.Builder
+		segments []*TextSegment
+		style    TextSegment
+		current  strings.Builder
+	)
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+		segment := style
+		segment.Text = current.String()
+		segments = append(segments, &segment)
+		current.Reset()
+	}
+	for len(s) > 0 {
+		i := strings.Index(s, ansiEscape)
+		if i < 0 {
+			current.WriteString(s)
+			plain.WriteString(s)
+			break
+		}
+		current.WriteString(s[:i])
+		plain.WriteString(s[:i])
+		s = s[i+len(ansiEscape):]
+		// find the final byte of the control sequence
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return r >= 0x40 && r <= 0x7e
+		})
+		if end < 0 {
+			// unterminated sequence - drop the rest
+			break
+		}
+		if s[end] == 'm' {
+			// SGR (Select Graphic Rendition) changes the style
+			flush()
+			style.applySGR(s[:end])
+		}
+		// other control sequences (cursor movement etc.) are ignored
+		s = s[end+1:]
+	}
+	flush()
+	return plain.String(), segments
+}
+
+// applySGR applies the semicolon separated SGR codes to the
+// TextSegment.
+func (t *TextSegment) applySGR(codes string) {
+	if codes == "" {
+		codes = "0"
+	}
+	segs := strings.Split(codes, ";")
+	for i := 0; i < len(segs); i++ {
+		code, err := strconv.Atoi(segs[i])
+		if err != nil {
+			continue // ignore garbage
+		}
+		switch {
+		case code == 0:
+			*t = TextSegment{}
+		case code == 1:
+			t.Bold = true
+		case code == 2:
+			t.Faint = true
+		case code == 3:
+			t.Italic = true
+		case code == 4:
+			t.Underline = true
+		case code == 9:
+			t.Strikethrough = true
+		case code == 22:
+			t.Bold, t.Faint = false, false
+		case code == 23:
+			t.Italic = false
+		case code == 24:
+			t.Underline = false
+		case code == 29:
+			t.Strikethrough = false
+		case code >= 30 && code <= 37:
+			t.Color = ansiColors[code-30]
+		case code == 38:
+			var color string
+			color, i = parseExtendedColor(segs, i)
+			t.Color = color
+		case code == 39:
+			t.Color = ""
+		case code >= 40 && code <= 47:
+			t.BackgroundColor = ansiColors[code-40]
+		case code == 48:
+			var color string
+			color, i = parseExtendedColor(segs, i)
+			t.BackgroundColor = color
+		case code == 49:
+			t.BackgroundColor = ""
+		case code >= 90 && code <= 97:
+			t.Color = ansiColors[code-90+8]
+		case code >= 100 && code <= 107:
+			t.BackgroundColor = ansiColors[code-100+8]
+		}
+	}
+}
+
+// parseExtendedColor parses 256 color (5;n) and true color (2;r;g;b)
+// values that follow a 38 or 48 code at segs[i].
+// It returns the color, and the index of the last segment consumed.
+func parseExtendedColor(segs []string, i int) (string, int) {
+	if i+1 >= len(segs) {
+		return "", i
+	}
+	switch segs[i+1] {
+	case "5":
+		if i+2 >= len(segs) {
+			return "", len(segs)
+		}
+		n, err := strconv.Atoi(segs[i+2])
+		if err != nil || n < 0 || n > 255 {
+			return "", i + 2
+		}
+		return ansi256Color(n), i + 2
+	case "2":
+		if i+4 >= len(segs) {
+			return "", len(segs)
+		}
+		var rgb [3]int
+		for j := range rgb {
+			v, err := strconv.Atoi(segs[i+2+j])
+			if err != nil || v < 0 || v > 255 {
+				return "", i + 4
+			}
+			rgb[j] = v
+		}
+		return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]), i + 4
+	}
+	return "", i + 1
+}
+
+// ansi256Color gets the hex color for an entry in the 256 color
+// palette.
+func ansi256Color(n int) string {
+	switch {
+	case n < 16:
+		return ansiColors[n]
+	case n < 232:
+		// 6x6x6 color cube
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[(n/6)%6], levels[n%6])
+	default:
+		// grayscale ramp
+		v := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", v, v, v)
+	}
+}
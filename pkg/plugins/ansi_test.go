@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseANSI(t *testing.T) {
+	is := is.New(t)
+
+	text, segments := parseANSI("no codes here")
+	is.Equal(text, "no codes here")
+	is.Equal(len(segments), 0)
+
+	text, segments = parseANSI("\x1b[31mred\x1b[0m and \x1b[1;32mbold green\x1b[0m")
+	is.Equal(text, "red and bold green")
+	is.Equal(len(segments), 3)
+	is.Equal(segments[0].Text, "red")
+	is.Equal(segments[0].Color, "#cd0000")
+	is.Equal(segments[0].Bold, false)
+	is.Equal(segments[1].Text, " and ")
+	is.Equal(segments[1].Color, "")
+	is.Equal(segments[2].Text, "bold green")
+	is.Equal(segments[2].Color, "#00cd00")
+	is.Equal(segments[2].Bold, true)
+
+	// bright, 256 and true color
+	text, segments = parseANSI("\x1b[91ma\x1b[38;5;196mb\x1b[38;2;1;2;3;48;5;232mc")
+	is.Equal(text, "abc")
+	is.Equal(len(segments), 3)
+	is.Equal(segments[0].Color, "#ff0000")
+	is.Equal(segments[1].Color, "#ff0000")
+	is.Equal(segments[2].Color, "#010203")
+	is.Equal(segments[2].BackgroundColor, "#080808")
+
+	// styles are turned on and off
+	_, segments = parseANSI("\x1b[3;4mon\x1b[23mhalf\x1b[24;39moff")
+	is.Equal(len(segments), 3)
+	is.Equal(segments[0].Italic, true)
+	is.Equal(segments[0].Underline, true)
+	is.Equal(segments[1].Italic, false)
+	is.Equal(segments[1].Underline, true)
+	is.Equal(segments[2].Underline, false)
+
+	// non-SGR sequences are stripped, and so is an unterminated one
+	text, _ = parseANSI("\x1b[2Kcleared\x1b[")
+	is.Equal(text, "cleared")
+}
+
+func TestParseOutputANSI(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "ansi.txt", strings.NewReader(strings.TrimSpace(`
+`+"\x1b[31mred\x1b[0m"+`
+`+"\x1b[31mraw\x1b[0m | ansi=false"+`
+`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 2)
+	is.Equal(items.CycleItems[0].Text, "red")
+	is.Equal(len(items.CycleItems[0].Segments), 1)
+	is.Equal(items.CycleItems[0].Segments[0].Color, "#cd0000")
+	is.Equal(items.CycleItems[1].Text, "\x1b[31mraw\x1b[0m")
+	is.Equal(len(items.CycleItems[1].Segments), 0)
+}
@@ -0,0 +1,45 @@
+package plugins
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// appearanceEnvVars are the environment variables that describe the
+// system appearance to plugins.
+var appearanceEnvVars = [][]byte{
+	[]byte("XBAR_DARK_MODE"),
+	[]byte("XBARDarkMode"),
+	[]byte("BitBarDarkMode"),
+	[]byte("XBAR_ACCENT_COLOR"),
+}
+
+// usesAppearance gets whether the source of the plugin uses any of
+// the appearanceEnvVars.
+func usesAppearance(command string) bool {
+	f, err := os.Open(command)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, 1_000_000 /* ~1MB */))
+	if err != nil {
+		return false
+	}
+	for _, name := range appearanceEnvVars {
+		if bytes.Contains(b, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AppearanceChanged tells the plugin the system appearance has
+// changed, so its menu is updated after the next refresh even if the
+// output is the same.
+// It doesn't refresh the plugin, see RequestRefresh.
+func (p *Plugin) AppearanceChanged() {
+	atomic.StoreInt32(&p.appearanceChanged, 1)
+}
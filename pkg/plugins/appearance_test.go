@@ -0,0 +1,31 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestUsesAppearance(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-appearance-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	themed := filepath.Join(dir, "themed.1m.sh")
+	is.NoErr(os.WriteFile(themed, []byte("#!/bin/bash\nif [ \"$XBAR_DARK_MODE\" = true ]; then echo 🌙; else echo ☀️; fi\n"), 0777))
+	plain := filepath.Join(dir, "plain.1m.sh")
+	is.NoErr(os.WriteFile(plain, []byte("#!/bin/bash\necho hi\n"), 0777))
+
+	is.True(usesAppearance(themed))
+	is.True(!usesAppearance(plain))
+	is.True(!usesAppearance(filepath.Join(dir, "missing.1m.sh")))
+
+	p := NewPlugin(themed)
+	is.NoErr(p.loadMetadata())
+	is.True(p.AppearanceSensitive)
+}
@@ -0,0 +1,153 @@
+package plugins
+
+import (
+	"archive/zip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// backupPluginsDir is the folder in a backup that holds the
+	// plugin directory.
+	backupPluginsDir = "plugins/"
+	// backupConfigDir is the folder in a backup that holds the
+	// configuration files.
+	backupConfigDir = "config/"
+)
+
+// Backup writes a zip archive of the plugins in pluginDir (with
+// their variables and metadata sidecar files, but not the values of
+// secret variables, which stay in the Keychain), and the
+// configFiles, which are keyed by their name in the backup (like
+// xbar.config.json).
+// Config files that don't exist are skipped.
+func Backup(w io.Writer, pluginDir string, configFiles map[string]string) error {
+	zw := zip.NewWriter(w)
+	files, err := ioutil.ReadDir(pluginDir)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "ReadDir")
+	}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		if err := addBackupFile(zw, backupPluginsDir+file.Name(), filepath.Join(pluginDir, file.Name())); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(configFiles))
+	for name := range configFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err := addBackupFile(zw, backupConfigDir+name, configFiles[name])
+		if err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return errors.Wrap(err, "close zip")
+	}
+	return nil
+}
+
+// addBackupFile adds the file to the zip archive, keeping its
+// permissions, so plugins are still executable when restored.
+func addBackupFile(zw *zip.Writer, name, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "stat")
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return errors.Wrap(err, name)
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return errors.Wrap(err, name)
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return errors.Wrap(err, name)
+	}
+	return nil
+}
+
+// Restore puts the plugins and configuration files from a backup
+// made with Backup back, replacing any that are already there.
+// Configuration files that aren't in configFiles are skipped.
+func Restore(r io.ReaderAt, size int64, pluginDir string, configFiles map[string]string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return errors.Wrap(err, "open backup")
+	}
+	for _, file := range zr.File {
+		var filename string
+		switch {
+		case strings.HasPrefix(file.Name, backupPluginsDir):
+			name := strings.TrimPrefix(file.Name, backupPluginsDir)
+			if name == "" || path.Base(name) != name || name == ".." {
+				return errors.Errorf("unexpected file in backup: %s", file.Name)
+			}
+			filename = filepath.Join(pluginDir, name)
+		case strings.HasPrefix(file.Name, backupConfigDir):
+			var ok bool
+			filename, ok = configFiles[strings.TrimPrefix(file.Name, backupConfigDir)]
+			if !ok {
+				continue
+			}
+		default:
+			continue
+		}
+		if err := restoreBackupFile(file, filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreBackupFile writes the file from a backup to filename.
+func restoreBackupFile(file *zip.File, filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return errors.Wrap(err, "MkdirAll")
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return errors.Wrap(err, file.Name)
+	}
+	defer rc.Close()
+	mode := file.Mode().Perm()
+	if mode == 0 {
+		mode = 0666
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return errors.Wrap(err, file.Name)
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		return errors.Wrap(err, file.Name)
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, file.Name)
+	}
+	// the file may have been there already, with other permissions
+	if err := os.Chmod(filename, mode); err != nil {
+		return errors.Wrap(err, file.Name)
+	}
+	return nil
+}
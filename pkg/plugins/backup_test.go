@@ -0,0 +1,61 @@
+package plugins
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestBackup(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-backup-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	write := func(filename, content string, perm os.FileMode) {
+		is.NoErr(os.MkdirAll(filepath.Dir(filename), 0777))
+		is.NoErr(ioutil.WriteFile(filename, []byte(content), perm))
+	}
+	pluginDir := filepath.Join(dir, "old", "plugins")
+	write(filepath.Join(pluginDir, "weather.1m.sh"), "#!/bin/bash", 0755)
+	write(filepath.Join(pluginDir, "weather.1m.sh"+variableJSONFileExt), `{"CITY": "London"}`, 0644)
+	write(filepath.Join(pluginDir, "github.1h.sh.off"), "#!/bin/bash", 0755)
+	write(filepath.Join(pluginDir, ".DS_Store"), "", 0644)
+	write(filepath.Join(dir, "old", "xbar.config.json"), `{"jitter": 5}`, 0644)
+	configFiles := map[string]string{
+		"xbar.config.json": filepath.Join(dir, "old", "xbar.config.json"),
+		"order.json":       filepath.Join(dir, "old", "order.json"), // missing
+	}
+	var buf bytes.Buffer
+	is.NoErr(Backup(&buf, pluginDir, configFiles))
+
+	newPluginDir := filepath.Join(dir, "new", "plugins")
+	write(filepath.Join(newPluginDir, "weather.1m.sh"+variableJSONFileExt), `{}`, 0644) // replaced
+	newConfigFiles := map[string]string{
+		"xbar.config.json": filepath.Join(dir, "new", "xbar.config.json"),
+	}
+	b := buf.Bytes()
+	is.NoErr(Restore(bytes.NewReader(b), int64(len(b)), newPluginDir, newConfigFiles))
+
+	files, err := ioutil.ReadDir(newPluginDir)
+	is.NoErr(err)
+	is.Equal(len(files), 3) // not .DS_Store
+	info, err := os.Stat(filepath.Join(newPluginDir, "weather.1m.sh"))
+	is.NoErr(err)
+	is.Equal(info.Mode().Perm(), os.FileMode(0755)) // still executable
+	values, err := ioutil.ReadFile(filepath.Join(newPluginDir, "weather.1m.sh"+variableJSONFileExt))
+	is.NoErr(err)
+	is.Equal(string(values), `{"CITY": "London"}`)
+	config, err := ioutil.ReadFile(filepath.Join(dir, "new", "xbar.config.json"))
+	is.NoErr(err)
+	is.Equal(string(config), `{"jitter": 5}`)
+
+	err = Restore(bytes.NewReader([]byte("nope")), 4, newPluginDir, newConfigFiles)
+	is.True(err != nil) // not a backup
+}
@@ -0,0 +1,47 @@
+package plugins
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxBadge is the largest badge count that is shown in full.
+const maxBadge = 99
+
+// superscriptDigits are the superscript versions of 0-9, used to
+// draw badges as a small count next to the title.
+var superscriptDigits = []rune("⁰¹²³⁴⁵⁶⁷⁸⁹")
+
+// badgeText renders the badge count as superscript digits
+// (like ¹²), or an empty string if there is no badge.
+// Counts over maxBadge are shown as ⁹⁹⁺.
+func badgeText(count int) string {
+	if count <= 0 {
+		return ""
+	}
+	suffix := ""
+	if count > maxBadge {
+		count = maxBadge
+		suffix = "⁺"
+	}
+	var b strings.Builder
+	for _, digit := range strconv.Itoa(count) {
+		b.WriteRune(superscriptDigits[digit-'0'])
+	}
+	b.WriteString(suffix)
+	return b.String()
+}
+
+// TitleText gets the text that should be displayed for this item
+// in the menu bar, including any badge.
+func (i Item) TitleText() string {
+	text := i.DisplayText()
+	badge := badgeText(i.Params.Badge)
+	if badge == "" {
+		return text
+	}
+	if text == "" {
+		return badge
+	}
+	return text + " " + badge
+}
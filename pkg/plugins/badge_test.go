@@ -0,0 +1,40 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestBadgeText(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(badgeText(0), "")
+	is.Equal(badgeText(3), "³")
+	is.Equal(badgeText(42), "⁴²")
+	is.Equal(badgeText(99), "⁹⁹")
+	is.Equal(badgeText(100), "⁹⁹⁺")
+}
+
+func TestTitleText(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "badge.txt", strings.NewReader(strings.TrimSpace(`
+Inbox | badge=3
+| badge=12
+Inbox | badge=0
+`)))
+	is.NoErr(err)
+	is.Equal(items.CycleItems[0].Params.Badge, 3)
+	is.Equal(items.CycleItems[0].TitleText(), "Inbox ³")
+	is.Equal(items.CycleItems[0].DisplayText(), "Inbox") // badge is only for the title
+	is.Equal(items.CycleItems[1].TitleText(), "¹²")
+	is.Equal(items.CycleItems[2].TitleText(), "Inbox")
+
+	_, err = p.parseOutput(context.Background(), "badge.txt", strings.NewReader(`Inbox | badge=-1`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `badge.txt:1: badge: expected a positive int, not "-1"`)
+}
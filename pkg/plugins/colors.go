@@ -144,3 +144,13 @@ var namedColors = map[string]string{
 	"lightpink":            "#ffb6c1",
 	"seashell":             "#fff5ee",
 }
+
+// semanticColors are named colors that have different values in
+// light and dark mode (the macOS system colors).
+var semanticColors = map[string]struct {
+	light, dark string
+}{
+	"success": {light: "#28cd41", dark: "#32d74b"},
+	"warning": {light: "#ff9500", dark: "#ff9f0a"},
+	"danger":  {light: "#ff3b30", dark: "#ff453a"},
+}
@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// columnSeparator separates the cells in the text of items with
+	// columns=true.
+	columnSeparator = "\t"
+	// columnGap is the space between aligned columns.
+	columnGap = "  "
+	// columnFont is the font of items with columns (unless they set
+	// one), since the cells are padded with spaces, which only line
+	// up in a fixed width font.
+	columnFont = "Menlo"
+)
+
+// splitColumns splits the text of an item with columns=true into
+// its cells, truncating each one to length (if it's not zero).
+func splitColumns(text string, length int) []string {
+	cells := strings.Split(text, columnSeparator)
+	for i := range cells {
+		cells[i] = truncate(strings.TrimSpace(cells[i]), length)
+	}
+	return cells
+}
+
+// alignColumns aligns the cells of consecutive items with columns
+// (and their sub items), by padding each column to the width of
+// the widest cell in it.
+// Columns of numbers (ignoring a header in the first row) are right
+// aligned.
+// Separators and items without columns start a new table.
+func alignColumns(items []*Item) {
+	var table []*Item
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		alignColumns(item.Items)
+		if item.Columns == nil || item.Params.Separator {
+			alignTable(table)
+			table = nil
+			continue
+		}
+		table = append(table, item)
+	}
+	alignTable(table)
+}
+
+// alignTable sets the Text of each item to its aligned cells, and
+// gives it a fixed width font.
+func alignTable(table []*Item) {
+	var widths []int
+	var numeric []bool
+	for row, item := range table {
+		for i, cell := range item.Columns {
+			if i == len(widths) {
+				widths = append(widths, 0)
+				numeric = append(numeric, len(table) > 1)
+			}
+			if w := displayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+			if row > 0 && cell != "" && !isNumeric(cell) {
+				numeric[i] = false
+			}
+		}
+	}
+	for _, item := range table {
+		var b strings.Builder
+		for i, cell := range item.Columns {
+			if i > 0 {
+				b.WriteString(columnGap)
+			}
+			padding := strings.Repeat(" ", widths[i]-displayWidth(cell))
+			if numeric[i] {
+				b.WriteString(padding)
+				b.WriteString(cell)
+				continue
+			}
+			b.WriteString(cell)
+			if i < len(item.Columns)-1 {
+				// no need to pad the last cell
+				b.WriteString(padding)
+			}
+		}
+		item.Text = b.String()
+		if item.Params.Font == "" {
+			item.Params.Font = columnFont
+		}
+	}
+}
+
+// displayWidth gets the width of s in columns, as it appears on
+// screen: emoji and East Asian wide characters take up two.
+func displayWidth(s string) int {
+	var width int
+	for _, char := range splitChars(s) {
+		r := []rune(char)[0]
+		if isWide(r) {
+			width += 2
+			continue
+		}
+		width++
+	}
+	return width
+}
+
+// isWide gets whether r is displayed two columns wide.
+func isWide(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hangul, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		(r >= 0xFF01 && r <= 0xFF60) || // fullwidth forms
+		(r >= 0x1F300 && r <= 0x1FAFF) || // emoji and pictographs
+		(r >= 0x1F1E6 && r <= 0x1F1FF) // flags
+}
+
+// isNumeric gets whether s is a number, like 42, -1.5, 12% or $3.
+func isNumeric(s string) bool {
+	s = strings.TrimPrefix(s, "$")
+	s = strings.TrimSuffix(s, "%")
+	s = strings.ReplaceAll(s, ",", "")
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,50 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestColumns(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "columns.txt", strings.NewReader("Processes\n---\n"+
+		"PID\tNAME\tCPU | columns=true\n"+
+		"1\tlaunchd\t0.1% | columns=true\n"+
+		"1234\tGoogle Chrome Helper\t12.5% | columns=true length=10\n"+
+		"---\n"+
+		"a\tb | columns=true font=Monaco\n"+
+		"Submenu\n"+
+		"--漢字\tx | columns=true\n"+
+		"--abcd\ty | columns=true\n"+
+		"raw\ttabs\n"))
+	is.NoErr(err)
+	expanded := items.ExpandedItems
+	is.Equal(len(expanded), 7)
+	is.Equal(expanded[0].Text, " PID  NAME          CPU")
+	is.Equal(expanded[1].Text, "   1  launchd      0.1%")
+	is.Equal(expanded[2].Text, "1234  Google Ch…  12.5%")
+	is.Equal(expanded[2].Columns, []string{"1234", "Google Ch…", "12.5%"})
+	is.Equal(expanded[2].DisplayText(), expanded[2].Text) // not truncated again
+	is.Equal(expanded[0].Params.Font, "Menlo")            // so the columns line up
+	is.Equal(expanded[4].Text, "a  b")                    // separate table
+	is.Equal(expanded[4].Params.Font, "Monaco")           // unless a font is set
+	is.Equal(expanded[5].Items[0].Text, "漢字  x")
+	is.Equal(expanded[5].Items[1].Text, "abcd  y")
+	is.Equal(expanded[6].Text, "raw\ttabs") // no columns
+	is.Equal(expanded[6].Columns, nil)
+	is.Equal(expanded[6].Params.Font, "")
+}
+
+func TestDisplayWidth(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(displayWidth("abc"), 3)
+	is.Equal(displayWidth("漢字"), 4)
+	is.Equal(displayWidth("👍🏽ok"), 4)
+	is.Equal(displayWidth(""), 0)
+}
@@ -0,0 +1,143 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/matryer/xbar/pkg/metadata"
+)
+
+// dependencyVersionTimeout is how long programs have to print their
+// version.
+const dependencyVersionTimeout = 5 * time.Second
+
+// DependencyStatus is whether a dependency of a plugin is installed.
+type DependencyStatus struct {
+	metadata.Dependency
+	// Path is where the program is, or empty if it isn't installed.
+	Path string `json:"path,omitempty"`
+	// InstalledVersion is what the program says its version is, if
+	// the Dependency needs a particular Version.
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	// Problem describes what's wrong, or is empty if the dependency
+	// is fine.
+	Problem string `json:"problem,omitempty"`
+	// InstallHint describes how to install the program, if the
+	// plugin says.
+	InstallHint string `json:"installHint,omitempty"`
+}
+
+// CheckDependencies checks whether the programs the plugin depends
+// on are installed, using the PATH in env, and that they're the
+// versions it needs.
+func CheckDependencies(ctx context.Context, deps []metadata.Dependency, env []string) []DependencyStatus {
+	statuses := make([]DependencyStatus, 0, len(deps))
+	for _, dep := range deps {
+		status := DependencyStatus{
+			Dependency:  dep,
+			InstallHint: dep.InstallHint(),
+		}
+		path := lookPath(dep.Name, env)
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			status.Problem = "not installed"
+			statuses = append(statuses, status)
+			continue
+		}
+		status.Path = path
+		if dep.Version != "" {
+			status.InstalledVersion = programVersion(ctx, path, env)
+			if !dep.Satisfied(status.InstalledVersion) {
+				status.Problem = fmt.Sprintf("needs version %s, found %s", dep.Version, status.InstalledVersion)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// programVersion gets the first line the program prints when run
+// with --version, or an empty string if it fails.
+func programVersion(ctx context.Context, path string, env []string) string {
+	ctx, cancel := context.WithTimeout(ctx, dependencyVersionTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "--version")
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	return lastLine(strings.SplitN(string(out), "\n", 2)[0])
+}
+
+// errMissingDependencies is returned when a plugin can't run
+// because some of its dependencies aren't installed.
+type errMissingDependencies []DependencyStatus
+
+func (e errMissingDependencies) Error() string {
+	problems := make([]string, len(e))
+	for i := range e {
+		problems[i] = e[i].Name + ": " + e[i].Problem
+	}
+	return "missing dependencies: " + strings.Join(problems, "; ")
+}
+
+// checkDependencies checks the plugin's Dependencies before it first
+// runs, returning errMissingDependencies if any aren't installed.
+// Once they all are, they aren't checked again.
+func (p *Plugin) checkDependencies(ctx context.Context) error {
+	if p.dependenciesChecked || len(p.Dependencies) == 0 {
+		return nil
+	}
+	var missing errMissingDependencies
+	for _, status := range CheckDependencies(ctx, p.Dependencies, append(os.Environ(), p.Env...)) {
+		if status.Problem != "" {
+			missing = append(missing, status)
+		}
+	}
+	if len(missing) > 0 {
+		return missing
+	}
+	p.dependenciesChecked = true
+	return nil
+}
+
+// missingDependencyItems gets the items that explain which of the
+// plugin's dependencies need installing, and how.
+func (p *Plugin) missingDependencyItems(missing errMissingDependencies) []*Item {
+	items := p.stringToItems("This plugin needs things that aren't installed")
+	for _, status := range missing {
+		item := &Item{
+			Plugin: p,
+			Text:   status.Name + ": " + status.Problem,
+			Params: ItemParams{
+				Dropdown: true,
+			},
+		}
+		switch {
+		case strings.HasPrefix(status.InstallHint, "https://"), strings.HasPrefix(status.InstallHint, "http://"):
+			item.Items = append(item.Items, &Item{
+				Plugin: p,
+				Text:   "Open " + status.InstallHint,
+				Params: ItemParams{
+					Dropdown: true,
+					Href:     status.InstallHint,
+				},
+			})
+		case status.InstallHint != "":
+			item.Items = append(item.Items, &Item{
+				Plugin: p,
+				Text:   "Copy: " + status.InstallHint,
+				Params: ItemParams{
+					Dropdown: true,
+					Copy:     status.InstallHint,
+				},
+			})
+		}
+		items = append(items, item)
+	}
+	return items
+}
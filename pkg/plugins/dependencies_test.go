@@ -0,0 +1,82 @@
+package plugins
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/matryer/xbar/pkg/metadata"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-dependencies-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	err = ioutil.WriteFile(filepath.Join(dir, "fakejq"), []byte("#!/bin/sh\necho jq-1.5\n"), 0777)
+	is.NoErr(err)
+	env := []string{"PATH=" + dir}
+
+	statuses := CheckDependencies(context.Background(), []metadata.Dependency{
+		{Name: "fakejq"},
+		{Name: "fakejq", Version: ">=1.5"},
+		{Name: "fakejq", Version: ">=1.6", Brew: "jq"},
+		{Name: "not-installed", Install: "https://example.com/install"},
+	}, env)
+	is.Equal(len(statuses), 4)
+	is.Equal(statuses[0].Path, filepath.Join(dir, "fakejq"))
+	is.Equal(statuses[0].Problem, "")
+	is.Equal(statuses[1].InstalledVersion, "jq-1.5")
+	is.Equal(statuses[1].Problem, "")
+	is.Equal(statuses[2].Problem, "needs version >=1.6, found jq-1.5")
+	is.Equal(statuses[2].InstallHint, "brew install jq")
+	is.Equal(statuses[3].Path, "")
+	is.Equal(statuses[3].Problem, "not installed")
+}
+
+func TestMissingDependencies(t *testing.T) {
+	is := is.New(t)
+
+	p := NewPlugin(filepath.Join("testdata", "plugins", "simple.1m.sh"))
+	p.Dependencies = []metadata.Dependency{
+		{Name: "xbar-not-installed", Brew: "xbar-not-installed"},
+	}
+	p.Refresh(context.Background())
+	is.True(len(p.Items.ExpandedItems) > 1)
+	is.Equal(p.Items.ExpandedItems[0].Text, "This plugin needs things that aren't installed")
+	is.Equal(p.Items.ExpandedItems[1].Text, "xbar-not-installed: not installed")
+	is.Equal(p.Items.ExpandedItems[1].Items[0].Params.Copy, "brew install xbar-not-installed")
+	is.True(!p.dependenciesChecked)
+
+	p.Dependencies = []metadata.Dependency{{Name: "sh"}}
+	p.Refresh(context.Background())
+	is.True(p.dependenciesChecked)
+}
+
+func TestFreeFormDependencies(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-dependencies-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "old.1m.sh")
+	err = os.WriteFile(command, []byte(`#!/bin/sh
+# <xbar.dependencies>python (with requests module),node.js</xbar.dependencies>
+echo "Still runs"
+`), 0777)
+	is.NoErr(err)
+
+	// old style lists are free-form, so they don't stop it running
+	p := NewPlugin(command)
+	is.NoErr(p.RunOnce(context.Background()))
+	is.Equal(len(p.Dependencies), 0)
+	is.Equal(p.Items.CycleItems[0].Text, "Still runs")
+}
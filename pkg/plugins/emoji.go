@@ -904,10 +904,34 @@ var name2codes = map[string][]rune{
 }
 
 // Emojize converts emoji names (e.g., :pizza:) to emoji characters.
+// The text is scanned once from left to right, so colons that are
+// not part of a known emoji name (like in 12:30:00) are left alone.
 func Emojize(s string) string {
-	for name, emoji := range name2codes {
-		marker := ":" + name + ":"
-		s = strings.ReplaceAll(s, marker, string(emoji))
+	if !strings.Contains(s, ":") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, ':')
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := strings.IndexByte(s[start+1:], ':')
+		if end < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end += start + 1
+		emoji, ok := name2codes[s[start+1:end]]
+		if !ok {
+			// the closing colon might open the next name
+			b.WriteString(s[:end])
+			s = s[end:]
+			continue
+		}
+		b.WriteString(s[:start])
+		b.WriteString(string(emoji))
+		s = s[end+1:]
 	}
-	return s
 }
@@ -0,0 +1,24 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestEmojize(t *testing.T) {
+	is := is.New(t)
+
+	for input, expected := range map[string]string{
+		"no emoji":                     "no emoji",
+		":pizza:":                      "🍕",
+		":pizza::pizza:":               "🍕🍕",
+		"at 12:30:00 exactly":          "at 12:30:00 exactly",
+		"key:value:pizza: done":        "key:value🍕 done",
+		"unclosed :pizza":              "unclosed :pizza",
+		":not_an_emoji_name: :+1:":     ":not_an_emoji_name: 👍",
+		"INFO 09:15:01 ready :rocket:": "INFO 09:15:01 ready 🚀",
+	} {
+		is.Equal(Emojize(input), expected)
+	}
+}
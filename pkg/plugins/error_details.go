@@ -0,0 +1,169 @@
+package plugins
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxErrorDetailsLines is the number of lines from the end of stderr
+// shown in the error details submenu. All of it is included in the
+// copied diagnostics.
+const maxErrorDetailsLines = 20
+
+// errorItems gets the items that explain why the plugin failed.
+// Plugins that exited with an error get a summary line, followed by
+// an Error details submenu with the exit code and stderr.
+func (p *Plugin) errorItems(err error) []*Item {
+	var missing errMissingDependencies
+	if errors.As(err, &missing) {
+		return p.missingDependencyItems(missing)
+	}
+	var execErr errExec
+	if !errors.As(err, &execErr) {
+		return p.stringToItems(err.Error())
+	}
+	var items []*Item
+	if execErr.exitCode() == ExitConfigRequired {
+		items = p.configRequiredItems(lastLine(execErr.Stderr))
+	} else {
+		summary := execErr.err.Error()
+		if line := lastLine(execErr.Stderr); line != "" {
+			summary += ": " + line
+		}
+		items = p.stringToItems(summary)
+	}
+	details := &Item{
+		Plugin: p,
+		Text:   "Error details",
+		Params: ItemParams{
+			Dropdown: true,
+		},
+	}
+	if code := execErr.exitCode(); code >= 0 {
+		details.Items = append(details.Items, &Item{
+			Plugin: p,
+			Text:   fmt.Sprintf("Exit code: %d", code),
+			Params: ItemParams{
+				Disabled: true,
+				Dropdown: true,
+			},
+		})
+	}
+	stderr := strings.Split(strings.TrimRight(execErr.Stderr, "\n"), "\n")
+	if len(stderr) > maxErrorDetailsLines {
+		stderr = stderr[len(stderr)-maxErrorDetailsLines:]
+	}
+	if execErr.Stderr != "" {
+		details.Items = append(details.Items, &Item{
+			Plugin: p,
+			Params: ItemParams{
+				Separator: true,
+				Dropdown:  true,
+			},
+		})
+		for _, line := range stderr {
+			details.Items = append(details.Items, &Item{
+				Plugin: p,
+				Text:   line,
+				Params: ItemParams{
+					Disabled: true,
+					Dropdown: true,
+					Font:     "Menlo",
+					Length:   100,
+				},
+			})
+		}
+	}
+	details.Items = append(details.Items,
+		&Item{
+			Plugin: p,
+			Params: ItemParams{
+				Separator: true,
+				Dropdown:  true,
+			},
+		},
+		&Item{
+			Plugin: p,
+			Text:   "Copy diagnostics",
+			Params: ItemParams{
+				Dropdown: true,
+				Copy:     p.diagnostics(execErr),
+			},
+		},
+	)
+	return append(items, details)
+}
+
+// staleItems gets the items added to the end of the last successful
+// output when a plugin with KeepOutputOnError fails.
+func (p *Plugin) staleItems(err error) []*Item {
+	return []*Item{
+		{
+			Plugin: p,
+			Params: ItemParams{
+				Separator: true,
+				Dropdown:  true,
+			},
+		},
+		{
+			Plugin: p,
+			Text:   fmt.Sprintf("⚠️ Couldn't refresh, showing output from %s", p.lastItemsAt.Format("15:04")),
+			Params: ItemParams{
+				Dropdown: true,
+			},
+			Items: p.errorItems(err),
+		},
+	}
+}
+
+// configRequiredItems gets the items for a plugin that exited with
+// ExitConfigRequired, with a Configure… item that opens the plugin in
+// the app, where its variables can be set.
+func (p *Plugin) configRequiredItems(message string) []*Item {
+	if message == "" {
+		message = "This plugin needs configuring"
+	}
+	items := p.stringToItems(message)
+	configureURL := url.URL{
+		Scheme:   "xbar",
+		Host:     "app.xbarapp.com",
+		Path:     "/openPlugin",
+		RawQuery: url.Values{"path": {filepath.Base(p.Command)}}.Encode(),
+	}
+	return append(items, &Item{
+		Plugin: p,
+		Text:   "Configure…",
+		Params: ItemParams{
+			Dropdown: true,
+			Href:     configureURL.String(),
+		},
+	})
+}
+
+// diagnostics gets a description of the failure, for pasting into a
+// bug report.
+func (p *Plugin) diagnostics(err errExec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plugin: %s\n", p.Command)
+	fmt.Fprintf(&b, "Error: %s\n", err.err)
+	if code := err.exitCode(); code >= 0 {
+		fmt.Fprintf(&b, "Exit code: %d\n", code)
+	}
+	if err.Stderr != "" {
+		fmt.Fprintf(&b, "Stderr:\n%s", err.Stderr)
+		if !strings.HasSuffix(err.Stderr, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// lastLine gets the last non-empty line of s.
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	return strings.TrimSpace(s[strings.LastIndexByte(s, '\n')+1:])
+}
@@ -0,0 +1,99 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestErrorDetails(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-error-details-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "broken.1m.sh")
+	script := "#!/bin/bash\n>&2 echo 'loading config'\n>&2 echo 'config.json: no such file'\nexit 3\n"
+	err = os.WriteFile(command, []byte(script), 0777)
+	is.NoErr(err)
+	p := NewPlugin(command)
+	p.Refresh(context.Background())
+
+	is.Equal(len(p.Items.ExpandedItems), 2)
+	is.Equal(p.Items.ExpandedItems[0].Text, "exit status 3: config.json: no such file")
+	details := p.Items.ExpandedItems[1]
+	is.Equal(details.Text, "Error details")
+	is.Equal(len(details.Items), 6)
+	is.Equal(details.Items[0].Text, "Exit code: 3")
+	is.True(details.Items[1].Params.Separator)
+	is.Equal(details.Items[2].Text, "loading config")
+	is.Equal(details.Items[3].Text, "config.json: no such file")
+	is.True(details.Items[4].Params.Separator)
+	copyItem := details.Items[5]
+	is.Equal(copyItem.Text, "Copy diagnostics")
+	is.True(strings.Contains(copyItem.Params.Copy, "Plugin: "+command+"\n"))
+	is.True(strings.Contains(copyItem.Params.Copy, "Exit code: 3\n"))
+	is.True(strings.HasSuffix(copyItem.Params.Copy, "Stderr:\nloading config\nconfig.json: no such file\n"))
+}
+
+func TestErrorDetailsLongStderr(t *testing.T) {
+	is := is.New(t)
+
+	var stderr strings.Builder
+	for i := 0; i < 50; i++ {
+		stderr.WriteString("line\n")
+	}
+	p := &Plugin{}
+	items := p.errorItems(errExec{err: errors.New("exit status 1"), Stderr: stderr.String()})
+	details := items[len(items)-1]
+	// no exit code (it didn't exit), separator, lines, separator, copy
+	is.Equal(len(details.Items), 1+maxErrorDetailsLines+2)
+	is.Equal(details.Items[len(details.Items)-1].Params.Copy, "Plugin: \nError: exit status 1\nStderr:\n"+stderr.String())
+}
+
+func TestKeepOutputOnError(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-keep-output-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "flaky.1m.sh")
+	write := func(script string) {
+		err := os.WriteFile(command, []byte("#!/bin/bash\n"+script), 0777)
+		is.NoErr(err)
+	}
+	write("echo online\necho ---\necho details\n")
+	p := NewPlugin(command)
+	p.KeepOutputOnError = true
+	p.Refresh(context.Background())
+	is.Equal(len(p.Items.ExpandedItems), 1)
+
+	write(">&2 echo 'connection refused'\nexit 1\n")
+	for i := 0; i < 2; i++ {
+		p.Refresh(context.Background())
+		is.Equal(p.failures, i+1) // still backs off
+		is.Equal(p.Items.CycleItems[0].Text, "online")
+		is.Equal(len(p.Items.ExpandedItems), 3) // stale items aren't repeated
+		is.Equal(p.Items.ExpandedItems[0].Text, "details")
+		is.True(p.Items.ExpandedItems[1].Params.Separator)
+		stale := p.Items.ExpandedItems[2]
+		is.Equal(stale.Text, "⚠️ Couldn't refresh, showing output from "+p.lastItemsAt.Format("15:04"))
+		is.Equal(stale.Items[0].Text, "exit status 1: connection refused")
+	}
+	is.Equal(len(p.lastItems.ExpandedItems), 1) // left alone
+
+	write("echo online again\n")
+	p.Refresh(context.Background())
+	is.Equal(p.failures, 0)
+	is.Equal(p.Items.CycleItems[0].Text, "online again")
+	is.Equal(len(p.Items.ExpandedItems), 0)
+}
@@ -0,0 +1,26 @@
+package plugins
+
+import "github.com/pkg/errors"
+
+// Exit codes (from sysexits.h) that plugins can use to tell xbar
+// about their state, instead of outputting fake menu items.
+const (
+	// ExitTempFail means the plugin failed for a reason that
+	// should pass (like the network being down), so the output from
+	// the last successful run is kept.
+	ExitTempFail = 75
+	// ExitConfigRequired means the plugin needs configuring (like an
+	// API key) before it can run, so the user is asked to set its
+	// variables.
+	ExitConfigRequired = 78
+)
+
+// exitCode gets the exit code of the plugin from an error returned
+// by running it, or -1 if there isn't one.
+func exitCode(err error) int {
+	var execErr errExec
+	if !errors.As(err, &execErr) {
+		return -1
+	}
+	return execErr.exitCode()
+}
@@ -0,0 +1,84 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/pkg/errors"
+)
+
+func TestExitTempFail(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-exit-codes-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "flaky.1m.sh")
+	write := func(script string) {
+		err := os.WriteFile(command, []byte("#!/bin/bash\n"+script), 0777)
+		is.NoErr(err)
+	}
+	p := NewPlugin(command)
+	var refreshErrs []error
+	p.OnRefresh = func(ctx context.Context, p *Plugin, err error) {
+		refreshErrs = append(refreshErrs, err)
+	}
+
+	// nothing to keep yet, so it's an error
+	write("exit 75\n")
+	p.Refresh(context.Background())
+	is.Equal(p.Items.CycleItems[0].Text, "⚠️ flaky.1m.sh")
+
+	write("echo online\n")
+	p.Refresh(context.Background())
+	is.Equal(p.Items.CycleItems[0].Text, "online")
+
+	write("exit 75\n")
+	p.Refresh(context.Background())
+	is.Equal(p.Items.CycleItems[0].Text, "online") // kept
+	is.Equal(p.failures, 0)
+	is.Equal(len(refreshErrs), 2) // nothing changed
+	is.NoErr(refreshErrs[1])
+}
+
+func TestExitConfigRequired(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-exit-codes-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "weather.1m.sh")
+	script := "#!/bin/bash\n>&2 echo 'Set VAR_API_KEY to your API key'\nexit 78\n"
+	err = os.WriteFile(command, []byte(script), 0777)
+	is.NoErr(err)
+	p := NewPlugin(command)
+	var configRequired int
+	p.OnConfigRequired = func(ctx context.Context, plugin *Plugin) {
+		is.Equal(plugin, p)
+		configRequired++
+	}
+	p.Refresh(context.Background())
+	p.Refresh(context.Background())
+	is.Equal(configRequired, 1) // only the first time
+
+	is.Equal(p.Items.CycleItems[0].Text, "⚙️ weather.1m.sh")
+	is.Equal(p.Items.ExpandedItems[0].Text, "Set VAR_API_KEY to your API key")
+	is.Equal(p.Items.ExpandedItems[1].Text, "Configure…")
+	is.Equal(p.Items.ExpandedItems[1].Params.Href, "xbar://app.xbarapp.com/openPlugin?path=weather.1m.sh")
+	is.Equal(p.Items.ExpandedItems[2].Text, "Error details")
+}
+
+func TestExitCode(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(exitCode(nil), -1)
+	is.Equal(exitCode(errors.New("not an exec error")), -1)
+	is.Equal(exitCode(errors.Wrap(errExec{err: errors.New("no exit")}, "run")), -1)
+}
@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"regexp"
+	"strings"
+)
+
+// variableReferenceRegexp matches ${NAME} references in variable
+// values.
+var variableReferenceRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandVariables gets the Variables, with any ${NAME} references in
+// their values replaced by the value of NAME; either another of the
+// Variables, or from env (like ${HOME}).
+// A variable that refers to itself (like PATH=${PATH}:~/bin) gets the
+// value from env.
+// References to things that aren't set are left as they are.
+func (p *Plugin) expandVariables(env []string) []string {
+	if len(p.Variables) == 0 {
+		return nil
+	}
+	envValues := envMap(env)
+	values := envMap(p.Variables)
+	expanded := make(map[string]string, len(values))
+	resolving := make(map[string]bool)
+	var resolve func(name string) (string, bool)
+	resolve = func(name string) (string, bool) {
+		if value, ok := expanded[name]; ok {
+			return value, true
+		}
+		value, isVariable := values[name]
+		if !isVariable || resolving[name] {
+			value, ok := envValues[name]
+			return value, ok
+		}
+		resolving[name] = true
+		value = variableReferenceRegexp.ReplaceAllStringFunc(value, func(ref string) string {
+			if v, ok := resolve(ref[2 : len(ref)-1]); ok {
+				return v
+			}
+			return ref
+		})
+		resolving[name] = false
+		expanded[name] = value
+		return value, true
+	}
+	variables := make([]string, 0, len(p.Variables))
+	for _, kv := range p.Variables {
+		name := kv
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			name = kv[:i]
+		}
+		value, _ := resolve(name)
+		variables = append(variables, name+"="+value)
+	}
+	return variables
+}
+
+// envMap gets the values of NAME=value environment variables, by name.
+// Later ones take precedence.
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		segs := strings.SplitN(kv, "=", 2)
+		if len(segs) != 2 {
+			continue
+		}
+		m[segs[0]] = segs[1]
+	}
+	return m
+}
@@ -0,0 +1,39 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestExpandVariables(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{
+		Variables: []string{
+			"VAR_DIR=${HOME}/notes",
+			"VAR_FILE=${VAR_DIR}/${VAR_NAME}.md",
+			"VAR_NAME=today",
+			"VAR_PRICE=$5 or ${NOT_SET}",
+			"PATH=${PATH}:${HOME}/bin",
+			"VAR_A=${VAR_B}",
+			"VAR_B=${VAR_A}",
+		},
+	}
+	env := []string{
+		"HOME=/Users/mat",
+		"PATH=/usr/bin",
+	}
+	is.Equal(p.expandVariables(env), []string{
+		"VAR_DIR=/Users/mat/notes",
+		"VAR_FILE=/Users/mat/notes/today.md",
+		"VAR_NAME=today",
+		"VAR_PRICE=$5 or ${NOT_SET}",
+		"PATH=/usr/bin:/Users/mat/bin",
+		"VAR_A=${VAR_A}",
+		"VAR_B=${VAR_A}",
+	})
+
+	p.Variables = nil
+	is.Equal(len(p.expandVariables(env)), 0)
+}
@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// GlobalVariablePrefix is the prefix of the environment variables
+// plugins get for the global variables.
+// A global variable called GITHUB_TOKEN is given to every plugin as
+// XBAR_GLOBAL_GITHUB_TOKEN.
+const GlobalVariablePrefix = "XBAR_GLOBAL_"
+
+// globalVariableNameRegexp matches valid global variable names.
+var globalVariableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateGlobalVariableName checks the name can be used for a
+// global variable, returning a nice error if it can't.
+func ValidateGlobalVariableName(name string) error {
+	if !globalVariableNameRegexp.MatchString(name) {
+		return errors.Errorf(`expected a name made of letters, numbers and underscores (like GITHUB_TOKEN), not "%s"`, name)
+	}
+	return nil
+}
+
+// LoadGlobalVariables loads the global variables from the file.
+// If there is no file, there are no global variables.
+func LoadGlobalVariables(filename string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, errors.Wrap(err, "ReadFile")
+	}
+	var values map[string]string
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	if values == nil {
+		values = map[string]string{}
+	}
+	return values, nil
+}
+
+// SaveGlobalVariables saves the global variables to the file.
+// The file is only readable by the user, since global variables are
+// often things like API keys.
+func SaveGlobalVariables(filename string, values map[string]string) error {
+	for name := range values {
+		if err := ValidateGlobalVariableName(name); err != nil {
+			return err
+		}
+	}
+	b, err := json.MarshalIndent(values, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "json.MarshalIndent")
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return errors.Wrap(err, "MkdirAll")
+	}
+	if err := ioutil.WriteFile(filename, b, 0600); err != nil {
+		return errors.Wrap(err, "WriteFile")
+	}
+	return nil
+}
+
+// GlobalVariablesEnv gets the environment variables for the global
+// variables, like XBAR_GLOBAL_GITHUB_TOKEN=value, sorted by name.
+func GlobalVariablesEnv(values map[string]string) []string {
+	env := make([]string, 0, len(values))
+	for name, value := range values {
+		env = append(env, GlobalVariablePrefix+name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,66 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestGlobalVariables(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-global-variables-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	filename := filepath.Join(dir, "xbar", "global.vars.json")
+
+	values, err := LoadGlobalVariables(filename)
+	is.NoErr(err)
+	is.Equal(len(values), 0) // no file, no variables
+
+	err = SaveGlobalVariables(filename, map[string]string{
+		"GITHUB_TOKEN": "abc123",
+		"CITY":         "London",
+	})
+	is.NoErr(err)
+	info, err := os.Stat(filename)
+	is.NoErr(err)
+	is.Equal(info.Mode().Perm(), os.FileMode(0600))
+
+	values, err = LoadGlobalVariables(filename)
+	is.NoErr(err)
+	is.Equal(values["GITHUB_TOKEN"], "abc123")
+	is.Equal(GlobalVariablesEnv(values), []string{
+		"XBAR_GLOBAL_CITY=London",
+		"XBAR_GLOBAL_GITHUB_TOKEN=abc123",
+	})
+
+	err = SaveGlobalVariables(filename, map[string]string{
+		"NOT VALID": "value",
+	})
+	is.Equal(err.Error(), `expected a name made of letters, numbers and underscores (like GITHUB_TOKEN), not "NOT VALID"`)
+}
+
+func TestGlobalVariablesPrecedence(t *testing.T) {
+	is := is.New(t)
+
+	// global variables are given to plugins in Env, which
+	// their own variables override
+	p := NewPlugin("./testdata/plugins/simple.1m.sh")
+	p.Env = GlobalVariablesEnv(map[string]string{"CITY": "London"})
+	p.Variables = []string{"XBAR_GLOBAL_CITY=Paris"}
+	var city string
+	for _, kv := range p.command().Env {
+		// the last one wins
+		if strings.HasPrefix(kv, "XBAR_GLOBAL_CITY=") {
+			city = strings.TrimPrefix(kv, "XBAR_GLOBAL_CITY=")
+		}
+	}
+	is.Equal(city, "Paris")
+}
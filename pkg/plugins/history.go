@@ -0,0 +1,123 @@
+package plugins
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxHistoryRuns is the number of runs kept in a plugin's
+	// history.
+	maxHistoryRuns = 20
+	// maxHistoryOutput is how much of the output (and stderr) of
+	// each run is kept in the history.
+	maxHistoryOutput = 4 << 10 // 4KB
+)
+
+// Run describes a time that a plugin ran.
+type Run struct {
+	// Time is when the plugin started.
+	Time time.Time `json:"time"`
+	// Duration is how long the plugin ran for.
+	Duration time.Duration `json:"duration"`
+	// CPUTime is the user and system CPU time the plugin used.
+	CPUTime time.Duration `json:"cpuTime"`
+	// ExitCode is the exit code of the plugin, or -1 if it didn't
+	// exit normally (like when it timed out).
+	ExitCode int `json:"exitCode"`
+	// Error is why the run failed, or empty if it worked.
+	Error string `json:"error,omitempty"`
+	// Output is the start of what the plugin wrote to stdout.
+	Output string `json:"output"`
+	// Stderr is the end of what the plugin wrote to stderr.
+	Stderr string `json:"stderr"`
+}
+
+// HistoryFilename gets the name of the history file in dir for the
+// plugin file.
+func HistoryFilename(dir, pluginFilename string) string {
+	return filepath.Join(dir, StateName(filepath.Base(pluginFilename))+".json")
+}
+
+// ReadHistory reads the runs in a history file, oldest first.
+// If the file doesn't exist, there is no history.
+func ReadHistory(filename string) ([]Run, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read history")
+	}
+	var runs []Run
+	if err := json.Unmarshal(b, &runs); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	return runs, nil
+}
+
+// recordRun adds a run to the plugin's HistoryFile, if it has one,
+// dropping the oldest runs so that only maxHistoryRuns are kept.
+func (p *Plugin) recordRun(run Run) error {
+	if p.HistoryFile == "" {
+		return nil
+	}
+	if p.history == nil {
+		runs, err := ReadHistory(p.HistoryFile)
+		if err != nil {
+			// start again
+			p.Debugf("ERR: %s", err)
+		}
+		p.history = runs
+	}
+	p.history = append(p.history, run)
+	if len(p.history) > maxHistoryRuns {
+		p.history = append(p.history[:0], p.history[len(p.history)-maxHistoryRuns:]...)
+	}
+	b, err := json.Marshal(p.history)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	if err := os.MkdirAll(filepath.Dir(p.HistoryFile), 0777); err != nil {
+		return errors.Wrap(err, "make history directory")
+	}
+	// write then rename, so the history is never half written
+	tmp := p.HistoryFile + ".tmp"
+	if err := os.WriteFile(tmp, b, 0666); err != nil {
+		return errors.Wrap(err, "write history")
+	}
+	if err := os.Rename(tmp, p.HistoryFile); err != nil {
+		return errors.Wrap(err, "rename history")
+	}
+	return nil
+}
+
+// newRun makes a Run from the state and output of the plugin's
+// process, and the error from running it.
+func newRun(start time.Time, state *os.ProcessState, stdout []byte, stderr string, err error) Run {
+	run := Run{
+		Time:     start,
+		Duration: time.Since(start),
+		Stderr:   stderr,
+	}
+	if state != nil {
+		// (nil for streamable plugins that are still running)
+		run.CPUTime = state.UserTime() + state.SystemTime()
+		run.ExitCode = state.ExitCode()
+	}
+	if len(stdout) > maxHistoryOutput {
+		stdout = stdout[:maxHistoryOutput]
+	}
+	run.Output = string(stdout)
+	if len(run.Stderr) > maxHistoryOutput {
+		run.Stderr = run.Stderr[len(run.Stderr)-maxHistoryOutput:]
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	return run
+}
@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestHistory(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-history-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "counter.1m.sh")
+	script := "#!/bin/bash\necho run >> runs.txt\nRUNS=$(( $(wc -l < runs.txt) ))\necho \"$RUNS runs\"\nif [ $RUNS = 2 ]; then\n>&2 echo 'second run'\nexit 3\nfi\n"
+	err = os.WriteFile(command, []byte(script), 0777)
+	is.NoErr(err)
+	historyFile := HistoryFilename(filepath.Join(dir, "history"), command)
+	is.Equal(historyFile, filepath.Join(dir, "history", "counter.sh.json"))
+
+	runs, err := ReadHistory(historyFile)
+	is.NoErr(err)
+	is.Equal(len(runs), 0) // no history yet
+
+	p := NewPlugin(command)
+	p.HistoryFile = historyFile
+	for i := 0; i < maxHistoryRuns+5; i++ {
+		p.Refresh(context.Background())
+	}
+	runs, err = ReadHistory(historyFile)
+	is.NoErr(err)
+	is.Equal(len(runs), maxHistoryRuns) // oldest are dropped
+	is.Equal(runs[0].Output, "6 runs\n")
+	is.Equal(runs[maxHistoryRuns-1].Output, fmt.Sprintf("%d runs\n", maxHistoryRuns+5))
+	is.Equal(runs[0].ExitCode, 0)
+	is.Equal(runs[0].Error, "")
+	is.True(runs[0].Duration > 0)
+	is.True(!runs[0].Time.IsZero())
+
+	// a new plugin carries on from the file
+	os.Remove(filepath.Join(dir, "runs.txt"))
+	p = NewPlugin(command)
+	p.HistoryFile = historyFile
+	p.Refresh(context.Background())
+	p.Refresh(context.Background())
+	runs, err = ReadHistory(historyFile)
+	is.NoErr(err)
+	is.Equal(len(runs), maxHistoryRuns)
+	failed := runs[maxHistoryRuns-1]
+	is.Equal(failed.ExitCode, 3)
+	is.Equal(failed.Error, "exit status 3: second run\n")
+	is.Equal(failed.Stderr, "second run\n")
+}
@@ -0,0 +1,260 @@
+package plugins
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ImageFetcher downloads images referenced by URL in plugin output,
+// caching them in memory and on disk.
+// Images fetched within MaxAge are used without asking the server.
+// Older images are revalidated using their ETag or Last-Modified
+// time, and cached images are used if they cannot be downloaded.
+type ImageFetcher struct {
+	// Client is the HTTP client used to download images.
+	Client *http.Client
+	// CacheDir is the directory where images are cached.
+	CacheDir string
+	// MaxBytes is the largest image that will be downloaded.
+	MaxBytes int64
+	// Timeout is the time allowed to download each image.
+	Timeout time.Duration
+	// MaxAge is how long a downloaded image is used before it is
+	// revalidated with the server.
+	MaxAge time.Duration
+
+	lock sync.Mutex
+	// images are the images fetched recently, by URL.
+	images map[string]fetchedImage
+}
+
+// fetchedImage is an image the ImageFetcher has in memory.
+type fetchedImage struct {
+	data      string
+	fetchedAt time.Time
+}
+
+// maxConcurrentImageFetches is how many images a plugin downloads
+// at once.
+const maxConcurrentImageFetches = 4
+
+// NewImageFetcher makes a new ImageFetcher that caches images in
+// cacheDir.
+func NewImageFetcher(client *http.Client, cacheDir string) *ImageFetcher {
+	return &ImageFetcher{
+		Client:   client,
+		CacheDir: cacheDir,
+		MaxBytes: 1_000_000, // ~1MB
+		Timeout:  10 * time.Second,
+		MaxAge:   5 * time.Minute,
+	}
+}
+
+// isHTTPURL gets whether s is an http or https URL, for example
+// an image parameter that is a URL rather than base64 encoded data.
+func isHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// Fetch gets the image at imageURL, returning it base64 encoded.
+func (f *ImageFetcher) Fetch(ctx context.Context, imageURL string) (string, error) {
+	if data, ok := f.recent(imageURL); ok {
+		return data, nil
+	}
+	key := sha256.Sum256([]byte(imageURL))
+	cacheFile := filepath.Join(f.CacheDir, hex.EncodeToString(key[:]))
+	etagFile := cacheFile + ".etag"
+	lastModifiedFile := cacheFile + ".modified"
+	cached, cacheErr := ioutil.ReadFile(cacheFile)
+	if cacheErr == nil {
+		if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < f.MaxAge {
+			// downloaded (or revalidated) recently, maybe before xbar
+			// was restarted
+			return f.remember(imageURL, cached, info.ModTime()), nil
+		}
+	}
+	// useCache is the fallback for when the image can't be downloaded.
+	useCache := func(err error) (string, error) {
+		if cacheErr != nil {
+			return "", err
+		}
+		return f.remember(imageURL, cached, time.Now()), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "new request")
+	}
+	if cacheErr == nil {
+		if etag, err := ioutil.ReadFile(etagFile); err == nil {
+			req.Header.Set("If-None-Match", string(etag))
+		}
+		if lastModified, err := ioutil.ReadFile(lastModifiedFile); err == nil {
+			req.Header.Set("If-Modified-Since", string(lastModified))
+		}
+	}
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		// offline or similar - the cached image will do
+		return useCache(errors.Wrap(err, "download image"))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cacheErr == nil {
+		now := time.Now()
+		// still fresh, so don't ask again until MaxAge has passed
+		_ = os.Chtimes(cacheFile, now, now)
+		return f.remember(imageURL, cached, now), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return useCache(errors.Errorf("download image %s: %s", imageURL, resp.Status))
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, f.MaxBytes+1))
+	if err != nil {
+		return useCache(errors.Wrap(err, "read image"))
+	}
+	if int64(len(b)) > f.MaxBytes {
+		return "", errors.Errorf("image %s is too big (limit is %d bytes)", imageURL, f.MaxBytes)
+	}
+	if err := os.MkdirAll(f.CacheDir, 0777); err != nil {
+		return "", errors.Wrap(err, "make cache directory")
+	}
+	if err := writeFileAtomic(cacheFile, b); err != nil {
+		return "", errors.Wrap(err, "write cache")
+	}
+	// stale validators would be wrong, so remove any missing ones
+	for filename, value := range map[string]string{
+		etagFile:         resp.Header.Get("ETag"),
+		lastModifiedFile: resp.Header.Get("Last-Modified"),
+	} {
+		if value == "" {
+			_ = os.Remove(filename)
+			continue
+		}
+		if err := writeFileAtomic(filename, []byte(value)); err != nil {
+			return "", errors.Wrap(err, "write cache validator")
+		}
+	}
+	return f.remember(imageURL, b, time.Now()), nil
+}
+
+// recent gets the image if it was fetched within MaxAge.
+func (f *ImageFetcher) recent(imageURL string) (string, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	image, ok := f.images[imageURL]
+	if !ok || time.Since(image.fetchedAt) >= f.MaxAge {
+		return "", false
+	}
+	return image.data, true
+}
+
+// remember keeps the image in memory, returning it base64 encoded.
+func (f *ImageFetcher) remember(imageURL string, b []byte, fetchedAt time.Time) string {
+	data := base64.StdEncoding.EncodeToString(b)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.images == nil {
+		f.images = make(map[string]fetchedImage)
+	}
+	f.images[imageURL] = fetchedImage{
+		data:      data,
+		fetchedAt: fetchedAt,
+	}
+	return data
+}
+
+// writeFileAtomic writes the file via a temporary file, so it is
+// never half written, even if two writes happen at once.
+func writeFileAtomic(filename string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// fetchImages replaces image URLs in the items (and their sub items)
+// with the base64 encoded image data.
+// Each image is downloaded once, with a few downloading at a time.
+// Images that cannot be fetched are removed.
+func (p *Plugin) fetchImages(ctx context.Context, items []*Item) {
+	images := make(map[string][]*string)
+	collectImages(images, items)
+	var (
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		limiter = make(chan struct{}, maxConcurrentImageFetches)
+	)
+	for image, params := range images {
+		wg.Add(1)
+		go func(image string, params []*string) {
+			defer wg.Done()
+			limiter <- struct{}{}
+			data := p.fetchImage(ctx, image)
+			<-limiter
+			lock.Lock()
+			defer lock.Unlock()
+			for _, param := range params {
+				*param = data
+			}
+		}(image, params)
+	}
+	wg.Wait()
+}
+
+// collectImages finds the image parameters in the items (and their
+// sub items) that are URLs, keyed by the URL.
+func collectImages(images map[string][]*string, items []*Item) {
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		for _, param := range []*string{&item.Params.Image, &item.Params.TemplateImage} {
+			if isHTTPURL(*param) {
+				images[*param] = append(images[*param], param)
+			}
+		}
+		if item.Alternate != nil {
+			collectImages(images, []*Item{item.Alternate})
+		}
+		collectImages(images, item.Items)
+	}
+}
+
+// fetchImage gets the base64 encoded image if image is a URL.
+func (p *Plugin) fetchImage(ctx context.Context, image string) string {
+	if !isHTTPURL(image) {
+		return image
+	}
+	if p.ImageFetcher == nil {
+		p.Debugf("no ImageFetcher: skipping image %s", image)
+		return ""
+	}
+	data, err := p.ImageFetcher.Fetch(ctx, image)
+	if err != nil {
+		p.warn(errors.Wrap(err, "fetch image"))
+		return ""
+	}
+	return data
+}
@@ -0,0 +1,159 @@
+package plugins
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestImageFetcher(t *testing.T) {
+	is := is.New(t)
+
+	var requests, notModified int
+	var broken bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if broken {
+			http.Error(w, "oops", http.StatusInternalServerError)
+			return
+		}
+		switch r.URL.Path {
+		case "/icon.png":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte("icon-data"))
+		case "/dated.png":
+			if r.Header.Get("If-Modified-Since") == "Wed, 21 Oct 2015 07:28:00 GMT" {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+			w.Write([]byte("dated-data"))
+		case "/huge.png":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cacheDir, err := os.MkdirTemp("", "xbar-images-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(cacheDir)
+	})
+
+	f := NewImageFetcher(srv.Client(), cacheDir)
+	f.MaxBytes = 50
+	ctx := context.Background()
+
+	data, err := f.Fetch(ctx, srv.URL+"/icon.png")
+	is.NoErr(err)
+	is.Equal(data, base64.StdEncoding.EncodeToString([]byte("icon-data")))
+	is.Equal(notModified, 0)
+
+	// recently fetched images don't make requests
+	data, err = f.Fetch(ctx, srv.URL+"/icon.png")
+	is.NoErr(err)
+	is.Equal(data, base64.StdEncoding.EncodeToString([]byte("icon-data")))
+	is.Equal(requests, 1)
+
+	// nor do ones recently cached on disk (like after a restart)
+	data, err = NewImageFetcher(srv.Client(), cacheDir).Fetch(ctx, srv.URL+"/icon.png")
+	is.NoErr(err)
+	is.Equal(data, base64.StdEncoding.EncodeToString([]byte("icon-data")))
+	is.Equal(requests, 1)
+
+	// older images are revalidated with the ETag
+	f.MaxAge = 0
+	data, err = f.Fetch(ctx, srv.URL+"/icon.png")
+	is.NoErr(err)
+	is.Equal(data, base64.StdEncoding.EncodeToString([]byte("icon-data")))
+	is.Equal(notModified, 1)
+
+	// or the Last-Modified time
+	_, err = f.Fetch(ctx, srv.URL+"/dated.png")
+	is.NoErr(err)
+	data, err = f.Fetch(ctx, srv.URL+"/dated.png")
+	is.NoErr(err)
+	is.Equal(data, base64.StdEncoding.EncodeToString([]byte("dated-data")))
+	is.Equal(notModified, 2)
+
+	// server errors fall back to the cached image
+	broken = true
+	data, err = f.Fetch(ctx, srv.URL+"/icon.png")
+	is.NoErr(err)
+	is.Equal(data, base64.StdEncoding.EncodeToString([]byte("icon-data")))
+	broken = false
+
+	// no temporary files are left behind
+	files, err := os.ReadDir(cacheDir)
+	is.NoErr(err)
+	for _, file := range files {
+		is.True(!strings.HasSuffix(file.Name(), ".tmp")) // temporary file
+	}
+
+	_, err = f.Fetch(ctx, srv.URL+"/huge.png")
+	is.True(err != nil) // too big
+
+	_, err = f.Fetch(ctx, srv.URL+"/missing.png")
+	is.True(err != nil) // not found
+
+	// when the server is unreachable, the cache is used
+	iconURL := srv.URL + "/icon.png"
+	srv.Close()
+	f.Timeout = 1 * time.Second
+	data, err = f.Fetch(ctx, iconURL)
+	is.NoErr(err)
+	is.Equal(data, base64.StdEncoding.EncodeToString([]byte("icon-data")))
+}
+
+func TestFetchImages(t *testing.T) {
+	is := is.New(t)
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("image"))
+	}))
+	defer srv.Close()
+	cacheDir, err := os.MkdirTemp("", "xbar-images-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(cacheDir)
+	})
+
+	p := &Plugin{
+		Debugf:       DebugfNoop,
+		ImageFetcher: NewImageFetcher(srv.Client(), cacheDir),
+	}
+	items, err := p.parseOutput(context.Background(), "images.txt", strings.NewReader(`title | image=`+srv.URL+`/a.png
+---
+parent
+--child | templateImage=`+srv.URL+`/b.png
+--child2 | image=aW1hZ2U=
+--child3 | image=`+srv.URL+`/b.png`))
+	is.NoErr(err)
+	is.Equal(items.CycleItems[0].Params.Image, srv.URL+"/a.png") // not fetched until refresh
+	p.fetchImages(context.Background(), items.CycleItems)
+	p.fetchImages(context.Background(), items.ExpandedItems)
+	expected := base64.StdEncoding.EncodeToString([]byte("image"))
+	is.Equal(items.CycleItems[0].Params.Image, expected)
+	is.Equal(items.ExpandedItems[0].Items[0].Params.TemplateImage, expected)
+	is.Equal(items.ExpandedItems[0].Items[1].Params.Image, "aW1hZ2U=") // untouched
+	is.Equal(items.ExpandedItems[0].Items[2].Params.Image, expected)
+	is.Equal(atomic.LoadInt32(&requests), int32(2)) // b.png is only downloaded once
+}
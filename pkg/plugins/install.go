@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -11,6 +12,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/matryer/xbar/pkg/metadata"
 	"github.com/pkg/errors"
@@ -23,14 +25,64 @@ import (
 type Installer struct {
 	Client    *http.Client
 	PluginDir string
+	// CacheDir and DataDir are where the plugins' state
+	// directories are (see Plugin.SetStateDirs), which are removed
+	// when a plugin is uninstalled.
+	// Optional.
+	CacheDir, DataDir string
+	// HistoryDir is where the plugins' history files are (see
+	// HistoryFilename), which are removed when a plugin is
+	// uninstalled.
+	// Optional.
+	HistoryDir string
+	// AppVersion and OSVersion are the versions of xbar (like v2.1.0)
+	// and macOS (like 11.6) the plugin will run on. Plugins that
+	// need newer versions (see metadata.Plugin.MinVersion) are not
+	// installed.
+	// Optional.
+	AppVersion, OSVersion string
+	// InstallsFile is where the records of where plugins were
+	// installed from are kept (see CheckForUpdates).
+	// Optional.
+	InstallsFile string
 }
 
-// Uninstall removes an installed plugin.
+// Uninstall removes an installed plugin, along with its state
+// directories, history and secret variables.
 func (i Installer) Uninstall(installedPluginPath string) error {
-	err := os.RemoveAll(filepath.Join(i.PluginDir, installedPluginPath))
+	vars, err := pluginVars(i.PluginDir, installedPluginPath)
 	if err != nil {
 		return err
 	}
+	err = os.RemoveAll(filepath.Join(i.PluginDir, installedPluginPath))
+	if err != nil {
+		return err
+	}
+	for _, name := range secretVarNames(vars) {
+		if err := deleteSecret(filepath.Join(i.PluginDir, installedPluginPath), name); err != nil {
+			return errors.Wrapf(err, "secret variable %s", name)
+		}
+	}
+	name := StateName(filepath.Base(installedPluginPath))
+	for _, dir := range []string{i.CacheDir, i.DataDir} {
+		if dir == "" {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return errors.Wrap(err, "remove state directory")
+		}
+	}
+	if i.HistoryDir != "" {
+		err := os.Remove(HistoryFilename(i.HistoryDir, installedPluginPath))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "remove history")
+		}
+	}
+	if i.InstallsFile != "" {
+		if err := updateInstallRecord(i.InstallsFile, installedPluginPath, nil); err != nil {
+			return errors.Wrap(err, "remove install record")
+		}
+	}
 	return nil
 }
 
@@ -45,6 +97,9 @@ func (i Installer) Install(pluginPath *url.URL) (string, error) {
 	if err != nil {
 		return "", errors.Wrapf(err, "fetchPlugin: %s", pluginPath)
 	}
+	if err := plugin.CheckCompatible(i.AppVersion, i.OSVersion); err != nil {
+		return "", err
+	}
 	dest, err := i.getInstalledPluginName(plugin)
 	if err != nil {
 		return "", errors.Wrap(err, "getInstalledPluginName")
@@ -56,9 +111,115 @@ func (i Installer) Install(pluginPath *url.URL) (string, error) {
 	if err != nil {
 		return "", errors.Wrap(err, "filepath.Rel")
 	}
+	if err := i.recordInstall(installedPluginPath, plugin); err != nil {
+		return "", err
+	}
 	return installedPluginPath, nil
 }
 
+// Upgrade installs the latest version of the plugin at pluginPath
+// (see CheckForUpdates) over the installed plugin, keeping its name
+// (so it stays enabled or disabled, with the same refresh time) and
+// its variable values.
+// New variables get their default values.
+func (i Installer) Upgrade(installedPluginPath string, pluginPath *url.URL) error {
+	plugin, err := i.fetchPlugin(pluginPath)
+	if err != nil {
+		return errors.Wrapf(err, "fetchPlugin: %s", pluginPath)
+	}
+	if err := plugin.CheckCompatible(i.AppVersion, i.OSVersion); err != nil {
+		return err
+	}
+	f, ok := entryFile(plugin)
+	if !ok {
+		return errors.New("no plugin files")
+	}
+	values, err := LoadVariableValues(i.PluginDir, installedPluginPath)
+	if err != nil {
+		return errors.Wrap(err, "load variable values")
+	}
+	filename := filepath.Join(i.PluginDir, installedPluginPath)
+	if err := ioutil.WriteFile(filename, []byte(f.Content), 0755); err != nil {
+		return errors.Wrap(err, "write plugin file")
+	}
+	if err := os.Chmod(filename, 0755); err != nil {
+		return errors.Wrap(err, "set executable permission on plugin")
+	}
+	vars, err := pluginVars(i.PluginDir, installedPluginPath)
+	if err != nil {
+		return err
+	}
+	if len(vars) > 0 {
+		for _, pluginVar := range vars {
+			if _, ok := values[pluginVar.Name]; !ok {
+				values[pluginVar.Name] = pluginVar.DefaultValue()
+			}
+		}
+		if err := SaveVariableValues(i.PluginDir, installedPluginPath, values); err != nil {
+			return errors.Wrap(err, "save variable values")
+		}
+	}
+	return i.recordInstall(installedPluginPath, plugin)
+}
+
+// recordInstall saves where the installed plugin came from in the
+// InstallsFile, if there is one.
+func (i Installer) recordInstall(installedPluginPath string, plugin metadata.Plugin) error {
+	if i.InstallsFile == "" {
+		return nil
+	}
+	record := &InstallRecord{
+		Path:        plugin.Path,
+		Version:     plugin.Version,
+		InstalledAt: time.Now(),
+	}
+	if f, ok := entryFile(plugin); ok {
+		record.Hash = contentHash(f.Content)
+	}
+	if err := updateInstallRecord(i.InstallsFile, installedPluginPath, record); err != nil {
+		return errors.Wrap(err, "save install record")
+	}
+	return nil
+}
+
+// Replace installs the plugin at replacementPath (see Install) in
+// place of the installed plugin, which is uninstalled.
+// The values of variables the replacement has too are kept.
+func (i Installer) Replace(installedPluginPath string, replacementPath *url.URL) (string, error) {
+	values, err := LoadVariableValues(i.PluginDir, installedPluginPath)
+	if err != nil {
+		return "", errors.Wrap(err, "load variable values")
+	}
+	replacementPluginPath, err := i.Install(replacementPath)
+	if err != nil {
+		return "", err
+	}
+	vars, err := pluginVars(i.PluginDir, replacementPluginPath)
+	if err != nil {
+		return "", err
+	}
+	replacementValues, err := LoadVariableValues(i.PluginDir, replacementPluginPath)
+	if err != nil {
+		return "", errors.Wrap(err, "load variable values")
+	}
+	var kept bool
+	for _, pluginVar := range vars {
+		if value, ok := values[pluginVar.Name]; ok {
+			replacementValues[pluginVar.Name] = value
+			kept = true
+		}
+	}
+	if kept {
+		if err := SaveVariableValues(i.PluginDir, replacementPluginPath, replacementValues); err != nil {
+			return "", errors.Wrap(err, "save variable values")
+		}
+	}
+	if err := i.Uninstall(installedPluginPath); err != nil {
+		return "", errors.Wrap(err, "uninstall")
+	}
+	return replacementPluginPath, nil
+}
+
 // fetchPlugin fetches the plugin metadata and file contents from the xbar website.
 func (i Installer) fetchPlugin(pluginPath *url.URL) (metadata.Plugin, error) {
 	resp, err := i.Client.Get(pluginPath.String())
@@ -143,7 +304,11 @@ func (i Installer) writePluginFiles(dstPath string, plugin metadata.Plugin) erro
 			for _, pluginVar := range plugin.Vars {
 				defaultVars[pluginVar.Name] = pluginVar.DefaultValue()
 			}
-			err := SaveVariableValues(i.PluginDir, pluginFile, defaultVars)
+			installedPluginPath, err := filepath.Rel(i.PluginDir, pluginFile)
+			if err != nil {
+				return errors.Wrap(err, "filepath.Rel")
+			}
+			err = SaveVariableValues(i.PluginDir, installedPluginPath, defaultVars)
 			if err != nil {
 				return errors.Wrap(err, "write default variables")
 			}
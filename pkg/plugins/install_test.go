@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -30,8 +31,9 @@ func TestInstall(t *testing.T) {
 
 		const plugin = "currency-tracker.1h.py"
 		installer := Installer{
-			Client:    srv.Client(),
-			PluginDir: pluginDir,
+			Client:       srv.Client(),
+			PluginDir:    pluginDir,
+			InstallsFile: filepath.Join(pluginDir, ".installs.json"),
 		}
 		pluginMetadataAPIPath, err := url.Parse(srv.URL + "/" + plugin + ".json")
 		is.NoErr(err)
@@ -43,6 +45,43 @@ func TestInstall(t *testing.T) {
 		fi, err := os.Stat(expected)
 		is.True(os.IsNotExist(err) == false)
 		is.Equal(fi.Mode(), os.FileMode(0755))
+
+		records, err := LoadInstallRecords(installer.InstallsFile)
+		is.NoErr(err)
+		is.Equal(records["001-currency-tracker.py"].Path, "Finance/currency-tracker.1h.py")
+		is.Equal(records["001-currency-tracker.py"].Version, "1.0")
+		is.True(records["001-currency-tracker.py"].Hash != "")
+	})
+
+	t.Run("incompatible plugin", func(t *testing.T) {
+		var (
+			is        = is.New(t)
+			pluginDir = filepath.Join("testdata", "incompatible_install_tests")
+		)
+		t.Cleanup(func() {
+			err := os.RemoveAll(pluginDir)
+			is.NoErr(err)
+		})
+
+		const plugin = "needs-newer-xbar.1h.sh"
+		installer := Installer{
+			Client:     srv.Client(),
+			PluginDir:  pluginDir,
+			AppVersion: "v2.0.42",
+			OSVersion:  "11.6",
+		}
+		pluginMetadataAPIPath, err := url.Parse(srv.URL + "/" + plugin + ".json")
+		is.NoErr(err)
+		_, err = installer.Install(pluginMetadataAPIPath)
+		is.True(err != nil)
+		is.Equal(err.Error(), "Needs newer xbar needs xbar v2.1.0 or later (this is v2.0.42)")
+		_, err = os.Stat(filepath.Join(pluginDir, "001-"+plugin))
+		is.True(os.IsNotExist(err))
+
+		installer.AppVersion = "v2.1.0"
+		installedPluginPath, err := installer.Install(pluginMetadataAPIPath)
+		is.NoErr(err)
+		is.Equal(installedPluginPath, "001-"+plugin)
 	})
 
 	// note: this feature isn't yet implemented
@@ -126,6 +165,99 @@ func TestInstall(t *testing.T) {
 		is.True(os.IsNotExist(err) == false)
 		is.Equal(fi.Mode(), os.FileMode(0755))
 	})
+
+	t.Run("replacing a deprecated plugin", func(t *testing.T) {
+		var (
+			is        = is.New(t)
+			pluginDir = filepath.Join("testdata", "replace_tests")
+		)
+		t.Cleanup(func() {
+			err := os.RemoveAll(pluginDir)
+			is.NoErr(err)
+		})
+		useMemorySecrets(t)
+
+		is.NoErr(os.MkdirAll(pluginDir, 0777))
+		const oldPlugin = "001-old-weather.1h.sh"
+		is.NoErr(ioutil.WriteFile(filepath.Join(pluginDir, oldPlugin), []byte(`#!/bin/bash
+# <xbar.title>Old weather</xbar.title>
+# <xbar.replacedBy>Weather/weather.1h.sh</xbar.replacedBy>
+# <xbar.var>string(VAR_CITY="London"): The city.</xbar.var>
+# <xbar.var>string(VAR_API_KEY=""): The old API key.</xbar.var>
+`), 0755))
+		is.NoErr(SaveVariableValues(pluginDir, oldPlugin, map[string]interface{}{
+			"VAR_CITY":    "Tokyo",
+			"VAR_API_KEY": "abc123",
+		}))
+
+		installer := Installer{
+			Client:    srv.Client(),
+			PluginDir: pluginDir,
+		}
+		replacementPath, err := url.Parse(srv.URL + "/weather.1h.sh.json")
+		is.NoErr(err)
+		installedPluginPath, err := installer.Replace(oldPlugin, replacementPath)
+		is.NoErr(err)
+		is.Equal(installedPluginPath, "001-weather.1h.sh")
+
+		_, err = os.Stat(filepath.Join(pluginDir, oldPlugin))
+		is.True(os.IsNotExist(err)) // old plugin removed
+		values, err := LoadVariableValues(pluginDir, installedPluginPath)
+		is.NoErr(err)
+		is.Equal(values, map[string]interface{}{
+			"VAR_CITY":    "Tokyo", // kept
+			"VAR_CELSIUS": true,    // default
+		})
+	})
+
+	t.Run("upgrading a plugin", func(t *testing.T) {
+		var (
+			is        = is.New(t)
+			pluginDir = filepath.Join("testdata", "upgrade_tests")
+		)
+		t.Cleanup(func() {
+			err := os.RemoveAll(pluginDir)
+			is.NoErr(err)
+		})
+		useMemorySecrets(t)
+
+		is.NoErr(os.MkdirAll(pluginDir, 0777))
+		const installedPlugin = "001-weather.5m.sh.off"
+		is.NoErr(ioutil.WriteFile(filepath.Join(pluginDir, installedPlugin), []byte(`#!/bin/bash
+# <xbar.title>Weather</xbar.title>
+# <xbar.version>v1.0</xbar.version>
+# <xbar.var>string(VAR_CITY="Paris"): The city.</xbar.var>
+`), 0644))
+		is.NoErr(SaveVariableValues(pluginDir, installedPlugin, map[string]interface{}{
+			"VAR_CITY": "Tokyo",
+		}))
+
+		installer := Installer{
+			Client:       srv.Client(),
+			PluginDir:    pluginDir,
+			InstallsFile: filepath.Join(pluginDir, ".installs.json"),
+		}
+		pluginPath, err := url.Parse(srv.URL + "/weather.1h.sh.json")
+		is.NoErr(err)
+		is.NoErr(installer.Upgrade(installedPlugin, pluginPath))
+
+		fi, err := os.Stat(filepath.Join(pluginDir, installedPlugin)) // same name
+		is.NoErr(err)
+		is.Equal(fi.Mode(), os.FileMode(0755))
+		md, err := metadata.ParseFile(metadata.DebugfNoop, filepath.Join(pluginDir, installedPlugin))
+		is.NoErr(err)
+		is.Equal(md.Version, "v2.0")
+		values, err := LoadVariableValues(pluginDir, installedPlugin)
+		is.NoErr(err)
+		is.Equal(values, map[string]interface{}{
+			"VAR_CITY":    "Tokyo", // kept
+			"VAR_CELSIUS": true,    // default
+		})
+		record, err := LoadInstallRecord(installer.InstallsFile, installedPlugin)
+		is.NoErr(err)
+		is.Equal(record.Path, "Weather/weather.1h.sh")
+		is.Equal(record.Version, "v2.0")
+	})
 }
 
 func TestGetInstalledPluginName(t *testing.T) {
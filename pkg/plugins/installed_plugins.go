@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/matryer/xbar/pkg/metadata"
 	"github.com/pkg/errors"
 )
 
@@ -31,23 +32,61 @@ func IsPluginEnabled(pluginPath string) bool {
 
 // SetEnabled sets a plugin to enabled or disabled state, depending on the value
 // of the enabled parameter.
+// The variables and metadata sidecar files are kept, so the plugin
+// works as before when it is enabled again.
 func SetEnabled(pluginDirectory, installedPluginPath string, enabled bool) (string, error) {
 	fullPluginPath := filepath.Join(pluginDirectory, installedPluginPath)
-	// Enable a disabled plugin.
-	if enabled && !IsPluginEnabled(installedPluginPath) {
-		newFullPluginPath := strings.TrimSuffix(fullPluginPath, disabledPluginExtension)
-		newInstalledPluginPath := strings.TrimSuffix(installedPluginPath, disabledPluginExtension)
-		err := os.Rename(fullPluginPath, newFullPluginPath)
-		return newInstalledPluginPath, err
+	var newInstalledPluginPath string
+	switch {
+	case enabled && !IsPluginEnabled(installedPluginPath):
+		// Enable a disabled plugin.
+		newInstalledPluginPath = strings.TrimSuffix(installedPluginPath, disabledPluginExtension)
+	case !enabled && IsPluginEnabled(installedPluginPath):
+		// Disable an enabled plugin.
+		newInstalledPluginPath = installedPluginPath + disabledPluginExtension
+	default:
+		return installedPluginPath, nil
+	}
+	newFullPluginPath := filepath.Join(pluginDirectory, newInstalledPluginPath)
+	if err := os.Rename(fullPluginPath, newFullPluginPath); err != nil {
+		return installedPluginPath, err
 	}
-	// Disable an enabled plugin.
-	if !enabled && IsPluginEnabled(installedPluginPath) {
-		newFullPluginPath := fullPluginPath + disabledPluginExtension
-		newInstalledPluginPath := installedPluginPath + disabledPluginExtension
-		err := os.Rename(fullPluginPath, newFullPluginPath)
+	if err := renamePluginFiles(pluginDirectory, installedPluginPath, newInstalledPluginPath); err != nil {
 		return newInstalledPluginPath, err
 	}
-	return installedPluginPath, nil
+	return newInstalledPluginPath, nil
+}
+
+// renamePluginFiles moves the variables and metadata sidecar files
+// of a plugin, which are named after it, to go with its new name.
+func renamePluginFiles(pluginDirectory, oldInstalledPluginPath, newInstalledPluginPath string) error {
+	for _, ext := range append([]string{variableJSONFileExt}, metadata.SidecarExts...) {
+		oldExtFullPath := filepath.Join(pluginDirectory, oldInstalledPluginPath+ext)
+		_, err := os.Stat(oldExtFullPath)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "stat plugin %s file", ext)
+		}
+		if err != nil && os.IsNotExist(err) {
+			// no file, no probs
+			continue
+		}
+		newExtFullPath := filepath.Join(pluginDirectory, newInstalledPluginPath+ext)
+		if err := os.Rename(oldExtFullPath, newExtFullPath); err != nil {
+			return errors.Wrapf(err, "rename plugin %s file", ext)
+		}
+	}
+	return nil
+}
+
+// isSidecarFile gets whether the file holds the metadata of a plugin
+// (see metadata.SidecarExts), rather than being a plugin.
+func isSidecarFile(filename string) bool {
+	for _, ext := range metadata.SidecarExts {
+		if strings.HasSuffix(filename, ext) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetInstalledPlugins gets the installed fplugins from the pluginDirectory.
@@ -73,6 +112,10 @@ func GetInstalledPlugins(pluginDirectory string) ([]InstalledPlugin, error) {
 			// ignore variable payload files
 			continue
 		}
+		if isSidecarFile(file.Name()) {
+			// ignore metadata sidecar files
+			continue
+		}
 		enabled := !strings.HasSuffix(file.Name(), disabledPluginExtension)
 		name := file.Name()
 		installedPlugin := InstalledPlugin{
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -99,6 +100,28 @@ func TestSetEnabled(t *testing.T) {
 		_, err = os.Stat(pluginPath)
 		is.NoErr(err)
 	})
+	t.Run("keeps variables", func(t *testing.T) {
+		var (
+			is         = is.New(t)
+			testdir    = scaffold(t, "keep-vars")
+			pluginPath = filepath.Join(testdir, installedPluginPath)
+		)
+		_, err := os.Create(pluginPath)
+		is.NoErr(err)
+		err = ioutil.WriteFile(pluginPath+variableJSONFileExt, []byte(`{"VAR_NAME": "Mat"}`), 0666)
+		is.NoErr(err)
+		newpath, err := SetEnabled(testdir, installedPluginPath, false)
+		is.NoErr(err)
+		values, err := LoadVariableValues(testdir, newpath)
+		is.NoErr(err)
+		is.Equal(values["VAR_NAME"], "Mat")
+		newpath, err = SetEnabled(testdir, newpath, true)
+		is.NoErr(err)
+		is.Equal(newpath, installedPluginPath)
+		b, err := ioutil.ReadFile(pluginPath + variableJSONFileExt)
+		is.NoErr(err)
+		is.Equal(string(b), `{"VAR_NAME": "Mat"}`)
+	})
 	t.Run("enable already enabled: no op", func(t *testing.T) {
 		var (
 			is         = is.New(t)
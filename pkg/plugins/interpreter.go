@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// workingDir gets the directory the plugin runs in: its WorkingDir,
+// or the directory it is in.
+func (p *Plugin) workingDir() string {
+	if p.WorkingDir != "" {
+		return p.WorkingDir
+	}
+	return filepath.Dir(p.Command)
+}
+
+// commandLine gets the program and arguments that run the plugin,
+// with the environment it runs in.
+func (p *Plugin) commandLine(env []string) (string, []string) {
+	script := "./" + filepath.Base(p.Command)
+	if p.WorkingDir != "" {
+		// it isn't in the working directory
+		if abs, err := filepath.Abs(p.Command); err == nil {
+			script = abs
+		}
+	}
+	if len(p.Interpreter) == 0 {
+		return script, nil
+	}
+	args := append([]string{}, p.Interpreter[1:]...)
+	return lookPath(p.Interpreter[0], env), append(args, script)
+}
+
+// lookPath finds the program in the PATH from env (where the last
+// one wins), or returns it as it is if it is a path, or can't be
+// found.
+func lookPath(program string, env []string) string {
+	if strings.Contains(program, "/") {
+		return program
+	}
+	var path string
+	for _, keyValue := range env {
+		if strings.HasPrefix(keyValue, "PATH=") {
+			path = strings.TrimPrefix(keyValue, "PATH=")
+		}
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		candidate := filepath.Join(dir, program)
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return candidate
+		}
+	}
+	return program
+}
@@ -0,0 +1,62 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestInterpreterAndWorkingDir(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-interpreter-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	dir, err = filepath.EvalSymlinks(dir) // for pwd on macOS
+	is.NoErr(err)
+	is.NoErr(os.Mkdir(filepath.Join(dir, "repo"), 0777))
+	is.NoErr(os.Mkdir(filepath.Join(dir, "plugins"), 0777))
+	command := filepath.Join(dir, "plugins", "pwd.1m.sh")
+	// not executable, and no shebang
+	script := `
+# <xbar.cwd>../repo</xbar.cwd>
+# <xbar.interpreter>bash -e</xbar.interpreter>
+pwd
+echo $-
+`
+	err = os.WriteFile(command, []byte(script), 0666)
+	is.NoErr(err)
+	p := NewPlugin(command)
+	is.NoErr(p.loadMetadata())
+	is.Equal(p.WorkingDir, filepath.Join(dir, "repo"))
+	is.Equal(p.Interpreter, []string{"bash", "-e"})
+	p.Refresh(context.Background())
+	is.Equal(len(p.Items.CycleItems), 2)
+	is.Equal(p.Items.CycleItems[0].Text, filepath.Join(dir, "repo"))
+	is.True(strings.Contains(p.Items.CycleItems[1].Text, "e")) // -e was passed
+}
+
+func TestLookPath(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-look-path-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	err = os.WriteFile(filepath.Join(dir, "python3"), []byte("#!/bin/sh\n"), 0777)
+	is.NoErr(err)
+	err = os.WriteFile(filepath.Join(dir, "notes"), []byte("not a program"), 0666)
+	is.NoErr(err)
+	env := []string{"PATH=/nowhere", "PATH=/nowhere:" + dir}
+	is.Equal(lookPath("python3", env), filepath.Join(dir, "python3"))
+	is.Equal(lookPath("notes", env), "notes") // not executable
+	is.Equal(lookPath("ruby", env), "ruby")
+	is.Equal(lookPath("/usr/bin/ruby", env), "/usr/bin/ruby")
+}
@@ -1,8 +1,15 @@
 package plugins
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
@@ -14,6 +21,21 @@ type Items struct {
 	// ExpandedItems are the items that appear when the menu
 	// is open.
 	ExpandedItems []*Item `json:"expandedItems"`
+	// Splits are additional menu bar items, each with their own
+	// dropdown, separated by ---SPLIT--- lines in the output.
+	Splits []Items `json:"splits"`
+}
+
+// hash gets a hash of the items, used to tell whether a plugin's
+// output has changed since it was last rendered.
+// Returns an empty string if the items cannot be hashed.
+func (i Items) hash() string {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
 }
 
 // Item is a single menu item.
@@ -22,6 +44,18 @@ type Item struct {
 	Plugin *Plugin `json:"-"`
 	// Text is the content of the menu item.
 	Text string `json:"text"`
+	// FullText is the content of the menu item before it was
+	// truncated by the Length parameter. Empty if it wasn't truncated.
+	FullText string `json:"fullText"`
+	// Segments are the styled runs of Text, parsed from ANSI
+	// escape codes. Nil if the text has no styling.
+	// Menu items are drawn in a single style, so the app only uses
+	// the first Color of the segments.
+	Segments []*TextSegment `json:"segments"`
+	// Columns are the cells of the text, for items with
+	// columns=true. Text holds the cells aligned with the other
+	// items in the same table.
+	Columns []string `json:"columns"`
 	// Params are the parameters associated with this Item.
 	Params ItemParams `json:"params"`
 	// Items are a collection of items that appear as a
@@ -38,6 +72,10 @@ type Item struct {
 // this item.
 // It takes into account the Length parameter.
 func (i Item) DisplayText() string {
+	if i.Columns != nil {
+		// each cell has already been truncated
+		return i.Text
+	}
 	return truncate(i.Text, i.Params.Length)
 }
 
@@ -46,14 +84,71 @@ type ItemParams struct {
 	// Disabled indicates that this Item should appear
 	// disabled.
 	Disabled bool `json:"disabled"`
+	// Checked indicates that this Item should appear with
+	// a checkmark.
+	Checked bool `json:"checked"`
 	// Separator indicates that this Item is a separator.
 	Separator bool `json:"separator"`
+	// Header indicates that this Item is a section header, from
+	// a ---Title line. Headers are not clickable.
+	Header bool `json:"header"`
 	// Href is the URL to open when the item is clicked.
 	Href string `json:"href"`
+	// Open is a file, folder or application to open when the item is
+	// clicked.
+	Open string `json:"open"`
+	// Badge is a count to show next to the title in the menu bar,
+	// or zero for no badge.
+	// Only used on title lines.
+	Badge int `json:"badge"`
+	// Sound is a system sound name (like Glass), or the path to a
+	// sound file, to play when the item is clicked.
+	Sound string `json:"sound"`
+	// AlertSound is a sound (like Sound) to play when this item
+	// appears in the menu bar after a refresh.
+	// Only used on title lines.
+	AlertSound string `json:"alertSound"`
+	// Notify shows a notification when this item appears, and the
+	// output of the plugin has changed since it last refreshed.
+	Notify bool `json:"notify"`
+	// NotifyTitle is the title of the notification, or empty for the
+	// name of the plugin.
+	NotifyTitle string `json:"notifyTitle"`
+	// NotifyBody is the message of the notification, or empty for
+	// the item's text.
+	NotifyBody string `json:"notifyBody"`
+	// Confirm indicates that the user must confirm before the item's
+	// actions are run.
+	Confirm bool `json:"confirm"`
+	// ConfirmTitle is the title of the confirmation prompt.
+	ConfirmTitle string `json:"confirmTitle"`
+	// ConfirmText is the message in the confirmation prompt.
+	ConfirmText string `json:"confirmText"`
+	// Copy is the text to copy to the clipboard when the item is
+	// clicked.
+	Copy string `json:"copy"`
+	// Webview is a URL to open in a popover webview when the item is
+	// clicked, instead of opening it in the browser.
+	Webview string `json:"webview"`
+	// WebviewWidth is the width of the webview, or zero for the
+	// default.
+	WebviewWidth int `json:"webviewWidth"`
+	// WebviewHeight is the height of the webview, or zero for the
+	// default.
+	WebviewHeight int `json:"webviewHeight"`
 	// Key is the accelerator (shortcut) for this item.
 	Key string `json:"key"`
+	// Shortcut is the normalized keyboard shortcut for this item,
+	// for example cmdorctrl+shift+k.
+	// Unlike Key, shortcuts are checked for conflicts across plugins.
+	Shortcut string `json:"shortcut"`
+	// Tooltip is the text shown when hovering over the item.
+	Tooltip string `json:"tooltip"`
 	// Color is the color of the text.
 	Color string `json:"color"`
+	// DarkColor is the color of the text when the system is in
+	// dark mode. If empty, Color is used.
+	DarkColor string `json:"darkColor"`
 	// Font is the font for the text.
 	Font string `json:"font"`
 	// Size is the font size.
@@ -62,6 +157,9 @@ type ItemParams struct {
 	Shell string `json:"shell"`
 	// ShellParams are the arguments to pass to the shell executable.
 	ShellParams []string `json:"shell_params"`
+	// ShellSteps are extra shell commands (from repeated shell
+	// parameters) to run with /bin/sh after Shell, in order.
+	ShellSteps []string `json:"shell_steps"`
 	// Terminal indicates whether to run the shell command in a terminal or not.
 	// Default is false.
 	Terminal bool `json:"terminal"`
@@ -79,15 +177,57 @@ type ItemParams struct {
 	// Alternate indicates that this item is an alternative for the
 	// previous item. It will be shown when the option key is depressed.
 	Alternate bool `json:"alternate"`
-	// TemplateImage is the te`mplate image for this item.
+	// TemplateImage is the base64 encoded template image for this item.
+	// Template images are monochrome, and macOS adapts them to dark
+	// and light menu bars.
+	// Plugins may also specify a URL, which is downloaded after parsing.
 	TemplateImage string `json:"template_image"`
-	// Image is the item for this item.
+	// Image is the base64 encoded image for this item.
+	// Plugins may also specify a URL, which is downloaded after parsing.
 	Image string `json:"image"`
+	// SFImage is the name of an SF Symbol to use as the image for
+	// this item, for example "cloud.sun.fill".
+	SFImage string `json:"sfimage"`
 	// Emojize indicates whether to process emoji strings (like :mushroom:)
 	// or not.
 	Emojize bool `json:"emojize"`
 	// ANSI indicates whether to parsing ANSI codes.
 	ANSI bool `json:"ansi"`
+	// Cycle is how long each title is shown for in the menu bar
+	// when there is more than one, or zero for the plugin's default.
+	// Only used on title lines.
+	Cycle time.Duration `json:"cycle"`
+	// Progress is the progress (between zero and one) to show as
+	// a progress bar after the text, or nil for no progress bar.
+	Progress *float64 `json:"progress"`
+	// Markdown indicates whether to parse inline Markdown (bold,
+	// italics, code and strikethrough) in the text.
+	Markdown bool `json:"md"`
+	// Columns indicates that the text is made up of tab separated
+	// cells, which are aligned with the cells of neighbouring items.
+	Columns bool `json:"columns"`
+
+	// copyText indicates that copy= was given without a value, so
+	// the item's text should be copied.
+	copyText bool
+}
+
+// DisplayColor gets the color of the text for light or dark mode.
+func (p ItemParams) DisplayColor(darkMode bool) string {
+	if darkMode && p.DarkColor != "" {
+		return p.DarkColor
+	}
+	return p.Color
+}
+
+// DisplayImage gets the image that should be displayed for this
+// item, and whether it is a template image or not.
+// TemplateImage takes precedence over Image.
+func (p ItemParams) DisplayImage() (string, bool) {
+	if p.TemplateImage != "" {
+		return p.TemplateImage, true
+	}
+	return p.Image, false
 }
 
 // parseParams parses the parameters from a single line.
@@ -109,45 +249,22 @@ func parseParams(s string) (string, ItemParams, error) {
 
 // parseParamStr parses the parameter string, updating params.
 func parseParamStr(params *ItemParams, s string) error {
-	var splitStr, endStr string
-	for {
-		s = strings.TrimSpace(s)
-		if len(s) == 0 {
-			return nil
-		}
-		splitStr = `=`
-		endStr = ` `
-		i := strings.Index(s, splitStr)
-		if i < 0 {
-			return errors.New("malformed parameters: missing equals")
-		}
-		if len(s) > i+1 && (s[i+1] == '"' || s[i+1] == '\'') {
-			// quotes
-			endStr = string(s[i+1])
-			splitStr = "=" + endStr
-		}
-		offset := i + len(splitStr)
-		key := s[:i]
-		if key[0] == '|' {
-			key = key[1:]
-			key = strings.TrimSpace(key)
-		}
-		valuePart := s[offset:]
-		end := strings.IndexAny(valuePart, endStr+"|")
-		if end < 0 {
-			end = len(s)
-		} else {
-			end += offset
-		}
-		value := s[i+len(splitStr) : end]
-		if err := params.setValueByKey(key, value); err != nil {
+	lexed, err := lexParams(s)
+	if err != nil {
+		return err
+	}
+	var imageErr error
+	for _, param := range lexed {
+		if err := params.setValueByKey(param.key, param.value); err != nil {
+			if _, ok := err.(errInvalidImage); ok {
+				// keep going, so only the image is left out
+				imageErr = err
+				continue
+			}
 			return err
 		}
-		if end+1 > len(s) {
-			return nil
-		}
-		s = s[end+1:]
 	}
+	return imageErr
 }
 
 // defaultParams are the default ItemParams.
@@ -158,6 +275,12 @@ var defaultParams = ItemParams{
 	ANSI:     true,
 }
 
+// DefaultItemParams gets the ItemParams of items that don't set
+// any parameters.
+func DefaultItemParams() ItemParams {
+	return defaultParams
+}
+
 func (p *ItemParams) setValueByKey(key, value string) error {
 	switch key {
 	case "disabled":
@@ -166,13 +289,88 @@ func (p *ItemParams) setValueByKey(key, value string) error {
 		if err != nil {
 			return errors.Wrap(err, key)
 		}
+	case "checked":
+		var err error
+		p.Checked, err = parseBool(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
 	case "key":
 		p.Key = value
 	case "href":
 		p.Href = value
+	case "open":
+		p.Open = value
+	case "badge":
+		val, err := parseInt(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
+		if val < 0 {
+			return errors.Errorf("%s: expected a positive int, not \"%s\"", key, value)
+		}
+		p.Badge = val
+	case "sound", "alertSound":
+		if err := validateSound(value); err != nil {
+			return errors.Wrap(err, key)
+		}
+		if key == "sound" {
+			p.Sound = value
+		} else {
+			p.AlertSound = value
+		}
+	case "confirm":
+		var err error
+		p.Confirm, err = parseBool(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
+	case "notify":
+		var err error
+		p.Notify, err = parseBool(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
+	case "title":
+		p.NotifyTitle = value
+	case "body":
+		p.NotifyBody = value
+	case "confirmTitle":
+		p.ConfirmTitle = value
+	case "confirmText":
+		p.ConfirmText = value
+	case "copy":
+		p.Copy = value
+		p.copyText = value == ""
+	case "webview":
+		if !isHTTPURL(value) {
+			return errors.Errorf("%s: expected an http or https URL, not \"%s\"", key, value)
+		}
+		p.Webview = value
+	case "webvieww", "webviewh":
+		val, err := parseInt(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
+		if val <= 0 {
+			return errors.Errorf("%s: expected a positive int, not \"%s\"", key, value)
+		}
+		if key == "webvieww" {
+			p.WebviewWidth = val
+		} else {
+			p.WebviewHeight = val
+		}
+	case "shortcut":
+		var err error
+		p.Shortcut, err = parseShortcut(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
+	case "tooltip":
+		p.Tooltip = value
 	case "color":
 		var err error
-		p.Color, err = parseColor(value)
+		p.Color, p.DarkColor, err = parseColorPair(value)
 		if err != nil {
 			return errors.Wrap(err, key)
 		}
@@ -183,13 +381,33 @@ func (p *ItemParams) setValueByKey(key, value string) error {
 		if err != nil {
 			return errors.Wrap(err, key)
 		}
+		if val <= 0 {
+			return errors.Errorf("%s: expected a positive int, not \"%s\"", key, value)
+		}
 		p.Size = val
 	case "shell", "bash":
+		if p.Shell != "" {
+			// more steps to run afterwards
+			p.ShellSteps = append(p.ShellSteps, value)
+			return nil
+		}
 		p.Shell = value
 	case "templateImage":
+		if !isImage(value) {
+			return errInvalidImage{key: key}
+		}
 		p.TemplateImage = value
 	case "image":
+		if !isImage(value) {
+			return errInvalidImage{key: key}
+		}
 		p.Image = value
+	case "sfimage":
+		var err error
+		p.SFImage, err = parseSFImage(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
 	case "terminal":
 		var err error
 		p.Terminal, err = parseBool(value)
@@ -213,6 +431,9 @@ func (p *ItemParams) setValueByKey(key, value string) error {
 		if err != nil {
 			return errors.Wrap(err, key)
 		}
+		if val < 0 {
+			return errors.Errorf("%s: expected a positive int, not \"%s\"", key, value)
+		}
 		p.Length = val
 	case "trim":
 		var err error
@@ -238,6 +459,30 @@ func (p *ItemParams) setValueByKey(key, value string) error {
 		if err != nil {
 			return errors.Wrap(err, key)
 		}
+	case "cycle":
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return errors.Errorf(`%s: expected a duration (like 5s), not "%s"`, key, value)
+		}
+		p.Cycle = d
+	case "progress":
+		progress, err := parseProgress(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
+		p.Progress = &progress
+	case "md":
+		var err error
+		p.Markdown, err = parseBool(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
+	case "columns":
+		var err error
+		p.Columns, err = parseBool(value)
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
 	default:
 		if strings.HasPrefix(key, "param") {
 			paramIndex, err := strconv.Atoi(key[5:])
@@ -288,6 +533,83 @@ func parseColor(s string) (string, error) {
 	return hexValue, nil
 }
 
+// parseColorPair parses a color, or a comma separated pair of colors
+// for light and dark mode (like black,white).
+// Semantic colors (like success) have their own light and dark values.
+// The dark color is empty if it is the same as the light one.
+func parseColorPair(s string) (string, string, error) {
+	parts := strings.Split(s, ",")
+	switch len(parts) {
+	case 1:
+		if semantic, ok := semanticColors[strings.ToLower(s)]; ok {
+			return semantic.light, semantic.dark, nil
+		}
+		light, err := parseColor(s)
+		return light, "", err
+	case 2:
+		light, err := parseThemeColor(strings.TrimSpace(parts[0]), false)
+		if err != nil {
+			return "", "", err
+		}
+		dark, err := parseThemeColor(strings.TrimSpace(parts[1]), true)
+		if err != nil {
+			return "", "", err
+		}
+		return light, dark, nil
+	}
+	return "", "", errors.Errorf(`expected a color or a light,dark pair, not "%s"`, s)
+}
+
+// parseThemeColor parses a color for light or dark mode.
+func parseThemeColor(s string, dark bool) (string, error) {
+	if semantic, ok := semanticColors[strings.ToLower(s)]; ok {
+		if dark {
+			return semantic.dark, nil
+		}
+		return semantic.light, nil
+	}
+	return parseColor(s)
+}
+
+// isImage checks that the image is a URL or base64 encoded.
+// Padding is optional.
+func isImage(s string) bool {
+	if isHTTPURL(s) {
+		return true
+	}
+	if _, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return true
+	}
+	if _, err := base64.RawStdEncoding.DecodeString(s); err == nil {
+		return true
+	}
+	return false
+}
+
+// errInvalidImage is returned when an image parameter isn't a URL
+// or base64 encoded.
+// Unlike other parameter errors, it doesn't stop the output being
+// parsed; the image is left out, like xbar has always done.
+type errInvalidImage struct {
+	key string
+}
+
+func (e errInvalidImage) Error() string {
+	return e.key + ": expected base64 encoded image data or URL"
+}
+
+// sfSymbolRegexp matches SF Symbol names, like cloud.sun.fill.
+var sfSymbolRegexp = regexp.MustCompile(`^[a-z0-9]+(\.[a-z0-9]+)*$`)
+
+// parseSFImage checks that s looks like an SF Symbol name, returning a
+// nice error if it doesn't.
+func parseSFImage(s string) (string, error) {
+	if !sfSymbolRegexp.MatchString(s) {
+		return "", errors.Errorf(`invalid SF Symbol name "%s"`, s)
+	}
+	return s, nil
+}
+
 // parseInt parses an int from a string, returning a nice
 // error if it fails.
 func parseInt(s string) (int, error) {
@@ -299,11 +621,48 @@ func parseInt(s string) (int, error) {
 }
 
 // truncate shrinks a string if it's too long.
+// The max is the number of characters as they appear on screen,
+// so multi-rune emoji are never split.
 func truncate(s string, max int) string {
-	runes := []rune(s)
-	if max > 0 && len(runes) > max {
-		s = string(runes[:max-1]) + "…"
+	if max <= 0 {
+		return s
+	}
+	chars := splitChars(s)
+	if len(chars) > max {
+		s = strings.Join(chars[:max-1], "") + "…"
 		return s
 	}
 	return s
 }
+
+// splitChars splits s into user perceived characters. Runes that modify
+// the previous one (combining marks, variation selectors, skin tones,
+// joined emoji, keycaps and flag pairs) are kept with it.
+func splitChars(s string) []string {
+	var chars []string
+	var joinNext, openFlag bool
+	for _, r := range s {
+		extends := len(chars) > 0 && (joinNext ||
+			unicode.Is(unicode.Mn, r) ||
+			unicode.Is(unicode.Me, r) ||
+			(r >= 0xFE00 && r <= 0xFE0F) || // variation selectors
+			(r >= 0x1F3FB && r <= 0x1F3FF) || // skin tones
+			(r >= 0xE0020 && r <= 0xE007F) || // tags
+			r == 0x200D || // zero width joiner
+			(openFlag && isRegionalIndicator(r)))
+		joinNext = r == 0x200D
+		if extends {
+			openFlag = false
+			chars[len(chars)-1] += string(r)
+			continue
+		}
+		openFlag = isRegionalIndicator(r)
+		chars = append(chars, string(r))
+	}
+	return chars
+}
+
+// isRegionalIndicator gets whether r is one half of a flag emoji.
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
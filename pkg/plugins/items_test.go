@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -42,11 +43,17 @@ func TestParseParamStr(t *testing.T) {
 		`param10=parameterValue10`,
 		`key=shift+g`,
 		`disabled=true`,
+		`checked=true`,
+		`tooltip="More details here"`,
+		`confirm=true`,
+		`confirmTitle="Delete?"`,
+		`confirmText='This cannot be undone.'`,
 	}, " | "))
 	is.NoErr(err)
 	is.Equal(params.Href, "https://xbarapp.com")
 	is.Equal(params.Color, "#ff0000")
 	is.Equal(params.Font, "MyFont")
+	is.Equal(params.Size, 12)
 	is.Equal(params.Shell, "script.sh")
 	is.Equal(params.Terminal, false)
 	is.Equal(params.Refresh, true)
@@ -60,6 +67,11 @@ func TestParseParamStr(t *testing.T) {
 	is.Equal(params.ANSI, false)
 	is.Equal(params.Key, "shift+g")
 	is.Equal(params.Disabled, true)
+	is.Equal(params.Checked, true)
+	is.Equal(params.Tooltip, "More details here")
+	is.Equal(params.Confirm, true)
+	is.Equal(params.ConfirmTitle, "Delete?")
+	is.Equal(params.ConfirmText, "This cannot be undone.")
 	is.Equal(len(params.ShellParams), 10)
 	is.Equal(params.ShellParams[0], "parameterValue1")
 	is.Equal(params.ShellParams[1], "parameterValue2")
@@ -146,6 +158,29 @@ yes2
 	is.Equal(items.ExpandedItems[1].Text, "yes2")
 }
 
+// TestLengthParsing tests that truncation happens at parse time.
+func TestLengthParsing(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "length.txt", strings.NewReader(strings.TrimSpace(`
+This is a long title | length=10
+Short | length=10
+:tada: party time | length=3
+	`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 3)
+	is.Equal(items.CycleItems[0].Text, "This is a…")
+	is.Equal(items.CycleItems[0].FullText, "This is a long title")
+	is.Equal(items.CycleItems[0].DisplayText(), "This is a…")
+	is.Equal(items.CycleItems[1].Text, "Short")
+	is.Equal(items.CycleItems[1].FullText, "")
+	is.Equal(items.CycleItems[2].Text, "🎉 …") // emoji counts as one
+
+	_, err = p.parseOutput(context.Background(), "length.txt", strings.NewReader(`bad | length=-1`))
+	is.True(err != nil)
+}
+
 func TestAlternate(t *testing.T) {
 	is := is.New(t)
 
@@ -173,6 +208,31 @@ before3 | alternate=false
 	is.Equal(items.ExpandedItems[2].Text, "before3")
 }
 
+// TestAlternateAfterSeparator ensures alternates only pair with
+// the item directly before them.
+func TestAlternateAfterSeparator(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	p := &Plugin{}
+	items, err := p.parseOutput(ctx, "test.txt", strings.NewReader(strings.TrimSpace(`
+title
+---
+one
+---
+alt | alternate=true
+two
+alt two | alternate=true
+	`)))
+	is.NoErr(err)
+	is.Equal(len(items.ExpandedItems), 4)
+	is.Equal(items.ExpandedItems[0].Text, "one")
+	is.True(items.ExpandedItems[0].Alternate == nil) // separator in between
+	is.Equal(items.ExpandedItems[2].Text, "alt")
+	is.Equal(items.ExpandedItems[3].Text, "two")
+	is.Equal(items.ExpandedItems[3].Alternate.Text, "alt two")
+}
+
 func TestTrim(t *testing.T) {
 	is := is.New(t)
 
@@ -191,6 +251,30 @@ func TestTrim(t *testing.T) {
 	is.Equal(items.CycleItems[2].Text, `cycle3`)
 }
 
+// TestTrimTable ensures trim=false keeps the alignment of
+// tabular output, including inside submenus.
+func TestTrimTable(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	p := &Plugin{}
+	items, err := p.parseOutput(ctx, "test.txt", strings.NewReader(`Processes
+---
+  PID  NAME|trim=false
+ 1234  xbar|trim=false
+Details
+--   CPU   12%|trim=false
+--   MEM   40%
+`))
+	is.NoErr(err)
+	is.Equal(len(items.ExpandedItems), 3)
+	is.Equal(items.ExpandedItems[0].Text, `  PID  NAME`)
+	is.Equal(items.ExpandedItems[1].Text, ` 1234  xbar`)
+	is.Equal(len(items.ExpandedItems[2].Items), 2)
+	is.Equal(items.ExpandedItems[2].Items[0].Text, `   CPU   12%`)
+	is.Equal(items.ExpandedItems[2].Items[1].Text, `MEM   40%`) // trimmed by default
+}
+
 func TestSeparator(t *testing.T) {
 	is := is.New(t)
 
@@ -264,11 +348,143 @@ func TestTruncate(t *testing.T) {
 	for input, expected := range map[string]string{
 		"basic characters":   "basic cha…",
 		"På tide å logge av": "På tide å…",
+		"short":              "short",
+		"👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽":                                                        "👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽…",
+		"🇬🇧🇺🇸🇫🇷🇩🇪🇯🇵🇮🇹🇪🇸🇨🇦🇧🇷🇦🇺🇳🇿":                                                        "🇬🇧🇺🇸🇫🇷🇩🇪🇯🇵🇮🇹🇪🇸🇨🇦🇧🇷…",
+		"👨‍👩‍👧 family of families":                                                      "👨‍👩‍👧 family …",
+		"e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301": "e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301e\u0301…",
 	} {
 		is.Equal(truncate(input, maxLen), expected)
 	}
 }
 
+func TestImages(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	p := &Plugin{}
+	items, err := p.parseOutput(ctx, "images.txt", strings.NewReader(strings.TrimSpace(`
+icon | image=iVBORw0KGgo=
+template | templateImage=iVBORw0KGgo=
+both | image=aW1hZ2U templateImage=dGVtcGxhdGU
+	`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 3)
+	image, template := items.CycleItems[0].Params.DisplayImage()
+	is.Equal(image, "iVBORw0KGgo=")
+	is.Equal(template, false)
+	image, template = items.CycleItems[1].Params.DisplayImage()
+	is.Equal(image, "iVBORw0KGgo=")
+	is.Equal(template, true)
+	image, template = items.CycleItems[2].Params.DisplayImage()
+	is.Equal(image, "dGVtcGxhdGU") // templateImage takes precedence
+	is.Equal(template, true)
+
+	// bad images are left out, without failing the plugin
+	var debug []string
+	p.Debugf = func(format string, v ...interface{}) {
+		debug = append(debug, fmt.Sprintf(format, v...))
+	}
+	items, err = p.parseOutput(ctx, "images.txt", strings.NewReader(`bad | templateImage=not*base64 color=red`))
+	is.NoErr(err)
+	is.Equal(items.CycleItems[0].Text, "bad")
+	image, _ = items.CycleItems[0].Params.DisplayImage()
+	is.Equal(image, "")
+	is.Equal(items.CycleItems[0].Params.Color, "#ff0000") // other params still apply
+	is.Equal(debug, []string{`ERR: images.txt:1: templateImage: expected base64 encoded image data or URL (ignoring the image)`})
+}
+
+func TestSFImage(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	p := &Plugin{}
+	items, err := p.parseOutput(ctx, "sfimage.txt", strings.NewReader(strings.TrimSpace(`
+Weather | sfimage=cloud.sun.fill
+Battery | sfimage=battery.100
+	`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 2)
+	is.Equal(items.CycleItems[0].Params.SFImage, "cloud.sun.fill")
+	is.Equal(items.CycleItems[1].Params.SFImage, "battery.100")
+
+	_, err = p.parseOutput(ctx, "sfimage.txt", strings.NewReader(`bad | sfimage=Not.A.Symbol`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `sfimage.txt:1: sfimage: invalid SF Symbol name "Not.A.Symbol"`)
+}
+
+func TestCopy(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	p := &Plugin{}
+	items, err := p.parseOutput(ctx, "copy.txt", strings.NewReader(strings.TrimSpace(`
+IP: 10.0.0.1 | copy=10.0.0.1
+abc123def456 | copy=
+A long commit message | copy= length=6
+No copy
+	`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 4)
+	is.Equal(items.CycleItems[0].Params.Copy, "10.0.0.1")
+	is.Equal(items.CycleItems[1].Params.Copy, "abc123def456")          // item text
+	is.Equal(items.CycleItems[2].Params.Copy, "A long commit message") // untruncated
+	is.Equal(items.CycleItems[3].Params.Copy, "")
+	is.True(items.CycleItems[0].Action() != nil)
+	is.True(items.CycleItems[3].Action() == nil)
+}
+
+func TestWebview(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	p := &Plugin{}
+	items, err := p.parseOutput(ctx, "webview.txt", strings.NewReader(strings.TrimSpace(`
+Dashboard | webview=https://xbarapp.com webvieww=400 webviewh=300
+Default size | webview=http://localhost:8080
+	`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 2)
+	is.Equal(items.CycleItems[0].Params.Webview, "https://xbarapp.com")
+	is.Equal(items.CycleItems[0].Params.WebviewWidth, 400)
+	is.Equal(items.CycleItems[0].Params.WebviewHeight, 300)
+	is.Equal(items.CycleItems[1].Params.Webview, "http://localhost:8080")
+	is.Equal(items.CycleItems[1].Params.WebviewWidth, 0)
+	is.Equal(items.CycleItems[1].Params.WebviewHeight, 0)
+
+	_, err = p.parseOutput(ctx, "webview.txt", strings.NewReader(`bad | webview=file:///etc/passwd`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `webview.txt:1: webview: expected an http or https URL, not "file:///etc/passwd"`)
+
+	_, err = p.parseOutput(ctx, "webview.txt", strings.NewReader(`bad | webview=https://xbarapp.com webviewh=0`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `webview.txt:1: webviewh: expected a positive int, not "0"`)
+}
+
+func TestFont(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	p := &Plugin{}
+	items, err := p.parseOutput(ctx, "fonts.txt", strings.NewReader(strings.TrimSpace(`
+PID   CPU | font=Menlo size=12
+1234  3.2 | font="Courier New" size=10
+plain
+	`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 3)
+	is.Equal(items.CycleItems[0].Params.Font, "Menlo")
+	is.Equal(items.CycleItems[0].Params.Size, 12)
+	is.Equal(items.CycleItems[1].Params.Font, "Courier New")
+	is.Equal(items.CycleItems[1].Params.Size, 10)
+	is.Equal(items.CycleItems[2].Params.Font, "")
+	is.Equal(items.CycleItems[2].Params.Size, 0)
+
+	_, err = p.parseOutput(ctx, "fonts.txt", strings.NewReader(`bad | size=0`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `fonts.txt:1: size: expected a positive int, not "0"`)
+}
+
 func TestGoodColors(t *testing.T) {
 	is := is.New(t)
 
@@ -292,6 +508,44 @@ func TestGoodColors(t *testing.T) {
 	is.Equal(items.CycleItems[5].Params.Color, `#9400d3`)
 }
 
+func TestColorPairs(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	p := &Plugin{}
+	items, err := p.parseOutput(ctx, "colors.txt", strings.NewReader(strings.TrimSpace(`
+pair | color=black,white
+spaced | color="#333, #ccc"
+semantic | color=success
+mixed | color=danger,#eee
+	`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 4)
+	is.Equal(items.CycleItems[0].Params.Color, `#000000`)
+	is.Equal(items.CycleItems[0].Params.DarkColor, `#ffffff`)
+	is.Equal(items.CycleItems[0].Params.DisplayColor(false), `#000000`)
+	is.Equal(items.CycleItems[0].Params.DisplayColor(true), `#ffffff`)
+	is.Equal(items.CycleItems[1].Params.Color, `#333`)
+	is.Equal(items.CycleItems[1].Params.DarkColor, `#ccc`)
+	is.Equal(items.CycleItems[2].Params.Color, `#28cd41`)
+	is.Equal(items.CycleItems[2].Params.DarkColor, `#32d74b`)
+	is.Equal(items.CycleItems[3].Params.Color, `#ff3b30`)
+	is.Equal(items.CycleItems[3].Params.DarkColor, `#eee`)
+
+	// single colors are the same in dark mode
+	items, err = p.parseOutput(ctx, "colors.txt", strings.NewReader(`single | color=red`))
+	is.NoErr(err)
+	is.Equal(items.CycleItems[0].Params.DarkColor, ``)
+	is.Equal(items.CycleItems[0].Params.DisplayColor(true), `#ff0000`)
+
+	_, err = p.parseOutput(ctx, "colors.txt", strings.NewReader(`bad | color=red,green,blue`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `colors.txt:1: color: expected a color or a light,dark pair, not "red,green,blue"`)
+	_, err = p.parseOutput(ctx, "colors.txt", strings.NewReader(`bad | color=red,nope`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `colors.txt:1: color: invalid named color "nope"`)
+}
+
 func TestBadColors(t *testing.T) {
 	is := is.New(t)
 
@@ -0,0 +1,30 @@
+package plugins
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// jitterRand is the random source for jitter, which is shared by
+// all plugins, so it is protected by jitterLock.
+var (
+	jitterLock sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// randomJitter gets a random number between -1 and 1.
+var randomJitter = func() float64 {
+	jitterLock.Lock()
+	defer jitterLock.Unlock()
+	return jitterRand.Float64()*2 - 1
+}
+
+// addJitter randomly lengthens or shortens the delay by up to
+// jitter (a fraction, like 0.1 for ±10%).
+func addJitter(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return delay
+	}
+	return delay + time.Duration(float64(delay)*jitter*randomJitter())
+}
@@ -0,0 +1,247 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// jsonMenu is the JSON output of a plugin.
+// Title may be a string, an item, or an array of strings and items
+// (which are cycled through in the menu bar).
+type jsonMenu struct {
+	Title  json.RawMessage `json:"title"`
+	Items  []jsonItem      `json:"items"`
+	Splits []jsonMenu      `json:"splits"`
+}
+
+// jsonItem is an item in the JSON output of a plugin.
+// Apart from the keys below, every key is a parameter (like color
+// or href), with the same meaning and validation as the line format.
+// Parameters that can be repeated (like shell) may be arrays.
+//
+//	text       - the text of the item
+//	items      - the items in its submenu
+//	alternate  - the item to show when the option key is held
+//	separator  - true for a separator
+//	header     - the text of a section header
+type jsonItem map[string]json.RawMessage
+
+// isJSONOutput gets whether the plugin output is JSON, which is the
+// case when the first non-space character is {.
+// Nothing is read from br.
+func isJSONOutput(br *bufio.Reader) bool {
+	for n := 1; n <= br.Size(); n++ {
+		b, err := br.Peek(n)
+		if err != nil {
+			return false
+		}
+		if c := rune(b[n-1]); !unicode.IsSpace(c) {
+			return c == '{'
+		}
+	}
+	return false
+}
+
+// parseJSONOutput parses plugin output in the JSON format.
+func (p *Plugin) parseJSONOutput(filename string, r io.Reader) (Items, error) {
+	var menu jsonMenu
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&menu); err != nil {
+		return Items{}, errors.Wrapf(err, "%s: json", filename)
+	}
+	items, err := p.jsonMenuItems(menu)
+	if err != nil {
+		return Items{}, errors.Wrapf(err, "%s", filename)
+	}
+	for i, split := range menu.Splits {
+		if len(split.Splits) > 0 {
+			return Items{}, errors.Errorf("%s: splits[%d]: splits cannot be nested", filename, i)
+		}
+		splitItems, err := p.jsonMenuItems(split)
+		if err != nil {
+			return Items{}, errors.Wrapf(err, "%s: splits[%d]", filename, i)
+		}
+		items.Splits = append(items.Splits, splitItems)
+	}
+	return items, nil
+}
+
+// jsonMenuItems makes the Items for a jsonMenu, ignoring its splits.
+func (p *Plugin) jsonMenuItems(menu jsonMenu) (Items, error) {
+	var items Items
+	titles, err := parseJSONTitle(menu.Title)
+	if err != nil {
+		return items, errors.Wrap(err, "title")
+	}
+	for i, title := range titles {
+		item, err := p.newJSONItem(title)
+		if err != nil {
+			return items, errors.Wrapf(err, "title[%d]", i)
+		}
+		if item != nil {
+			items.CycleItems = append(items.CycleItems, item)
+		}
+	}
+	items.ExpandedItems, err = p.newJSONItems(menu.Items)
+	if err != nil {
+		return items, errors.Wrap(err, "items")
+	}
+	alignColumns(items.ExpandedItems)
+	return items, nil
+}
+
+// parseJSONTitle parses the title, which may be a string, an item,
+// or an array of items.
+func parseJSONTitle(raw json.RawMessage) ([]jsonItem, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	switch raw[0] {
+	case '"':
+		return []jsonItem{{"text": raw}}, nil
+	case '{':
+		var item jsonItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return nil, err
+		}
+		return []jsonItem{item}, nil
+	}
+	var elements []json.RawMessage
+	if raw[0] != '[' || json.Unmarshal(raw, &elements) != nil {
+		return nil, errors.New("expected a string, an item, or an array of items")
+	}
+	var titles []jsonItem
+	for i, element := range elements {
+		element = bytes.TrimSpace(element)
+		if len(element) == 0 || (element[0] != '"' && element[0] != '{') {
+			return nil, errors.Errorf("[%d]: expected a string or an item", i)
+		}
+		title, err := parseJSONTitle(element)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[%d]", i)
+		}
+		titles = append(titles, title...)
+	}
+	return titles, nil
+}
+
+// newJSONItems makes the Items for a list of jsonItem.
+// Items with dropdown=false are left out.
+func (p *Plugin) newJSONItems(src []jsonItem) ([]*Item, error) {
+	var items []*Item
+	for i, jsonItem := range src {
+		item, err := p.newJSONItem(jsonItem)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[%d]", i)
+		}
+		if item == nil || !item.Params.Dropdown {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// newJSONItem makes an Item from a jsonItem.
+func (p *Plugin) newJSONItem(src jsonItem) (*Item, error) {
+	var (
+		text, header string
+		isSeparator  bool
+		children     []jsonItem
+		alternate    jsonItem
+	)
+	params := defaultParams
+	for key, raw := range src {
+		var err error
+		switch key {
+		case "text":
+			err = json.Unmarshal(raw, &text)
+		case "header":
+			err = json.Unmarshal(raw, &header)
+		case "separator":
+			err = json.Unmarshal(raw, &isSeparator)
+		case "items":
+			err = json.Unmarshal(raw, &children)
+		case "alternate":
+			err = json.Unmarshal(raw, &alternate)
+		default:
+			// errors already mention the key
+			err := setJSONParam(&params, key, raw)
+			if _, ok := err.(errInvalidImage); ok {
+				p.warn(errors.Errorf("%s (ignoring the image)", err))
+				err = nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, key)
+		}
+	}
+	if isSeparator {
+		params.Separator = true
+		return &Item{
+			Plugin: p,
+			Params: params,
+		}, nil
+	}
+	var item *Item
+	if header != "" {
+		item = p.newHeader(header, params)
+	} else {
+		item = p.newItem(text, params)
+	}
+	var err error
+	item.Items, err = p.newJSONItems(children)
+	if err != nil {
+		return nil, errors.Wrap(err, "items")
+	}
+	if alternate != nil {
+		item.Alternate, err = p.newJSONItem(alternate)
+		if err != nil {
+			return nil, errors.Wrap(err, "alternate")
+		}
+		item.Alternate.Params.Alternate = true
+	}
+	return item, nil
+}
+
+// setJSONParam sets a parameter from its JSON value, which may be
+// a string, number, bool, or an array of them.
+func setJSONParam(params *ItemParams, key string, raw json.RawMessage) error {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return errors.Wrap(err, key)
+	}
+	values, isArray := value.([]interface{})
+	if !isArray {
+		values = []interface{}{value}
+	}
+	for _, value := range values {
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case bool:
+			s = strconv.FormatBool(v)
+		case float64:
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return errors.Errorf("%s: expected a string, number or bool, not %s", key, raw)
+		}
+		if err := params.setValueByKey(key, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseJSONOutput(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "json.txt", strings.NewReader(`
+	{
+		"title": [
+			{"text": "☀️ 21°", "color": "orange"},
+			"not an item"
+		],
+		"items": [
+			{"header": "Forecast"},
+			{"text": "Pipes | in text are fine", "href": "https://xbarapp.com", "length": 10},
+			{"separator": true},
+			{
+				"text": "More",
+				"items": [
+					{"text": "Tomorrow", "shell": ["echo one", "echo two"], "refresh": true}
+				]
+			},
+			{"text": "Click me", "alternate": {"text": "Option click me"}},
+			{"text": "Hidden", "dropdown": false}
+		],
+		"splits": [
+			{"title": "🌧", "items": [{"text": "Rain"}]}
+		]
+	}`))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 2)
+	is.Equal(items.CycleItems[0].Text, "☀️ 21°")
+	is.Equal(items.CycleItems[0].Params.Color, "#ffa500")
+	is.Equal(items.CycleItems[1].Text, "not an item") // strings are the same as {"text":...}
+	expanded := items.ExpandedItems
+	is.Equal(len(expanded), 5)
+	is.Equal(expanded[0].Text, "Forecast")
+	is.Equal(expanded[0].Params.Header, true)
+	is.Equal(expanded[1].Text, "Pipes | i…")
+	is.Equal(expanded[1].FullText, "Pipes | in text are fine")
+	is.Equal(expanded[1].Params.Href, "https://xbarapp.com")
+	is.Equal(expanded[2].Params.Separator, true)
+	is.Equal(len(expanded[3].Items), 1)
+	tomorrow := expanded[3].Items[0]
+	is.Equal(tomorrow.Params.Shell, "echo one")
+	is.Equal(tomorrow.Params.ShellSteps, []string{"echo two"})
+	is.Equal(tomorrow.Params.Refresh, true)
+	is.Equal(expanded[4].Alternate.Text, "Option click me")
+	is.Equal(expanded[4].Alternate.Params.Alternate, true)
+	is.Equal(len(items.Splits), 1)
+	is.Equal(items.Splits[0].CycleItems[0].Text, "🌧")
+	is.Equal(items.Splits[0].ExpandedItems[0].Text, "Rain")
+}
+
+func TestParseJSONOutputErrors(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	for src, expected := range map[string]string{
+		`{"title": "x", "items": [{"text": "a", "color": "nope"}]}`: `json.txt: items: [0]: color: invalid named color "nope"`,
+		`{"title": "x", "items": [{"text": 1}]}`:                    `json.txt: items: [0]: text: json: cannot unmarshal number into Go value of type string`,
+		`{"title": 42}`:                                             `json.txt: title: expected a string, an item, or an array of items`,
+		`{"title": [1]}`:                                            `json.txt: title: [0]: expected a string or an item`,
+		`{"title": "x", "unknown": true}`:                           `json.txt: json: json: unknown field "unknown"`,
+		`{"title": "x"`:                                             `json.txt: json: unexpected EOF`,
+	} {
+		_, err := p.parseOutput(context.Background(), "json.txt", strings.NewReader(src))
+		is.True(err != nil)
+		is.Equal(err.Error(), expected)
+	}
+}
@@ -0,0 +1,145 @@
+package plugins
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultLogMaxBytes is how big a plugin's log file gets before
+	// it is rotated, unless Plugin.LogMaxBytes says otherwise.
+	defaultLogMaxBytes = 1 << 20 // 1MB
+	// defaultLogMaxFiles is how many rotated log files are kept,
+	// unless Plugin.LogMaxFiles says otherwise.
+	defaultLogMaxFiles = 3
+	// maxTailLogBytes is how much of the end of a log file TailLog
+	// reads.
+	maxTailLogBytes = 256 << 10 // 256KB
+)
+
+// LogFilename gets the name of the log file in dir for the plugin
+// file.
+func LogFilename(dir, pluginFilename string) string {
+	return filepath.Join(dir, StateName(filepath.Base(pluginFilename))+".log")
+}
+
+// TailLog gets the last lines of a log file.
+// If the file doesn't exist, there is nothing logged.
+func TailLog(filename string, lines int) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "open log")
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", errors.Wrap(err, "stat log")
+	}
+	offset := info.Size() - maxTailLogBytes
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", errors.Wrap(err, "seek log")
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return "", errors.Wrap(err, "read log")
+	}
+	s := strings.TrimSuffix(string(b), "\n")
+	all := strings.Split(s, "\n")
+	if offset > 0 {
+		// the first line is probably only part of one
+		all = all[1:]
+	}
+	if lines > 0 && len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	return strings.Join(all, "\n"), nil
+}
+
+// logRun writes the run, along with all of its output, to the
+// plugin's LogFile, if it has one, rotating the file when it gets
+// bigger than LogMaxBytes.
+func (p *Plugin) logRun(run Run, stdout []byte, stderr string) error {
+	if p.LogFile == "" {
+		return nil
+	}
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "=== %s ran for %s, exit code %d\n", run.Time.Format("2006-01-02T15:04:05.000Z07:00"), run.Duration, run.ExitCode)
+	if run.Error != "" {
+		fmt.Fprintf(&entry, "error: %s\n", strings.TrimSpace(run.Error))
+	}
+	if len(stdout) > 0 {
+		entry.WriteString("--- stdout\n")
+		entry.Write(stdout)
+		if !strings.HasSuffix(string(stdout), "\n") {
+			entry.WriteString("\n")
+		}
+	}
+	if stderr != "" {
+		entry.WriteString("--- stderr\n")
+		entry.WriteString(stderr)
+		if !strings.HasSuffix(stderr, "\n") {
+			entry.WriteString("\n")
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(p.LogFile), 0777); err != nil {
+		return errors.Wrap(err, "make log directory")
+	}
+	maxBytes := p.LogMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultLogMaxBytes
+	}
+	info, err := os.Stat(p.LogFile)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "stat log")
+	}
+	if err == nil && info.Size() > 0 && info.Size()+int64(entry.Len()) > maxBytes {
+		if err := p.rotateLog(); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(p.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return errors.Wrap(err, "open log")
+	}
+	defer f.Close()
+	if _, err := io.WriteString(f, entry.String()); err != nil {
+		return errors.Wrap(err, "write log")
+	}
+	return nil
+}
+
+// rotateLog moves the LogFile to LogFile.1 (and LogFile.1 to
+// LogFile.2, and so on), removing the oldest so only LogMaxFiles
+// rotated files are kept.
+func (p *Plugin) rotateLog() error {
+	maxFiles := p.LogMaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultLogMaxFiles
+	}
+	oldest := fmt.Sprintf("%s.%d", p.LogFile, maxFiles)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove oldest log")
+	}
+	for i := maxFiles - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", p.LogFile, i)
+		to := fmt.Sprintf("%s.%d", p.LogFile, i+1)
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "rotate log")
+		}
+	}
+	if err := os.Rename(p.LogFile, p.LogFile+".1"); err != nil {
+		return errors.Wrap(err, "rotate log")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLogs(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-logs-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "counter.1m.sh")
+	script := "#!/bin/bash\necho run >> runs.txt\nRUNS=$(( $(wc -l < runs.txt) ))\necho \"$RUNS runs\"\nif [ $RUNS = 2 ]; then\n>&2 echo 'second run'\nexit 3\nfi\n"
+	err = os.WriteFile(command, []byte(script), 0777)
+	is.NoErr(err)
+	logFile := LogFilename(filepath.Join(dir, "logs"), command)
+	is.Equal(logFile, filepath.Join(dir, "logs", "counter.sh.log"))
+
+	tail, err := TailLog(logFile, 10)
+	is.NoErr(err)
+	is.Equal(tail, "") // nothing logged yet
+
+	p := NewPlugin(command)
+	p.LogFile = logFile
+	p.Refresh(context.Background())
+	p.Refresh(context.Background())
+	tail, err = TailLog(logFile, 0)
+	is.NoErr(err)
+	lines := strings.Split(tail, "\n")
+	is.Equal(len(lines), 9)
+	is.True(strings.HasPrefix(lines[0], "=== "))
+	is.True(strings.HasSuffix(lines[0], "exit code 0"))
+	is.Equal(lines[1], "--- stdout")
+	is.Equal(lines[2], "1 runs")
+	is.True(strings.HasSuffix(lines[3], "exit code 3"))
+	is.Equal(lines[4], "error: exit status 3: second run")
+	is.Equal(lines[8], "second run")
+
+	tail, err = TailLog(logFile, 2)
+	is.NoErr(err)
+	is.Equal(tail, "--- stderr\nsecond run")
+
+	// rotate when the log gets too big, keeping LogMaxFiles
+	p.LogMaxBytes = 100
+	p.LogMaxFiles = 2
+	for i := 0; i < 5; i++ {
+		p.Refresh(context.Background())
+	}
+	for _, filename := range []string{logFile, logFile + ".1", logFile + ".2"} {
+		info, err := os.Stat(filename)
+		is.NoErr(err)
+		is.True(info.Size() <= 100)
+	}
+	_, err = os.Stat(logFile + ".3")
+	is.True(os.IsNotExist(err))
+	tail, err = TailLog(logFile, 1)
+	is.NoErr(err)
+	is.Equal(tail, "7 runs")
+}
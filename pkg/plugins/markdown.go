@@ -0,0 +1,127 @@
+package plugins
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// markdownEscapable are the characters that may be escaped with
+// a backslash in Markdown text.
+const markdownEscapable = "\\`*_~"
+
+// parseMarkdown parses inline Markdown (**bold**, *italic*, `code`
+// and ~~strikethrough~~) out of s.
+// It returns the plain text, and the styled segments that make up
+// the text.
+// Delimiters without a matching closing delimiter are left as they
+// are.
+func parseMarkdown(s string) (string, []*TextSegment) {
+	return parseMarkdownSegments([]*TextSegment{{Text: s}})
+}
+
+// parseMarkdownSegments parses inline Markdown within each of the
+// segments (which may already be styled by ANSI codes).
+// Markdown spans cannot cross segment boundaries.
+func parseMarkdownSegments(segments []*TextSegment) (string, []*TextSegment) {
+	var out []*TextSegment
+	for _, segment := range segments {
+		appendMarkdown(&out, *segment, segment.Text)
+	}
+	var plain strings.Builder
+	for _, segment := range out {
+		plain.WriteString(segment.Text)
+	}
+	return plain.String(), out
+}
+
+// appendMarkdown parses s, appending the segments to out.
+// style is the style of the text outside of any Markdown span.
+func appendMarkdown(out *[]*TextSegment, style TextSegment, s string) {
+	var current strings.Builder
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+		segment := style
+		segment.Text = current.String()
+		*out = append(*out, &segment)
+		current.Reset()
+	}
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.IndexByte(markdownEscapable, rest[1]) > -1:
+			current.WriteByte(rest[1])
+			i += 2
+			continue
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				flush()
+				code := style
+				code.Monospace = true
+				code.Text = rest[1 : end+1]
+				*out = append(*out, &code)
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**"), strings.HasPrefix(rest, "__"), strings.HasPrefix(rest, "~~"):
+			if inner, ok := markdownSpan(s, i, rest[:2]); ok {
+				flush()
+				nested := style
+				if rest[0] == '~' {
+					nested.Strikethrough = true
+				} else {
+					nested.Bold = true
+				}
+				appendMarkdown(out, nested, inner)
+				i += len(inner) + 4
+				continue
+			}
+		case rest[0] == '*', rest[0] == '_':
+			if inner, ok := markdownSpan(s, i, rest[:1]); ok {
+				flush()
+				nested := style
+				nested.Italic = true
+				appendMarkdown(out, nested, inner)
+				i += len(inner) + 2
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		current.WriteString(rest[:size])
+		i += size
+	}
+	flush()
+}
+
+// markdownSpan finds the text between the delimiter at s[i:] and
+// its closing delimiter.
+// Spans must not be empty or start or end with a space, and
+// underscores only count at word boundaries (so snake_case is left
+// alone).
+func markdownSpan(s string, i int, delim string) (string, bool) {
+	rest := s[i+len(delim):]
+	end := strings.Index(rest, delim)
+	if end <= 0 {
+		return "", false
+	}
+	inner := rest[:end]
+	if strings.HasPrefix(inner, " ") || strings.HasSuffix(inner, " ") {
+		return "", false
+	}
+	if delim[0] == '_' {
+		if before, _ := utf8.DecodeLastRuneInString(s[:i]); i > 0 && isWordRune(before) {
+			return "", false
+		}
+		if after, _ := utf8.DecodeRuneInString(rest[end+len(delim):]); end+len(delim) < len(rest) && isWordRune(after) {
+			return "", false
+		}
+	}
+	return inner, true
+}
+
+// isWordRune gets whether r is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	is := is.New(t)
+
+	text, segments := parseMarkdown("plain text")
+	is.Equal(text, "plain text")
+	is.Equal(len(segments), 1)
+	is.Equal(segments[0].Bold, false)
+
+	text, segments = parseMarkdown("**bold**, *italic*, `code` and ~~gone~~")
+	is.Equal(text, "bold, italic, code and gone")
+	is.Equal(len(segments), 7)
+	is.Equal(segments[0].Text, "bold")
+	is.Equal(segments[0].Bold, true)
+	is.Equal(segments[1].Text, ", ")
+	is.Equal(segments[1].Bold, false)
+	is.Equal(segments[2].Text, "italic")
+	is.Equal(segments[2].Italic, true)
+	is.Equal(segments[4].Text, "code")
+	is.Equal(segments[4].Monospace, true)
+	is.Equal(segments[6].Text, "gone")
+	is.Equal(segments[6].Strikethrough, true)
+
+	// nested spans
+	text, segments = parseMarkdown("__bold _and italic_ text__")
+	is.Equal(text, "bold and italic text")
+	is.Equal(len(segments), 3)
+	is.Equal(segments[1].Text, "and italic")
+	is.Equal(segments[1].Bold, true)
+	is.Equal(segments[1].Italic, true)
+
+	// code spans are literal
+	text, segments = parseMarkdown("`**not bold**`")
+	is.Equal(text, "**not bold**")
+	is.Equal(segments[0].Bold, false)
+
+	// things that are not Markdown are left alone
+	for _, s := range []string{
+		"2 * 3 * 4",
+		"some_snake_case_name",
+		"unclosed **bold",
+		"a lone ` backtick",
+		"**",
+	} {
+		text, _ = parseMarkdown(s)
+		is.Equal(text, s)
+	}
+
+	// escapes
+	text, _ = parseMarkdown(`\*not italic\*`)
+	is.Equal(text, "*not italic*")
+}
+
+func TestParseOutputMarkdown(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "md.txt", strings.NewReader(strings.TrimSpace(`
+Build **passed** | md=true
+Build **passed**
+`+"\x1b[31mfailed: `make test`\x1b[0m | md=true"+`
+`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 3)
+	is.Equal(items.CycleItems[0].Text, "Build passed")
+	is.Equal(len(items.CycleItems[0].Segments), 2)
+	is.Equal(items.CycleItems[0].Segments[1].Bold, true)
+	is.Equal(items.CycleItems[1].Text, "Build **passed**") // md is off by default
+	is.Equal(items.CycleItems[2].Text, "failed: make test")
+	is.Equal(items.CycleItems[2].Segments[1].Monospace, true)
+	is.Equal(items.CycleItems[2].Segments[1].Color, "#cd0000") // keeps ANSI style
+}
@@ -0,0 +1,153 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/matryer/xbar/pkg/metadata"
+	"github.com/pkg/errors"
+)
+
+// MigrationSource is the plugin folder of another app (like BitBar
+// or SwiftBar) to import plugins from.
+type MigrationSource struct {
+	// App is the name of the app, like BitBar.
+	App string `json:"app"`
+	// PluginDir is the app's plugin folder.
+	PluginDir string `json:"pluginDir"`
+}
+
+// MigrationItem describes a plugin that is (or would be) imported.
+type MigrationItem struct {
+	// App is the name of the app the plugin is from.
+	App string `json:"app"`
+	// Source is the plugin file in the app's plugin folder.
+	Source string `json:"source"`
+	// Destination is where the plugin is copied to.
+	Destination string `json:"destination"`
+	// Variables are the values written to the plugin's .vars.json
+	// file, if any.
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	// Skipped is why the plugin isn't imported, or empty if it is.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// MigrationReport describes what Migrate did, or would do in a
+// dry run.
+type MigrationReport struct {
+	DryRun bool            `json:"dryRun"`
+	Items  []MigrationItem `json:"items"`
+}
+
+// swiftBarEnvironmentRegexp matches SwiftBar's environment metadata,
+// like <swiftbar.environment>[VAR_NAME=Mat, VAR_CITY=London]</swiftbar.environment>.
+var swiftBarEnvironmentRegexp = regexp.MustCompile(`<swiftbar\.environment>\s*\[(.*)\]\s*</swiftbar\.environment>`)
+
+// Migrate copies the plugins from the sources into pluginDir, along
+// with their variables.
+// Variables in .vars.json files (which SwiftBar also uses) are copied,
+// and the values in SwiftBar's <swiftbar.environment> metadata become
+// variables.
+// Plugins that are already in pluginDir are skipped.
+// If dryRun is true, nothing is changed, and the report describes
+// what would be imported.
+func Migrate(pluginDir string, sources []MigrationSource, dryRun bool) (*MigrationReport, error) {
+	report := &MigrationReport{
+		DryRun: dryRun,
+	}
+	if !dryRun {
+		if err := os.MkdirAll(pluginDir, 0777); err != nil {
+			return nil, errors.Wrap(err, "make plugin directory")
+		}
+	}
+	for _, source := range sources {
+		files, err := ioutil.ReadDir(source.PluginDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return report, errors.Wrapf(err, "read %s plugins", source.App)
+		}
+		for _, file := range files {
+			if file.IsDir() || !file.Mode().IsRegular() {
+				continue
+			}
+			name := file.Name()
+			if strings.HasPrefix(name, ".") || strings.HasSuffix(name, variableJSONFileExt) || isSidecarFile(name) {
+				continue
+			}
+			item, err := migratePlugin(pluginDir, source, name, dryRun)
+			if err != nil {
+				return report, errors.Wrapf(err, "import %s", name)
+			}
+			report.Items = append(report.Items, item)
+		}
+	}
+	return report, nil
+}
+
+// migratePlugin imports a single plugin.
+func migratePlugin(pluginDir string, source MigrationSource, name string, dryRun bool) (MigrationItem, error) {
+	item := MigrationItem{
+		App:         source.App,
+		Source:      filepath.Join(source.PluginDir, name),
+		Destination: filepath.Join(pluginDir, name),
+	}
+	if _, err := os.Stat(item.Destination); err == nil {
+		item.Skipped = "already installed"
+		return item, nil
+	}
+	b, err := ioutil.ReadFile(item.Source)
+	if err != nil {
+		return item, errors.Wrap(err, "read plugin")
+	}
+	item.Variables, err = migrationVariables(item.Source, string(b))
+	if err != nil {
+		return item, err
+	}
+	if dryRun {
+		return item, nil
+	}
+	if err := ioutil.WriteFile(item.Destination, b, 0755); err != nil {
+		return item, errors.Wrap(err, "write plugin")
+	}
+	if len(item.Variables) > 0 {
+		b, err := json.MarshalIndent(item.Variables, "", "\t")
+		if err != nil {
+			return item, errors.Wrap(err, "json.MarshalIndent")
+		}
+		if err := ioutil.WriteFile(item.Destination+variableJSONFileExt, b, 0666); err != nil {
+			return item, errors.Wrap(err, "write variables")
+		}
+	}
+	return item, nil
+}
+
+// migrationVariables gets the variables for a plugin being imported,
+// from its .vars.json file, or SwiftBar's environment metadata.
+func migrationVariables(pluginFile, content string) (map[string]interface{}, error) {
+	b, err := ioutil.ReadFile(pluginFile + variableJSONFileExt)
+	if err == nil {
+		var values map[string]interface{}
+		if err := json.Unmarshal(b, &values); err != nil {
+			return nil, errors.Wrap(err, "json.Unmarshal")
+		}
+		return values, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "read variables")
+	}
+	match := swiftBarEnvironmentRegexp.FindStringSubmatch(content)
+	if match == nil {
+		return nil, nil
+	}
+	values := make(map[string]interface{})
+	for _, v := range metadata.SwiftBarEnvironment(match[1]) {
+		values[v.Name] = v.Default
+	}
+	return values, nil
+}
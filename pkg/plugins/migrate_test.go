@@ -0,0 +1,72 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestMigrate(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-migrate-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	bitbarDir := filepath.Join(dir, "bitbar")
+	swiftbarDir := filepath.Join(dir, "swiftbar")
+	pluginDir := filepath.Join(dir, "xbar")
+	for filename, content := range map[string]string{
+		filepath.Join(bitbarDir, "weather.1h.sh"):           "#!/bin/bash\necho weather",
+		filepath.Join(bitbarDir, "weather.1h.sh.vars.json"): `{"VAR_CITY": "London"}`,
+		filepath.Join(bitbarDir, ".hidden.sh"):              "#!/bin/bash\necho hidden",
+		filepath.Join(bitbarDir, "lib", "helper.sh"):        "#!/bin/bash\necho helper",
+		filepath.Join(swiftbarDir, "clock.1s.sh"):           "#!/bin/bash\n# <swiftbar.environment>[VAR_ZONE=UTC, VAR_FORMAT=%H:%M]</swiftbar.environment>\ndate",
+		filepath.Join(swiftbarDir, "already-installed.sh"):  "#!/bin/bash\necho new",
+		filepath.Join(pluginDir, "already-installed.sh"):    "#!/bin/bash\necho old",
+		filepath.Join(swiftbarDir, "no-vars.5m.py"):         "#!/usr/bin/env python3\nprint('hi')",
+	} {
+		is.NoErr(os.MkdirAll(filepath.Dir(filename), 0777))
+		is.NoErr(ioutil.WriteFile(filename, []byte(content), 0777))
+	}
+	sources := []MigrationSource{
+		{App: "BitBar", PluginDir: bitbarDir},
+		{App: "SwiftBar", PluginDir: swiftbarDir},
+		{App: "Missing", PluginDir: filepath.Join(dir, "missing")},
+	}
+
+	report, err := Migrate(pluginDir, sources, true)
+	is.NoErr(err)
+	is.True(report.DryRun)
+	is.Equal(len(report.Items), 4)
+	is.Equal(report.Items[0].App, "BitBar")
+	is.Equal(report.Items[0].Destination, filepath.Join(pluginDir, "weather.1h.sh"))
+	is.Equal(report.Items[0].Variables["VAR_CITY"], "London")
+	is.Equal(report.Items[1].Skipped, "already installed")
+	is.Equal(report.Items[2].Variables["VAR_ZONE"], "UTC")
+	is.Equal(report.Items[2].Variables["VAR_FORMAT"], "%H:%M")
+	_, err = os.Stat(filepath.Join(pluginDir, "weather.1h.sh"))
+	is.True(os.IsNotExist(err)) // dry run changes nothing
+
+	report, err = Migrate(pluginDir, sources, false)
+	is.NoErr(err)
+	is.True(!report.DryRun)
+	values, err := LoadVariableValues(pluginDir, "weather.1h.sh")
+	is.NoErr(err)
+	is.Equal(values["VAR_CITY"], "London")
+	values, err = LoadVariableValues(pluginDir, "clock.1s.sh")
+	is.NoErr(err)
+	is.Equal(values["VAR_ZONE"], "UTC")
+	b, err := ioutil.ReadFile(filepath.Join(pluginDir, "already-installed.sh"))
+	is.NoErr(err)
+	is.Equal(string(b), "#!/bin/bash\necho old") // not overwritten
+	info, err := os.Stat(filepath.Join(pluginDir, "no-vars.5m.py"))
+	is.NoErr(err)
+	is.True(info.Mode()&0100 != 0) // executable
+	_, err = os.Stat(filepath.Join(pluginDir, "no-vars.5m.py.vars.json"))
+	is.True(os.IsNotExist(err))
+}
@@ -0,0 +1,47 @@
+package plugins
+
+import (
+	"context"
+)
+
+// Notification is a notification that a plugin asked to show, with
+// an item with notify=true.
+type Notification struct {
+	// Title is the title of the notification, or empty for the
+	// name of the plugin.
+	Title string `json:"title"`
+	// Body is the message of the notification.
+	Body string `json:"body"`
+}
+
+// notifications gets the Notifications of the items with
+// notify=true. Items without a body use their text.
+func (items Items) notifications() []Notification {
+	var notifications []Notification
+	for _, list := range [][]*Item{items.CycleItems, items.ExpandedItems} {
+		for _, item := range list {
+			if !item.Params.Notify {
+				continue
+			}
+			body := item.Params.NotifyBody
+			if body == "" {
+				body = item.Text
+			}
+			notifications = append(notifications, Notification{
+				Title: item.Params.NotifyTitle,
+				Body:  body,
+			})
+		}
+	}
+	return notifications
+}
+
+// notify calls OnNotify with the Notifications in the Items.
+func (p *Plugin) notify(ctx context.Context) {
+	if p.OnNotify == nil {
+		return
+	}
+	for _, notification := range p.Items.notifications() {
+		p.OnNotify(ctx, p, notification)
+	}
+}
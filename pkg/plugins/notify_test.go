@@ -0,0 +1,55 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseOutputNotify(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "notify.txt", strings.NewReader(strings.TrimSpace(`
+Build failed | notify=true title="CI" body="main is broken"
+---
+Tests failed | notify=true
+Quiet
+`)))
+	is.NoErr(err)
+	notifications := items.notifications()
+	is.Equal(len(notifications), 2)
+	is.Equal(notifications[0], Notification{Title: "CI", Body: "main is broken"})
+	is.Equal(notifications[1], Notification{Body: "Tests failed"}) // uses the text
+
+	_, err = p.parseOutput(context.Background(), "notify.txt", strings.NewReader(`Bad | notify=yes`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `notify.txt:1: notify: expected "true" or "false", not "yes"`)
+}
+
+func TestNotify(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-notify-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "build.1m.sh")
+	err = os.WriteFile(command, []byte("#!/bin/bash\necho 'Build failed | notify=true'\n"), 0777)
+	is.NoErr(err)
+
+	var notifications []Notification
+	p := NewPlugin(command)
+	p.OnNotify = func(ctx context.Context, p *Plugin, notification Notification) {
+		notifications = append(notifications, notification)
+	}
+	p.Refresh(context.Background())
+	p.Refresh(context.Background())
+	is.Equal(len(notifications), 1) // not again, since nothing changed
+	is.Equal(notifications[0].Body, "Build failed")
+}
@@ -0,0 +1,126 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// LoadOrder loads the order of the plugins in the menu bar from the
+// file, as the names of the plugins (see StateName), so the order
+// survives their refresh time changing, or being disabled.
+// If there is no file, there is no order.
+func LoadOrder(filename string) ([]string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "ReadFile")
+	}
+	var order []string
+	if err := json.Unmarshal(b, &order); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	return order, nil
+}
+
+// SaveOrder saves the order of the plugins in the menu bar to the
+// file.
+func SaveOrder(filename string, order []string) error {
+	b, err := json.MarshalIndent(order, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "json.MarshalIndent")
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return errors.Wrap(err, "MkdirAll")
+	}
+	if err := ioutil.WriteFile(filename, b, 0666); err != nil {
+		return errors.Wrap(err, "WriteFile")
+	}
+	return nil
+}
+
+// Sort sorts the plugins into the order (see LoadOrder).
+// Plugins that aren't in the order go after the ones that are, in
+// the order they were in.
+func (p Plugins) Sort(order []string) {
+	positions := orderPositions(order)
+	sort.SliceStable(p, func(i, j int) bool {
+		return orderPosition(positions, p[i].Command) < orderPosition(positions, p[j].Command)
+	})
+}
+
+// SortInstalledPlugins sorts the installed plugins into the order
+// (see LoadOrder).
+// Plugins that aren't in the order go after the ones that are, in
+// the order they were in.
+func SortInstalledPlugins(installedPlugins []InstalledPlugin, order []string) {
+	positions := orderPositions(order)
+	sort.SliceStable(installedPlugins, func(i, j int) bool {
+		return orderPosition(positions, installedPlugins[i].Path) < orderPosition(positions, installedPlugins[j].Path)
+	})
+}
+
+// MovePlugin moves the installed plugin up (if by is negative) or
+// down the order of the plugins in pluginDir, and saves the new
+// order in orderFile.
+// Moving past the first or last plugin leaves it there.
+func MovePlugin(pluginDir, orderFile, installedPluginPath string, by int) error {
+	installedPlugins, err := GetInstalledPlugins(pluginDir)
+	if err != nil {
+		return err
+	}
+	order, err := LoadOrder(orderFile)
+	if err != nil {
+		return err
+	}
+	SortInstalledPlugins(installedPlugins, order)
+	from := -1
+	names := make([]string, 0, len(installedPlugins))
+	for i, installedPlugin := range installedPlugins {
+		if installedPlugin.Path == installedPluginPath {
+			from = i
+		}
+		names = append(names, StateName(installedPlugin.Path))
+	}
+	if from == -1 {
+		return errors.Errorf("plugin %s is not installed", installedPluginPath)
+	}
+	to := from + by
+	if to < 0 {
+		to = 0
+	}
+	if to > len(names)-1 {
+		to = len(names) - 1
+	}
+	name := names[from]
+	names = append(names[:from], names[from+1:]...)
+	names = append(names[:to], append([]string{name}, names[to:]...)...)
+	return SaveOrder(orderFile, names)
+}
+
+// orderPositions gets the position of each name in the order.
+func orderPositions(order []string) map[string]int {
+	positions := make(map[string]int, len(order))
+	for _, name := range order {
+		if _, ok := positions[name]; !ok {
+			positions[name] = len(positions)
+		}
+	}
+	return positions
+}
+
+// orderPosition gets the position of the plugin (at path) in the
+// order, or one past the end if it isn't in it.
+func orderPosition(positions map[string]int, path string) int {
+	position, ok := positions[StateName(filepath.Base(path))]
+	if !ok {
+		return len(positions)
+	}
+	return position
+}
@@ -0,0 +1,66 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestOrder(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-order-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	pluginDir := filepath.Join(dir, "plugins")
+	orderFile := filepath.Join(dir, "xbar", "order.json")
+	is.NoErr(os.MkdirAll(pluginDir, 0777))
+	for _, filename := range []string{"a.1m.sh", "b.1h.sh", "c.5s.sh.off"} {
+		is.NoErr(ioutil.WriteFile(filepath.Join(pluginDir, filename), []byte("#!/bin/bash"), 0777))
+	}
+
+	order, err := LoadOrder(orderFile)
+	is.NoErr(err)
+	is.Equal(len(order), 0) // no file, no order
+
+	is.NoErr(MovePlugin(pluginDir, orderFile, "c.5s.sh.off", -1))
+	order, err = LoadOrder(orderFile)
+	is.NoErr(err)
+	is.Equal(order, []string{"a.sh", "c.sh", "b.sh"})
+
+	is.NoErr(MovePlugin(pluginDir, orderFile, "c.5s.sh.off", -5)) // stops at the top
+	order, err = LoadOrder(orderFile)
+	is.NoErr(err)
+	is.Equal(order, []string{"c.sh", "a.sh", "b.sh"})
+
+	is.NoErr(MovePlugin(pluginDir, orderFile, "a.1m.sh", 1))
+	order, err = LoadOrder(orderFile)
+	is.NoErr(err)
+	is.Equal(order, []string{"c.sh", "b.sh", "a.sh"})
+
+	err = MovePlugin(pluginDir, orderFile, "nope.1m.sh", 1)
+	is.True(err != nil) // not installed
+
+	installedPlugins, err := GetInstalledPlugins(pluginDir)
+	is.NoErr(err)
+	SortInstalledPlugins(installedPlugins, order)
+	is.Equal(installedPlugins[0].Path, "c.5s.sh.off")
+	is.Equal(installedPlugins[1].Path, "b.1h.sh")
+	is.Equal(installedPlugins[2].Path, "a.1m.sh")
+
+	// plugins that aren't in the order go last
+	plugins := Plugins{
+		NewPlugin(filepath.Join(pluginDir, "new.1m.sh")),
+		NewPlugin(filepath.Join(pluginDir, "a.1m.sh")),
+		NewPlugin(filepath.Join(pluginDir, "b.10m.sh")),
+	}
+	plugins.Sort(order)
+	is.Equal(filepath.Base(plugins[0].Command), "b.10m.sh") // new refresh time, same place
+	is.Equal(filepath.Base(plugins[1].Command), "a.1m.sh")
+	is.Equal(filepath.Base(plugins[2].Command), "new.1m.sh")
+}
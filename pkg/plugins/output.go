@@ -0,0 +1,106 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// defaultMaxOutputBytes is the default Plugin.MaxOutputBytes.
+const defaultMaxOutputBytes = 5 << 20 // 5MB
+
+// limitedBuffer is an io.Writer that keeps up to max bytes, and
+// quietly discards the rest (so the plugin isn't blocked writing).
+type limitedBuffer struct {
+	buf bytes.Buffer
+	// max is the number of bytes to keep, or zero for no limit.
+	max int64
+	// truncated is whether any bytes were discarded.
+	truncated bool
+}
+
+func (l *limitedBuffer) Write(b []byte) (int, error) {
+	n := len(b)
+	if l.max > 0 {
+		remaining := l.max - int64(l.buf.Len())
+		if remaining < int64(len(b)) {
+			if remaining < 0 {
+				remaining = 0
+			}
+			b = b[:remaining]
+			l.truncated = true
+		}
+	}
+	l.buf.Write(b)
+	return n, nil
+}
+
+// completeLines gets the output, without any partial last line if
+// it was truncated.
+func (l *limitedBuffer) completeLines() []byte {
+	b := l.buf.Bytes()
+	if !l.truncated {
+		return b
+	}
+	return b[:bytes.LastIndexByte(b, '\n')+1]
+}
+
+// outputTruncatedItems gets the items that explain that the plugin's
+// output was truncated.
+func (p *Plugin) outputTruncatedItems() []*Item {
+	return []*Item{
+		{
+			Plugin: p,
+			Params: ItemParams{
+				Separator: true,
+				Dropdown:  true,
+			},
+		},
+		{
+			Plugin: p,
+			Text:   fmt.Sprintf("⚠️ Output truncated (over %s)", formatSize(p.MaxOutputBytes)),
+			Params: ItemParams{
+				Disabled: true,
+				Dropdown: true,
+			},
+		},
+	}
+}
+
+// formatSize formats a number of bytes for people.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// maxStderrBytes is how much of the end of a plugin's stderr output
+// is kept, to show when it fails.
+const maxStderrBytes = 16 << 10 // 16KB
+
+// tailBuffer is an io.Writer that keeps the last max bytes written
+// to it.
+type tailBuffer struct {
+	buf []byte
+	max int
+}
+
+func (t *tailBuffer) Write(b []byte) (int, error) {
+	n := len(b)
+	if len(b) >= t.max {
+		b = b[len(b)-t.max:]
+		t.buf = t.buf[:0]
+	}
+	if over := len(t.buf) + len(b) - t.max; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	t.buf = append(t.buf, b...)
+	return n, nil
+}
+
+func (t *tailBuffer) String() string {
+	return string(t.buf)
+}
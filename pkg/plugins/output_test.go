@@ -0,0 +1,82 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLimitedBuffer(t *testing.T) {
+	is := is.New(t)
+
+	l := &limitedBuffer{max: 10}
+	n, err := l.Write([]byte("one\ntwo\n"))
+	is.NoErr(err)
+	is.Equal(n, 8)
+	is.Equal(l.truncated, false)
+	n, err = l.Write([]byte("three\n"))
+	is.NoErr(err)
+	is.Equal(n, 6) // reports everything was written
+	is.Equal(l.truncated, true)
+	is.Equal(l.buf.String(), "one\ntwo\nth")
+	is.Equal(string(l.completeLines()), "one\ntwo\n")
+
+	unlimited := &limitedBuffer{}
+	unlimited.Write([]byte("no\nlimit"))
+	is.Equal(string(unlimited.completeLines()), "no\nlimit")
+}
+
+func TestTailBuffer(t *testing.T) {
+	is := is.New(t)
+
+	tail := &tailBuffer{max: 10}
+	n, err := tail.Write([]byte("one\ntwo\n"))
+	is.NoErr(err)
+	is.Equal(n, 8)
+	is.Equal(tail.String(), "one\ntwo\n")
+	n, err = tail.Write([]byte("three\n"))
+	is.NoErr(err)
+	is.Equal(n, 6) // reports everything was written
+	is.Equal(tail.String(), "two\nthree\n")
+	tail.Write([]byte("a very long line\n"))
+	is.Equal(tail.String(), "long line\n")
+}
+
+func TestOutputTruncated(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-output-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "chatty.sh")
+	err = os.WriteFile(command, []byte(`#!/bin/bash
+echo "Chatty"
+echo "---"
+for i in $(seq 1 10000); do echo "line $i"; done
+`), 0777)
+	is.NoErr(err)
+	p := NewPlugin(command)
+	p.MaxOutputBytes = 1024
+	err = p.refresh(context.Background())
+	is.NoErr(err)
+	is.Equal(p.Items.CycleItems[0].Text, "Chatty")
+	last := p.Items.ExpandedItems[len(p.Items.ExpandedItems)-1]
+	is.Equal(last.Text, "⚠️ Output truncated (over 1KB)")
+	is.Equal(last.Params.Disabled, true)
+	is.True(p.Items.ExpandedItems[len(p.Items.ExpandedItems)-2].Params.Separator)
+	is.Equal(p.Items.ExpandedItems[0].Text, "line 1")
+	is.True(len(p.Items.ExpandedItems) < 200)
+}
+
+func TestFormatSize(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(formatSize(5<<20), "5MB")
+	is.Equal(formatSize(512<<10), "512KB")
+	is.Equal(formatSize(1000), "1000 bytes")
+}
@@ -0,0 +1,52 @@
+package plugins
+
+import "context"
+
+// Overlap policies, for when a plugin is asked to refresh while it is
+// still running.
+const (
+	// OverlapQueue runs the plugin again once it has finished.
+	OverlapQueue = "queue"
+	// OverlapSkip ignores the request.
+	OverlapSkip = "skip"
+)
+
+// startRun waits until the plugin isn't running, so that runs of the
+// same plugin never overlap (which could race on any files it uses).
+// Returns false if the run should be skipped, because of the Overlap
+// policy or because the context is done, otherwise runDone must be
+// called when the run has finished.
+func (p *Plugin) startRun(ctx context.Context) bool {
+	if p.runLock == nil {
+		return true
+	}
+	select {
+	case p.runLock <- struct{}{}:
+		return true
+	default:
+	}
+	if p.Overlap == OverlapSkip {
+		p.Debugf("still running, skipping refresh")
+		return false
+	}
+	p.Debugf("still running, refreshing once it has finished")
+	select {
+	case p.runLock <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// runDone is called when a run started with startRun has finished.
+func (p *Plugin) runDone() {
+	if p.runLock == nil {
+		return
+	}
+	<-p.runLock
+}
+
+// Running gets whether the plugin is running.
+func (p *Plugin) Running() bool {
+	return len(p.runLock) > 0
+}
@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// param is a single key=value parameter.
+type param struct {
+	key, value string
+}
+
+// lexParams splits a parameter string into its key=value parameters.
+// Parameters are separated by spaces or pipes. Values may be quoted
+// with single or double quotes, in which case they may contain spaces,
+// pipes and escaped quotes (\" or \'), otherwise they end at the next
+// space or pipe. Values may contain = characters.
+func lexParams(s string) ([]param, error) {
+	var params []param
+	for i := 0; ; {
+		// skip separators
+		for i < len(s) && isParamSeparator(s[i]) {
+			i++
+		}
+		if i == len(s) {
+			return params, nil
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && !isParamSeparator(s[i]) {
+			i++
+		}
+		key := s[start:i]
+		if i == len(s) || s[i] != '=' {
+			return nil, errors.Errorf(`malformed parameters: missing equals after "%s"`, key)
+		}
+		if key == "" {
+			return nil, errors.New("malformed parameters: missing key before equals")
+		}
+		i++ // skip =
+		var value string
+		if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+			var err error
+			value, i, err = lexQuotedValue(s, i)
+			if err != nil {
+				return nil, errors.Wrap(err, key)
+			}
+			if i < len(s) && !isParamSeparator(s[i]) {
+				return nil, errors.Errorf(`%s: unexpected "%c" after quoted value`, key, s[i])
+			}
+		} else {
+			start := i
+			for i < len(s) && !isParamSeparator(s[i]) {
+				i++
+			}
+			value = s[start:i]
+		}
+		params = append(params, param{key: key, value: value})
+	}
+}
+
+// lexQuotedValue reads the quoted value that starts at s[i].
+// It returns the unquoted value, and the index just after the
+// closing quote.
+// Inside the quotes, a backslash escapes the quote character or
+// another backslash; other backslashes are kept as they are.
+func lexQuotedValue(s string, i int) (string, int, error) {
+	quote := s[i]
+	var value strings.Builder
+	for i++; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == quote || s[i+1] == '\\'):
+			i++
+			value.WriteByte(s[i])
+		case s[i] == quote:
+			return value.String(), i + 1, nil
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return "", i, errors.Errorf("missing closing %c quote", quote)
+}
+
+// isParamSeparator gets whether b separates parameters.
+func isParamSeparator(b byte) bool {
+	return b == ' ' || b == '\t' || b == '|'
+}
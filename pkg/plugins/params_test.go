@@ -0,0 +1,51 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLexParams(t *testing.T) {
+	is := is.New(t)
+
+	params, err := lexParams(` bash="/path/with spaces/run.sh" param1="a=b" param2=c=d | href='https://xbarapp.com/?q=a|b' color=red|size=12 `)
+	is.NoErr(err)
+	is.Equal(params, []param{
+		{key: "bash", value: "/path/with spaces/run.sh"},
+		{key: "param1", value: "a=b"},
+		{key: "param2", value: "c=d"},
+		{key: "href", value: "https://xbarapp.com/?q=a|b"},
+		{key: "color", value: "red"},
+		{key: "size", value: "12"},
+	})
+
+	// escaped quotes and backslashes
+	params, err = lexParams(`tooltip="say \"hi\"" param1='it\'s' param2="C:\\dir\n"`)
+	is.NoErr(err)
+	is.Equal(params, []param{
+		{key: "tooltip", value: `say "hi"`},
+		{key: "param1", value: `it's`},
+		{key: "param2", value: `C:\dir\n`},
+	})
+
+	// empty values
+	params, err = lexParams(`href= color=""`)
+	is.NoErr(err)
+	is.Equal(params, []param{
+		{key: "href", value: ""},
+		{key: "color", value: ""},
+	})
+
+	for s, expected := range map[string]string{
+		`href`:                `malformed parameters: missing equals after "href"`,
+		`color=red size 12`:   `malformed parameters: missing equals after "size"`,
+		`=red`:                `malformed parameters: missing key before equals`,
+		`shell="unterminated`: `shell: missing closing " quote`,
+		`shell="a"b`:          `shell: unexpected "b" after quoted value`,
+	} {
+		_, err := lexParams(s)
+		is.True(err != nil)
+		is.Equal(err.Error(), expected)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"io"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 )
@@ -12,17 +14,20 @@ import (
 const (
 	nesting   = "--"
 	separator = "---"
+	// splitSeparator starts another menu bar item.
+	splitSeparator = "---SPLIT---"
 )
 
 // parseOutput parses the output of a plugin run, and returns the
 // Items.
+// Output after each ---SPLIT--- line is parsed into Items.Splits.
+// Output that starts with { is parsed as JSON (see jsonMenu).
 func (p *Plugin) parseOutput(ctx context.Context, filename string, r io.Reader) (Items, error) {
 	var (
+		sections        []Items
 		items           Items
+		tree            itemTree
 		params          ItemParams
-		depthPrefix     string
-		previousItem    *Item
-		ancestorItems   []*Item
 		captureExpanded bool
 		line            int
 		text            string
@@ -30,6 +35,9 @@ func (p *Plugin) parseOutput(ctx context.Context, filename string, r io.Reader)
 		readErr         error
 	)
 	br := bufio.NewReader(r)
+	if isJSONOutput(br) {
+		return p.parseJSONOutput(filename, br)
+	}
 	for readErr == nil { // keep reading until we hit io.EOF
 		line++
 		text, readErr = br.ReadString('\n')
@@ -45,7 +53,19 @@ func (p *Plugin) parseOutput(ctx context.Context, filename string, r io.Reader)
 			// not io.EOF, to trim off the delimiter
 			text = text[:len(text)-1]
 		}
+		if strings.TrimSpace(text) == splitSeparator {
+			// start another menu bar item
+			alignColumns(tree.items)
+			items.ExpandedItems = tree.items
+			sections = append(sections, items)
+			items, tree, captureExpanded = Items{}, itemTree{}, false
+			continue
+		}
 		text, params, err = parseParams(text)
+		if _, ok := err.(errInvalidImage); ok {
+			p.warn(errors.Errorf("%s:%d: %s (ignoring the image)", filename, line, err))
+			err = nil
+		}
 		if err != nil {
 			return items, &errParsing{
 				filename: filename,
@@ -54,90 +74,217 @@ func (p *Plugin) parseOutput(ctx context.Context, filename string, r io.Reader)
 				err:      err,
 			}
 		}
-		if !captureExpanded && strings.TrimSpace(text) == separator {
-			// first --- means end of cycle items,
-			// start collecting expanded items now
-			captureExpanded = true
+		depth, itemText, isSeparator := parseDepth(text)
+		if !captureExpanded {
+			if depth == 0 && isSeparator {
+				// first --- means end of cycle items,
+				// start collecting expanded items now
+				captureExpanded = true
+				if itemText != "" {
+					// ---Title starts the first section
+					tree.addSeparator(depth, p.newHeader(itemText, params))
+				}
+				continue
+			}
+			items.CycleItems = append(items.CycleItems, p.newItem(text, params))
 			continue
 		}
-
-		text, isSeparator := parseSeparator(text)
-
-		for !strings.HasPrefix(text, depthPrefix) {
-			// drop a level
-			ancestorItems = ancestorItems[:len(ancestorItems)-1]
-			depthPrefix = strings.TrimPrefix(depthPrefix, nesting)
-		}
-		if strings.HasPrefix(text, depthPrefix+nesting) {
-			// if this is a separator in the submenu,
-			// then don't treat it as a another submenu.
-			if strings.TrimPrefix(text, depthPrefix) != separator {
-				// increase a level
-				ancestorItems = append(ancestorItems, previousItem)
-				depthPrefix += nesting
+		if isSeparator {
+			if itemText != "" {
+				tree.addSeparator(depth, p.newHeader(itemText, params))
+				continue
 			}
-		}
-		text = strings.TrimPrefix(text, depthPrefix)
-		if captureExpanded && isSeparator {
 			params.Separator = true
-			separatorItem := &Item{
+			tree.addSeparator(depth, &Item{
 				Plugin: p,
-				Text:   text,
 				Params: params,
-			}
-			if len(ancestorItems) > 0 {
-				parentItem := ancestorItems[len(ancestorItems)-1]
-				parentItem.Items = append(parentItem.Items, separatorItem)
-			} else {
-				items.ExpandedItems = append(items.ExpandedItems, separatorItem)
-			}
+			})
 			continue
 		}
-		if params.Trim {
-			text = strings.TrimSpace(text)
-		}
-		if params.Emojize {
-			text = Emojize(text)
-		}
-		item := &Item{
-			Plugin: p,
-			Text:   text,
-			Params: params,
-		}
-		if captureExpanded {
-			if len(ancestorItems) > 0 {
-				parentItem := ancestorItems[len(ancestorItems)-1]
-				parentItem.Items = append(parentItem.Items, item)
-			} else {
-				if item.Params.Alternate {
-					// add to previous item, as Alternate
-					previousItem.Alternate = item
-				} else if item.Params.Dropdown {
-					// if Dropdown=false then don't include it
-					items.ExpandedItems = append(items.ExpandedItems, item)
-				}
-			}
-		} else {
-			items.CycleItems = append(items.CycleItems, item)
-		}
-		previousItem = item
+		tree.add(depth, p.newItem(itemText, params))
+	}
+	if readErr != nil && readErr != io.EOF {
+		return items, errors.Wrap(readErr, "reading")
 	}
-	if err != nil && err != io.EOF {
-		return items, errors.Wrap(err, "reading")
+	alignColumns(tree.items)
+	items.ExpandedItems = tree.items
+	if len(sections) == 0 {
+		return items, nil
 	}
+	sections = append(sections, items)
+	items = sections[0]
+	items.Splits = sections[1:]
 	return items, nil
 }
 
-func parseSeparator(src string) (string, bool) {
-	text := strings.TrimSpace(src)
-	if text == separator {
-		return "", true
+// newItem makes an Item from the text of a line, processing the text
+// according to the params.
+func (p *Plugin) newItem(text string, params ItemParams) *Item {
+	if params.Trim {
+		text = strings.TrimSpace(text)
 	}
-	for strings.HasPrefix(text, nesting) {
-		text = strings.TrimPrefix(text, nesting)
-		if text == separator {
-			return src[:len(src)-3], true
+	if params.Emojize {
+		text = Emojize(text)
+	}
+	if params.Columns {
+		// cells are plain text, aligned after parsing
+		text, _ = parseANSI(text)
+		columns := splitColumns(text, params.Length)
+		return &Item{
+			Plugin:  p,
+			Text:    strings.Join(columns, columnGap),
+			Columns: columns,
+			Params:  params,
+		}
+	}
+	var segments []*TextSegment
+	if params.ANSI {
+		text, segments = parseANSI(text)
+	}
+	if params.Markdown {
+		if segments == nil {
+			text, segments = parseMarkdown(text)
+		} else {
+			text, segments = parseMarkdownSegments(segments)
+		}
+	}
+	var fullText string
+	if truncated := truncate(text, params.Length); truncated != text {
+		fullText = text
+		text = truncated
+	}
+	if params.copyText {
+		params.Copy = text
+		if fullText != "" {
+			params.Copy = fullText
+		}
+	}
+	if params.Progress != nil {
+		bar := progressBar(*params.Progress)
+		if text != "" {
+			bar = " " + bar
+		}
+		if segments == nil && text != "" {
+			segments = []*TextSegment{{Text: text}}
 		}
+		segments = append(segments, &TextSegment{Text: bar})
+		text += bar
+	}
+	return &Item{
+		Plugin:   p,
+		Text:     text,
+		FullText: fullText,
+		Segments: segments,
+		Params:   params,
+	}
+}
+
+// newHeader makes a section header Item from a ---Title line.
+// Headers are like separators, but have text and can't be clicked.
+func (p *Plugin) newHeader(text string, params ItemParams) *Item {
+	params.Header = true
+	params.Disabled = true
+	return p.newItem(text, params)
+}
+
+// parseDepth works out how deeply nested a line is from the
+// number of -- prefixes.
+// Returns the depth, the text without the prefixes, and whether
+// the line is a separator.
+// For section headers (---Title), the text is the title.
+func parseDepth(src string) (int, string, bool) {
+	var depth int
+	text := src
+	for {
+		if strings.TrimSpace(text) == separator {
+			return depth, "", true
+		}
+		if title := strings.TrimPrefix(text, separator); title != text && isHeaderTitle(title) {
+			return depth, strings.TrimSpace(title), true
+		}
+		if !strings.HasPrefix(text, nesting) {
+			return depth, text, false
+		}
+		text = strings.TrimPrefix(text, nesting)
+		depth++
+	}
+}
+
+// isHeaderTitle gets whether the text after a --- is the title of a
+// section header, which starts with a letter or symbol (like an
+// emoji).
+// Otherwise the line is a nested item whose text starts with a -,
+// like ---1.2% (an item with the text -1.2% in a submenu).
+func isHeaderTitle(title string) bool {
+	r, _ := utf8.DecodeRuneInString(title)
+	return unicode.IsLetter(r) || unicode.IsSymbol(r)
+}
+
+// itemTree builds the tree of expanded items, one line at a time.
+type itemTree struct {
+	// items are the top level items.
+	items []*Item
+	// ancestors are the parents of the current level, the
+	// immediate parent is last.
+	ancestors []*Item
+	// previous is the most recent item at the current level.
+	// If the next line is nested deeper, it becomes the parent.
+	previous *Item
+	// previousIsLast is whether previous is the last thing at the
+	// current level (not followed by a separator), and so can have
+	// an Alternate.
+	previousIsLast bool
+}
+
+// moveTo moves the tree to the specified depth.
+// It is only possible to go one level deeper than the previous
+// item, so any skipped levels are ignored.
+func (t *itemTree) moveTo(depth int) {
+	if depth < len(t.ancestors) {
+		// back up, the parent at this depth is now the
+		// most recent item
+		t.previous = t.ancestors[depth]
+		t.previousIsLast = true
+		t.ancestors = t.ancestors[:depth]
+		return
+	}
+	if depth > len(t.ancestors) && t.previous != nil {
+		// go down a level
+		t.ancestors = append(t.ancestors, t.previous)
+		t.previous = nil
+	}
+}
+
+// add adds an item at the specified depth.
+func (t *itemTree) add(depth int, item *Item) {
+	t.moveTo(depth)
+	if item.Params.Alternate && t.previous != nil && t.previousIsLast {
+		// add to previous item, as Alternate
+		t.previous.Alternate = item
+		return
+	}
+	t.previous = item
+	t.previousIsLast = true
+	if !item.Params.Dropdown {
+		// if Dropdown=false then don't include it
+		return
+	}
+	t.append(item)
+}
+
+// addSeparator adds a separator at the specified depth.
+func (t *itemTree) addSeparator(depth int, item *Item) {
+	t.moveTo(depth)
+	t.previousIsLast = false
+	t.append(item)
+}
+
+// append adds the item to the current level.
+func (t *itemTree) append(item *Item) {
+	if len(t.ancestors) == 0 {
+		t.items = append(t.items, item)
+		return
 	}
-	return src, false
+	parent := t.ancestors[len(t.ancestors)-1]
+	parent.Items = append(parent.Items, item)
 }
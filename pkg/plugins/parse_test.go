@@ -47,6 +47,7 @@ func TestParseParams(t *testing.T) {
 	is.Equal(params.Alternate, false) // Alternate
 	is.Equal(params.Emojize, true)    // Emojize
 	is.Equal(params.ANSI, true)       // ANSI
+	is.Equal(params.Checked, false)   // Checked
 
 	s, params, err = parseParams(`Before params |color=#123def`)
 	is.NoErr(err)
@@ -197,27 +198,220 @@ h`
 
 }
 
-func TestParseSeparator(t *testing.T) {
+func TestParseDepth(t *testing.T) {
 	is := is.New(t)
 
-	text, isSep := parseSeparator("no")
+	depth, text, isSep := parseDepth("no")
 	is.Equal(isSep, false)
+	is.Equal(depth, 0)
 	is.Equal(text, "no")
 
-	text, isSep = parseSeparator(separator)
+	depth, text, isSep = parseDepth(separator)
 	is.Equal(isSep, true)
+	is.Equal(depth, 0)
 	is.Equal(text, "")
 
-	text, isSep = parseSeparator(nesting + separator)
+	depth, text, isSep = parseDepth(nesting + separator)
 	is.Equal(isSep, true)
-	is.Equal(text, nesting)
+	is.Equal(depth, 1)
+	is.Equal(text, "")
 
-	text, isSep = parseSeparator(nesting + nesting + separator)
+	depth, text, isSep = parseDepth(nesting + nesting + separator)
 	is.Equal(isSep, true)
-	is.Equal(text, nesting+nesting)
+	is.Equal(depth, 2)
+	is.Equal(text, "")
 
-	text, isSep = parseSeparator(nesting + nesting + "item")
+	depth, text, isSep = parseDepth(nesting + nesting + "item")
 	is.Equal(isSep, false)
-	is.Equal(text, nesting+nesting+"item")
+	is.Equal(depth, 2)
+	is.Equal(text, "item")
+
+	depth, text, isSep = parseDepth(separator + "Section ")
+	is.Equal(isSep, true)
+	is.Equal(depth, 0)
+	is.Equal(text, "Section")
+
+	depth, text, isSep = parseDepth(nesting + separator + "Sub section")
+	is.Equal(isSep, true)
+	is.Equal(depth, 1)
+	is.Equal(text, "Sub section")
+
+}
+
+func TestSectionHeaders(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "headers.txt", strings.NewReader(`title
+---Servers | color=red
+web1
+web2
+---
+plain
+---Databases
+db1
+-----Replicas
+--replica1`))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 1)
+	expanded := items.ExpandedItems
+	is.Equal(len(expanded), 7)
+	is.Equal(expanded[0].Text, "Servers")
+	is.Equal(expanded[0].Params.Header, true)
+	is.Equal(expanded[0].Params.Separator, false)
+	is.Equal(expanded[0].Params.Disabled, true)
+	is.Equal(expanded[0].Params.Color, "#ff0000")
+	is.Equal(expanded[1].Text, "web1")
+	is.Equal(expanded[3].Params.Separator, true)
+	is.Equal(expanded[3].Params.Header, false)
+	is.Equal(expanded[5].Text, "Databases")
+	is.Equal(expanded[5].Params.Header, true)
+	is.Equal(expanded[6].Text, "db1")
+	is.Equal(len(expanded[6].Items), 2)
+	is.Equal(expanded[6].Items[0].Text, "Replicas")
+	is.Equal(expanded[6].Items[0].Params.Header, true)
+	is.Equal(expanded[6].Items[1].Text, "replica1")
+}
+
+// TestNegativeNumbersInSubmenus ensures items in submenus whose text
+// starts with a - aren't mistaken for section headers.
+func TestNegativeNumbersInSubmenus(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "stocks.txt", strings.NewReader(`title
+---
+AAPL
+---1.2% | href=https://example.com
+-----5°C
+--- bullet
+---📈Markets
+up`))
+	is.NoErr(err)
+	expanded := items.ExpandedItems
+	is.Equal(len(expanded), 3)
+	is.Equal(expanded[0].Text, "AAPL")
+	is.Equal(len(expanded[0].Items), 2)
+	is.Equal(expanded[0].Items[0].Text, "-1.2%")
+	is.Equal(expanded[0].Items[0].Params.Header, false)
+	is.Equal(expanded[0].Items[0].Params.Href, "https://example.com")
+	is.Equal(expanded[0].Items[0].Items[0].Text, "-5°C")
+	is.Equal(expanded[0].Items[0].Items[0].Params.Header, false)
+	is.Equal(expanded[0].Items[1].Text, "- bullet")
+	is.Equal(expanded[1].Text, "📈Markets")
+	is.Equal(expanded[1].Params.Header, true)
+	is.Equal(expanded[2].Text, "up")
+}
+
+// TestDeepNesting ensures submenus can be nested to any depth.
+func TestDeepNesting(t *testing.T) {
+	is := is.New(t)
+
+	src := `a
+---
+1
+--2
+----3
+------4
+--------5
+----------6
+--------5b
+--2b
+1b`
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "nesting.txt", strings.NewReader(src))
+	is.NoErr(err)
+
+	is.Equal(len(items.ExpandedItems), 2)
+	is.Equal(items.ExpandedItems[1].Text, "1b")
+	item := items.ExpandedItems[0]
+	for _, expected := range []string{"1", "2", "3", "4", "5"} {
+		is.Equal(item.Text, expected)
+		item = item.Items[0]
+	}
+	is.Equal(item.Text, "6")
+	is.Equal(len(item.Items), 0)
+	level4 := items.ExpandedItems[0].Items[0].Items[0].Items[0]
+	is.Equal(len(level4.Items), 2)
+	is.Equal(level4.Items[1].Text, "5b")
+	is.Equal(len(items.ExpandedItems[0].Items), 2)
+	is.Equal(items.ExpandedItems[0].Items[1].Text, "2b")
+}
+
+// TestNestingEdgeCases checks skipped levels, nesting without a parent,
+// and alternate and hidden items inside submenus.
+func TestNestingEdgeCases(t *testing.T) {
+	is := is.New(t)
 
+	src := `a
+---
+--orphan
+parent
+------skipped
+--child
+--child alt | alternate=true
+--hidden | dropdown=false
+----hidden child`
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "nesting.txt", strings.NewReader(src))
+	is.NoErr(err)
+
+	is.Equal(len(items.ExpandedItems), 2)
+	is.Equal(items.ExpandedItems[0].Text, "orphan") // nothing to nest under
+	parent := items.ExpandedItems[1]
+	is.Equal(parent.Text, "parent")
+	is.Equal(len(parent.Items), 2)
+	is.Equal(parent.Items[0].Text, "skipped") // only one level deeper is possible
+	is.Equal(parent.Items[1].Text, "child")
+	is.True(parent.Items[1].Alternate != nil)
+	is.Equal(parent.Items[1].Alternate.Text, "child alt")
+}
+
+func TestSplit(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "split.txt", strings.NewReader(strings.TrimSpace(`
+CPU 12%
+---
+Top process
+---SPLIT---
+RAM 4GB
+RAM 50%
+---
+Free memory
+--Purge
+---SPLIT---
+Disk 80%
+`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 1)
+	is.Equal(items.CycleItems[0].Text, "CPU 12%")
+	is.Equal(len(items.ExpandedItems), 1)
+	is.Equal(items.ExpandedItems[0].Text, "Top process")
+	is.Equal(len(items.Splits), 2)
+	is.Equal(len(items.Splits[0].CycleItems), 2)
+	is.Equal(items.Splits[0].CycleItems[1].Text, "RAM 50%")
+	is.Equal(len(items.Splits[0].ExpandedItems), 1)
+	is.Equal(items.Splits[0].ExpandedItems[0].Items[0].Text, "Purge")
+	is.Equal(items.Splits[1].CycleItems[0].Text, "Disk 80%")
+	is.Equal(len(items.Splits[1].ExpandedItems), 0)
+	is.Equal(len(items.Splits[0].Splits), 0)
+
+	p.Items = items
+	is.Equal(p.SplitCycleItem(0).Text, "RAM 4GB")
+	p.cycle(context.Background())
+	is.Equal(p.CurrentCycleItem().Text, "CPU 12%")
+	is.Equal(p.SplitCycleItem(0).Text, "RAM 50%") // splits cycle independently
+	is.Equal(p.SplitCycleItem(1).Text, "Disk 80%")
+	p.cycle(context.Background())
+	is.Equal(p.SplitCycleItem(0).Text, "RAM 4GB")
+	is.True(p.SplitCycleItem(2) == nil)
+
+	// no splits
+	items, err = p.parseOutput(context.Background(), "split.txt", strings.NewReader("one\n---\ntwo"))
+	is.NoErr(err)
+	is.Equal(len(items.Splits), 0)
 }
@@ -13,9 +13,11 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/matryer/xbar/pkg/metadata"
 	"github.com/pkg/errors"
 )
 
@@ -27,6 +29,21 @@ type (
 	CycleFunc func(ctx context.Context, p *Plugin)
 	// DebugFunc is a function that records debug information.
 	DebugFunc func(format string, v ...interface{})
+	// WebviewFunc is a callback fired when an item with a webview
+	// is clicked. Zero width or height means the default size.
+	WebviewFunc func(ctx context.Context, url string, width, height int)
+	// ConfirmFunc asks the user to confirm an action, returning
+	// true if they do.
+	ConfirmFunc func(ctx context.Context, title, message string) bool
+	// ConfigureFunc is a callback fired when a Plugin needs
+	// configuring before it can run.
+	ConfigureFunc func(ctx context.Context, p *Plugin)
+	// NotifyFunc is a callback fired when a Plugin wants to show a
+	// notification.
+	NotifyFunc func(ctx context.Context, p *Plugin, notification Notification)
+	// WarningFunc is a callback fired when something is wrong with a
+	// Plugin that doesn't stop it from running.
+	WarningFunc func(p *Plugin, err error)
 )
 
 // Plugin is a single executable xbar plugin.
@@ -35,26 +52,136 @@ type Plugin struct {
 	Command string
 	// Variables are the values in the accompanying .vars.json file.
 	Variables []string
+	// WorkingDir is the directory to run the plugin in, or empty
+	// for the directory it is in.
+	WorkingDir string
+	// Interpreter is the program (and any arguments) to run the
+	// plugin with, like python3, instead of running it directly.
+	// Programs are found using the PATH in Env.
+	Interpreter []string
+	// Dependencies are the programs the plugin needs, which are
+	// checked before it first runs.
+	// Only dependencies listed as a JSON array are checked (see
+	// metadata.Plugin.DependencyDetails).
+	Dependencies []metadata.Dependency
+	// Env are extra environment variables (like PATH=...) for the
+	// plugin, which override the outside environment, and are
+	// overridden by the Variables.
+	Env []string
 	// Items are the menu items for this plugin.
 	Items Items
 	// RefreshInterval is the duration at which this Plugin should
 	// update.
 	RefreshInterval RefreshInterval
+	// Jitter randomly lengthens or shortens the time between
+	// refreshes by up to this fraction of the RefreshInterval (like
+	// 0.1 for ±10%), so plugins with the same RefreshInterval don't
+	// all run at once. Not used with a Schedule.
+	Jitter float64
+	// AppearanceSensitive indicates that the output of the plugin
+	// depends on the system appearance (it uses XBAR_DARK_MODE or
+	// XBAR_ACCENT_COLOR), so it should be refreshed when that
+	// changes. Set from its source when it starts running.
+	AppearanceSensitive bool
+	// NeverThrottle indicates that the plugin keeps refreshing as
+	// often as usual when SetThrottle is used to save power.
+	NeverThrottle bool
+	// Schedule is a cron schedule for refreshing the plugin, from
+	// the xbar.schedule metadata. If nil, RefreshInterval is used.
+	Schedule *metadata.Schedule
 	// CycleInterval is the interval at which the Items.CycleItems
 	// will change.
 	CycleInterval time.Duration
 	// CycleIndex is the currently active Item from CycleItems.
 	CycleIndex int
 	// Timeout is the time.Duration within which a plugin execution
-	// must complete before being cancelled.
+	// must complete before being stopped (with SIGTERM, and then
+	// SIGKILL).
 	Timeout time.Duration
+	// MaxOutputBytes is the most output that will be read from each
+	// run of the plugin. Any more is ignored, and an item is added
+	// explaining that the output was truncated.
+	// Zero means no limit.
+	MaxOutputBytes int64
+	// RefreshOnOpen indicates that the plugin is only refreshed when
+	// its menu is opened (see MenuOpened), and not on a timer.
+	// It still runs once when it starts, to get its title.
+	RefreshOnOpen bool
+	// WatchPaths are files or directories that cause the plugin to
+	// refresh when they change (see watch).
+	WatchPaths []string
+	// Streamable indicates that the plugin keeps running, updating
+	// the menu each time it outputs a ~~~ line, instead of being
+	// run every RefreshInterval.
+	// If it exits, it is started again after RefreshInterval.
+	Streamable bool
+	// KeepOutputOnError indicates that the Items from the last
+	// successful refresh are kept (marked as stale) when the plugin
+	// fails, instead of being replaced by the error.
+	KeepOutputOnError bool
+	// CacheDir is a directory the plugin can keep caches in, passed
+	// to it as XBAR_PLUGIN_CACHE_DIR, or empty for none.
+	// It may be deleted when the user clears the cache.
+	CacheDir string
+	// DataDir is a directory the plugin can keep tokens and other
+	// state in, passed to it as XBAR_PLUGIN_DATA_DIR, or empty for
+	// none.
+	// See SetStateDirs.
+	DataDir string
+	// HistoryFile is where the last runs of the plugin are kept, or
+	// empty to not keep them. See ReadHistory.
+	HistoryFile string
+	// LogFile is where the run details and whole output of every run
+	// of the plugin are logged, or empty to not log them.
+	// See LogFilename and TailLog.
+	LogFile string
+	// LogMaxBytes is how big the LogFile gets before it is rotated,
+	// or zero for defaultLogMaxBytes.
+	LogMaxBytes int64
+	// LogMaxFiles is how many rotated log files (like weather.sh.log.1)
+	// are kept, or zero for defaultLogMaxFiles.
+	LogMaxFiles int
+	// Overlap is what to do when the plugin is asked to refresh while
+	// it is still running; OverlapQueue or OverlapSkip.
+	// Runs of the same plugin never overlap. Streamable plugins
+	// are restarted instead.
+	Overlap string
 	// Debugf is a function that writes debug information.
 	Debugf DebugFunc
+	// ImageFetcher downloads images that are specified by URL.
+	// If nil, such images are ignored.
+	ImageFetcher *ImageFetcher
+	// TerminalApp is the terminal app (one of TerminalApps) that
+	// items with terminal=true run their shell commands in.
+	// If empty, Terminal is used.
+	TerminalApp string
+	// Shortcuts is where the keyboard shortcuts of this plugin's
+	// items are registered. If nil, conflicts are not checked.
+	Shortcuts *ShortcutRegistry
 	// OnRefresh is called when the plugin has been updated.
 	// Ignored if nil.
 	OnRefresh RefreshFunc
 	// OnCycle is called when the Plugin's CycleIndex has changed.
 	OnCycle CycleFunc
+	// OnWebview is called when an item with a webview is clicked.
+	// If nil, the webview URL is opened in the browser instead.
+	OnWebview WebviewFunc
+	// OnConfirm is called to confirm the actions of items with the
+	// confirm parameter. If nil, such actions are not run.
+	OnConfirm ConfirmFunc
+	// OnConfigRequired is called when the plugin starts exiting with
+	// ExitConfigRequired, so the user can set its variables.
+	// Ignored if nil.
+	OnConfigRequired ConfigureFunc
+	// OnNotify is called with the notifications of items with
+	// notify=true, when the output of the plugin changes.
+	// Ignored if nil.
+	OnNotify NotifyFunc
+	// OnWarning is called with problems that don't stop the plugin
+	// from running, like invalid metadata or images, or its output
+	// being truncated.
+	// Ignored if nil.
+	OnWarning WarningFunc
 
 	// Stdout is a writer that will have stdout written to if not nil.
 	Stdout io.Writer
@@ -64,6 +191,53 @@ type Plugin struct {
 	// refreshSignal is a signal which will trigger the plugin to refresh.
 	// Called via TriggerRefresh().
 	refreshSignal chan (struct{})
+	// throttle holds the bits of the float64 factor set by
+	// SetThrottle, and is accessed atomically.
+	throttle uint64
+	// paused is non-zero when the plugin is paused, and is accessed
+	// atomically. See Pause.
+	paused int32
+	// dependenciesChecked is whether all the Dependencies have been
+	// found. See checkDependencies.
+	dependenciesChecked bool
+	// variablesChanged is non-zero when the variables should be
+	// loaded again before the plugin next runs, and is accessed
+	// atomically. See VariablesChanged.
+	variablesChanged int32
+	// appearanceChanged is non-zero when the menu should be updated
+	// after the next refresh, even if the output is the same, and is
+	// accessed atomically. See AppearanceChanged.
+	appearanceChanged int32
+	// failures is the number of times in a row the plugin has
+	// failed, used to back off refreshing it.
+	failures int
+	// history are the runs in the HistoryFile, loaded when the first
+	// run is recorded.
+	history []Run
+	// stats holds the Stats of the runs so far, and is replaced
+	// (by the single run at a time) rather than changed, so it can
+	// be read while the plugin runs.
+	stats atomic.Value
+	// secretVars are the names of the variables whose values are
+	// kept in the Keychain.
+	secretVars []string
+	// runLock is full while the plugin is running. See startRun.
+	runLock chan struct{}
+	// startup limits how many plugins run for the first time at
+	// once, or is nil for no limit. See Plugins.RunStaggered.
+	startup chan struct{}
+	// lastItems are the Items from the last successful refresh,
+	// shown again when the plugin exits with ExitTempFail, or fails
+	// with KeepOutputOnError.
+	lastItems *Items
+	// lastItemsAt is when lastItems were set.
+	lastItemsAt time.Time
+	// itemsHash is the hash of the Items when OnRefresh was last
+	// called, used to skip refreshes that didn't change anything.
+	itemsHash string
+	// cycles is the number of times the plugin has cycled since it
+	// was last refreshed, used to cycle the Items.Splits.
+	cycles int
 	// cycleSignal is a signal channel which will trigger the plugin to
 	// update its cycle.
 	// Called in TriggerRefresh() when updating the plugin menu to the
@@ -83,6 +257,7 @@ func (p Plugin) CleanFilename() string {
 // cycle advances the CycleIndex, and wraps around if
 // we've reached the end.
 func (p *Plugin) cycle(ctx context.Context) {
+	p.cycles++
 	p.CycleIndex++
 	if p.CycleIndex == len(p.Items.CycleItems) {
 		p.CycleIndex = 0
@@ -101,8 +276,28 @@ type Plugins []*Plugin
 // executable.
 // Use the context for cancelation.
 func (p Plugins) Run(ctx context.Context) {
+	p.RunStaggered(ctx, 0, 0)
+}
+
+// RunStaggered is like Run, but waits for stagger between starting
+// each plugin, and only runs up to concurrency plugins for the first
+// time at once (or any number if zero), so that starting lots of
+// plugins doesn't slow down the computer.
+// Streamable plugins keep running, so they are not limited.
+func (p Plugins) RunStaggered(ctx context.Context, concurrency int, stagger time.Duration) {
+	var startup chan struct{}
+	if concurrency > 0 {
+		startup = make(chan struct{}, concurrency)
+	}
 	var wg sync.WaitGroup
 	for i := range p {
+		if i > 0 && stagger > 0 {
+			select {
+			case <-time.After(stagger):
+			case <-ctx.Done():
+			}
+		}
+		p[i].startup = startup
 		wg.Add(1)
 		go func(p *Plugin) {
 			p.Run(ctx)
@@ -144,6 +339,10 @@ func Dir(path string) (Plugins, error) {
 			// ignore .vars.json files
 			continue
 		}
+		if isSidecarFile(filename) {
+			// ignore metadata sidecar files
+			continue
+		}
 		if !IsPluginEnabled(filename) {
 			// ignore disabled plugins
 			continue
@@ -154,17 +353,48 @@ func Dir(path string) (Plugins, error) {
 	return plugins, nil
 }
 
+// Dirs gets Plugins from the directories, in order of priority.
+// A plugin with the same name (see StateName) as one in an earlier
+// directory is skipped, so a personal copy of a plugin can override
+// a shared one.
+// Directories that don't exist are skipped.
+func Dirs(paths ...string) (Plugins, error) {
+	var plugins Plugins
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		dirPlugins, err := Dir(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, plugin := range dirPlugins {
+			name := StateName(filepath.Base(plugin.Command))
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins, nil
+}
+
 // NewPlugin makes a new Plugin with the specified executable
 // file.
 func NewPlugin(command string) *Plugin {
 	filename := filepath.Base(command)
 	p := &Plugin{
-		Timeout:       1 * time.Minute,
-		CycleInterval: 5 * time.Second,
-		Command:       command,
-		Debugf:        DebugfNoop,
-		refreshSignal: make(chan struct{}, 1),
-		cycleSignal:   make(chan struct{}, 1),
+		Timeout:        1 * time.Minute,
+		CycleInterval:  5 * time.Second,
+		MaxOutputBytes: defaultMaxOutputBytes,
+		Command:        command,
+		Debugf:         DebugfNoop,
+		Overlap:        OverlapQueue,
+		refreshSignal:  make(chan struct{}, 1),
+		cycleSignal:    make(chan struct{}, 1),
+		runLock:        make(chan struct{}, 1),
 	}
 	var err error
 	p.RefreshInterval, err = ParseFilenameInterval(filename)
@@ -180,14 +410,24 @@ func NewPlugin(command string) *Plugin {
 // executable.
 // Use the context for cancelation.
 func (p *Plugin) Run(ctx context.Context) {
-	var err error
-	p.Variables, err = p.loadVariablesFromJSONFile()
-	if err != nil {
+	if err := p.loadMetadata(); err != nil {
+		p.warn(err)
+	}
+	if err := p.loadVariables(); err != nil {
 		p.Debugf("ERR: %s", err)
 		p.OnErr(err)
 	}
-	p.Refresh(ctx)
+	if err := p.makeStateDirs(); err != nil {
+		p.warn(err)
+	}
 	cycleReset := make(chan struct{})
+	if !p.Streamable {
+		if !p.waitForStartup(ctx) {
+			return
+		}
+		p.Refresh(ctx)
+		p.startupDone()
+	}
 	var wg sync.WaitGroup
 	// cycle loop
 	wg.Add(1)
@@ -199,11 +439,12 @@ func (p *Plugin) Run(ctx context.Context) {
 				// this will loop round and start the CycleInterval
 				// timer again.
 				p.CycleIndex = 0
+				p.cycles = 0
 				continue
 			case <-p.cycleSignal:
 				p.Debugf("cycling: %s", filepath.Base(p.Command))
 				p.cycle(ctx)
-			case <-time.After(p.CycleInterval):
+			case <-time.After(p.cycleInterval()):
 				p.Debugf("cycling: %s", filepath.Base(p.Command))
 				p.cycle(ctx)
 			case <-ctx.Done():
@@ -211,17 +452,32 @@ func (p *Plugin) Run(ctx context.Context) {
 			}
 		}
 	}()
+	if len(p.WatchPaths) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.watch(ctx)
+		}()
+	}
 	// refresh (reexecutation) loop
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		if p.Streamable {
+			p.runStream(ctx, cycleReset)
+			return
+		}
 		for {
 			select {
 			case <-p.refreshSignal:
 				p.Debugf("refreshing: %s", filepath.Base(p.Command))
 				p.Refresh(ctx)
 				cycleReset <- struct{}{}
-			case <-time.After(p.RefreshInterval.Duration()):
+			case <-p.refreshTimer():
+				if p.Paused() {
+					// paused since the timer started
+					continue
+				}
 				p.Debugf("refreshing: %s", filepath.Base(p.Command))
 				p.Refresh(ctx)
 				cycleReset <- struct{}{}
@@ -239,13 +495,16 @@ func (p *Plugin) TriggerRefresh() {
 	// disable the menu
 	p.CycleIndex = 0 // reset
 	// just keep the current item
-	currentItem := p.CurrentCycleItem()
+	// (copied so the last items are left alone)
+	currentItem := *p.CurrentCycleItem()
 	//currentItem.Text = "…"
 	currentItem.Params.Disabled = true
 	p.Items.CycleItems = []*Item{
-		currentItem,
+		&currentItem,
 	}
 	p.CycleIndex = 0 // reset
+	// the menu has changed, so it must be updated after the refresh
+	p.itemsHash = ""
 	if p.OnCycle != nil {
 		p.cycleSignal <- struct{}{}
 	}
@@ -253,21 +512,246 @@ func (p *Plugin) TriggerRefresh() {
 	p.refreshSignal <- struct{}{}
 }
 
+// Pause stops the plugin from refreshing on its own, keeping its
+// current Items, until Resume is called.
+// Explicit refreshes (like TriggerRefresh) still run the plugin.
+func (p *Plugin) Pause() {
+	atomic.StoreInt32(&p.paused, 1)
+}
+
+// Resume undoes Pause, and refreshes the plugin.
+func (p *Plugin) Resume() {
+	if atomic.SwapInt32(&p.paused, 0) == 0 {
+		return
+	}
+	p.RequestRefresh()
+}
+
+// Paused gets whether the plugin is paused.
+func (p *Plugin) Paused() bool {
+	return atomic.LoadInt32(&p.paused) != 0
+}
+
+// MenuOpened is called when the plugin's menu is opened, and
+// refreshes plugins with RefreshOnOpen.
+// It doesn't wait for the refresh to finish, and does nothing if a
+// refresh is already waiting to happen.
+func (p *Plugin) MenuOpened() {
+	if !p.RefreshOnOpen {
+		return
+	}
+	p.RequestRefresh()
+}
+
+// RequestRefresh asks the plugin to refresh as soon as it can.
+// Unlike TriggerRefresh, it doesn't change the menu or wait, and
+// does nothing if a refresh is already waiting to happen, or if the
+// plugin is running and its Overlap policy is OverlapSkip.
+func (p *Plugin) RequestRefresh() {
+	if p.Overlap == OverlapSkip && p.Running() {
+		p.Debugf("still running, skipping refresh request")
+		return
+	}
+	select {
+	case p.refreshSignal <- struct{}{}:
+	default:
+	}
+}
+
 // Refresh executes and updates the Plugin.
 // The menu is updated in an instant, unlike with Refresh().
 // Run calls this method periodically.
+// If the plugin is already running, its Overlap policy decides
+// whether to wait for it to finish, or to do nothing.
 func (p *Plugin) Refresh(ctx context.Context) {
-	err := p.refresh(ctx)
-	if err != nil {
-		p.Debugf("ERR: %s", err)
+	if !p.startRun(ctx) {
+		return
+	}
+	defer p.runDone()
+	p.refreshed(ctx, p.refresh(ctx))
+}
+
+// RunOnce runs the plugin once and sets its Items, without
+// refreshing it again later. It returns why the plugin failed, if
+// it did.
+// Streamable plugins are stopped once they first update the Items.
+// It is for running plugins outside of the menu bar, like from the
+// command line.
+func (p *Plugin) RunOnce(ctx context.Context) error {
+	if err := p.loadMetadata(); err != nil {
+		p.warn(err)
+	}
+	if err := p.loadVariables(); err != nil {
+		return err
+	}
+	if err := p.makeStateDirs(); err != nil {
+		p.warn(err)
+	}
+	if p.Streamable {
+		return p.streamOnce(ctx)
+	}
+	return p.refresh(ctx)
+}
+
+// refreshed is called after the plugin has run (or a streamable
+// plugin has output some items) to notify listeners.
+// Listeners are not notified if the Items are the same as last time.
+func (p *Plugin) refreshed(ctx context.Context, err error) {
+	code := exitCode(err)
+	switch {
+	case code == ExitTempFail && p.lastItems != nil:
+		p.Debugf("temporary failure, keeping the previous output: %s", err)
+		p.Items = *p.lastItems
+		err = nil
+	case err != nil && p.KeepOutputOnError && p.lastItems != nil && code != ExitConfigRequired:
+		p.failures++
+		p.Debugf("ERR: %s (failed %d times, keeping the previous output)", err, p.failures)
+		p.Items = *p.lastItems
+		// (capped so appending copies, leaving the last items alone)
+		expanded := p.Items.ExpandedItems
+		p.Items.ExpandedItems = append(expanded[:len(expanded):len(expanded)], p.staleItems(err)...)
+	case err != nil:
+		p.failures++
+		p.Debugf("ERR: %s (failed %d times)", err, p.failures)
 		p.OnErr(err)
+		if code == ExitConfigRequired && p.failures == 1 && p.OnConfigRequired != nil {
+			p.OnConfigRequired(ctx, p)
+		}
+	default:
+		p.failures = 0
+		items := p.Items
+		p.lastItems = &items
+		p.lastItemsAt = time.Now()
 	}
+	if p.Shortcuts != nil {
+		for _, err := range p.Shortcuts.Register(p) {
+			p.Debugf("ERR: %s", err)
+		}
+	}
+	if atomic.SwapInt32(&p.appearanceChanged, 0) != 0 {
+		// the same output might look different now
+		p.itemsHash = ""
+	}
+	itemsHash := p.Items.hash()
+	if err == nil && itemsHash != "" && itemsHash == p.itemsHash {
+		// nothing changed, so don't update the menus
+		p.Debugf("output unchanged")
+		return
+	}
+	p.itemsHash = itemsHash
 	p.CycleIndex = 0 // reset
+	p.cycles = 0
+	if err == nil {
+		p.notify(ctx)
+		p.playAlertSound(ctx)
+	}
 	if p.OnRefresh != nil {
 		p.OnRefresh(ctx, p, err)
 	}
 }
 
+// SplitCycleItem returns the current cycle Item for one of the
+// Items.Splits, or nil if it has none.
+func (p *Plugin) SplitCycleItem(split int) *Item {
+	if split < 0 || split >= len(p.Items.Splits) {
+		return nil
+	}
+	cycleItems := p.Items.Splits[split].CycleItems
+	if len(cycleItems) == 0 {
+		return nil
+	}
+	return cycleItems[p.cycles%len(cycleItems)]
+}
+
+// waitForStartup waits until the plugin may run for the first time.
+// Returns false if the context is done first.
+func (p *Plugin) waitForStartup(ctx context.Context) bool {
+	if p.startup == nil {
+		return true
+	}
+	select {
+	case p.startup <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// startupDone lets the next plugin waiting in waitForStartup run.
+func (p *Plugin) startupDone() {
+	if p.startup == nil {
+		return
+	}
+	<-p.startup
+}
+
+// refreshTimer gets a channel that receives when it is time to
+// refresh the plugin, or nil (which never receives) if the plugin
+// is paused, or only refreshes when its menu is opened.
+func (p *Plugin) refreshTimer() <-chan time.Time {
+	if p.RefreshOnOpen || p.Paused() {
+		return nil
+	}
+	return time.After(p.nextRefresh(time.Now()))
+}
+
+// maxFailureBackoff is the longest that refreshing a failing plugin
+// will be backed off to, unless its RefreshInterval is longer.
+const maxFailureBackoff = 15 * time.Minute
+
+// nextRefresh gets how long to wait from now until the plugin should
+// next be refreshed.
+// Plugins that keep failing, or are throttled, are refreshed less
+// often.
+func (p *Plugin) nextRefresh(now time.Time) time.Duration {
+	delay := p.throttled(addJitter(p.RefreshInterval.Duration(), p.Jitter))
+	if p.Schedule != nil {
+		if next := p.Schedule.Next(now); !next.IsZero() {
+			delay = next.Sub(now)
+		} else {
+			p.Debugf("schedule never matches: using refresh interval")
+		}
+	}
+	if backoff := p.failureBackoff(); backoff > delay {
+		return backoff
+	}
+	return delay
+}
+
+// failureBackoff gets how long to wait before refreshing a failing
+// plugin: the RefreshInterval, doubled for each failure after the
+// first, up to maxFailureBackoff.
+// Returns zero if the plugin isn't failing.
+func (p *Plugin) failureBackoff() time.Duration {
+	if p.failures == 0 {
+		return 0
+	}
+	interval := p.RefreshInterval.Duration()
+	backoff := interval
+	for i := 1; i < p.failures && backoff < maxFailureBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxFailureBackoff {
+		backoff = maxFailureBackoff
+	}
+	if interval > backoff {
+		// never refresh more often than usual
+		return interval
+	}
+	return backoff
+}
+
+// cycleInterval gets how long to show each of the CycleItems for.
+// A cycle parameter on any of the CycleItems overrides CycleInterval.
+func (p *Plugin) cycleInterval() time.Duration {
+	for _, item := range p.Items.CycleItems {
+		if item.Params.Cycle > 0 {
+			return item.Params.Cycle
+		}
+	}
+	return p.CycleInterval
+}
+
 // CurrentCycleItem returns the Item related to the current cycle.
 func (p *Plugin) CurrentCycleItem() *Item {
 	if len(p.Items.CycleItems) == 0 {
@@ -282,37 +766,90 @@ func (p *Plugin) CurrentCycleItem() *Item {
 // refresh runs the plugin and parses the output, updating the
 // state of Plugin.
 func (p *Plugin) refresh(ctx context.Context) error {
-	commandCtx, cancel := context.WithTimeout(ctx, p.Timeout)
-	defer cancel()
-	cmd := exec.CommandContext(commandCtx, "./"+filepath.Base(p.Command))
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
+	if err := p.checkDependencies(ctx); err != nil {
+		return err
 	}
-	cmd.Dir = filepath.Dir(p.Command)
-	// inherit outside environment
-	cmd.Env = append(cmd.Env, os.Environ()...)
-	// add variables from .vars.json file
-	cmd.Env = append(cmd.Env, p.Variables...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	p.reloadVariables()
+	cmd := p.command()
+	stderr := &tailBuffer{max: maxStderrBytes}
+	stdout := &limitedBuffer{max: p.MaxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 	if p.Stdout != nil {
 		cmd.Stdout = io.MultiWriter(cmd.Stdout, p.Stdout)
 	}
 	if p.Stderr != nil {
 		cmd.Stderr = io.MultiWriter(cmd.Stderr, p.Stderr)
 	}
-	if err := cmd.Run(); err != nil {
-		return errExec{
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return errExec{err: err}
+	}
+	waited := watchProcess(ctx, cmd, p.Timeout)
+	err := cmd.Wait()
+	switch {
+	case waited():
+		err = errTimeout{timeout: p.Timeout}
+	case err != nil:
+		err = errExec{
 			err:    err,
 			Stderr: stderr.String(),
 		}
+	default:
+		err = p.update(ctx, stdout)
 	}
-	var err error
-	p.Items, err = p.parseOutput(ctx, filepath.Base(p.Command), &stdout)
+	p.recordOutput(newRun(start, cmd.ProcessState, stdout.buf.Bytes(), stderr.String(), err), stdout.buf.Bytes(), stderr.String())
+	return err
+}
+
+// recordOutput records a run of the plugin (or an update from a
+// streamable one) in its Stats, history and log.
+func (p *Plugin) recordOutput(run Run, stdout []byte, stderr string) {
+	p.recordStats(run)
+	if err := p.recordRun(run); err != nil {
+		p.Debugf("ERR: %s", err)
+	}
+	if err := p.logRun(run, stdout, stderr); err != nil {
+		p.Debugf("ERR: %s", err)
+	}
+}
+
+// command makes the command that runs the plugin.
+// The plugin runs in its own process group, see watchProcess.
+func (p *Plugin) command() *exec.Cmd {
+	// inherit outside environment
+	env := os.Environ()
+	env = append(env, p.Env...)
+	// add variables from .vars.json file
+	env = append(env, p.expandVariables(env)...)
+	env = append(env, p.stateDirsEnv()...)
+	name, args := p.commandLine(env)
+	cmd := exec.Command(name, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	cmd.Dir = p.workingDir()
+	cmd.Env = env
+	return cmd
+}
+
+// update parses the output of the plugin, and updates the Items.
+func (p *Plugin) update(ctx context.Context, stdout *limitedBuffer) error {
+	items, err := p.parseOutput(ctx, filepath.Base(p.Command), bytes.NewReader(stdout.completeLines()))
 	if err != nil {
 		return errors.Wrap(err, "parse stdout")
 	}
+	if stdout.truncated {
+		p.warn(errors.Errorf("output truncated to %d bytes", p.MaxOutputBytes))
+		items.ExpandedItems = append(items.ExpandedItems, p.outputTruncatedItems()...)
+	}
+	p.fetchImages(ctx, items.CycleItems)
+	p.fetchImages(ctx, items.ExpandedItems)
+	for _, split := range items.Splits {
+		p.fetchImages(ctx, split.CycleItems)
+		p.fetchImages(ctx, split.ExpandedItems)
+	}
+	p.Items = items
 	return nil
 }
 
@@ -344,15 +881,174 @@ func (p *Plugin) loadVariablesFromJSONFile() ([]string, error) {
 	return vars, nil
 }
 
+// loadMetadata configures the plugin from the metadata in its source
+// (or sidecar file): when it refreshes and cycles (xbar.cycle,
+// xbar.schedule, xbar.jitter, xbar.refreshOnOpen, xbar.throttle,
+// xbar.watch), how it runs (xbar.timeout, xbar.maxoutput,
+// xbar.streamable, xbar.overlap, xbar.keepOutputOnError, xbar.cwd,
+// xbar.interpreter), the Dependencies to check, which variables are
+// secret, and whether it depends on the appearance.
+// Metadata that can't be used is skipped so the rest still applies,
+// and the problems are returned together as metadataErrors.
+func (p *Plugin) loadMetadata() error {
+	// fields that can't be used are skipped, so the rest still apply
+	var errs metadataErrors
+	md, err := metadata.ParseFile(metadata.DebugfNoop, p.Command)
+	if tagErrs, ok := err.(metadata.TagErrors); ok {
+		errs = append(errs, tagErrs...)
+	} else if err != nil {
+		return errors.Wrap(err, "parse metadata")
+	}
+	if md.Cycle != "" {
+		cycle, err := time.ParseDuration(md.Cycle)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "xbar.cycle"))
+		} else {
+			p.CycleInterval = cycle
+		}
+	}
+	if md.MaxOutput != "" {
+		maxOutput, err := metadata.ParseSize(md.MaxOutput)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "xbar.maxoutput"))
+		} else {
+			p.MaxOutputBytes = maxOutput
+		}
+	}
+	if md.Schedule != "" {
+		schedule, err := metadata.ParseSchedule(md.Schedule)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "xbar.schedule"))
+		} else {
+			p.Schedule = schedule
+		}
+	}
+	if md.Jitter != "" {
+		jitter, err := metadata.ParsePercent(md.Jitter)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "xbar.jitter"))
+		} else {
+			p.Jitter = jitter
+		}
+	}
+	if md.Timeout != "" {
+		timeout, err := time.ParseDuration(md.Timeout)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "xbar.timeout"))
+		} else {
+			p.Timeout = timeout
+		}
+	}
+	if md.RefreshOnOpen {
+		p.RefreshOnOpen = true
+	}
+	for _, path := range md.Watch {
+		path, err := resolveOpenPath(filepath.Dir(p.Command), path)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "xbar.watch"))
+			continue
+		}
+		p.WatchPaths = append(p.WatchPaths, path)
+	}
+	if md.Streamable {
+		p.Streamable = true
+	}
+	if md.KeepOutputOnError {
+		p.KeepOutputOnError = true
+	}
+	if md.Overlap != "" {
+		p.Overlap = md.Overlap
+	}
+	if md.NeverThrottle {
+		p.NeverThrottle = true
+	}
+	if usesAppearance(p.Command) {
+		p.AppearanceSensitive = true
+	}
+	p.secretVars = secretVarNames(md.Vars)
+	p.Dependencies = md.DependencyDetails
+	if md.Cwd != "" {
+		workingDir, err := resolveOpenPath(filepath.Dir(p.Command), md.Cwd)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "xbar.cwd"))
+		} else {
+			p.WorkingDir = workingDir
+		}
+	}
+	if md.Interpreter != "" {
+		var err error
+		interpreter := strings.Fields(md.Interpreter)
+		if len(interpreter) > 0 && strings.Contains(interpreter[0], "/") {
+			// like ~/.venv/bin/python or ./venv/bin/python
+			interpreter[0], err = resolveOpenPath(filepath.Dir(p.Command), interpreter[0])
+		}
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "xbar.interpreter"))
+		} else {
+			p.Interpreter = interpreter
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// metadataErrors are the errors for the metadata fields of a plugin
+// that couldn't be used.
+type metadataErrors []error
+
+func (e metadataErrors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// warn is called when something is wrong that doesn't stop the
+// plugin from running.
+func (p *Plugin) warn(err error) {
+	p.Debugf("ERR: %s", err)
+	if p.OnWarning != nil {
+		p.OnWarning(p, err)
+	}
+}
+
 // OnErr is called when something has gone wrong at some point.
 func (p *Plugin) OnErr(err error) {
+	icon := "⚠️"
+	if _, ok := errors.Cause(err).(errTimeout); ok {
+		icon = "⏱"
+	}
+	if exitCode(err) == ExitConfigRequired {
+		icon = "⚙️"
+	}
 	p.Items.CycleItems = []*Item{
 		{
 			Plugin: p,
-			Text:   "⚠️ " + p.CleanFilename(),
+			Text:   icon + " " + p.CleanFilename(),
 		},
 	}
-	p.Items.ExpandedItems = p.stringToItems(err.Error())
+	p.Items.ExpandedItems = p.errorItems(err)
+	if p.failures > 1 {
+		p.Items.ExpandedItems = append(p.Items.ExpandedItems,
+			&Item{
+				Plugin: p,
+				Params: ItemParams{
+					Separator: true,
+				},
+			},
+			&Item{
+				Plugin: p,
+				Text:   fmt.Sprintf("Failed %d times in a row, next try in %s", p.failures, p.failureBackoff()),
+				Params: ItemParams{
+					Dropdown: true,
+					Disabled: true,
+				},
+			},
+		)
+	}
 }
 
 // errExec is used for plugin execution errors.
@@ -370,6 +1066,16 @@ func (e errExec) Error() string {
 	return e.err.Error()
 }
 
+// exitCode gets the exit code of the process, or -1 if it didn't
+// exit normally.
+func (e errExec) exitCode() int {
+	var exitErr *exec.ExitError
+	if !errors.As(e.err, &exitErr) {
+		return -1
+	}
+	return exitErr.ExitCode()
+}
+
 // stringToItems turns a string into one or more Item objects,
 // breaking long strings down effectively wrapping them.
 func (p *Plugin) stringToItems(s string) []*Item {
@@ -2,6 +2,8 @@ package plugins
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -58,6 +60,33 @@ func TestRun(t *testing.T) {
 
 }
 
+func TestDirs(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-dirs-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	personal := filepath.Join(dir, "personal")
+	dotfiles := filepath.Join(dir, "dotfiles")
+	for _, filename := range []string{
+		filepath.Join(personal, "weather.1m.sh"),
+		filepath.Join(dotfiles, "weather.5m.sh"),
+		filepath.Join(dotfiles, "github.1h.sh"),
+		filepath.Join(dotfiles, "disabled.1h.sh.off"),
+	} {
+		is.NoErr(os.MkdirAll(filepath.Dir(filename), 0777))
+		is.NoErr(ioutil.WriteFile(filename, []byte("#!/bin/bash"), 0777))
+	}
+
+	plugins, err := Dirs(personal, dotfiles, filepath.Join(dir, "missing"))
+	is.NoErr(err)
+	is.Equal(len(plugins), 2)
+	is.Equal(plugins[0].Command, filepath.Join(personal, "weather.1m.sh")) // earlier directory wins
+	is.Equal(plugins[1].Command, filepath.Join(dotfiles, "github.1h.sh"))
+}
+
 func TestRunStderr(t *testing.T) {
 	is := is.New(t)
 
@@ -110,6 +139,38 @@ func TestPluginOnRefresh(t *testing.T) {
 	is.Equal(OnRefreshCalls, 1) // OnRefreshCalls
 }
 
+func TestPluginOnRefreshUnchanged(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	p := NewPlugin(filepath.Join("testdata", "plugins", "simple.1s.sh"))
+	OnRefreshCalls := 0
+	p.OnRefresh = func(ctx context.Context, plugin *Plugin, err error) {
+		OnRefreshCalls++
+	}
+	p.Refresh(ctx)
+	is.Equal(OnRefreshCalls, 1)
+	p.Refresh(ctx)
+	is.Equal(OnRefreshCalls, 1) // output didn't change
+
+	// the menu changes when a refresh is triggered
+	p.itemsHash = ""
+	p.Refresh(ctx)
+	is.Equal(OnRefreshCalls, 2)
+
+	// errors are always shown
+	p.refreshed(ctx, errors.New("boom"))
+	is.Equal(OnRefreshCalls, 3)
+	p.Refresh(ctx)
+	is.Equal(OnRefreshCalls, 4)
+
+	// the same output is drawn again for the new appearance
+	p.AppearanceChanged()
+	p.Refresh(ctx)
+	is.Equal(OnRefreshCalls, 5)
+	p.Refresh(ctx)
+	is.Equal(OnRefreshCalls, 5)
+}
+
 func TestPluginSimple(t *testing.T) {
 	is := is.New(t)
 	ctx := context.Background()
@@ -125,6 +186,89 @@ func TestPluginSimple(t *testing.T) {
 	is.Equal(len(p.Items.ExpandedItems), 0)
 }
 
+func TestPluginRunOnce(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+	p := NewPlugin(filepath.Join("testdata", "plugins", "simple.1s.sh"))
+	OnRefreshCalls := 0
+	p.OnRefresh = func(context.Context, *Plugin, error) {
+		OnRefreshCalls++
+	}
+	err := p.RunOnce(ctx)
+	is.NoErr(err)
+	is.Equal(len(p.Items.CycleItems), 3)
+	is.Equal(p.Items.CycleItems[0].Text, "one")
+	is.Equal(OnRefreshCalls, 0) // RunOnce doesn't notify listeners
+}
+
+func TestPluginRunOnceStreamable(t *testing.T) {
+	is := is.New(t)
+	dir, err := os.MkdirTemp("", "xbar-run-once-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "live.1h.sh")
+	err = os.WriteFile(command, []byte(`#!/bin/bash
+# <xbar.streamable>true</xbar.streamable>
+echo "first"
+echo "~~~"
+echo "second"
+echo "~~~"
+sleep 60
+`), 0777)
+	is.NoErr(err)
+	p := NewPlugin(command)
+	OnRefreshCalls := 0
+	p.OnRefresh = func(context.Context, *Plugin, error) {
+		OnRefreshCalls++
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	err = p.RunOnce(ctx)
+	is.NoErr(err)
+	is.True(time.Since(start) < 5*time.Second) // stopped after the first update
+	is.Equal(p.Streamable, true)
+	is.Equal(p.Items.CycleItems[0].Text, "first")
+	is.Equal(OnRefreshCalls, 0)
+}
+
+func TestPluginRunOnceWarnings(t *testing.T) {
+	is := is.New(t)
+	dir, err := os.MkdirTemp("", "xbar-run-once-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "warn.1h.sh")
+	err = os.WriteFile(command, []byte(`#!/bin/bash
+# <xbar.cycle>often</xbar.cycle>
+echo "Hello | image=@@@"
+`), 0777)
+	is.NoErr(err)
+	p := NewPlugin(command)
+	var warnings []string
+	p.OnWarning = func(p *Plugin, err error) {
+		warnings = append(warnings, err.Error())
+	}
+	err = p.RunOnce(context.Background())
+	is.NoErr(err)
+	is.Equal(p.Items.CycleItems[0].Text, "Hello")
+	is.Equal(len(warnings), 2)
+	is.True(strings.Contains(warnings[0], "xbar.cycle"))
+	is.True(strings.Contains(warnings[1], "ignoring the image"))
+}
+
+func TestPluginMetadataSidecar(t *testing.T) {
+	is := is.New(t)
+	p := NewPlugin(filepath.Join("testdata", "plugins", "simple.1m.sh"))
+	is.NoErr(p.loadMetadata())
+	is.Equal(p.CycleInterval, 2*time.Second) // from simple.1m.sh.yaml
+}
+
 func TestPluginExpanded(t *testing.T) {
 	is := is.New(t)
 	ctx := context.Background()
@@ -205,6 +349,168 @@ func TestCycleItems(t *testing.T) {
 
 }
 
+func TestCycleInterval(t *testing.T) {
+	is := is.New(t)
+
+	p := NewPlugin(filepath.Join("testdata", "cycle-test", "cycle.10s.sh"))
+	is.Equal(p.cycleInterval(), 5*time.Second) // default
+
+	err := p.loadMetadata()
+	is.NoErr(err)
+	is.Equal(p.CycleInterval, 2*time.Second) // from xbar.cycle
+	is.Equal(p.cycleInterval(), 2*time.Second)
+
+	items, err := p.parseOutput(context.Background(), "cycle.txt", strings.NewReader(strings.TrimSpace(`
+one
+two | cycle=500ms
+	`)))
+	is.NoErr(err)
+	p.Items = items
+	is.Equal(p.cycleInterval(), 500*time.Millisecond) // cycle parameter wins
+
+	_, err = p.parseOutput(context.Background(), "cycle.txt", strings.NewReader(`one | cycle=fast`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `cycle.txt:1: cycle: expected a duration (like 5s), not "fast"`)
+}
+
+func TestLoadMetadataErrors(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-metadata-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "broken.1m.sh")
+	is.NoErr(os.WriteFile(command, []byte(`#!/bin/bash
+# <xbar.cycle>fast</xbar.cycle>
+# <xbar.timeout>soon</xbar.timeout>
+# <xbar.var secret="true">string(VAR_TOKEN=""): Your API token.</xbar.var>
+# <xbar.cwd>/tmp</xbar.cwd>
+# <xbar.minversion>soon</xbar.minversion>
+# <xbar.interpreter>python3</xbar.interpreter>
+echo hi
+`), 0777))
+
+	p := NewPlugin(command)
+	err = p.loadMetadata()
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "xbar.cycle"))
+	is.True(strings.Contains(err.Error(), "xbar.timeout"))
+	is.True(strings.Contains(err.Error(), "xbar.minVersion"))
+	is.True(!strings.Contains(err.Error(), "xbar.interpreter"))
+	// the other fields still apply
+	is.Equal(p.secretVars, []string{"VAR_TOKEN"})
+	is.Equal(p.WorkingDir, "/tmp")
+	is.Equal(p.Interpreter, []string{"python3"})
+}
+
+func TestNextRefresh(t *testing.T) {
+	is := is.New(t)
+
+	p := NewPlugin(filepath.Join("testdata", "schedule-test", "work-hours.1m.sh"))
+	now := time.Date(2021, time.March, 19, 16, 58, 30, 0, time.UTC) // Friday
+	is.Equal(p.nextRefresh(now), 1*time.Minute)                     // from filename
+
+	err := p.loadMetadata()
+	is.NoErr(err)
+	is.True(p.Schedule != nil)
+	is.Equal(p.nextRefresh(now), 90*time.Second) // 17:00
+	weekend := time.Date(2021, time.March, 20, 12, 0, 0, 0, time.UTC)
+	is.Equal(p.nextRefresh(weekend), 45*time.Hour) // Monday 09:00
+}
+
+func TestJitter(t *testing.T) {
+	is := is.New(t)
+
+	realJitter := randomJitter
+	defer func() {
+		randomJitter = realJitter
+	}()
+	p := NewPlugin("jitter.1m.sh")
+	now := time.Now()
+	randomJitter = func() float64 { return 1 }
+	is.Equal(p.nextRefresh(now), 1*time.Minute) // no jitter by default
+
+	p.Jitter = 0.1
+	is.Equal(p.nextRefresh(now), 66*time.Second)
+	randomJitter = func() float64 { return -0.5 }
+	is.Equal(p.nextRefresh(now), 57*time.Second)
+
+	// the real thing stays in range
+	randomJitter = realJitter
+	for i := 0; i < 100; i++ {
+		d := p.nextRefresh(now)
+		is.True(d >= 54*time.Second && d <= 66*time.Second)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	is := is.New(t)
+
+	p := NewPlugin(filepath.Join("testdata", "plugins", "simple.1m.sh"))
+	now := time.Now()
+	is.Equal(p.nextRefresh(now), 1*time.Minute)
+	p.SetThrottle(2.5)
+	is.Equal(p.nextRefresh(now), 150*time.Second)
+	p.SetThrottle(1)
+	is.Equal(p.nextRefresh(now), 1*time.Minute)
+
+	p.SetThrottle(3)
+	p.NeverThrottle = true
+	is.Equal(p.nextRefresh(now), 1*time.Minute)
+}
+
+func TestPause(t *testing.T) {
+	is := is.New(t)
+
+	p := NewPlugin(filepath.Join("testdata", "cycle-test", "cycle.10s.sh"))
+	p.RefreshInterval = RefreshInterval{N: 50, Unit: "milliseconds"}
+	var lock sync.Mutex
+	var refreshes int
+	p.OnRefresh = func(ctx context.Context, p *Plugin, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		refreshes++
+		// count every run, even though the output is the same
+		p.itemsHash = ""
+	}
+	count := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return refreshes
+	}
+	p.Pause()
+	is.True(p.Paused())
+	is.True(p.refreshTimer() == nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+	time.Sleep(300 * time.Millisecond)
+	is.Equal(count(), 1) // only the first run
+	is.Equal(len(p.Items.CycleItems), 3)
+
+	p.Resume()
+	is.True(!p.Paused())
+	time.Sleep(300 * time.Millisecond)
+	is.True(count() > 2) // refreshing again
+}
+
+func TestRefreshOnOpen(t *testing.T) {
+	is := is.New(t)
+
+	p := NewPlugin("lazy.1m.sh")
+	p.MenuOpened()
+	is.Equal(len(p.refreshSignal), 0) // refreshes on a timer
+	is.True(p.refreshTimer() != nil)
+
+	p.RefreshOnOpen = true
+	is.True(p.refreshTimer() == nil) // never
+	p.MenuOpened()
+	p.MenuOpened() // doesn't block
+	is.Equal(len(p.refreshSignal), 1)
+}
+
 func TestSubmenus(t *testing.T) {
 	is := is.New(t)
 
@@ -266,6 +572,50 @@ It also contains a very long line which should be wrapped, because otherwise it
 
 }
 
+func TestFailureBackoff(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-backoff-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "broken.1m.sh")
+	err = os.WriteFile(command, []byte("#!/bin/bash\nexit 1\n"), 0777)
+	is.NoErr(err)
+	p := NewPlugin(command)
+	now := time.Now()
+	is.Equal(p.nextRefresh(now), 1*time.Minute)
+
+	var expected = []time.Duration{
+		1 * time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		8 * time.Minute,
+		15 * time.Minute, // capped
+		15 * time.Minute,
+	}
+	for _, backoff := range expected {
+		p.Refresh(context.Background())
+		is.Equal(p.nextRefresh(now), backoff)
+	}
+	is.Equal(p.Items.CycleItems[0].Text, "⚠️ broken.1m.sh")
+	last := p.Items.ExpandedItems[len(p.Items.ExpandedItems)-1]
+	is.Equal(last.Text, "Failed 6 times in a row, next try in 15m0s")
+
+	// working again
+	err = os.WriteFile(command, []byte("#!/bin/bash\necho fixed\n"), 0777)
+	is.NoErr(err)
+	p.Refresh(context.Background())
+	is.Equal(p.nextRefresh(now), 1*time.Minute)
+	is.Equal(p.Items.CycleItems[0].Text, "fixed")
+
+	// long intervals are not shortened
+	p.RefreshInterval = RefreshInterval{N: 1, Unit: "hours"}
+	p.failures = 3
+	is.Equal(p.nextRefresh(now), 1*time.Hour)
+}
+
 func TestEnvironmentVariables(t *testing.T) {
 	is := is.New(t)
 
@@ -295,6 +645,14 @@ func TestEnvironmentVariables(t *testing.T) {
 	is.Equal(p.Items.CycleItems[0].Text, `XBAR_TEST_EXPLICIT_VAR=explicit`)
 	is.Equal(p.Items.CycleItems[1].Text, `XBAR_TEST_SET_IN_VARS_JSON=json`)
 
+	// Env overrides the outside environment, but not the variables
+	p.Env = []string{
+		"XBAR_TEST_EXPLICIT_VAR=from settings",
+		"XBAR_TEST_SET_IN_VARS_JSON=from settings",
+	}
+	p.Refresh(context.Background())
+	is.Equal(p.Items.CycleItems[0].Text, `XBAR_TEST_EXPLICIT_VAR=from settings`)
+	is.Equal(p.Items.CycleItems[1].Text, `XBAR_TEST_SET_IN_VARS_JSON=json`)
 }
 
 func TestCleanFilename(t *testing.T) {
@@ -333,3 +691,95 @@ func TestPluginWontQuit(t *testing.T) {
 	p.Refresh(ctx)
 	p.Refresh(ctx)
 }
+
+func TestRunStaggered(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-staggered-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	var ps Plugins
+	for i := 0; i < 6; i++ {
+		command := filepath.Join(dir, fmt.Sprintf("slow-%d.1h.sh", i))
+		err := os.WriteFile(command, []byte("#!/bin/bash\nsleep 0.1\necho slow\n"), 0777)
+		is.NoErr(err)
+		ps = append(ps, NewPlugin(command))
+	}
+	var lock sync.Mutex
+	var running, maxRunning, refreshes int
+	for _, p := range ps {
+		p.Stdout = writerFunc(func(b []byte) (int, error) {
+			// called while the plugin is still running
+			lock.Lock()
+			defer lock.Unlock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			return len(b), nil
+		})
+		p.OnRefresh = func(ctx context.Context, p *Plugin, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			running--
+			refreshes++
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	start := time.Now()
+	ps.RunStaggered(ctx, 2, 10*time.Millisecond)
+	is.Equal(refreshes, 6)
+	is.True(maxRunning <= 2)
+	is.True(time.Since(start) >= 50*time.Millisecond) // staggered
+}
+
+type writerFunc func(b []byte) (int, error)
+
+func (fn writerFunc) Write(b []byte) (int, error) {
+	return fn(b)
+}
+
+func TestOverlap(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-overlap-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "counter.1h.sh")
+	script := "#!/bin/bash\necho run >> runs.txt\nsleep 0.2\nwc -l < runs.txt\n"
+	err = os.WriteFile(command, []byte(script), 0777)
+	is.NoErr(err)
+	ctx := context.Background()
+	for _, test := range []struct {
+		overlap string
+		runs    int
+	}{
+		{overlap: OverlapQueue, runs: 2},
+		{overlap: OverlapSkip, runs: 1},
+	} {
+		os.Remove(filepath.Join(dir, "runs.txt"))
+		p := NewPlugin(command)
+		p.Overlap = test.overlap
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Refresh(ctx)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		is.True(p.Running())
+		p.RequestRefresh()
+		is.Equal(len(p.refreshSignal), test.runs-1) // test.overlap
+		p.Refresh(ctx)
+		wg.Wait()
+		is.True(!p.Running())
+		b, err := os.ReadFile(filepath.Join(dir, "runs.txt"))
+		is.NoErr(err)
+		is.Equal(strings.Count(string(b), "run"), test.runs) // test.overlap
+	}
+}
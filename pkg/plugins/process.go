@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killGracePeriod is how long a plugin has to exit after being sent
+// SIGTERM, before it is sent SIGKILL.
+var killGracePeriod = 5 * time.Second
+
+// errTimeout is returned when a plugin takes longer than its
+// Timeout to run.
+type errTimeout struct {
+	timeout time.Duration
+}
+
+func (e errTimeout) Error() string {
+	return fmt.Sprintf("timed out after %s", e.timeout)
+}
+
+// watchProcess stops the started command (and any processes it
+// started) when ctx is done, or if it is still running after timeout
+// (zero for no timeout).
+// When the timeout is reached, the processes are sent SIGTERM, and
+// then SIGKILL if they haven't exited after killGracePeriod.
+// Call the returned function after cmd.Wait, it returns whether the
+// timeout was reached.
+func watchProcess(ctx context.Context, cmd *exec.Cmd, timeout time.Duration) func() bool {
+	exited := make(chan struct{})
+	timedOut := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		var timer <-chan time.Time
+		if timeout > 0 {
+			t := time.NewTimer(timeout)
+			defer t.Stop()
+			timer = t.C
+		}
+		select {
+		case <-exited:
+			return
+		case <-ctx.Done():
+			killProcessGroup(cmd, syscall.SIGKILL)
+			return
+		case <-timer:
+			close(timedOut)
+			killProcessGroup(cmd, syscall.SIGTERM)
+		}
+		select {
+		case <-exited:
+		case <-time.After(killGracePeriod):
+			killProcessGroup(cmd, syscall.SIGKILL)
+		case <-ctx.Done():
+			killProcessGroup(cmd, syscall.SIGKILL)
+		}
+	}()
+	return func() bool {
+		close(exited)
+		<-stopped
+		select {
+		case <-timedOut:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// killProcessGroup sends the signal to the command's process group,
+// so that children don't keep running (or keep stdout open).
+func killProcessGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	_ = syscall.Kill(-cmd.Process.Pid, sig)
+}
@@ -0,0 +1,57 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestTimeout(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-timeout-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "stubborn.1m.sh")
+	err = os.WriteFile(command, []byte(`#!/bin/bash
+# <xbar.timeout>200ms</xbar.timeout>
+trap '' TERM
+echo "never finishes"
+sleep 30
+`), 0777)
+	is.NoErr(err)
+	defer func(d time.Duration) {
+		killGracePeriod = d
+	}(killGracePeriod)
+	killGracePeriod = 200 * time.Millisecond
+
+	p := NewPlugin(command)
+	is.NoErr(p.loadMetadata())
+	is.Equal(p.Timeout, 200*time.Millisecond)
+	start := time.Now()
+	err = p.refresh(context.Background())
+	is.True(err != nil)
+	is.Equal(err.Error(), "timed out after 200ms")
+	is.True(time.Since(start) < 5*time.Second) // SIGKILL'd after ignoring SIGTERM
+
+	p.OnErr(err)
+	is.Equal(p.Items.CycleItems[0].Text, "⏱ stubborn.1m.sh")
+	is.True(strings.Contains(p.Items.ExpandedItems[0].Text, "timed out"))
+}
+
+func TestTimeoutNotReached(t *testing.T) {
+	is := is.New(t)
+
+	p := NewPlugin(filepath.Join("testdata", "cycle-test", "cycle.10s.sh"))
+	p.Timeout = 10 * time.Second
+	err := p.refresh(context.Background())
+	is.NoErr(err)
+	is.Equal(len(p.Items.CycleItems), 3)
+}
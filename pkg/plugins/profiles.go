@@ -0,0 +1,184 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Profile is a named set of plugins to have enabled (and the
+// variables to give them) for a context, like work, home, or
+// presenting.
+type Profile struct {
+	// Name is the name of the profile, shown in the menu.
+	Name string `json:"name"`
+	// Plugins are the plugins in the profile, by their names (see
+	// StateName), so the profile survives their refresh time
+	// changing.
+	// Plugins that aren't in the profile are left alone when it is
+	// applied.
+	Plugins map[string]ProfilePlugin `json:"plugins"`
+}
+
+// ProfilePlugin is the state of a plugin in a Profile.
+type ProfilePlugin struct {
+	// Enabled is whether the plugin is enabled.
+	Enabled bool `json:"enabled"`
+	// Variables are the values of the plugin's variables, which
+	// override the ones it has when the profile is applied.
+	// The values of secret variables are kept in the Keychain, so
+	// they aren't included.
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// LoadProfiles loads the profiles from the file.
+// If there is no file, there are no profiles.
+func LoadProfiles(filename string) ([]Profile, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "ReadFile")
+	}
+	var profiles []Profile
+	if err := json.Unmarshal(b, &profiles); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	return profiles, nil
+}
+
+// SaveProfiles saves the profiles to the file.
+func SaveProfiles(filename string, profiles []Profile) error {
+	b, err := json.MarshalIndent(profiles, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "json.MarshalIndent")
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return errors.Wrap(err, "MkdirAll")
+	}
+	if err := ioutil.WriteFile(filename, b, 0666); err != nil {
+		return errors.Wrap(err, "WriteFile")
+	}
+	return nil
+}
+
+// SaveProfile saves the profile to the file, replacing the one with
+// the same name, if there is one.
+func SaveProfile(filename string, profile Profile) error {
+	if profile.Name == "" {
+		return errors.New("profile needs a name")
+	}
+	profiles, err := LoadProfiles(filename)
+	if err != nil {
+		return err
+	}
+	i := profileIndex(profiles, profile.Name)
+	if i == -1 {
+		profiles = append(profiles, profile)
+	} else {
+		profiles[i] = profile
+	}
+	return SaveProfiles(filename, profiles)
+}
+
+// DeleteProfile removes the profile with the name from the file.
+func DeleteProfile(filename, name string) error {
+	profiles, err := LoadProfiles(filename)
+	if err != nil {
+		return err
+	}
+	i := profileIndex(profiles, name)
+	if i == -1 {
+		return errors.Errorf("no profile called %q", name)
+	}
+	profiles = append(profiles[:i], profiles[i+1:]...)
+	return SaveProfiles(filename, profiles)
+}
+
+// FindProfile gets the profile with the name from the file.
+func FindProfile(filename, name string) (Profile, error) {
+	profiles, err := LoadProfiles(filename)
+	if err != nil {
+		return Profile{}, err
+	}
+	i := profileIndex(profiles, name)
+	if i == -1 {
+		return Profile{}, errors.Errorf("no profile called %q", name)
+	}
+	return profiles[i], nil
+}
+
+// profileIndex gets the index of the profile with the name, or -1
+// if there isn't one.
+func profileIndex(profiles []Profile, name string) int {
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// CaptureProfile makes a profile called name from which of the
+// plugins in pluginDir are enabled, and their variables.
+func CaptureProfile(pluginDir, name string) (Profile, error) {
+	installedPlugins, err := GetInstalledPlugins(pluginDir)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile := Profile{
+		Name:    name,
+		Plugins: make(map[string]ProfilePlugin, len(installedPlugins)),
+	}
+	for _, installedPlugin := range installedPlugins {
+		values, err := loadVariablesFile(pluginDir, installedPlugin.Path)
+		if err != nil {
+			return Profile{}, errors.Wrap(err, installedPlugin.Path)
+		}
+		profilePlugin := ProfilePlugin{
+			Enabled: installedPlugin.Enabled,
+		}
+		if len(values) > 0 {
+			profilePlugin.Variables = values
+		}
+		profile.Plugins[StateName(installedPlugin.Path)] = profilePlugin
+	}
+	return profile, nil
+}
+
+// ApplyProfile enables and disables the plugins in pluginDir, and
+// sets their variables, to match the profile.
+func ApplyProfile(pluginDir string, profile Profile) error {
+	installedPlugins, err := GetInstalledPlugins(pluginDir)
+	if err != nil {
+		return err
+	}
+	for _, installedPlugin := range installedPlugins {
+		profilePlugin, ok := profile.Plugins[StateName(installedPlugin.Path)]
+		if !ok {
+			continue
+		}
+		path, err := SetEnabled(pluginDir, installedPlugin.Path, profilePlugin.Enabled)
+		if err != nil {
+			return errors.Wrap(err, installedPlugin.Path)
+		}
+		if len(profilePlugin.Variables) == 0 {
+			continue
+		}
+		values, err := loadVariablesFile(pluginDir, path)
+		if err != nil {
+			return errors.Wrap(err, path)
+		}
+		for name, value := range profilePlugin.Variables {
+			values[name] = value
+		}
+		if err := SaveVariableValues(pluginDir, path, values); err != nil {
+			return errors.Wrap(err, path)
+		}
+	}
+	return nil
+}
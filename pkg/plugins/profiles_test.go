@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestProfiles(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-profiles-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	pluginDir := filepath.Join(dir, "plugins")
+	profilesFile := filepath.Join(dir, "xbar", "profiles.json")
+	is.NoErr(os.MkdirAll(pluginDir, 0777))
+	for _, filename := range []string{"chat.1m.sh", "ci.5m.sh", "weather.1h.sh.off"} {
+		is.NoErr(ioutil.WriteFile(filepath.Join(pluginDir, filename), []byte("#!/bin/bash"), 0777))
+	}
+	is.NoErr(SaveVariableValues(pluginDir, "chat.1m.sh", map[string]interface{}{"CHANNEL": "general"}))
+
+	profiles, err := LoadProfiles(profilesFile)
+	is.NoErr(err)
+	is.Equal(len(profiles), 0) // no file, no profiles
+
+	work, err := CaptureProfile(pluginDir, "Work")
+	is.NoErr(err)
+	is.Equal(len(work.Plugins), 3)
+	is.Equal(work.Plugins["chat.sh"].Enabled, true)
+	is.Equal(work.Plugins["chat.sh"].Variables["CHANNEL"], "general")
+	is.Equal(work.Plugins["weather.sh"].Enabled, false)
+	is.NoErr(SaveProfile(profilesFile, work))
+
+	presenting := Profile{
+		Name: "Presenting",
+		Plugins: map[string]ProfilePlugin{
+			"chat.sh":    {Enabled: false},
+			"weather.sh": {Enabled: true},
+		},
+	}
+	is.NoErr(SaveProfile(profilesFile, presenting))
+	is.True(SaveProfile(profilesFile, Profile{}) != nil) // needs a name
+
+	profiles, err = LoadProfiles(profilesFile)
+	is.NoErr(err)
+	is.Equal(len(profiles), 2)
+	is.Equal(profiles[0].Name, "Work")
+	is.Equal(profiles[1].Name, "Presenting")
+
+	profile, err := FindProfile(profilesFile, "Presenting")
+	is.NoErr(err)
+	is.NoErr(ApplyProfile(pluginDir, profile))
+	installedPlugins, err := GetInstalledPlugins(pluginDir)
+	is.NoErr(err)
+	is.Equal(len(installedPlugins), 3)
+	is.Equal(installedPlugins[0].Path, "chat.1m.sh.off")
+	is.Equal(installedPlugins[1].Path, "ci.5m.sh") // not in the profile
+	is.Equal(installedPlugins[2].Path, "weather.1h.sh")
+
+	// the variables go with the disabled plugin, and are overridden
+	// by the profile
+	work.Plugins["chat.sh"] = ProfilePlugin{
+		Enabled:   true,
+		Variables: map[string]interface{}{"CHANNEL": "random"},
+	}
+	is.NoErr(ApplyProfile(pluginDir, work))
+	values, err := LoadVariableValues(pluginDir, "chat.1m.sh")
+	is.NoErr(err)
+	is.Equal(values["CHANNEL"], "random")
+	_, err = os.Stat(filepath.Join(pluginDir, "weather.1h.sh.off"))
+	is.NoErr(err)
+
+	is.NoErr(DeleteProfile(profilesFile, "Work"))
+	_, err = FindProfile(profilesFile, "Work")
+	is.True(err != nil) // deleted
+	is.True(DeleteProfile(profilesFile, "Work") != nil)
+}
@@ -0,0 +1,66 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// progressBarWidth is the number of characters in a progress bar.
+	progressBarWidth = 10
+	// progressBarFull and progressBarEmpty are the characters that
+	// make up the progress bar.
+	progressBarFull  = "█"
+	progressBarEmpty = "░"
+)
+
+// parseProgress parses a progress value, either as a fraction
+// (0.45), a ratio (45/100) or a percentage (45%).
+// Returns a nice error if it fails, or if the progress is not
+// between zero and one.
+func parseProgress(s string) (float64, error) {
+	var (
+		progress float64
+		err      error
+	)
+	switch {
+	case strings.HasSuffix(s, "%"):
+		progress, err = strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		progress /= 100
+	case strings.Contains(s, "/"):
+		parts := strings.SplitN(s, "/", 2)
+		var n, total float64
+		n, err = strconv.ParseFloat(parts[0], 64)
+		if err == nil {
+			total, err = strconv.ParseFloat(parts[1], 64)
+		}
+		if err == nil && total <= 0 {
+			return 0, errors.Errorf(`expected a positive total, not "%s"`, s)
+		}
+		progress = n / total
+	default:
+		progress, err = strconv.ParseFloat(s, 64)
+	}
+	if err != nil || math.IsNaN(progress) {
+		return 0, errors.Errorf(`expected a fraction (like 0.45 or 45/100), not "%s"`, s)
+	}
+	if progress < 0 || progress > 1 {
+		return 0, errors.Errorf(`expected progress between 0 and 1, not "%s"`, s)
+	}
+	return progress, nil
+}
+
+// progressBar renders the progress (between zero and one) as a
+// text progress bar with a percentage, like ████░░░░░░ 45%.
+func progressBar(progress float64) string {
+	full := int(math.Round(progress * progressBarWidth))
+	return fmt.Sprintf("%s%s %d%%",
+		strings.Repeat(progressBarFull, full),
+		strings.Repeat(progressBarEmpty, progressBarWidth-full),
+		int(math.Round(progress*100)),
+	)
+}
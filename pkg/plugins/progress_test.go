@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseProgress(t *testing.T) {
+	is := is.New(t)
+
+	for s, expected := range map[string]float64{
+		"0":      0,
+		"0.45":   0.45,
+		"1":      1,
+		"45/100": 0.45,
+		"3/4":    0.75,
+		"45%":    0.45,
+		"100%":   1,
+	} {
+		progress, err := parseProgress(s)
+		is.NoErr(err)
+		is.Equal(progress, expected)
+	}
+
+	for s, expected := range map[string]string{
+		"half":  `expected a fraction (like 0.45 or 45/100), not "half"`,
+		"1/0":   `expected a positive total, not "1/0"`,
+		"1/x":   `expected a fraction (like 0.45 or 45/100), not "1/x"`,
+		"1.5":   `expected progress between 0 and 1, not "1.5"`,
+		"-10%":  `expected progress between 0 and 1, not "-10%"`,
+		"5/4":   `expected progress between 0 and 1, not "5/4"`,
+		"":      `expected a fraction (like 0.45 or 45/100), not ""`,
+		"NaN":   `expected a fraction (like 0.45 or 45/100), not "NaN"`,
+		"0/100": "",
+	} {
+		_, err := parseProgress(s)
+		if expected == "" {
+			is.NoErr(err)
+			continue
+		}
+		is.True(err != nil)
+		is.Equal(err.Error(), expected)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(progressBar(0), "░░░░░░░░░░ 0%")
+	is.Equal(progressBar(0.45), "█████░░░░░ 45%")
+	is.Equal(progressBar(1), "██████████ 100%")
+}
+
+func TestParseOutputProgress(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "progress.txt", strings.NewReader(strings.TrimSpace(`
+Download | progress=45/100
+| progress=0.1
+Plain
+`)))
+	is.NoErr(err)
+	is.Equal(len(items.CycleItems), 3)
+	is.Equal(items.CycleItems[0].Text, "Download █████░░░░░ 45%")
+	is.Equal(*items.CycleItems[0].Params.Progress, 0.45)
+	is.Equal(len(items.CycleItems[0].Segments), 2)
+	is.Equal(items.CycleItems[0].Segments[0].Text, "Download")
+	is.Equal(items.CycleItems[0].Segments[1].Text, " █████░░░░░ 45%")
+	is.Equal(items.CycleItems[1].Text, "█░░░░░░░░░ 10%")
+	is.True(items.CycleItems[2].Params.Progress == nil)
+
+	_, err = p.parseOutput(context.Background(), "progress.txt", strings.NewReader(`Bad | progress=2`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `progress.txt:1: progress: expected progress between 0 and 1, not "2"`)
+}
@@ -79,19 +79,8 @@ func SetRefreshInterval(pluginDirectory, installedPluginPath string, refreshInte
 	if err != nil {
 		return "", RefreshInterval{}, errors.Wrap(err, "stat plugin file")
 	}
-	oldVarFullPath := oldFullPath + variableJSONFileExt
-	_, err = os.Stat(oldVarFullPath)
-	if err != nil && !os.IsNotExist(err) {
-		return "", RefreshInterval{}, errors.Wrap(err, "stat plugin vars file")
-	}
-	if err != nil && os.IsNotExist(err) {
-		// no variable file, no probs
-		return newFilename, refreshInterval, nil
-	}
-	newVarFilename := newFilename + variableJSONFileExt
-	newVarFullPath := filepath.Join(pluginDirectory, newVarFilename)
-	if err := os.Rename(oldVarFullPath, newVarFullPath); err != nil {
-		return "", RefreshInterval{}, errors.Wrap(err, "rename plugin vars file to new refresh interval")
+	if err := renamePluginFiles(pluginDirectory, installedPluginPath, newFilename); err != nil {
+		return "", RefreshInterval{}, errors.Wrap(err, "new refresh interval")
 	}
 	return newFilename, refreshInterval, nil
 }
@@ -65,6 +65,29 @@ func TestSetRefreshInterval(t *testing.T) {
 		_, err = os.Stat(newPluginVarsPath)
 		is.NoErr(err)
 	})
+	t.Run("update metadata sidecar file too", func(t *testing.T) {
+		var (
+			testpath      = filepath.Join(baseTestPath, "single-file-plugin")
+			oldPluginName = "set-refresh-interval.1m.sh"
+			oldPluginPath = filepath.Join(testpath, oldPluginName)
+			newPluginPath = filepath.Join(testpath, "set-refresh-interval.1d.sh")
+		)
+		err := os.MkdirAll(testpath, 0777)
+		is.NoErr(err)
+		t.Cleanup(func() {
+			os.RemoveAll(testpath)
+		})
+		_, err = os.Create(oldPluginPath)
+		is.NoErr(err)
+		_, err = os.Create(oldPluginPath + ".yaml")
+		is.NoErr(err)
+		_, _, err = SetRefreshInterval(testpath, oldPluginName, RefreshInterval{N: 1, Unit: "days"})
+		is.NoErr(err)
+		_, err = os.Stat(newPluginPath + ".yaml")
+		is.NoErr(err)
+		_, err = os.Stat(oldPluginPath + ".yaml")
+		is.True(os.IsNotExist(err))
+	})
 	t.Run("disabled plugin", func(t *testing.T) {
 		var (
 			testpath      = filepath.Join(baseTestPath, "single-file-plugin")
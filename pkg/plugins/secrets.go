@@ -0,0 +1,158 @@
+package plugins
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/matryer/xbar/pkg/metadata"
+	"github.com/pkg/errors"
+)
+
+// keychainService is the Keychain service secret variables are
+// kept under.
+const keychainService = "xbar"
+
+// keychainItemNotFound is the exit code from the security tool
+// when there is no such item in the Keychain.
+const keychainItemNotFound = 44
+
+// secretStore keeps the values of secret variables.
+type secretStore interface {
+	// Get gets the value for account, or an empty string if there
+	// isn't one.
+	Get(account string) (string, error)
+	// Set sets the value for account.
+	Set(account, value string) error
+	// Delete deletes the value for account, if there is one.
+	Delete(account string) error
+}
+
+// secrets is where the values of secret variables are kept.
+var secrets secretStore = keychain{}
+
+// keychain is a secretStore that uses the macOS Keychain, via the
+// security tool.
+type keychain struct{}
+
+func (keychain) Get(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	if err != nil {
+		if keychainNotFound(err) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "security find-generic-password")
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (keychain) Set(account, value string) error {
+	// the command is given on stdin, and the value is hex encoded,
+	// so the value doesn't appear in the arguments of any process
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %q -a %q -X %s\n",
+		keychainService, account, hex.EncodeToString([]byte(value))))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "security add-generic-password: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (keychain) Delete(account string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", account).Run()
+	if err != nil && !keychainNotFound(err) {
+		return errors.Wrap(err, "security delete-generic-password")
+	}
+	return nil
+}
+
+// keychainNotFound gets whether err is from the security tool not
+// finding the item.
+func keychainNotFound(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == keychainItemNotFound
+}
+
+// secretAccount gets the Keychain account for the named secret
+// variable of the plugin file.
+// Like the state directories, it doesn't change when the plugin's
+// refresh time is changed, or when it is disabled. It includes the
+// directory, so plugins with the same name in different plugin
+// directories don't share secrets.
+func secretAccount(pluginFilename, name string) string {
+	if abs, err := filepath.Abs(pluginFilename); err == nil {
+		pluginFilename = abs
+	}
+	dir, file := filepath.Split(pluginFilename)
+	return filepath.Join(dir, StateName(file)) + "/" + name
+}
+
+// legacySecretAccount gets the Keychain account secret variables were
+// kept under before secretAccount included the directory.
+func legacySecretAccount(pluginFilename, name string) string {
+	return StateName(filepath.Base(pluginFilename)) + "/" + name
+}
+
+// getSecret gets the value of the named secret variable of the
+// plugin file, or an empty string if it has none.
+// Values kept under the legacySecretAccount are moved to the
+// secretAccount.
+func getSecret(pluginFilename, name string) (string, error) {
+	account := secretAccount(pluginFilename, name)
+	value, err := secrets.Get(account)
+	if err != nil || value != "" {
+		return value, err
+	}
+	legacyAccount := legacySecretAccount(pluginFilename, name)
+	value, err = secrets.Get(legacyAccount)
+	if err != nil || value == "" {
+		return value, err
+	}
+	if err := secrets.Set(account, value); err != nil {
+		return "", err
+	}
+	if err := secrets.Delete(legacyAccount); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// deleteSecret deletes the value of the named secret variable of the
+// plugin file, if it has one.
+func deleteSecret(pluginFilename, name string) error {
+	if err := secrets.Delete(secretAccount(pluginFilename, name)); err != nil {
+		return err
+	}
+	return secrets.Delete(legacySecretAccount(pluginFilename, name))
+}
+
+// secretVarNames gets the names of the secret variables.
+func secretVarNames(vars []metadata.PluginVar) []string {
+	var names []string
+	for _, v := range vars {
+		if v.Secret {
+			names = append(names, v.Name)
+		}
+	}
+	return names
+}
+
+// loadSecretVariables gets the NAME=value environment variables for
+// the plugin's secret variables that have values.
+// The values are never logged.
+func (p *Plugin) loadSecretVariables() ([]string, error) {
+	var vars []string
+	for _, name := range p.secretVars {
+		value, err := getSecret(p.Command, name)
+		if err != nil {
+			return vars, errors.Wrapf(err, "secret variable %s", name)
+		}
+		if value == "" {
+			continue
+		}
+		vars = append(vars, name+"="+value)
+	}
+	return vars, nil
+}
@@ -0,0 +1,126 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+// memorySecrets is a secretStore for tests.
+type memorySecrets map[string]string
+
+func (m memorySecrets) Get(account string) (string, error) {
+	return m[account], nil
+}
+
+func (m memorySecrets) Set(account, value string) error {
+	m[account] = value
+	return nil
+}
+
+func (m memorySecrets) Delete(account string) error {
+	delete(m, account)
+	return nil
+}
+
+// useMemorySecrets makes secret variables use a memorySecrets for
+// the rest of the test.
+func useMemorySecrets(t *testing.T) memorySecrets {
+	store := memorySecrets{}
+	secrets = store
+	t.Cleanup(func() {
+		secrets = keychain{}
+	})
+	return store
+}
+
+func TestSecretVariables(t *testing.T) {
+	is := is.New(t)
+	store := useMemorySecrets(t)
+
+	installedPluginPath := "secret-plugin.1m.sh"
+	pluginDir, err := ioutil.TempDir("", "xbar-secrets-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(pluginDir)
+	})
+	err = ioutil.WriteFile(filepath.Join(pluginDir, installedPluginPath), []byte(`#!/bin/bash
+# <xbar.var>string(VAR_NAME="Mat"): Your name.</xbar.var>
+# <xbar.var secret="true">string(VAR_TOKEN=""): Your API token.</xbar.var>
+echo "$VAR_NAME $VAR_TOKEN"
+`), 0777)
+	is.NoErr(err)
+
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{
+		"VAR_NAME":  "David",
+		"VAR_TOKEN": "s3cr3t",
+	})
+	is.NoErr(err)
+	account := filepath.Join(pluginDir, "secret-plugin.sh") + "/VAR_TOKEN"
+	is.Equal(secretAccount(filepath.Join(pluginDir, installedPluginPath), "VAR_TOKEN"), account)
+	is.Equal(store[account], "s3cr3t")
+
+	// the secret isn't in the .vars.json file
+	b, err := ioutil.ReadFile(filepath.Join(pluginDir, installedPluginPath+variableJSONFileExt))
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "David"))
+	is.True(!strings.Contains(string(b), "s3cr3t"))
+
+	values, err := LoadVariableValues(pluginDir, installedPluginPath)
+	is.NoErr(err)
+	is.Equal(values["VAR_NAME"], "David")
+	is.Equal(values["VAR_TOKEN"], "s3cr3t")
+
+	// the secret is given to the plugin when it runs
+	p := NewPlugin(filepath.Join(pluginDir, installedPluginPath))
+	is.NoErr(p.loadMetadata())
+	is.Equal(p.secretVars, []string{"VAR_TOKEN"})
+	secretVariables, err := p.loadSecretVariables()
+	is.NoErr(err)
+	is.Equal(secretVariables, []string{"VAR_TOKEN=s3cr3t"})
+
+	// saving an empty value removes the secret
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{
+		"VAR_TOKEN": "",
+	})
+	is.NoErr(err)
+	_, ok := store[account]
+	is.True(!ok)
+
+	// uninstalling removes the secrets
+	store[account] = "s3cr3t"
+	err = Installer{PluginDir: pluginDir}.Uninstall(installedPluginPath)
+	is.NoErr(err)
+	is.Equal(len(store), 0)
+}
+
+func TestSecretAccounts(t *testing.T) {
+	is := is.New(t)
+	store := useMemorySecrets(t)
+
+	// plugins with the same name in different directories
+	personal := filepath.Join("/plugins", "todo.1m.sh")
+	dotfiles := filepath.Join("/dotfiles", "xbar", "todo.5m.sh")
+	is.True(secretAccount(personal, "TOKEN") != secretAccount(dotfiles, "TOKEN"))
+	// but the same plugin with another refresh time
+	is.Equal(secretAccount(personal, "TOKEN"), secretAccount(filepath.Join("/plugins", "todo.1h.sh"), "TOKEN"))
+
+	// secrets from before the directory was included are moved
+	store["todo.sh/TOKEN"] = "s3cr3t"
+	value, err := getSecret(personal, "TOKEN")
+	is.NoErr(err)
+	is.Equal(value, "s3cr3t")
+	is.Equal(store, memorySecrets{
+		secretAccount(personal, "TOKEN"): "s3cr3t",
+	})
+	value, err = getSecret(dotfiles, "TOKEN")
+	is.NoErr(err)
+	is.Equal(value, "")
+
+	is.NoErr(deleteSecret(personal, "TOKEN"))
+	is.Equal(len(store), 0)
+}
@@ -0,0 +1,180 @@
+package plugins
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// shortcutModifiers maps the accepted modifier names to the
+// canonical ones, in the order they appear in a normalized shortcut.
+var shortcutModifiers = []struct {
+	name    string
+	aliases []string
+}{
+	{name: "cmdorctrl", aliases: []string{"cmd", "command", "cmdorctrl"}},
+	{name: "optionoralt", aliases: []string{"opt", "option", "alt", "optionoralt"}},
+	{name: "ctrl", aliases: []string{"ctrl", "control"}},
+	{name: "shift", aliases: []string{"shift"}},
+	{name: "super", aliases: []string{"super"}},
+}
+
+// parseShortcut parses a keyboard shortcut like CMD+SHIFT+K into
+// the normalized form (cmdorctrl+shift+k).
+// Shortcuts must have at least one modifier and exactly one key.
+func parseShortcut(s string) (string, error) {
+	used := make(map[string]bool)
+	var key string
+	for _, part := range strings.Split(s, "+") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			return "", errors.Errorf(`malformed shortcut "%s"`, s)
+		}
+		modifier := ""
+		for _, m := range shortcutModifiers {
+			for _, alias := range m.aliases {
+				if part == alias {
+					modifier = m.name
+				}
+			}
+		}
+		if modifier != "" {
+			used[modifier] = true
+			continue
+		}
+		if key != "" {
+			return "", errors.Errorf(`shortcut "%s" has more than one key`, s)
+		}
+		key = part
+	}
+	if key == "" {
+		return "", errors.Errorf(`shortcut "%s" has no key`, s)
+	}
+	if len(used) == 0 {
+		return "", errors.Errorf(`shortcut "%s" needs a modifier (like cmd or shift)`, s)
+	}
+	var parts []string
+	for _, m := range shortcutModifiers {
+		if used[m.name] {
+			parts = append(parts, m.name)
+		}
+	}
+	parts = append(parts, key)
+	return strings.Join(parts, "+"), nil
+}
+
+// ShortcutRegistry keeps track of the keyboard shortcuts used by
+// plugin items, so that conflicting shortcuts can be detected, and
+// so the shortcuts can work globally (while the menus are closed).
+// The first item to register a shortcut keeps it.
+type ShortcutRegistry struct {
+	lock sync.Mutex
+	// owners maps shortcuts to the Command of the plugin that
+	// registered it.
+	owners map[string]string
+	// items maps shortcuts to the items that registered them.
+	items map[string]*Item
+}
+
+// reservedShortcutOwner is the owner of shortcuts that are reserved
+// for xbar itself.
+const reservedShortcutOwner = "xbar"
+
+// NewShortcutRegistry makes a new ShortcutRegistry.
+// The reserved shortcuts (in the same format as the shortcut parameter)
+// cannot be used by plugins.
+func NewShortcutRegistry(reserved ...string) *ShortcutRegistry {
+	r := &ShortcutRegistry{
+		owners: make(map[string]string),
+		items:  make(map[string]*Item),
+	}
+	for _, s := range reserved {
+		shortcut, err := parseShortcut(s)
+		if err != nil {
+			panic("plugins: bad reserved shortcut: " + err.Error())
+		}
+		r.owners[shortcut] = reservedShortcutOwner
+	}
+	return r
+}
+
+// Register registers the shortcuts for all of the plugin's items,
+// replacing any that were registered before.
+// Items with conflicting shortcuts have their Shortcut cleared, and an
+// error is returned for each of them.
+func (r *ShortcutRegistry) Register(p *Plugin) []error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.unregister(p)
+	var errs []error
+	var walk func(items []*Item)
+	walk = func(items []*Item) {
+		for _, item := range items {
+			if item == nil {
+				continue
+			}
+			if shortcut := item.Params.Shortcut; shortcut != "" {
+				if owner, taken := r.owners[shortcut]; taken {
+					if owner == p.Command {
+						owner = "another item"
+					}
+					errs = append(errs, errors.Errorf("shortcut %s for %q is already used by %s", shortcut, item.Text, owner))
+					item.Params.Shortcut = ""
+				} else {
+					r.owners[shortcut] = p.Command
+					r.items[shortcut] = item
+				}
+			}
+			if item.Alternate != nil {
+				walk([]*Item{item.Alternate})
+			}
+			walk(item.Items)
+		}
+	}
+	walk(p.Items.CycleItems)
+	walk(p.Items.ExpandedItems)
+	for _, split := range p.Items.Splits {
+		walk(split.CycleItems)
+		walk(split.ExpandedItems)
+	}
+	return errs
+}
+
+// Unregister removes all shortcuts registered by the plugin.
+func (r *ShortcutRegistry) Unregister(p *Plugin) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.unregister(p)
+}
+
+func (r *ShortcutRegistry) unregister(p *Plugin) {
+	for shortcut, owner := range r.owners {
+		if owner == p.Command {
+			delete(r.owners, shortcut)
+			delete(r.items, shortcut)
+		}
+	}
+}
+
+// Shortcuts gets the shortcuts registered by plugin items (not the
+// reserved ones), sorted.
+func (r *ShortcutRegistry) Shortcuts() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	shortcuts := make([]string, 0, len(r.items))
+	for shortcut := range r.items {
+		shortcuts = append(shortcuts, shortcut)
+	}
+	sort.Strings(shortcuts)
+	return shortcuts
+}
+
+// Item gets the item that registered the shortcut, or nil if no
+// plugin item has.
+func (r *ShortcutRegistry) Item(shortcut string) *Item {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.items[shortcut]
+}
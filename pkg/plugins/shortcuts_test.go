@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseShortcut(t *testing.T) {
+	is := is.New(t)
+
+	for input, expected := range map[string]string{
+		"CMD+SHIFT+K":       "cmdorctrl+shift+k",
+		"shift + cmd + k":   "cmdorctrl+shift+k",
+		"CmdOrCtrl+Shift+K": "cmdorctrl+shift+k",
+		"alt+F12":           "optionoralt+f12",
+		"ctrl+option+space": "optionoralt+ctrl+space",
+	} {
+		actual, err := parseShortcut(input)
+		is.NoErr(err)
+		is.Equal(actual, expected)
+	}
+
+	for _, input := range []string{"k", "cmd+shift", "cmd+k+j", "cmd++k", ""} {
+		_, err := parseShortcut(input)
+		is.True(err != nil) // should be an error
+	}
+}
+
+func TestShortcutRegistry(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+	parse := func(command, output string) *Plugin {
+		p := &Plugin{Command: command}
+		var err error
+		p.Items, err = p.parseOutput(ctx, command, strings.NewReader(output))
+		is.NoErr(err)
+		return p
+	}
+	one := parse("one.sh", `one
+---
+Connect | shortcut=cmd+shift+k
+Refresh | shortcut=cmd+r
+--Nested | shortcut=cmd+shift+n`)
+	two := parse("two.sh", `two
+---
+Kill | shortcut=shift+cmd+k
+Other | shortcut=cmd+shift+o
+Again | shortcut=cmd+shift+o`)
+
+	r := NewShortcutRegistry("cmd+r")
+	errs := r.Register(one)
+	is.Equal(len(errs), 1) // cmd+r is reserved
+	is.Equal(one.Items.ExpandedItems[0].Params.Shortcut, "cmdorctrl+shift+k")
+	is.Equal(one.Items.ExpandedItems[1].Params.Shortcut, "")
+	is.Equal(one.Items.ExpandedItems[1].Items[0].Params.Shortcut, "cmdorctrl+shift+n")
+
+	errs = r.Register(two)
+	is.Equal(len(errs), 2) // conflicts with one.sh, and itself
+	is.True(strings.Contains(errs[0].Error(), "one.sh"))
+	is.Equal(two.Items.ExpandedItems[0].Params.Shortcut, "")
+	is.Equal(two.Items.ExpandedItems[1].Params.Shortcut, "cmdorctrl+shift+o")
+	is.Equal(two.Items.ExpandedItems[2].Params.Shortcut, "")
+
+	is.Equal(r.Shortcuts(), []string{"cmdorctrl+shift+k", "cmdorctrl+shift+n", "cmdorctrl+shift+o"})
+	is.Equal(r.Item("cmdorctrl+shift+k").Text, "Connect")
+	is.Equal(r.Item("cmdorctrl+shift+o").Text, "Other")
+	is.True(r.Item("cmdorctrl+r") == nil) // reserved shortcuts have no item
+
+	// re-registering replaces the plugin's shortcuts
+	errs = r.Register(one)
+	is.Equal(len(errs), 0)
+
+	// once unregistered, the shortcut is free
+	r.Unregister(one)
+	is.Equal(r.Shortcuts(), []string{"cmdorctrl+shift+o"})
+	two = parse("two.sh", `two
+---
+Kill | shortcut=shift+cmd+k`)
+	errs = r.Register(two)
+	is.Equal(len(errs), 0)
+}
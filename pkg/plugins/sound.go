@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// systemSoundsDir is where the macOS system sounds live.
+const systemSoundsDir = "/System/Library/Sounds"
+
+// systemSoundRegexp matches system sound names, like Glass.
+var systemSoundRegexp = regexp.MustCompile(`^[A-Za-z0-9 _-]+$`)
+
+// isSoundPath gets whether the sound is a path to a file, rather
+// than the name of a system sound.
+func isSoundPath(sound string) bool {
+	return strings.ContainsRune(sound, '/') || sound == "~"
+}
+
+// validateSound checks that sound is a system sound name or a path,
+// returning a nice error if it isn't.
+func validateSound(sound string) error {
+	if isSoundPath(sound) || systemSoundRegexp.MatchString(sound) {
+		return nil
+	}
+	return errors.Errorf(`expected a system sound name (like Glass) or a file path, not "%s"`, sound)
+}
+
+// soundFile gets the file to play for the sound.
+// Paths are resolved like the open parameter.
+func soundFile(pluginDir, sound string) (string, error) {
+	if isSoundPath(sound) {
+		return resolveOpenPath(pluginDir, sound)
+	}
+	return filepath.Join(systemSoundsDir, sound+".aiff"), nil
+}
+
+// playSound plays the sound, waiting until it has finished.
+func playSound(ctx context.Context, pluginDir, sound string) error {
+	file, err := soundFile(pluginDir, sound)
+	if err != nil {
+		return err
+	}
+	commandCtx, cancel := context.WithTimeout(ctx, actionTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(commandCtx, "afplay", file)
+	case "linux":
+		cmd = exec.CommandContext(commandCtx, "paplay", file)
+	default:
+		return errors.New("unsupported platform")
+	}
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "play %s", file)
+	}
+	return nil
+}
+
+// actionSound gets an ActionFunc that plays a sound.
+func actionSound(debugf DebugFunc, item *Item, sound string) ActionFunc {
+	return func(ctx context.Context) {
+		debugf("action sound: %s", sound)
+		var pluginDir string
+		if item.Plugin != nil {
+			pluginDir = filepath.Dir(item.Plugin.Command)
+		}
+		if err := playSound(ctx, pluginDir, sound); err != nil {
+			debugf("ERR: action sound: %s", err)
+			return
+		}
+	}
+}
+
+// playAlertSound plays the alert sound of the first cycle item that
+// has one, without waiting for it to finish.
+func (p *Plugin) playAlertSound(ctx context.Context) {
+	for _, item := range p.Items.CycleItems {
+		if item.Params.AlertSound == "" {
+			continue
+		}
+		sound := item.Params.AlertSound
+		go func() {
+			if err := playSound(ctx, filepath.Dir(p.Command), sound); err != nil {
+				p.Debugf("ERR: alert sound: %s", err)
+			}
+		}()
+		return
+	}
+}
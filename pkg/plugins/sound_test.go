@@ -0,0 +1,46 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSoundFile(t *testing.T) {
+	is := is.New(t)
+
+	file, err := soundFile("/plugins", "Glass")
+	is.NoErr(err)
+	is.Equal(file, "/System/Library/Sounds/Glass.aiff")
+
+	file, err = soundFile("/plugins", "/sounds/alarm.aiff")
+	is.NoErr(err)
+	is.Equal(file, "/sounds/alarm.aiff")
+
+	file, err = soundFile("/plugins", "./sounds/alarm.aiff")
+	is.NoErr(err)
+	is.Equal(file, "/plugins/sounds/alarm.aiff")
+}
+
+func TestParseOutputSound(t *testing.T) {
+	is := is.New(t)
+
+	p := &Plugin{}
+	items, err := p.parseOutput(context.Background(), "sound.txt", strings.NewReader(strings.TrimSpace(`
+Server down | alertSound=Sosumi
+---
+Acknowledge | sound=Glass
+Custom | sound="~/Sounds/my alarm.aiff"
+`)))
+	is.NoErr(err)
+	is.Equal(items.CycleItems[0].Params.AlertSound, "Sosumi")
+	is.Equal(items.ExpandedItems[0].Params.Sound, "Glass")
+	is.Equal(items.ExpandedItems[1].Params.Sound, "~/Sounds/my alarm.aiff")
+	is.True(items.ExpandedItems[0].Action() != nil)
+
+	_, err = p.parseOutput(context.Background(), "sound.txt", strings.NewReader(`Bad | sound=Glass.aiff`))
+	is.True(err != nil)
+	is.Equal(err.Error(), `sound.txt:1: sound: expected a system sound name (like Glass) or a file path, not "Glass.aiff"`)
+}
@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SetStateDirs sets the CacheDir and DataDir of the plugin to its own
+// directories inside cacheDir and dataDir.
+// The directories don't change when the plugin's refresh time is
+// changed, or when it is disabled.
+func (p *Plugin) SetStateDirs(cacheDir, dataDir string) {
+	name := StateName(filepath.Base(p.Command))
+	p.CacheDir = filepath.Join(cacheDir, name)
+	p.DataDir = filepath.Join(dataDir, name)
+}
+
+// makeStateDirs makes the CacheDir and DataDir, if the plugin has
+// them.
+func (p *Plugin) makeStateDirs() error {
+	for _, dir := range []string{p.CacheDir, p.DataDir} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return errors.Wrap(err, "make state directory")
+		}
+	}
+	return nil
+}
+
+// stateDirsEnv gets the environment variables that tell the plugin
+// where its CacheDir and DataDir are.
+func (p *Plugin) stateDirsEnv() []string {
+	var env []string
+	if p.CacheDir != "" {
+		env = append(env, "XBAR_PLUGIN_CACHE_DIR="+p.CacheDir)
+	}
+	if p.DataDir != "" {
+		env = append(env, "XBAR_PLUGIN_DATA_DIR="+p.DataDir)
+	}
+	return env
+}
+
+// StateName gets the name of the things xbar keeps for the plugin
+// file (like its state directories), which is the filename without
+// any order prefix, refresh time or disabled extension.
+// For example, 001-weather.1m.sh gets weather.sh.
+func StateName(filename string) string {
+	filename = strings.TrimSuffix(filename, disabledPluginExtension)
+	var order int
+	_, _ = fmt.Sscanf(filename, "%d-%v", &order, &filename)
+	interval := findIntervalInFilename(filename)
+	if interval == "" {
+		return filename
+	}
+	if _, err := parseInterval(interval); err != nil {
+		// not an interval, just part of the name
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(strings.TrimSuffix(filename, ext), "."+interval) + ext
+}
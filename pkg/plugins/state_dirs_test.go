@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestStateDirName(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(StateName("weather.1m.sh"), "weather.sh")
+	is.Equal(StateName("weather.5s.sh"), "weather.sh")
+	is.Equal(StateName("001-weather.1m.sh.off"), "weather.sh")
+	is.Equal(StateName("weather.sh"), "weather.sh")
+	is.Equal(StateName("my.tool.sh"), "my.tool.sh")
+}
+
+func TestStateDirs(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-state-dirs-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	pluginDir := filepath.Join(dir, "plugins")
+	is.NoErr(os.Mkdir(pluginDir, 0777))
+	command := filepath.Join(pluginDir, "token.1h.sh")
+	script := "#!/bin/bash\necho $XBAR_PLUGIN_CACHE_DIR\necho $XBAR_PLUGIN_DATA_DIR\n"
+	err = os.WriteFile(command, []byte(script), 0777)
+	is.NoErr(err)
+	p := NewPlugin(command)
+	p.SetStateDirs(filepath.Join(dir, "cache"), filepath.Join(dir, "data"))
+	is.Equal(p.CacheDir, filepath.Join(dir, "cache", "token.sh"))
+	is.Equal(p.DataDir, filepath.Join(dir, "data", "token.sh"))
+	p.HistoryFile = HistoryFilename(filepath.Join(dir, "history"), command)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	p.Run(ctx)
+	is.Equal(p.Items.CycleItems[0].Text, p.CacheDir)
+	is.Equal(p.Items.CycleItems[1].Text, p.DataDir)
+	info, err := os.Stat(p.DataDir)
+	is.NoErr(err)
+	is.True(info.IsDir())
+
+	installer := Installer{
+		PluginDir:  pluginDir,
+		CacheDir:   filepath.Join(dir, "cache"),
+		DataDir:    filepath.Join(dir, "data"),
+		HistoryDir: filepath.Join(dir, "history"),
+	}
+	is.NoErr(installer.Uninstall("token.1h.sh"))
+	_, err = os.Stat(p.CacheDir)
+	is.True(os.IsNotExist(err))
+	_, err = os.Stat(p.DataDir)
+	is.True(os.IsNotExist(err))
+	_, err = os.Stat(p.HistoryFile)
+	is.True(os.IsNotExist(err))
+}
@@ -0,0 +1,61 @@
+package plugins
+
+import (
+	"time"
+)
+
+// Stats are statistics about the runs of a plugin since it was made,
+// for finding out which plugins slow the Mac down.
+type Stats struct {
+	// Runs is how many times the plugin has run.
+	Runs int `json:"runs"`
+	// Failures is how many of the Runs failed.
+	Failures int `json:"failures"`
+	// FailureRate is the fraction (0-1) of the Runs that failed.
+	FailureRate float64 `json:"failureRate"`
+	// TotalDuration is how long the plugin has run for altogether.
+	TotalDuration time.Duration `json:"totalDuration"`
+	// MeanDuration is how long a run takes on average.
+	MeanDuration time.Duration `json:"meanDuration"`
+	// MaxDuration is how long the longest run took.
+	MaxDuration time.Duration `json:"maxDuration"`
+	// CPUTime is the user and system CPU time all the runs have
+	// used.
+	CPUTime time.Duration `json:"cpuTime"`
+	// LastRun is when the plugin last ran, or zero if it hasn't.
+	LastRun time.Time `json:"lastRun"`
+	// LastSuccess is when the plugin last ran without failing, or
+	// zero if it hasn't.
+	LastSuccess time.Time `json:"lastSuccess"`
+}
+
+// add adds the run to the stats.
+func (s *Stats) add(run Run) {
+	s.Runs++
+	if run.Error != "" {
+		s.Failures++
+	} else {
+		s.LastSuccess = run.Time
+	}
+	s.LastRun = run.Time
+	s.FailureRate = float64(s.Failures) / float64(s.Runs)
+	s.TotalDuration += run.Duration
+	s.MeanDuration = s.TotalDuration / time.Duration(s.Runs)
+	if run.Duration > s.MaxDuration {
+		s.MaxDuration = run.Duration
+	}
+	s.CPUTime += run.CPUTime
+}
+
+// Stats gets statistics about the runs of the plugin.
+func (p *Plugin) Stats() Stats {
+	stats, _ := p.stats.Load().(Stats)
+	return stats
+}
+
+// recordStats adds the run to the plugin's Stats.
+func (p *Plugin) recordStats(run Run) {
+	stats := p.Stats()
+	stats.add(run)
+	p.stats.Store(stats)
+}
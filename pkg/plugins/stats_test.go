@@ -0,0 +1,58 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestStats(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-stats-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "counter.1m.sh")
+	script := "#!/bin/bash\necho run >> runs.txt\nRUNS=$(( $(wc -l < runs.txt) ))\necho \"$RUNS runs\"\nif [ $RUNS = 2 ]; then\nexit 3\nfi\n"
+	err = os.WriteFile(command, []byte(script), 0777)
+	is.NoErr(err)
+
+	p := NewPlugin(command)
+	is.Equal(p.Stats().Runs, 0)
+	p.Refresh(context.Background())
+	p.Refresh(context.Background())
+	p.Refresh(context.Background())
+	stats := p.Stats()
+	is.Equal(stats.Runs, 3)
+	is.Equal(stats.Failures, 1)
+	is.Equal(stats.FailureRate, 1.0/3.0)
+	is.True(stats.MaxDuration > 0)
+	is.True(stats.MeanDuration <= stats.MaxDuration)
+	is.Equal(stats.MeanDuration, stats.TotalDuration/3)
+	is.True(stats.CPUTime >= 0)
+	is.True(!stats.LastSuccess.IsZero())
+	is.True(!stats.LastSuccess.Before(stats.LastRun)) // the last run worked
+}
+
+func TestStatsAdd(t *testing.T) {
+	is := is.New(t)
+
+	start := time.Date(2021, 10, 16, 12, 0, 0, 0, time.UTC)
+	var stats Stats
+	stats.add(Run{Time: start, Duration: 1 * time.Second, CPUTime: 100 * time.Millisecond})
+	stats.add(Run{Time: start.Add(time.Minute), Duration: 3 * time.Second, CPUTime: 200 * time.Millisecond, Error: "exit status 1"})
+	is.Equal(stats.Runs, 2)
+	is.Equal(stats.Failures, 1)
+	is.Equal(stats.FailureRate, 0.5)
+	is.Equal(stats.MeanDuration, 2*time.Second)
+	is.Equal(stats.MaxDuration, 3*time.Second)
+	is.Equal(stats.CPUTime, 300*time.Millisecond)
+	is.Equal(stats.LastRun, start.Add(time.Minute))
+	is.Equal(stats.LastSuccess, start)
+}
@@ -0,0 +1,164 @@
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// streamSeparator is the line a streamable plugin outputs to
+// update its menu with the output since the last one.
+const streamSeparator = "~~~"
+
+// runStream keeps a streamable plugin running until ctx is done.
+// When the plugin exits, it is started again at its next refresh,
+// or when a refresh is triggered (which also restarts a plugin that
+// is still running).
+func (p *Plugin) runStream(ctx context.Context, cycleReset chan<- struct{}) {
+	for {
+		streamCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		restarted := make(chan bool, 1)
+		go func() {
+			select {
+			case <-p.refreshSignal:
+				cancel()
+				restarted <- true
+			case <-done:
+				restarted <- false
+			}
+		}()
+		p.Debugf("streaming: %s", filepath.Base(p.Command))
+		err := p.stream(streamCtx, cycleReset)
+		close(done)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if <-restarted {
+			continue
+		}
+		if err != nil {
+			p.refreshed(ctx, err)
+		}
+		select {
+		case <-p.refreshSignal:
+		case <-time.After(p.nextRefresh(time.Now())):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stream runs the plugin, updating the Items each time it outputs
+// a ~~~ line, and once more with any output after the last one when
+// the plugin exits.
+func (p *Plugin) stream(ctx context.Context, cycleReset chan<- struct{}) error {
+	return p.streamUpdates(ctx, func(err error) {
+		p.refreshed(ctx, err)
+		select {
+		case cycleReset <- struct{}{}:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// streamOnce runs the plugin until it first updates the Items, and
+// then stops it, without notifying listeners.
+func (p *Plugin) streamOnce(ctx context.Context) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var updated bool
+	var updateErr error
+	err := p.streamUpdates(streamCtx, func(err error) {
+		updated, updateErr = true, err
+		cancel()
+	})
+	switch {
+	case updated:
+		return updateErr
+	case err != nil:
+		return err
+	}
+	return ctx.Err()
+}
+
+// streamUpdates runs the plugin like stream, calling updated after
+// each update of the Items.
+func (p *Plugin) streamUpdates(ctx context.Context, updated func(err error)) error {
+	if err := p.checkDependencies(ctx); err != nil {
+		return err
+	}
+	p.reloadVariables()
+	cmd := p.command()
+	stderr := &tailBuffer{max: maxStderrBytes}
+	cmd.Stderr = stderr
+	if p.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, p.Stderr)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "stdout pipe")
+	}
+	if err := cmd.Start(); err != nil {
+		return errExec{err: err}
+	}
+	// streamable plugins keep running, so there's no timeout
+	waited := watchProcess(ctx, cmd, 0)
+	// each update is recorded like a run, from the one before
+	updateStart := time.Now()
+	update := func(output *limitedBuffer) {
+		if p.Paused() {
+			// keep showing the output from before
+			return
+		}
+		if ctx.Err() != nil {
+			// stopped, so the rest of the output is ignored
+			return
+		}
+		err := p.update(ctx, output)
+		p.recordOutput(newRun(updateStart, nil, output.buf.Bytes(), stderr.String(), err), output.buf.Bytes(), stderr.String())
+		updateStart = time.Now()
+		updated(err)
+	}
+	br := bufio.NewReader(stdout)
+	output := &limitedBuffer{max: p.MaxOutputBytes}
+	for {
+		line, readErr := br.ReadString('\n')
+		if p.Stdout != nil {
+			_, _ = io.WriteString(p.Stdout, line)
+		}
+		if strings.TrimSpace(line) == streamSeparator {
+			update(output)
+			output = &limitedBuffer{max: p.MaxOutputBytes}
+		} else {
+			_, _ = output.Write([]byte(line))
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	err = cmd.Wait()
+	waited()
+	if err != nil {
+		if ctx.Err() != nil {
+			// stopped on purpose
+			return nil
+		}
+		err = errExec{
+			err:    err,
+			Stderr: stderr.String(),
+		}
+		p.recordOutput(newRun(updateStart, cmd.ProcessState, output.buf.Bytes(), stderr.String(), err), output.buf.Bytes(), stderr.String())
+		return err
+	}
+	if output.buf.Len() > 0 {
+		update(output)
+	}
+	return nil
+}
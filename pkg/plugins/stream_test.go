@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestStream(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-stream-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "live.1h.sh")
+	err = os.WriteFile(command, []byte(`#!/bin/bash
+# <xbar.title>Live</xbar.title>
+# <xbar.streamable>true</xbar.streamable>
+echo "one"
+echo "~~~"
+echo "two"
+echo "---"
+echo "item"
+echo "~~~"
+echo "three"
+`), 0777)
+	is.NoErr(err)
+
+	p := NewPlugin(command)
+	p.HistoryFile = filepath.Join(dir, "history.json")
+	p.LogFile = filepath.Join(dir, "live.log")
+	is.NoErr(p.loadMetadata())
+	is.Equal(p.Streamable, true)
+	var lock sync.Mutex
+	var titles []string
+	p.OnRefresh = func(ctx context.Context, p *Plugin, err error) {
+		is.NoErr(err)
+		lock.Lock()
+		defer lock.Unlock()
+		titles = append(titles, p.Items.CycleItems[0].Text)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cycleReset := make(chan struct{}, 10)
+	err = p.stream(ctx, cycleReset)
+	is.NoErr(err)
+	is.Equal(titles, []string{"one", "two", "three"})
+	is.Equal(len(cycleReset), 3)
+
+	// each update is recorded like a run
+	is.Equal(p.Stats().Runs, 3)
+	runs, err := ReadHistory(p.HistoryFile)
+	is.NoErr(err)
+	is.Equal(len(runs), 3)
+	is.Equal(runs[1].Output, "two\n---\nitem\n")
+	log, err := os.ReadFile(p.LogFile)
+	is.NoErr(err)
+	is.True(strings.Contains(string(log), "three"))
+}
+
+func TestStreamStopped(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-stream-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	command := filepath.Join(dir, "forever.1h.sh")
+	err = os.WriteFile(command, []byte(`#!/bin/bash
+echo "started"
+echo "~~~"
+sleep 60
+`), 0777)
+	is.NoErr(err)
+
+	p := NewPlugin(command)
+	ctx, cancel := context.WithCancel(context.Background())
+	p.OnRefresh = func(ctx context.Context, p *Plugin, err error) {
+		cancel() // stop after the first update
+	}
+	start := time.Now()
+	err = p.stream(ctx, make(chan struct{}, 1))
+	is.NoErr(err) // stopping isn't an error
+	is.True(time.Since(start) < 10*time.Second)
+	is.Equal(p.Items.CycleItems[0].Text, "started")
+}
@@ -0,0 +1,115 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Terminal apps that terminal=true commands can be run in.
+const (
+	TerminalAppTerminal = "Terminal"
+	TerminalAppITerm2   = "iTerm2"
+	TerminalAppKitty    = "kitty"
+)
+
+// TerminalApps are the supported terminal apps.
+var TerminalApps = []string{
+	TerminalAppTerminal,
+	TerminalAppITerm2,
+	TerminalAppKitty,
+}
+
+// terminalCommand gets the command that opens terminalApp, and runs
+// the script (see terminalScript) in it.
+// An empty terminalApp means Terminal.
+func terminalCommand(terminalApp, script string) (string, []string, error) {
+	line := "/bin/sh " + shellQuote(script)
+	switch terminalApp {
+	case "", TerminalAppTerminal:
+		return "osascript", []string{
+			"-e", `tell application "Terminal"`,
+			"-e", "activate",
+			"-e", "do script " + appleScriptQuote(line),
+			"-e", "end tell",
+		}, nil
+	case TerminalAppITerm2:
+		return "osascript", []string{
+			"-e", `tell application "iTerm"`,
+			"-e", "activate",
+			"-e", "set newWindow to (create window with default profile)",
+			"-e", "tell current session of newWindow to write text " + appleScriptQuote(line),
+			"-e", "end tell",
+		}, nil
+	case TerminalAppKitty:
+		// kitty runs the command directly, so no quoting is needed
+		return "open", []string{"-na", "kitty", "--args", "--hold", "/bin/sh", script}, nil
+	}
+	return "", nil, errors.Errorf("unsupported terminal app %q (expected one of %s)", terminalApp, strings.Join(TerminalApps, ", "))
+}
+
+// shellVariableName matches the names of environment variables that
+// can be exported by a shell.
+var shellVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// terminalScript gets a shell script that runs command with args in
+// dir, with the environment variables in env, followed by the extra
+// shell steps, like actionShell does in the background.
+// The script removes itself when it starts, since env may contain
+// secrets.
+func terminalScript(dir string, env []string, command string, args []string, steps []string) string {
+	var script strings.Builder
+	script.WriteString("rm -f -- \"$0\"\n")
+	for _, variable := range env {
+		parts := strings.SplitN(variable, "=", 2)
+		if len(parts) != 2 || !shellVariableName.MatchString(parts[0]) {
+			continue
+		}
+		script.WriteString("export " + shellQuote(variable) + "\n")
+	}
+	script.WriteString("cd " + shellQuote(dir) + " || exit\n")
+	line := shellQuote(command)
+	for _, arg := range args {
+		line += " " + shellQuote(arg)
+	}
+	for _, step := range steps {
+		line += " && /bin/sh -c " + shellQuote(step)
+	}
+	script.WriteString(line + "\n")
+	return script.String()
+}
+
+// writeTerminalScript writes the script to a private temporary file,
+// returning its path.
+func writeTerminalScript(script string) (string, error) {
+	f, err := ioutil.TempFile("", "xbar-terminal-*.sh")
+	if err != nil {
+		return "", errors.Wrap(err, "create terminal script")
+	}
+	if _, err := f.WriteString(script); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", errors.Wrap(err, "write terminal script")
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", errors.Wrap(err, "write terminal script")
+	}
+	return f.Name(), nil
+}
+
+// shellQuote quotes s so that it is a single word in a shell
+// command line.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// appleScriptQuote quotes s as an AppleScript string.
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
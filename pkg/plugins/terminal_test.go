@@ -0,0 +1,76 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestShellQuote(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(shellQuote("simple"), `'simple'`)
+	is.Equal(shellQuote("with spaces"), `'with spaces'`)
+	is.Equal(shellQuote("it's"), `'it'\''s'`)
+	is.Equal(shellQuote(`$HOME "quoted" $(rm -rf /)`), `'$HOME "quoted" $(rm -rf /)'`)
+}
+
+func TestTerminalCommand(t *testing.T) {
+	is := is.New(t)
+
+	exec, args, err := terminalCommand("", "/tmp/xbar terminal.sh")
+	is.NoErr(err)
+	is.Equal(exec, "osascript")
+	is.Equal(args[5], `do script "/bin/sh '/tmp/xbar terminal.sh'"`)
+
+	exec, args, err = terminalCommand(TerminalAppITerm2, "/tmp/run.sh")
+	is.NoErr(err)
+	is.Equal(exec, "osascript")
+	is.Equal(args[1], `tell application "iTerm"`)
+	is.Equal(args[7], `tell current session of newWindow to write text "/bin/sh '/tmp/run.sh'"`)
+
+	exec, args, err = terminalCommand(TerminalAppKitty, "/tmp/run.sh")
+	is.NoErr(err)
+	is.Equal(exec, "open")
+	is.Equal(args, []string{"-na", "kitty", "--args", "--hold", "/bin/sh", "/tmp/run.sh"})
+
+	_, _, err = terminalCommand("Hyper", "/tmp/run.sh")
+	is.True(err != nil)
+	is.Equal(err.Error(), `unsupported terminal app "Hyper" (expected one of Terminal, iTerm2, kitty)`)
+}
+
+func TestTerminalScript(t *testing.T) {
+	is := is.New(t)
+
+	script := terminalScript("/work dir", []string{"XBAR_ITEM_TEXT=it's", "API_KEY=abc", "not-a-name=1", "BROKEN"}, "/plugins/my script.sh", []string{`say "hi"`}, []string{"echo done"})
+	is.Equal(script, `rm -f -- "$0"
+export 'XBAR_ITEM_TEXT=it'\''s'
+export 'API_KEY=abc'
+cd '/work dir' || exit
+'/plugins/my script.sh' 'say "hi"' && /bin/sh -c 'echo done'
+`)
+
+	// the script runs the command with the environment, in the
+	// directory, and removes itself
+	dir, err := ioutil.TempDir("", "xbar-terminal-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	scriptPath, err := writeTerminalScript(terminalScript(dir, []string{"GREETING=hello there"}, "/bin/sh", []string{"-c", `echo "$GREETING" > out.txt`}, []string{"echo step >> out.txt"}))
+	is.NoErr(err)
+	info, err := os.Stat(scriptPath)
+	is.NoErr(err)
+	is.Equal(info.Mode().Perm(), os.FileMode(0600)) // private
+	err = osexec.Command("/bin/sh", scriptPath).Run()
+	is.NoErr(err)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "out.txt"))
+	is.NoErr(err)
+	is.Equal(string(b), "hello there\nstep\n")
+	_, err = os.Stat(scriptPath)
+	is.True(os.IsNotExist(err)) // removed itself
+}
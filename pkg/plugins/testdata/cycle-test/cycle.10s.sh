@@ -0,0 +1,8 @@
+#!/bin/bash
+
+# <xbar.title>Cycle test</xbar.title>
+# <xbar.cycle>2s</xbar.cycle>
+
+echo "one"
+echo "two"
+echo "three"
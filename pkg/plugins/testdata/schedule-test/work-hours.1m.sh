@@ -0,0 +1,6 @@
+#!/bin/bash
+
+# <xbar.title>Schedule test</xbar.title>
+# <xbar.schedule>*/5 9-17 * * 1-5</xbar.schedule>
+
+echo "working"
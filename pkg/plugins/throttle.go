@@ -0,0 +1,30 @@
+package plugins
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// SetThrottle slows down the plugin's refreshes, to save power, by
+// multiplying the time between them by factor (like 2 to refresh half
+// as often). A factor of 1 (or less) stops throttling.
+// Plugins with NeverThrottle, or a Schedule, aren't throttled.
+// It is safe to call while the plugin is running, and takes effect
+// from the next refresh.
+func (p *Plugin) SetThrottle(factor float64) {
+	atomic.StoreUint64(&p.throttle, math.Float64bits(factor))
+}
+
+// throttled gets the delay, multiplied by the factor set with
+// SetThrottle.
+func (p *Plugin) throttled(delay time.Duration) time.Duration {
+	if p.NeverThrottle {
+		return delay
+	}
+	factor := math.Float64frombits(atomic.LoadUint64(&p.throttle))
+	if factor <= 1 {
+		return delay
+	}
+	return time.Duration(float64(delay) * factor)
+}
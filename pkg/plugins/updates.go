@@ -0,0 +1,214 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matryer/xbar/pkg/metadata"
+	"github.com/pkg/errors"
+)
+
+// InstallRecord describes where an installed plugin came from, so
+// it can be checked for updates.
+type InstallRecord struct {
+	// Path is the path of the plugin in the xbar plugin repository,
+	// like Finance/currency-tracker.1h.py.
+	Path string `json:"path"`
+	// Version is the version (from xbar.version) that was installed.
+	Version string `json:"version,omitempty"`
+	// Hash is the hash of the plugin that was installed (see
+	// contentHash), so plugins without versions can be checked for
+	// updates too.
+	Hash string `json:"hash,omitempty"`
+	// InstalledAt is when the plugin was installed.
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// LoadInstallRecords loads the records of the installed plugins from
+// the file, by installRecordKey.
+// If there is no file, there are no records.
+func LoadInstallRecords(filename string) (map[string]InstallRecord, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]InstallRecord{}, nil
+		}
+		return nil, errors.Wrap(err, "ReadFile")
+	}
+	var records map[string]InstallRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	if records == nil {
+		records = map[string]InstallRecord{}
+	}
+	return records, nil
+}
+
+// LoadInstallRecord loads the record of where the installed plugin
+// came from, or nil if it wasn't installed from the repository.
+func LoadInstallRecord(filename, installedPluginPath string) (*InstallRecord, error) {
+	records, err := LoadInstallRecords(filename)
+	if err != nil {
+		return nil, err
+	}
+	record, ok := records[installRecordKey(installedPluginPath)]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// updateInstallRecord sets (or deletes, if record is nil) the record
+// for the installed plugin in the file.
+func updateInstallRecord(filename, installedPluginPath string, record *InstallRecord) error {
+	records, err := LoadInstallRecords(filename)
+	if err != nil {
+		return err
+	}
+	key := installRecordKey(installedPluginPath)
+	if record == nil {
+		if _, ok := records[key]; !ok {
+			return nil
+		}
+		delete(records, key)
+	} else {
+		records[key] = *record
+	}
+	b, err := json.MarshalIndent(records, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "json.MarshalIndent")
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return errors.Wrap(err, "MkdirAll")
+	}
+	if err := ioutil.WriteFile(filename, b, 0666); err != nil {
+		return errors.Wrap(err, "WriteFile")
+	}
+	return nil
+}
+
+// installRecordKey gets the key of the record for the installed
+// plugin, which is the filename without its refresh time or disabled
+// extension, so the record survives those changing.
+// For example, 001-weather.1m.sh.off gets 001-weather.sh.
+func installRecordKey(installedPluginPath string) string {
+	filename := filepath.Base(installedPluginPath)
+	var prefix string
+	if i := strings.Index(filename, "-"); i > 0 {
+		if _, err := strconv.Atoi(filename[:i]); err == nil {
+			prefix = filename[:i+1]
+		}
+	}
+	return prefix + StateName(filename)
+}
+
+// contentHash gets the hash of the content of a plugin, to tell
+// whether it has changed.
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// entryFile gets the file of the plugin that is run.
+func entryFile(plugin metadata.Plugin) (metadata.File, bool) {
+	for _, f := range plugin.Files {
+		if f.Filename == plugin.Filename {
+			return f, true
+		}
+	}
+	return metadata.File{}, false
+}
+
+// isNewerPlugin gets whether the latest plugin in the repository is
+// newer than the one that was installed, by its version, or (if
+// neither has a version) by whether its content has changed.
+func isNewerPlugin(latest *metadata.Plugin, record InstallRecord) bool {
+	if record.Version != "" {
+		return metadata.IsNewerVersion(latest.Version, record.Version)
+	}
+	if record.Hash == "" || latest.Version != "" {
+		return false
+	}
+	f, ok := entryFile(*latest)
+	if !ok {
+		return false
+	}
+	return contentHash(f.Content) != record.Hash
+}
+
+// PluginUpdate describes a newer version of an installed plugin.
+type PluginUpdate struct {
+	// InstalledPluginPath is the installed plugin.
+	InstalledPluginPath string `json:"installedPluginPath"`
+	// Path is the path of the plugin in the xbar plugin repository.
+	Path string `json:"path"`
+	// InstalledVersion is the version that is installed, or empty if
+	// the plugin doesn't have versions.
+	InstalledVersion string `json:"installedVersion"`
+	// LatestVersion is the newest version in the repository, or
+	// empty if the plugin doesn't have versions, but has changed.
+	LatestVersion string `json:"latestVersion"`
+	// Changes are the changelog entries for the versions newer than
+	// the installed one.
+	Changes []metadata.ChangelogEntry `json:"changes,omitempty"`
+	// ChangelogURL is where to find out more about what changed.
+	ChangelogURL string `json:"changelogURL,omitempty"`
+}
+
+// CheckForUpdates finds the installed plugins that have newer versions
+// in the xbar plugin repository, using latest to get the metadata of
+// the plugin at a repository path.
+// Plugins without versions are checked by whether they have changed
+// since they were installed.
+// Plugins that weren't installed from the repository are skipped, as
+// are any that latest fails to get.
+func CheckForUpdates(pluginDir, installsFile string, latest func(path string) (*metadata.Plugin, error)) ([]PluginUpdate, error) {
+	installedPlugins, err := GetInstalledPlugins(pluginDir)
+	if err != nil {
+		return nil, err
+	}
+	records, err := LoadInstallRecords(installsFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load install records")
+	}
+	latestPlugins := make(map[string]*metadata.Plugin)
+	updates := []PluginUpdate{}
+	for _, installedPlugin := range installedPlugins {
+		record, ok := records[installRecordKey(installedPlugin.Path)]
+		if !ok || (record.Version == "" && record.Hash == "") {
+			continue
+		}
+		plugin, ok := latestPlugins[record.Path]
+		if !ok {
+			plugin, err = latest(record.Path)
+			if err != nil {
+				plugin = nil
+			}
+			latestPlugins[record.Path] = plugin
+		}
+		if plugin == nil || !isNewerPlugin(plugin, record) {
+			continue
+		}
+		updates = append(updates, PluginUpdate{
+			InstalledPluginPath: installedPlugin.Path,
+			Path:                record.Path,
+			InstalledVersion:    record.Version,
+			LatestVersion:       plugin.Version,
+			Changes:             plugin.ChangesSince(record.Version),
+			ChangelogURL:        plugin.ChangelogURL,
+		})
+	}
+	sort.Slice(updates, func(i, j int) bool {
+		return updates[i].InstalledPluginPath < updates[j].InstalledPluginPath
+	})
+	return updates, nil
+}
@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/matryer/xbar/pkg/metadata"
+	"github.com/pkg/errors"
+)
+
+func TestInstallRecordKey(t *testing.T) {
+	is := is.New(t)
+	is.Equal(installRecordKey("001-weather.1m.sh"), "001-weather.sh")
+	is.Equal(installRecordKey("001-weather.5m.sh.off"), "001-weather.sh")
+	is.Equal(installRecordKey("weather.1m.sh"), "weather.sh")
+	is.Equal(installRecordKey("my-weather.1m.sh"), "my-weather.sh")
+}
+
+func TestCheckForUpdates(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-updates-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	pluginDir := filepath.Join(dir, "plugins")
+	installsFile := filepath.Join(dir, "installs.json")
+	is.NoErr(os.MkdirAll(pluginDir, 0777))
+	for _, filename := range []string{
+		"001-old.1m.sh.off",
+		"001-current.1h.sh",
+		"001-unversioned.1h.sh",
+		"001-mine.1h.sh",
+		"001-changed.1h.sh",
+		"001-unchanged.1h.sh",
+		"001-gone.1h.sh",
+	} {
+		is.NoErr(ioutil.WriteFile(filepath.Join(pluginDir, filename), []byte("#!/bin/bash"), 0755))
+	}
+	for installedPluginPath, record := range map[string]InstallRecord{
+		"001-old.5m.sh":         {Path: "Tools/old.5m.sh", Version: "v1.2.0"},
+		"001-current.1h.sh":     {Path: "Tools/current.1h.sh", Version: "1.0"},
+		"001-unversioned.1h.sh": {Path: "Tools/unversioned.1h.sh"},
+		"001-gone.1h.sh":        {Path: "Tools/gone.1h.sh", Version: "1.0"},
+		"001-changed.1h.sh":     {Path: "Tools/changed.1h.sh", Hash: contentHash("echo old")},
+		"001-unchanged.1h.sh":   {Path: "Tools/unchanged.1h.sh", Hash: contentHash("echo same")},
+	} {
+		record := record
+		record.InstalledAt = time.Now()
+		is.NoErr(updateInstallRecord(installsFile, installedPluginPath, &record))
+	}
+	latestVersions := map[string]string{
+		"Tools/old.5m.sh":         "v1.10.0",
+		"Tools/current.1h.sh":     "1.0.0",
+		"Tools/unversioned.1h.sh": "2.0",
+		"Tools/changed.1h.sh":     "",
+		"Tools/unchanged.1h.sh":   "",
+	}
+	contents := map[string]string{
+		"Tools/changed.1h.sh":   "echo new",
+		"Tools/unchanged.1h.sh": "echo same",
+	}
+	var fetched []string
+	updates, err := CheckForUpdates(pluginDir, installsFile, func(path string) (*metadata.Plugin, error) {
+		fetched = append(fetched, path)
+		version, ok := latestVersions[path]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return &metadata.Plugin{
+			Path:         path,
+			Filename:     filepath.Base(path),
+			Files:        []metadata.File{{Filename: filepath.Base(path), Content: contents[path]}},
+			Version:      version,
+			ChangelogURL: "https://example.com/changes",
+			Changelog: []metadata.ChangelogEntry{
+				{Version: "v1.10.0", Changes: "Faster"},
+				{Version: "v1.2.0", Changes: "Installed already"},
+			},
+		}, nil
+	})
+	is.NoErr(err)
+	is.Equal(len(updates), 2)
+	is.Equal(updates[0].InstalledPluginPath, "001-changed.1h.sh") // no versions, but changed
+	is.Equal(updates[0].LatestVersion, "")
+	is.Equal(updates[1], PluginUpdate{
+		InstalledPluginPath: "001-old.1m.sh.off",
+		Path:                "Tools/old.5m.sh",
+		InstalledVersion:    "v1.2.0",
+		LatestVersion:       "v1.10.0",
+		Changes: []metadata.ChangelogEntry{
+			{Version: "v1.10.0", Changes: "Faster"},
+		},
+		ChangelogURL: "https://example.com/changes",
+	})
+	is.Equal(len(fetched), 5) // unversioned and unknown plugins aren't fetched
+
+	is.NoErr(updateInstallRecord(installsFile, "001-old.1m.sh.off", nil))
+	records, err := LoadInstallRecords(installsFile)
+	is.NoErr(err)
+	is.Equal(len(records), 5)
+}
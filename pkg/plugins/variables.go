@@ -2,11 +2,14 @@ package plugins
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 
+	"github.com/matryer/xbar/pkg/metadata"
 	"github.com/pkg/errors"
 )
 
@@ -14,7 +17,34 @@ import (
 const variableJSONFileExt = ".vars.json"
 
 // SaveVariableValues saves the values for a plugin.
+// Values that don't suit the type of their xbar.var are rejected.
+// The values of secret variables are kept in the Keychain, instead
+// of in the .vars.json file.
 func SaveVariableValues(pluginDir, installedPluginPath string, values map[string]interface{}) error {
+	vars, err := pluginVars(pluginDir, installedPluginPath)
+	if err != nil {
+		return err
+	}
+	values, err = metadata.Values(vars, values)
+	if err != nil {
+		return err
+	}
+	for _, name := range secretVarNames(vars) {
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		delete(values, name)
+		pluginFilename := filepath.Join(pluginDir, installedPluginPath)
+		if value == "" {
+			err = deleteSecret(pluginFilename, name)
+		} else {
+			err = secrets.Set(secretAccount(pluginFilename, name), fmt.Sprint(value))
+		}
+		if err != nil {
+			return errors.Wrapf(err, "secret variable %s", name)
+		}
+	}
 	b, err := json.MarshalIndent(values, "", "\t")
 	if err != nil {
 		return errors.Wrap(err, "json.MarshalIndent")
@@ -27,8 +57,43 @@ func SaveVariableValues(pluginDir, installedPluginPath string, values map[string
 	return nil
 }
 
-// LoadVariableValues loads the variables for a plugin.
+// LoadVariableValues loads the variables for a plugin, including
+// the values of secret variables from the Keychain.
 func LoadVariableValues(pluginDir, installedPluginPath string) (map[string]interface{}, error) {
+	values, err := loadVariablesFile(pluginDir, installedPluginPath)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := pluginVars(pluginDir, installedPluginPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range secretVarNames(vars) {
+		value, err := getSecret(filepath.Join(pluginDir, installedPluginPath), name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "secret variable %s", name)
+		}
+		if value != "" {
+			values[name] = value
+		}
+	}
+	for _, pluginVar := range vars {
+		v, ok := values[pluginVar.Name]
+		if !ok {
+			continue
+		}
+		// values saved before the type was enforced fall
+		// back to the default
+		values[pluginVar.Name], err = pluginVar.Value(v)
+		if err != nil {
+			values[pluginVar.Name] = pluginVar.DefaultValue()
+		}
+	}
+	return values, nil
+}
+
+// loadVariablesFile loads the values in the plugin's .vars.json file.
+func loadVariablesFile(pluginDir, installedPluginPath string) (map[string]interface{}, error) {
 	filename := filepath.Join(pluginDir, installedPluginPath+variableJSONFileExt)
 	f, err := os.Open(filename)
 	if err != nil {
@@ -50,3 +115,53 @@ func LoadVariableValues(pluginDir, installedPluginPath string) (map[string]inter
 	}
 	return values, nil
 }
+
+// loadVariables sets the Variables to the values in the plugin's
+// .vars.json file, and those of its secret variables.
+func (p *Plugin) loadVariables() error {
+	variables, err := p.loadVariablesFromJSONFile()
+	if err != nil {
+		p.Variables = nil
+		return err
+	}
+	secretVariables, err := p.loadSecretVariables()
+	p.Variables = append(variables, secretVariables...)
+	return err
+}
+
+// VariablesChanged tells the plugin its variables have been saved, so
+// it loads them again before it next runs.
+// It doesn't refresh the plugin, see RequestRefresh.
+func (p *Plugin) VariablesChanged() {
+	atomic.StoreInt32(&p.variablesChanged, 1)
+}
+
+// reloadVariables loads the Variables again, if VariablesChanged has
+// been called since they were last loaded.
+func (p *Plugin) reloadVariables() {
+	if atomic.SwapInt32(&p.variablesChanged, 0) == 0 {
+		return
+	}
+	if err := p.loadVariables(); err != nil {
+		p.Debugf("ERR: %s", err)
+	}
+}
+
+// pluginVars gets the xbar.var metadata for a plugin.
+// If the plugin is missing, or its metadata can't be parsed,
+// there are no variables to check values against. Other tags that
+// can't be parsed don't matter.
+func pluginVars(pluginDir, installedPluginPath string) ([]metadata.PluginVar, error) {
+	pluginFile := filepath.Join(pluginDir, installedPluginPath)
+	if _, err := os.Stat(pluginFile); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "Stat")
+	}
+	md, err := metadata.ParseFile(metadata.DebugfNoop, pluginFile)
+	if _, ok := err.(metadata.TagErrors); !ok && err != nil {
+		return nil, nil
+	}
+	return md.Vars, nil
+}
@@ -1,11 +1,14 @@
 package plugins
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/matryer/is"
+	"github.com/matryer/xbar/pkg/metadata"
 )
 
 func TestVariablesPersistence(t *testing.T) {
@@ -37,3 +40,118 @@ func TestVariablesPersistence(t *testing.T) {
 	is.Equal(loadedValues["VAR_CITY"], "London")
 	is.Equal(loadedValues["VAR_COUNTRY"], "UK")
 }
+
+func TestVariablesTypes(t *testing.T) {
+	is := is.New(t)
+
+	installedPluginPath := "test-plugin.sh"
+	pluginDir, err := ioutil.TempDir("", "xbar-variables-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(pluginDir)
+	})
+	err = ioutil.WriteFile(filepath.Join(pluginDir, installedPluginPath), []byte(`#!/bin/bash
+# <xbar.var>number(VAR_COUNT=5): How many. [1..10]</xbar.var>
+# <xbar.var>boolean(VAR_VERBOSE=false): Whether to be verbose or not.</xbar.var>
+# <xbar.var>select(VAR_STYLE="normal"): Which style to use. [small, normal, big]</xbar.var>
+echo "Hello"
+`), 0777)
+	is.NoErr(err)
+
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{
+		"VAR_COUNT":   "3",
+		"VAR_VERBOSE": "true",
+		"VAR_STYLE":   "big",
+	})
+	is.NoErr(err)
+	loadedValues, err := LoadVariableValues(pluginDir, installedPluginPath)
+	is.NoErr(err)
+	is.Equal(loadedValues["VAR_COUNT"], 3.0)
+	is.Equal(loadedValues["VAR_VERBOSE"], true)
+	is.Equal(loadedValues["VAR_STYLE"], "big")
+
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{
+		"VAR_COUNT": 30.0,
+	})
+	is.Equal(err.Error(), "Count: expected a number from 1 to 10, not 30")
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{
+		"VAR_STYLE": "huge",
+	})
+	is.Equal(err.Error(), "Style: expected one of small, normal, big, not huge")
+
+	// invalid values already saved fall back to the default
+	err = ioutil.WriteFile(filepath.Join(pluginDir, installedPluginPath+variableJSONFileExt), []byte(`{"VAR_COUNT": 99}`), 0666)
+	is.NoErr(err)
+	loadedValues, err = LoadVariableValues(pluginDir, installedPluginPath)
+	is.NoErr(err)
+	is.Equal(loadedValues["VAR_COUNT"], 5.0)
+}
+
+func TestVariablesValidation(t *testing.T) {
+	is := is.New(t)
+
+	installedPluginPath := "test-plugin.sh"
+	pluginDir, err := ioutil.TempDir("", "xbar-variables-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(pluginDir)
+	})
+	err = ioutil.WriteFile(filepath.Join(pluginDir, installedPluginPath), []byte(`#!/bin/bash
+# <xbar.var required="true" pattern="[a-z]+">string(VAR_USERNAME=""): Your username.</xbar.var>
+echo "Hello"
+`), 0777)
+	is.NoErr(err)
+
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{})
+	valueErrs, ok := err.(metadata.ValueErrors)
+	is.True(ok)
+	is.Equal(len(valueErrs), 1)
+	is.Equal(valueErrs[0].Name, "VAR_USERNAME")
+	is.Equal(valueErrs[0].Message, "required")
+
+	// nothing is saved
+	_, err = os.Stat(filepath.Join(pluginDir, installedPluginPath+variableJSONFileExt))
+	is.True(os.IsNotExist(err))
+
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{
+		"VAR_USERNAME": "matryer",
+	})
+	is.NoErr(err)
+}
+
+func TestVariablesChanged(t *testing.T) {
+	is := is.New(t)
+
+	installedPluginPath := "test-plugin.sh"
+	pluginDir, err := ioutil.TempDir("", "xbar-variables-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(pluginDir)
+	})
+	err = ioutil.WriteFile(filepath.Join(pluginDir, installedPluginPath), []byte(`#!/bin/bash
+# <xbar.var>string(VAR_NAME="Mat"): Your name.</xbar.var>
+echo "Hello $VAR_NAME"
+`), 0777)
+	is.NoErr(err)
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{
+		"VAR_NAME": "David",
+	})
+	is.NoErr(err)
+
+	ctx := context.Background()
+	p := NewPlugin(filepath.Join(pluginDir, installedPluginPath))
+	is.NoErr(p.loadVariables())
+	p.Refresh(ctx)
+	is.Equal(p.Items.CycleItems[0].Text, "Hello David")
+
+	err = SaveVariableValues(pluginDir, installedPluginPath, map[string]interface{}{
+		"VAR_NAME": "Mat",
+	})
+	is.NoErr(err)
+	p.Refresh(ctx)
+	is.Equal(p.Items.CycleItems[0].Text, "Hello David") // not reloaded yet
+
+	p.VariablesChanged()
+	p.Refresh(ctx)
+	is.Equal(p.Items.CycleItems[0].Text, "Hello Mat")
+}
@@ -0,0 +1,165 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchInterval is how often the WatchPaths are checked for changes,
+// when they can't be watched with fsnotify.
+var watchInterval = 1 * time.Second
+
+// watchSettle is how long to wait for more changes, before refreshing.
+// Saving a file usually causes a few events.
+var watchSettle = 50 * time.Millisecond
+
+// watch refreshes the plugin whenever any of its WatchPaths change,
+// until ctx is done.
+// Directories are not watched recursively, but files being added,
+// removed or changed in them counts as a change.
+func (p *Plugin) watch(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		p.Debugf("watch: %s (checking every %s instead)", err, watchInterval)
+		p.watchPoll(ctx)
+		return
+	}
+	defer watcher.Close()
+	if err := addWatchPaths(watcher, p.WatchPaths); err != nil {
+		p.Debugf("watch: %s (checking every %s instead)", err, watchInterval)
+		p.watchPoll(ctx)
+		return
+	}
+	p.watchNotify(ctx, watcher)
+}
+
+// addWatchPaths adds the paths to the watcher, along with the
+// directories they are in, so that files which are replaced (as
+// editors do when saving) or don't exist yet are noticed.
+func addWatchPaths(watcher *fsnotify.Watcher, paths []string) error {
+	for _, path := range paths {
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		if err := watcher.Add(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchNotify refreshes the plugin when the watcher reports changes
+// to its WatchPaths, until ctx is done.
+func (p *Plugin) watchNotify(ctx context.Context, watcher *fsnotify.Watcher) {
+	watched := make(map[string]bool)
+	for _, path := range p.WatchPaths {
+		watched[filepath.Clean(path)] = true
+	}
+	settle := time.NewTimer(watchSettle)
+	settle.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Clean(event.Name)
+			if !watched[name] && !watched[filepath.Dir(name)] {
+				continue
+			}
+			if watched[name] && event.Op&fsnotify.Create != 0 {
+				// a watched directory appeared, so watch its files too
+				if info, err := os.Stat(name); err == nil && info.IsDir() {
+					_ = watcher.Add(name)
+				}
+			}
+			settle.Reset(watchSettle)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			p.Debugf("watch: %s", err)
+		case <-settle.C:
+			p.watchChanged()
+		case <-ctx.Done():
+			settle.Stop()
+			return
+		}
+	}
+}
+
+// watchPoll refreshes the plugin when a snapshot of its WatchPaths
+// changes, checking every watchInterval, until ctx is done.
+func (p *Plugin) watchPoll(ctx context.Context) {
+	last := watchSnapshot(p.WatchPaths)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			snapshot := watchSnapshot(p.WatchPaths)
+			if snapshot == last {
+				continue
+			}
+			last = snapshot
+			p.watchChanged()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watchChanged refreshes the plugin, unless it is paused.
+func (p *Plugin) watchChanged() {
+	if p.Paused() {
+		return
+	}
+	p.Debugf("watched files changed: %s", filepath.Base(p.Command))
+	p.RequestRefresh()
+}
+
+// watchSnapshot describes the state of the paths (their size and
+// modification time, and the same for the files in directories),
+// so that changes can be detected by comparing snapshots.
+func watchSnapshot(paths []string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			// missing files are fine, they might appear later
+			fmt.Fprintf(&b, "%s: missing\n", path)
+			continue
+		}
+		writeFileSnapshot(&b, path, info)
+		if !info.IsDir() {
+			continue
+		}
+		files, err := ioutil.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].Name() < files[j].Name()
+		})
+		for _, file := range files {
+			writeFileSnapshot(&b, filepath.Join(path, file.Name()), file)
+		}
+	}
+	return b.String()
+}
+
+func writeFileSnapshot(b *strings.Builder, path string, info os.FileInfo) {
+	fmt.Fprintf(b, "%s: %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+}
@@ -0,0 +1,73 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestWatch(t *testing.T) {
+	testWatch(t, (*Plugin).watch)
+}
+
+func TestWatchPoll(t *testing.T) {
+	testWatch(t, (*Plugin).watchPoll)
+}
+
+func testWatch(t *testing.T, watch func(p *Plugin, ctx context.Context)) {
+	is := is.New(t)
+
+	dir, err := os.MkdirTemp("", "xbar-watch-")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	todo := filepath.Join(dir, "todo.txt")
+	is.NoErr(os.WriteFile(todo, []byte("one\n"), 0666))
+	logs := filepath.Join(dir, "logs")
+	is.NoErr(os.Mkdir(logs, 0777))
+	command := filepath.Join(dir, "todo.1h.sh")
+	is.NoErr(os.WriteFile(command, []byte(`#!/bin/bash
+# <xbar.watch>todo.txt</xbar.watch>
+# <xbar.watch>logs</xbar.watch>
+# <xbar.watch>missing.txt</xbar.watch>
+cat todo.txt
+`), 0777))
+
+	p := NewPlugin(command)
+	is.NoErr(p.loadMetadata())
+	is.Equal(p.WatchPaths, []string{todo, logs, filepath.Join(dir, "missing.txt")})
+
+	defer func(d time.Duration) {
+		watchInterval = d
+	}(watchInterval)
+	watchInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watch(p, ctx)
+	changed := func() bool {
+		select {
+		case <-p.refreshSignal:
+			return true
+		case <-time.After(200 * time.Millisecond):
+			return false
+		}
+	}
+	is.Equal(changed(), false) // nothing yet
+
+	is.NoErr(os.WriteFile(todo, []byte("one\ntwo\n"), 0666))
+	is.Equal(changed(), true) // file changed
+
+	is.NoErr(os.WriteFile(filepath.Join(logs, "today.log"), []byte("hi"), 0666))
+	is.Equal(changed(), true) // file added to directory
+
+	is.NoErr(os.WriteFile(filepath.Join(dir, "missing.txt"), []byte("here"), 0666))
+	is.Equal(changed(), true) // file created
+
+	is.NoErr(os.WriteFile(filepath.Join(dir, "unwatched.txt"), []byte("hi"), 0666))
+	is.Equal(changed(), false)
+}
@@ -0,0 +1,116 @@
+package update
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// appcast is a Sparkle appcast feed (see https://sparkle-project.org/documentation/publishing/).
+type appcast struct {
+	Items []appcastItem `xml:"channel>item"`
+}
+
+// appcastItem is a release in an appcast.
+type appcastItem struct {
+	Title              string           `xml:"title"`
+	Description        string           `xml:"description"`
+	Version            string           `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle version"`
+	ShortVersionString string           `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle shortVersionString"`
+	Channel            string           `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle channel"`
+	Enclosure          appcastEnclosure `xml:"enclosure"`
+}
+
+// appcastEnclosure is the download of an appcastItem.
+type appcastEnclosure struct {
+	URL                string `xml:"url,attr"`
+	Version            string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle version,attr"`
+	ShortVersionString string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle shortVersionString,attr"`
+	EdSignature        string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle edSignature,attr"`
+	Length             int64  `xml:"length,attr"`
+}
+
+// getAppcastReleases gets the releases in u.AppcastURL that are in
+// u.Channel.
+func (u *Updater) getAppcastReleases() ([]Release, error) {
+	resp, err := u.Client.Get(u.AppcastURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "get appcast")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get appcast: got %s", resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, u.DownloadBytesLimit))
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+	return parseAppcast(b, u.Channel)
+}
+
+// parseAppcast gets the releases in the appcast that are in the
+// channel.
+// Items in a Sparkle channel (like beta) are prereleases.
+func parseAppcast(b []byte, channel Channel) ([]Release, error) {
+	var feed appcast
+	if err := xml.Unmarshal(b, &feed); err != nil {
+		return nil, errors.Wrap(err, "parse appcast")
+	}
+	var releases []Release
+	for _, item := range feed.Items {
+		if item.Enclosure.URL == "" || !appcastChannelIncluded(channel, item.Channel) {
+			continue
+		}
+		version := firstNonEmpty(
+			item.ShortVersionString, item.Enclosure.ShortVersionString,
+			item.Version, item.Enclosure.Version,
+		)
+		if version == "" {
+			continue
+		}
+		if !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		releases = append(releases, Release{
+			TagName:    version,
+			Body:       strings.TrimSpace(item.Description),
+			Prerelease: item.Channel != "",
+			Assets: []Asset{
+				{
+					Name:               path.Base(item.Enclosure.URL),
+					BrowserDownloadURL: item.Enclosure.URL,
+					EdSignature:        item.Enclosure.EdSignature,
+					Size:               item.Enclosure.Length,
+				},
+			},
+		})
+	}
+	return releases, nil
+}
+
+// appcastChannelIncluded gets whether items in the Sparkle channel
+// (which is empty for the default channel) are in the channel.
+func appcastChannelIncluded(channel Channel, sparkleChannel string) bool {
+	switch strings.ToLower(sparkleChannel) {
+	case "":
+		return true
+	case string(ChannelBeta):
+		return channel == ChannelBeta || channel == ChannelNightly
+	default:
+		return channel == ChannelNightly
+	}
+}
+
+// firstNonEmpty gets the first of the values that isn't empty.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
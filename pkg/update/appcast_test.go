@@ -0,0 +1,132 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+const testAppcast = `<?xml version="1.0" encoding="utf-8"?>
+<rss version="2.0" xmlns:sparkle="http://www.andymatuschak.org/xml-namespaces/sparkle">
+	<channel>
+		<title>xbar</title>
+		<item>
+			<title>Version 2.2.0 beta 1</title>
+			<sparkle:channel>beta</sparkle:channel>
+			<enclosure url="https://example.com/xbar.v2.2.0-beta.1.tar.gz" sparkle:shortVersionString="2.2.0-beta.1" />
+		</item>
+		<item>
+			<title>Version 2.1.0</title>
+			<description><![CDATA[ Features ]]></description>
+			<sparkle:version>210</sparkle:version>
+			<sparkle:shortVersionString>2.1.0</sparkle:shortVersionString>
+			<enclosure url="https://example.com/xbar.v2.1.0.tar.gz" sparkle:edSignature="c2lnbmF0dXJl" />
+		</item>
+		<item>
+			<title>Version 2.0.0</title>
+			<enclosure url="https://example.com/xbar.v2.0.0.tar.gz" sparkle:version="2.0.0" />
+		</item>
+		<item>
+			<title>Nightly</title>
+			<sparkle:channel>nightly</sparkle:channel>
+			<enclosure url="https://example.com/xbar.v2.2.0-nightly.tar.gz" sparkle:version="2.2.0-nightly" />
+		</item>
+		<item>
+			<title>No download</title>
+			<sparkle:version>3.0.0</sparkle:version>
+		</item>
+	</channel>
+</rss>`
+
+func TestParseAppcast(t *testing.T) {
+	is := is.New(t)
+
+	releases, err := parseAppcast([]byte(testAppcast), ChannelStable)
+	is.NoErr(err)
+	is.Equal(len(releases), 2)
+	is.Equal(releases[0].TagName, "v2.1.0")
+	is.Equal(releases[0].Body, "Features")
+	is.Equal(releases[0].Prerelease, false)
+	is.Equal(len(releases[0].Assets), 1)
+	is.Equal(releases[0].Assets[0].Name, "xbar.v2.1.0.tar.gz")
+	is.Equal(releases[0].Assets[0].BrowserDownloadURL, "https://example.com/xbar.v2.1.0.tar.gz")
+	is.Equal(releases[0].Assets[0].EdSignature, "c2lnbmF0dXJl")
+	is.Equal(releases[1].TagName, "v2.0.0")
+
+	releases, err = parseAppcast([]byte(testAppcast), ChannelBeta)
+	is.NoErr(err)
+	is.Equal(len(releases), 3)
+	is.Equal(releases[0].TagName, "v2.2.0-beta.1")
+	is.Equal(releases[0].Prerelease, true)
+
+	releases, err = parseAppcast([]byte(testAppcast), ChannelNightly)
+	is.NoErr(err)
+	is.Equal(len(releases), 4)
+
+	_, err = parseAppcast([]byte("<rss>"), ChannelStable)
+	is.True(err != nil)
+}
+
+func TestAppcastUpdate(t *testing.T) {
+	is := is.New(t)
+
+	feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(testAppcast))
+		is.NoErr(err) // write
+	}))
+	t.Cleanup(func() {
+		feedServer.Close()
+	})
+	u := &Updater{
+		CurrentVersion:     "v2.0.0",
+		AppcastURL:         feedServer.URL + "/appcast.xml",
+		Client:             &http.Client{Timeout: 10 * time.Second},
+		DownloadBytesLimit: 1_000_000,
+	}
+	latest, hasUpdate, err := u.HasUpdate()
+	is.NoErr(err)
+	is.True(hasUpdate)
+	is.Equal(latest.TagName, "v2.1.0")
+
+	u.Channel = ChannelBeta
+	latest, hasUpdate, err = u.HasUpdate()
+	is.NoErr(err)
+	is.True(hasUpdate)
+	is.Equal(latest.TagName, "v2.2.0-beta.1")
+}
+
+func TestVerifyEdSignature(t *testing.T) {
+	is := is.New(t)
+
+	// appcasts are signed with the plain Ed25519 key, which is
+	// the same key as in the minisign public key
+	pub, priv, err := ed25519.GenerateKey(nil)
+	is.NoErr(err)
+	publicKey := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(minisignAlgorithm), "xbartest"...), pub...))
+	message := []byte("xbar.v2.1.0.tar.gz")
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, message))
+	is.NoErr(verifyEdSignature(publicKey, message, signature))
+
+	err = verifyEdSignature(publicKey, []byte("tampered"), signature)
+	is.Equal(err.Error(), "invalid signature")
+
+	// verifyAsset uses the signature from the appcast
+	tmp := t.TempDir()
+	filename := filepath.Join(tmp, "xbar.v2.1.0.tar.gz")
+	is.NoErr(os.WriteFile(filename, message, 0644))
+	u := &Updater{PublicKey: publicKey}
+	asset := Asset{Name: "xbar.v2.1.0.tar.gz", EdSignature: signature}
+	is.NoErr(u.verifyAsset(Release{Assets: []Asset{asset}}, asset, filename))
+	asset.EdSignature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte("other")))
+	err = u.verifyAsset(Release{Assets: []Asset{asset}}, asset, filename)
+	is.True(strings.Contains(err.Error(), "invalid signature"))
+}
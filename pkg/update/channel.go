@@ -0,0 +1,110 @@
+package update
+
+import (
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+)
+
+// Channel is a stream of releases to update from.
+type Channel string
+
+const (
+	// ChannelStable gets full releases only.
+	ChannelStable Channel = "stable"
+	// ChannelBeta gets prereleases (like v2.2.0-beta.1) too, so
+	// new features can be tried early.
+	ChannelBeta Channel = "beta"
+	// ChannelNightly gets every release, including nightly builds
+	// (like v2.2.0-nightly.20211016).
+	ChannelNightly Channel = "nightly"
+)
+
+// Channels are the supported channels, most stable first.
+var Channels = []Channel{ChannelStable, ChannelBeta, ChannelNightly}
+
+// ParseChannel gets the Channel from s, which is empty for
+// ChannelStable.
+func ParseChannel(s string) (Channel, error) {
+	if s == "" {
+		return ChannelStable, nil
+	}
+	for _, channel := range Channels {
+		if strings.EqualFold(s, string(channel)) {
+			return channel, nil
+		}
+	}
+	return "", errors.Errorf("unsupported update channel %q (expected stable, beta or nightly)", s)
+}
+
+// includes gets whether the release with the tag is in the channel.
+// prerelease is whether GitHub says it is a prerelease.
+func (c Channel) includes(tag string, prerelease bool) bool {
+	switch c {
+	case ChannelNightly:
+		return true
+	case ChannelBeta:
+		return !strings.Contains(strings.ToLower(tag), "nightly")
+	default:
+		if prerelease {
+			return false
+		}
+		v, err := semver.NewVersion(tag)
+		return err != nil || v.Prerelease() == ""
+	}
+}
+
+// releasesEndpoint gets the URL of the API that lists the releases.
+func (u *Updater) releasesEndpoint() string {
+	if u.ReleasesGitHubEndpoint != "" {
+		return u.ReleasesGitHubEndpoint
+	}
+	return strings.TrimSuffix(u.LatestReleaseGitHubEndpoint, "/latest")
+}
+
+// getLatestChannelRelease gets the newest release in u.Channel, from
+// all the releases (since the latest release endpoint never includes
+// prereleases).
+func (u *Updater) getLatestChannelRelease() (*Release, error) {
+	releases, err := u.getReleases()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		latest        *Release
+		latestVersion *semver.Version
+	)
+	for i := range releases {
+		release := &releases[i]
+		if release.Draft || !u.Channel.includes(release.TagName, release.Prerelease) {
+			continue
+		}
+		v, err := semver.NewVersion(release.TagName)
+		if err != nil {
+			continue
+		}
+		if latestVersion == nil || v.GreaterThan(latestVersion) {
+			latest, latestVersion = release, v
+		}
+	}
+	if latest == nil {
+		return nil, errors.Errorf("no %s releases", u.Channel)
+	}
+	return latest, nil
+}
+
+// needsUpdate gets whether the latest release should be installed.
+// Besides newer versions, when the current version isn't in the
+// channel (like a beta, after switching to the stable channel), the
+// latest release is installed to roll back to it.
+func (u *Updater) needsUpdate(latest string) bool {
+	channel := u.Channel
+	if channel == "" {
+		channel = ChannelStable
+	}
+	if !channel.includes(u.CurrentVersion, false) {
+		return latest != u.CurrentVersion
+	}
+	return hasUpdate(u.CurrentVersion, latest)
+}
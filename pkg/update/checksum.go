@@ -0,0 +1,92 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// checksumsAssetName is the name of the release asset listing the
+// SHA-256 checksum of each asset, like goreleaser makes.
+const checksumsAssetName = "checksums.txt"
+
+// maxChecksumsBytes is the most that is downloaded for the checksums.
+const maxChecksumsBytes = 64 * 1024
+
+// verifyChecksum checks the SHA-256 of the downloaded asset (in
+// filename) matches the one in the release's checksums.txt.
+// Releases without a checksums.txt are refused, unless their
+// signature is checked instead (when there's a PublicKey).
+func (u *Updater) verifyChecksum(release Release, asset Asset, filename string) error {
+	var checksumsAsset *Asset
+	for i := range release.Assets {
+		if release.Assets[i].Name == checksumsAssetName {
+			checksumsAsset = &release.Assets[i]
+			break
+		}
+	}
+	if checksumsAsset == nil {
+		if u.PublicKey != "" {
+			// verifyAsset checks the signature instead
+			return nil
+		}
+		return errors.Errorf("the release has no %s", checksumsAssetName)
+	}
+	resp, err := u.Client.Get(checksumsAsset.BrowserDownloadURL)
+	if err != nil {
+		return errors.Wrap(err, "download checksums")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("download checksums: got %s", resp.Status)
+	}
+	checksums, err := io.ReadAll(io.LimitReader(resp.Body, maxChecksumsBytes))
+	if err != nil {
+		return errors.Wrap(err, "download checksums")
+	}
+	expected, err := findChecksum(checksums, asset.Name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return errors.Wrap(err, "hash download")
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return errors.Errorf("%s is corrupt or has been tampered with: expected SHA-256 %s, got %s", asset.Name, expected, actual)
+	}
+	return nil
+}
+
+// findChecksum finds the checksum of the file in checksums, where
+// each line is a checksum and a filename (like sha256sum makes).
+func findChecksum(checksums []byte, filename string) (string, error) {
+	s := bufio.NewScanner(bytes.NewReader(checksums))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum puts * before the filename in binary mode
+		if strings.TrimPrefix(fields[1], "*") == filename {
+			return fields[0], nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", errors.Wrap(err, "read checksums")
+	}
+	return "", errors.Errorf("%s has no checksum in %s", filename, checksumsAssetName)
+}
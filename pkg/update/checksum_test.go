@@ -0,0 +1,101 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestFindChecksum(t *testing.T) {
+	is := is.New(t)
+
+	checksums := []byte(`
+6b86b273ff34fce19d6b804eff5a3f5747ada4eaa22f1d49c01e52ddb7875b4b  xbar.v2.1.0.tar.gz
+d4735e3a265e16eee03f59718b9b5d03019c07d8b6c51f90da3a666eec13ab35 *xbar.v2.1.0.dmg
+`)
+	checksum, err := findChecksum(checksums, "xbar.v2.1.0.tar.gz")
+	is.NoErr(err)
+	is.Equal(checksum, "6b86b273ff34fce19d6b804eff5a3f5747ada4eaa22f1d49c01e52ddb7875b4b")
+	checksum, err = findChecksum(checksums, "xbar.v2.1.0.dmg")
+	is.NoErr(err)
+	is.Equal(checksum, "d4735e3a265e16eee03f59718b9b5d03019c07d8b6c51f90da3a666eec13ab35")
+	_, err = findChecksum(checksums, "xbar.v2.0.0.tar.gz")
+	is.Equal(err.Error(), "xbar.v2.0.0.tar.gz has no checksum in checksums.txt")
+}
+
+func TestUpdateRefusesBadChecksum(t *testing.T) {
+	is := is.New(t)
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(filepath.Join("testchecksum-testarea"))
+		is.NoErr(err)
+	})
+	appPath := filepath.Join("testchecksum-testarea", "xbar.app")
+	is.NoErr(os.MkdirAll(appPath, 0777))
+
+	sum := sha256.Sum256([]byte("what was released"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			w.Write([]byte(`{
+				"tag_name": "v2.0.0",
+				"assets": [
+					{"name": "xbar-v2.0.0.tar.gz", "browser_download_url": "http://` + r.Host + `/xbar-v2.0.0.tar.gz"},
+					{"name": "checksums.txt", "browser_download_url": "http://` + r.Host + `/checksums.txt"}
+				]
+			}`))
+		case "/xbar-v2.0.0.tar.gz":
+			w.Write([]byte("what was downloaded"))
+		case "/checksums.txt":
+			w.Write([]byte(hex.EncodeToString(sum[:]) + "  xbar-v2.0.0.tar.gz\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	u := Updater{
+		DownloadBytesLimit:          1_000_000,
+		LatestReleaseGitHubEndpoint: server.URL + "/releases/latest",
+		CurrentVersion:              "v1.9.0",
+		Client:                      &http.Client{Timeout: 1 * time.Minute},
+		SelectAsset: func(release Release, asset Asset) bool {
+			return asset.Name == "xbar-"+release.TagName+".tar.gz"
+		},
+		GetExecutable: func() (string, error) {
+			return filepath.Join(appPath, "Contents", "MacOS", "xbar"), nil
+		},
+	}
+	_, err := u.Update()
+	is.True(err != nil) // bad checksum
+	is.True(strings.Contains(err.Error(), "xbar-v2.0.0.tar.gz is corrupt or has been tampered with"))
+	_, err = os.Stat(appPath)
+	is.NoErr(err) // current app is left alone
+}
+
+func TestVerifyChecksumMissing(t *testing.T) {
+	is := is.New(t)
+
+	release := Release{
+		TagName: "v2.0.0",
+		Assets: []Asset{
+			{Name: "xbar-v2.0.0.tar.gz", BrowserDownloadURL: "https://example.com/xbar-v2.0.0.tar.gz"},
+		},
+	}
+	u := Updater{}
+	err := u.verifyChecksum(release, release.Assets[0], "xbar-v2.0.0.tar.gz")
+	is.True(err != nil) // no checksums.txt
+	is.Equal(err.Error(), "the release has no checksums.txt")
+
+	// the signature is checked instead
+	u.PublicKey = "RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3"
+	is.NoErr(u.verifyChecksum(release, release.Assets[0], "xbar-v2.0.0.tar.gz"))
+}
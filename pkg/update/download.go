@@ -0,0 +1,213 @@
+package update
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultRetries is the default Updater.Retries.
+const defaultRetries = 3
+
+// defaultRetryBackoff is the default Updater.RetryBackoff.
+const defaultRetryBackoff = 2 * time.Second
+
+// download downloads the file at url to filename.
+// If filename already has some of the file in it (from a download
+// that dropped), the rest is downloaded, if the server supports
+// ranges.
+// If size isn't zero, the download must be that many bytes.
+// Failed downloads are retried, waiting longer between each attempt.
+func (u *Updater) download(url, filename string, size int64) error {
+	retries := u.Retries
+	if retries == 0 {
+		retries = defaultRetries
+	}
+	backoff := u.RetryBackoff
+	if backoff == 0 {
+		backoff = defaultRetryBackoff
+	}
+	if size > u.DownloadBytesLimit {
+		return errors.Errorf("%d bytes is more than the limit of %d", size, u.DownloadBytesLimit)
+	}
+	for attempt := 0; ; attempt++ {
+		retry, err := u.downloadAttempt(url, filename, size)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= retries {
+			return err
+		}
+		log.Printf("download failed (retrying in %s): %s", backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// downloadAttempt downloads what's left of the file at url into
+// filename, returning whether it is worth retrying if it fails.
+func (u *Updater) downloadAttempt(url, filename string, size int64) (bool, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return false, errors.Wrap(err, "open download file")
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, errors.Wrap(err, "seek download file")
+	}
+	// restart starts the download again next time, because what has
+	// been downloaded so far can't be trusted
+	restart := func(retry bool, err error) (bool, error) {
+		if truncErr := f.Truncate(0); truncErr != nil {
+			return false, errors.Wrap(truncErr, "truncate download file")
+		}
+		return retry, err
+	}
+	if size > 0 && offset > size {
+		return restart(true, errors.Errorf("download file has %d bytes, expected %d", offset, size))
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	total := size
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// the whole file is coming, so start again
+		if offset > 0 {
+			if err := f.Truncate(0); err != nil {
+				return false, errors.Wrap(err, "truncate download file")
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return false, errors.Wrap(err, "seek download file")
+			}
+			offset = 0
+		}
+		if total == 0 && resp.ContentLength > 0 {
+			total = resp.ContentLength
+		}
+	case http.StatusPartialContent:
+		start, length, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return restart(true, err)
+		}
+		if start != offset {
+			return restart(true, errors.Errorf("resumed from %d bytes, not %d", start, offset))
+		}
+		if total == 0 {
+			total = length
+		}
+		log.Printf("resuming download from %d bytes", offset)
+	case http.StatusRequestedRangeNotSatisfiable:
+		// there's nothing left to download, if the file is complete
+		_, length, _ := parseContentRange(resp.Header.Get("Content-Range"))
+		if total == 0 {
+			total = length
+		}
+		if total > 0 && offset == total {
+			return false, nil
+		}
+		return restart(true, errors.Errorf("got %s with %d bytes downloaded", resp.Status, offset))
+	default:
+		retry := resp.StatusCode >= 500 ||
+			resp.StatusCode == http.StatusTooManyRequests ||
+			resp.StatusCode == http.StatusRequestTimeout
+		return retry, errors.Errorf("got %s", resp.Status)
+	}
+	if total > u.DownloadBytesLimit {
+		return restart(false, errors.Errorf("%d bytes is more than the limit of %d", total, u.DownloadBytesLimit))
+	}
+	remaining := u.DownloadBytesLimit - offset
+	if remaining <= 0 {
+		return restart(false, errors.Errorf("download is more than the limit of %d bytes", u.DownloadBytesLimit))
+	}
+	// one more byte than allowed is read, to tell if there's too much
+	n, err := io.Copy(f, io.LimitReader(resp.Body, remaining+1))
+	if err != nil {
+		return true, err
+	}
+	downloaded := offset + n
+	if downloaded > u.DownloadBytesLimit {
+		return restart(false, errors.Errorf("download is more than the limit of %d bytes", u.DownloadBytesLimit))
+	}
+	if total > 0 && downloaded != total {
+		if downloaded < total {
+			// it dropped, so carry on from here next time
+			return true, errors.Errorf("downloaded %d of %d bytes", downloaded, total)
+		}
+		return restart(false, errors.Errorf("downloaded %d bytes, expected %d", downloaded, total))
+	}
+	return false, nil
+}
+
+// parseContentRange parses a Content-Range header, like
+// "bytes 100-199/1000", getting the start and the complete length
+// (which is zero if it's "*"). Unsatisfied ranges, like "bytes */1000",
+// have a start of -1.
+func parseContentRange(contentRange string) (int64, int64, error) {
+	invalid := errors.Errorf("invalid Content-Range %q", contentRange)
+	spec := strings.TrimPrefix(contentRange, "bytes ")
+	if spec == contentRange {
+		return 0, 0, invalid
+	}
+	slash := strings.Index(spec, "/")
+	if slash < 0 {
+		return 0, 0, invalid
+	}
+	byteRange, completeLength := spec[:slash], spec[slash+1:]
+	var length int64
+	if completeLength != "*" {
+		var err error
+		length, err = strconv.ParseInt(completeLength, 10, 64)
+		if err != nil || length < 0 {
+			return 0, 0, invalid
+		}
+	}
+	if byteRange == "*" {
+		return -1, length, nil
+	}
+	dash := strings.Index(byteRange, "-")
+	if dash < 0 {
+		return 0, 0, invalid
+	}
+	start, err := strconv.ParseInt(byteRange[:dash], 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, invalid
+	}
+	return start, length, nil
+}
+
+// downloadDir gets the directory downloads are kept in while they
+// are incomplete, which only the current user can access.
+func downloadDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cacheDir, "xbar", "updates")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	// in case it was made by something else
+	if err := os.Chmod(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
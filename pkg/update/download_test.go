@@ -0,0 +1,192 @@
+package update
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestDownloadResumes(t *testing.T) {
+	is := is.New(t)
+
+	content := bytes.Repeat([]byte("xbar"), 1000)
+	var requests, ranges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Range") != "" {
+			ranges++
+		}
+		if requests == 1 {
+			// send half, then drop the connection
+			w.Header().Set("Content-Length", "4000")
+			w.WriteHeader(http.StatusOK)
+			w.Write(content[:2000])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		if requests == 2 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		http.ServeContent(w, r, "xbar.tar.gz", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+
+	dir, err := ioutil.TempDir("", "xbar-download-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	filename := filepath.Join(dir, "xbar.tar.gz")
+	u := Updater{
+		Client:             server.Client(),
+		DownloadBytesLimit: 1_000_000,
+		RetryBackoff:       time.Millisecond,
+	}
+	is.NoErr(u.download(server.URL+"/xbar.tar.gz", filename, 0))
+	b, err := ioutil.ReadFile(filename)
+	is.NoErr(err)
+	is.True(bytes.Equal(b, content)) // downloaded it all
+	is.Equal(requests, 3)
+	is.Equal(ranges, 2) // carried on from where it dropped
+
+	// a complete download doesn't download anything else
+	is.NoErr(u.download(server.URL+"/xbar.tar.gz", filename, 0))
+	b, err = ioutil.ReadFile(filename)
+	is.NoErr(err)
+	is.Equal(len(b), len(content))
+}
+
+func TestDownloadGivesUp(t *testing.T) {
+	is := is.New(t)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	dir, err := ioutil.TempDir("", "xbar-download-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	u := Updater{
+		Client:             server.Client(),
+		DownloadBytesLimit: 1_000_000,
+		Retries:            2,
+		RetryBackoff:       time.Millisecond,
+	}
+	err = u.download(server.URL+"/busy", filepath.Join(dir, "busy.tar.gz"), 0)
+	is.True(err != nil)
+	is.Equal(requests, 3) // tried, then retried twice
+
+	requests = 0
+	err = u.download(server.URL+"/missing", filepath.Join(dir, "missing.tar.gz"), 0)
+	is.True(err != nil)
+	is.Equal(requests, 1) // not worth retrying
+}
+
+func TestDownloadChecksRanges(t *testing.T) {
+	is := is.New(t)
+
+	content := bytes.Repeat([]byte("xbar"), 1000)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/wrong-start" && requests == 1 {
+			// claims to resume, but from the wrong place
+			w.Header().Set("Content-Range", "bytes 0-3999/4000")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content)
+			return
+		}
+		http.ServeContent(w, r, "xbar.tar.gz", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+
+	dir, err := ioutil.TempDir("", "xbar-download-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	u := Updater{
+		Client:             server.Client(),
+		DownloadBytesLimit: 1_000_000,
+		RetryBackoff:       time.Millisecond,
+	}
+
+	filename := filepath.Join(dir, "wrong-start.tar.gz")
+	is.NoErr(ioutil.WriteFile(filename, content[:1000], 0600))
+	is.NoErr(u.download(server.URL+"/wrong-start", filename, 0))
+	b, err := ioutil.ReadFile(filename)
+	is.NoErr(err)
+	is.True(bytes.Equal(b, content)) // started again
+	is.Equal(requests, 2)
+
+	// more than the whole file is already there (like from another
+	// release), so the range can't be satisfied
+	requests = 0
+	filename = filepath.Join(dir, "too-long.tar.gz")
+	is.NoErr(ioutil.WriteFile(filename, append(content, "extra"...), 0600))
+	is.NoErr(u.download(server.URL+"/too-long", filename, 0))
+	b, err = ioutil.ReadFile(filename)
+	is.NoErr(err)
+	is.True(bytes.Equal(b, content)) // started again
+	is.Equal(requests, 2)
+
+	// the asset is meant to be a different size
+	requests = 0
+	filename = filepath.Join(dir, "wrong-size.tar.gz")
+	err = u.download(server.URL+"/wrong-size", filename, int64(len(content)-1))
+	is.True(err != nil)
+	is.Equal(err.Error(), "downloaded 4000 bytes, expected 3999")
+	is.Equal(requests, 1) // not worth retrying
+
+	// bigger than the limit
+	requests = 0
+	u.DownloadBytesLimit = 1000
+	filename = filepath.Join(dir, "too-big.tar.gz")
+	err = u.download(server.URL+"/too-big", filename, 0)
+	is.True(err != nil)
+	is.Equal(err.Error(), "4000 bytes is more than the limit of 1000")
+	info, err := os.Stat(filename)
+	is.NoErr(err)
+	is.Equal(info.Size(), int64(0)) // nothing kept
+	err = u.download(server.URL+"/too-big", filename, int64(len(content)))
+	is.True(err != nil)
+	is.Equal(requests, 1) // the asset size is checked first
+}
+
+func TestParseContentRange(t *testing.T) {
+	is := is.New(t)
+
+	start, length, err := parseContentRange("bytes 100-199/1000")
+	is.NoErr(err)
+	is.Equal(start, int64(100))
+	is.Equal(length, int64(1000))
+	start, length, err = parseContentRange("bytes 100-199/*")
+	is.NoErr(err)
+	is.Equal(start, int64(100))
+	is.Equal(length, int64(0))
+	start, length, err = parseContentRange("bytes */1000")
+	is.NoErr(err)
+	is.Equal(start, int64(-1))
+	is.Equal(length, int64(1000))
+	for _, invalid := range []string{"", "100-199/1000", "bytes 100-199", "bytes x-199/1000", "bytes 100-199/x"} {
+		_, _, err = parseContentRange(invalid)
+		is.True(err != nil) // invalid
+	}
+}
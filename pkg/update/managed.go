@@ -0,0 +1,110 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// caskroomDirs are where Homebrew keeps the casks it has installed.
+var caskroomDirs = []string{
+	"/opt/homebrew/Caskroom",
+	"/usr/local/Caskroom",
+}
+
+// caskName is the name of the xbar Homebrew cask.
+const caskName = "xbar"
+
+// caskAppPath is where the Homebrew cask puts the app.
+const caskAppPath = "/Applications/xbar.app"
+
+// Managed explains why the app can't update itself, and what to do
+// instead.
+type Managed struct {
+	// Reason is why the app can't update itself.
+	Reason string
+	// Instructions are what to do to update.
+	Instructions string
+}
+
+// Error gets the Reason and Instructions.
+func (m *Managed) Error() string {
+	return m.Reason + " " + m.Instructions
+}
+
+// Managed gets whether the app can't replace itself with an update,
+// because it was installed with Homebrew (which should update it
+// instead), or it is running from somewhere it can't write to (like
+// a disk image, or where macOS translocated it).
+// Returns nil if the app can update itself.
+func (u *Updater) Managed() (*Managed, error) {
+	getExecutable := u.GetExecutable
+	if getExecutable == nil {
+		getExecutable = os.Executable
+	}
+	executable, err := getExecutable()
+	if err != nil {
+		return nil, errors.Wrap(err, "get executable")
+	}
+	appPath, err := appPathFromExecutable(executable)
+	if err != nil {
+		return nil, errors.Wrap(err, "find app path")
+	}
+	if resolved, err := filepath.EvalSymlinks(appPath); err == nil {
+		appPath = resolved
+	}
+	if isHomebrewCask(appPath) {
+		return &Managed{
+			Reason:       "xbar was installed with Homebrew.",
+			Instructions: "Update it with: brew upgrade --cask " + caskName,
+		}, nil
+	}
+	if strings.Contains(appPath, "/AppTranslocation/") {
+		return &Managed{
+			Reason:       "xbar is running from a temporary location.",
+			Instructions: "Move xbar to your Applications folder, open it from there, and check for updates again.",
+		}, nil
+	}
+	if !writable(filepath.Dir(appPath)) {
+		return &Managed{
+			Reason:       "xbar can't update itself where it is (" + filepath.Dir(appPath) + ").",
+			Instructions: "Download the latest version from https://github.com/matryer/xbar/releases instead.",
+		}, nil
+	}
+	return nil, nil
+}
+
+// isHomebrewCask gets whether the app at appPath was installed by
+// Homebrew, which either links to it in the Caskroom, or moves it
+// to caskAppPath (and keeps a record in the Caskroom).
+func isHomebrewCask(appPath string) bool {
+	for _, dir := range caskroomDirs {
+		if strings.HasPrefix(appPath, dir+string(filepath.Separator)) {
+			return true
+		}
+		if appPath != caskAppPath {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, caskName)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// writable gets whether the directory (or, if it doesn't exist yet,
+// the closest parent that does) can be written to.
+func writable(dir string) bool {
+	const writeOK = 0x2
+	for {
+		_, err := os.Stat(dir)
+		if err == nil || !os.IsNotExist(err) || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	return syscall.Access(dir, writeOK) == nil
+}
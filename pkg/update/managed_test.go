@@ -0,0 +1,55 @@
+package update
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestManaged(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-managed-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.Chmod(filepath.Join(dir, "readonly"), 0777)
+		os.RemoveAll(dir)
+	})
+	caskroom := filepath.Join(dir, "Caskroom")
+	originalCaskroomDirs := caskroomDirs
+	caskroomDirs = []string{caskroom}
+	t.Cleanup(func() {
+		caskroomDirs = originalCaskroomDirs
+	})
+	managed := func(appPath string) *Managed {
+		u := &Updater{
+			GetExecutable: func() (string, error) {
+				return filepath.Join(appPath, "Contents", "MacOS", "xbar"), nil
+			},
+		}
+		m, err := u.Managed()
+		is.NoErr(err)
+		return m
+	}
+
+	is.Equal(managed(filepath.Join(dir, "Applications", "xbar.app")), nil) // can update itself
+
+	m := managed(filepath.Join(caskroom, "xbar", "2.1.0", "xbar.app"))
+	is.True(m != nil) // Homebrew
+	is.True(strings.Contains(m.Instructions, "brew upgrade --cask xbar"))
+
+	m = managed("/private/var/folders/x/AppTranslocation/ABC/d/xbar.app")
+	is.True(m != nil) // translocated
+	is.Equal(m.Reason, "xbar is running from a temporary location.")
+
+	if os.Geteuid() != 0 {
+		readonly := filepath.Join(dir, "readonly")
+		is.NoErr(os.MkdirAll(readonly, 0555))
+		m = managed(filepath.Join(readonly, "xbar.app"))
+		is.True(m != nil) // read-only
+	}
+}
@@ -0,0 +1,92 @@
+package update
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+)
+
+// ReleaseNotes gets the releases (with their notes in Body) newer
+// than the current version, up to and including latest, newest
+// first, so users can see everything that has changed since the
+// version they have.
+// If the releases can't be listed, just latest is returned, along
+// with the error.
+func (u *Updater) ReleaseNotes(latest Release) ([]Release, error) {
+	releases, err := u.getReleases()
+	if err != nil {
+		return []Release{latest}, errors.Wrap(err, "list releases")
+	}
+	latestVersion, err := semver.NewVersion(latest.TagName)
+	if err != nil {
+		return []Release{latest}, errors.Wrapf(err, "latest version %q", latest.TagName)
+	}
+	channel := u.Channel
+	if channel == "" {
+		channel = ChannelStable
+	}
+	var notes []Release
+	for _, release := range releases {
+		if release.Draft || !channel.includes(release.TagName, release.Prerelease) {
+			continue
+		}
+		v, err := semver.NewVersion(release.TagName)
+		if err != nil || v.GreaterThan(latestVersion) {
+			continue
+		}
+		if !hasUpdate(u.CurrentVersion, release.TagName) {
+			continue
+		}
+		notes = append(notes, release)
+	}
+	if len(notes) == 0 {
+		return []Release{latest}, nil
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		return hasUpdate(notes[j].TagName, notes[i].TagName)
+	})
+	return notes, nil
+}
+
+var (
+	// markdownLinkRegexp matches links, like [text](url).
+	markdownLinkRegexp = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	// markdownEmphasisRegexp matches bold, italic and code markers.
+	markdownEmphasisRegexp = regexp.MustCompile("\\*\\*|__|`")
+	// markdownHeadingRegexp matches heading markers.
+	markdownHeadingRegexp = regexp.MustCompile(`^#+\s*`)
+	// markdownBulletRegexp matches list item markers.
+	markdownBulletRegexp = regexp.MustCompile(`^[*+-]\s+`)
+)
+
+// PlainReleaseNotes gets the release notes (which are markdown) as
+// plain text, for places like dialogs, cut short (with …) if they
+// are longer than maxLength characters.
+func PlainReleaseNotes(notes string, maxLength int) string {
+	var lines []string
+	var blank bool
+	for _, line := range strings.Split(strings.ReplaceAll(notes, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if !blank && len(lines) > 0 {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		line = markdownHeadingRegexp.ReplaceAllString(line, "")
+		line = markdownBulletRegexp.ReplaceAllString(line, "• ")
+		line = markdownLinkRegexp.ReplaceAllString(line, "$1")
+		line = markdownEmphasisRegexp.ReplaceAllString(line, "")
+		lines = append(lines, line)
+	}
+	s := strings.TrimSpace(strings.Join(lines, "\n"))
+	if runes := []rune(s); maxLength > 0 && len(runes) > maxLength {
+		s = strings.TrimSpace(string(runes[:maxLength])) + "…"
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package update
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestReleaseNotes(t *testing.T) {
+	is := is.New(t)
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal([]Release{
+			{TagName: "v2.2.0-beta.1", Body: "Beta", Prerelease: true},
+			{TagName: "v2.0.1", Body: "Fixes"},
+			{TagName: "v2.1.0", Body: "Features"},
+			{TagName: "v2.0.0", Body: "Installed"},
+		})
+		is.NoErr(err) // marshal
+		_, err = w.Write(b)
+		is.NoErr(err) // write
+	}))
+	t.Cleanup(func() {
+		apiServer.Close()
+	})
+	u := &Updater{
+		CurrentVersion:              "v2.0.0",
+		LatestReleaseGitHubEndpoint: apiServer.URL + "/releases/latest",
+		Client:                      &http.Client{Timeout: 10 * time.Second},
+		DownloadBytesLimit:          1_000_000,
+	}
+	releases, err := u.ReleaseNotes(Release{TagName: "v2.1.0", Body: "Features"})
+	is.NoErr(err)
+	is.Equal(len(releases), 2)
+	is.Equal(releases[0].Body, "Features") // newest first
+	is.Equal(releases[1].Body, "Fixes")
+
+	// just the latest if the releases can't be listed
+	u.LatestReleaseGitHubEndpoint = apiServer.URL + "/nope/latest"
+	apiServer.Close()
+	releases, err = u.ReleaseNotes(Release{TagName: "v2.1.0", Body: "Features"})
+	is.True(err != nil) // can't list the releases
+	is.Equal(len(releases), 1)
+	is.Equal(releases[0].Body, "Features")
+}
+
+func TestPlainReleaseNotes(t *testing.T) {
+	is := is.New(t)
+
+	notes := "## What's new\r\n\r\n* **Faster** plugins\r\n* See [the docs](https://xbarapp.com) for `xbar.var`\r\n\r\n\r\nThanks!"
+	is.Equal(PlainReleaseNotes(notes, 0), "What's new\n\n• Faster plugins\n• See the docs for xbar.var\n\nThanks!")
+	is.Equal(PlainReleaseNotes(notes, 12), "What's new…")
+}
@@ -0,0 +1,102 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// previousAppSuffix is added to the path of the app being replaced
+// by an update, like xbar.app.previous.
+const previousAppSuffix = ".previous"
+
+// ErrNoPreviousVersion is returned by Rollback when there is no
+// previous version to roll back to.
+var ErrNoPreviousVersion = errors.New("no previous version to roll back to")
+
+// bundleVersionRegexp matches the version in an app's Info.plist.
+var bundleVersionRegexp = regexp.MustCompile(`<key>CFBundleShortVersionString</key>\s*<string>(.*?)</string>`)
+
+// PreviousVersion gets the version of the app that was replaced by
+// the last update (see KeepPrevious), or an empty string if there
+// isn't one to roll back to.
+func (u *Updater) PreviousVersion() (string, error) {
+	appPreviousPath, err := u.previousAppPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(appPreviousPath); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	b, err := os.ReadFile(filepath.Join(appPreviousPath, "Contents", "Info.plist"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "unknown", nil
+		}
+		return "", errors.Wrap(err, "read Info.plist")
+	}
+	match := bundleVersionRegexp.FindSubmatch(b)
+	if match == nil {
+		return "unknown", nil
+	}
+	return string(match[1]), nil
+}
+
+// Rollback puts back the app that was replaced by the last update
+// (see KeepPrevious), in place of the current one, which is removed.
+// Use Restart to run it.
+// Returns ErrNoPreviousVersion if there isn't one.
+func (u *Updater) Rollback() error {
+	appPreviousPath, err := u.previousAppPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(appPreviousPath); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNoPreviousVersion
+		}
+		return err
+	}
+	appPath := appPreviousPath[:len(appPreviousPath)-len(previousAppSuffix)]
+	appRolledBackPath := appPath + ".rolledback"
+	if err := os.RemoveAll(appRolledBackPath); err != nil {
+		return errors.Wrap(err, "remove rolled back app")
+	}
+	if err := os.Rename(appPath, appRolledBackPath); err != nil {
+		return errors.Wrap(err, "move current app")
+	}
+	if err := os.Rename(appPreviousPath, appPath); err != nil {
+		// put the current one back
+		if restoreErr := os.Rename(appRolledBackPath, appPath); restoreErr != nil {
+			return errors.Wrapf(err, "restore previous app (and restore current app: %s)", restoreErr)
+		}
+		return errors.Wrap(err, "restore previous app")
+	}
+	if err := os.RemoveAll(appRolledBackPath); err != nil {
+		return errors.Wrap(err, "remove rolled back app")
+	}
+	return nil
+}
+
+// previousAppPath gets where the app replaced by the last update is
+// kept.
+func (u *Updater) previousAppPath() (string, error) {
+	getExecutable := u.GetExecutable
+	if getExecutable == nil {
+		getExecutable = os.Executable
+	}
+	executable, err := getExecutable()
+	if err != nil {
+		return "", errors.Wrap(err, "get executable")
+	}
+	appPath, err := appPathFromExecutable(executable)
+	if err != nil {
+		return "", errors.Wrap(err, "find app path")
+	}
+	return appPath + previousAppSuffix, nil
+}
@@ -0,0 +1,54 @@
+package update
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRollback(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-rollback-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	appPath := filepath.Join(dir, "xbar.app")
+	u := &Updater{
+		GetExecutable: func() (string, error) {
+			return filepath.Join(appPath, "Contents", "MacOS", "xbar"), nil
+		},
+	}
+	previousVersion, err := u.PreviousVersion()
+	is.NoErr(err)
+	is.Equal(previousVersion, "") // nothing to roll back to
+	is.Equal(u.Rollback(), ErrNoPreviousVersion)
+
+	writeApp := func(path, version string) {
+		is.NoErr(os.MkdirAll(filepath.Join(path, "Contents"), 0777))
+		plist := `<plist><dict>
+	<key>CFBundleShortVersionString</key><string>` + version + `</string>
+</dict></plist>`
+		is.NoErr(ioutil.WriteFile(filepath.Join(path, "Contents", "Info.plist"), []byte(plist), 0666))
+	}
+	writeApp(appPath, "v2.1.0")
+	writeApp(appPath+previousAppSuffix, "v2.0.0")
+
+	previousVersion, err = u.PreviousVersion()
+	is.NoErr(err)
+	is.Equal(previousVersion, "v2.0.0")
+
+	is.NoErr(u.Rollback())
+	b, err := ioutil.ReadFile(filepath.Join(appPath, "Contents", "Info.plist"))
+	is.NoErr(err)
+	is.True(bundleVersionRegexp.FindSubmatch(b) != nil)
+	is.Equal(string(bundleVersionRegexp.FindSubmatch(b)[1]), "v2.0.0") // rolled back
+	_, err = os.Stat(appPath + previousAppSuffix)
+	is.True(os.IsNotExist(err)) // can only roll back once
+	_, err = os.Stat(appPath + ".rolledback")
+	is.True(os.IsNotExist(err)) // newer version removed
+}
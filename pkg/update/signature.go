@@ -0,0 +1,124 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// signatureExt is the extension of the minisign signature asset
+// released alongside each archive (like xbar.v2.1.0.tar.gz.minisig).
+const signatureExt = ".minisig"
+
+// maxSignatureBytes is the most that is downloaded for a signature.
+const maxSignatureBytes = 4096
+
+// minisign algorithms (see https://jedisct1.github.io/minisign/).
+const (
+	minisignAlgorithm          = "Ed"
+	minisignPrehashedAlgorithm = "ED"
+)
+
+// publicKey is a minisign public key.
+type publicKey struct {
+	keyID [8]byte
+	key   ed25519.PublicKey
+}
+
+// parsePublicKey parses a minisign public key, which is either the
+// contents of the .pub file, or just the base64 line from it.
+func parsePublicKey(s string) (publicKey, error) {
+	var pk publicKey
+	lines := nonEmptyLines(s)
+	if len(lines) == 0 {
+		return pk, errors.New("missing public key")
+	}
+	b, err := base64.StdEncoding.DecodeString(lines[len(lines)-1])
+	if err != nil {
+		return pk, errors.Wrap(err, "decode public key")
+	}
+	if len(b) != 2+len(pk.keyID)+ed25519.PublicKeySize || string(b[:2]) != minisignAlgorithm {
+		return pk, errors.New("not a minisign public key")
+	}
+	copy(pk.keyID[:], b[2:10])
+	pk.key = ed25519.PublicKey(b[10:])
+	return pk, nil
+}
+
+// verifySignature checks that the minisign signature of message was
+// made with the public key.
+func verifySignature(publicKeyString string, message, signature []byte) error {
+	pk, err := parsePublicKey(publicKeyString)
+	if err != nil {
+		return err
+	}
+	lines := nonEmptyLines(string(signature))
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return errors.New("malformed signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil {
+		return errors.Wrap(err, "decode signature")
+	}
+	if len(sig) != 2+len(pk.keyID)+ed25519.SignatureSize {
+		return errors.New("malformed signature")
+	}
+	switch string(sig[:2]) {
+	case minisignAlgorithm:
+		// ok
+	case minisignPrehashedAlgorithm:
+		return errors.New("prehashed signatures are not supported (sign with minisign -S -l)")
+	default:
+		return errors.Errorf("unsupported signature algorithm %q", sig[:2])
+	}
+	if !bytes.Equal(sig[2:10], pk.keyID[:]) {
+		return errors.New("signed with a different key")
+	}
+	if !ed25519.Verify(pk.key, message, sig[10:]) {
+		return errors.New("invalid signature")
+	}
+	// the trusted comment is signed along with the signature
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil {
+		return errors.Wrap(err, "decode trusted comment signature")
+	}
+	trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(pk.key, append(sig[10:], trustedComment...), globalSig) {
+		return errors.New("invalid trusted comment signature")
+	}
+	return nil
+}
+
+// verifyEdSignature checks the Ed25519 signature of message (like
+// the sparkle:edSignature in an appcast) was made with the key in
+// the minisign public key.
+func verifyEdSignature(publicKeyString string, message []byte, signature string) error {
+	pk, err := parsePublicKey(publicKeyString)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return errors.Wrap(err, "decode signature")
+	}
+	if !ed25519.Verify(pk.key, message, sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// nonEmptyLines gets the lines in s, without surrounding whitespace,
+// skipping blank ones.
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
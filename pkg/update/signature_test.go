@@ -0,0 +1,119 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// testMinisignKey makes a minisign key pair, returning the public key
+// (as in a .pub file) and a function that signs like minisign -S -l.
+func testMinisignKey(t *testing.T) (string, func(message []byte) string) {
+	is := is.New(t)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	is.NoErr(err)
+	keyID := []byte("xbartest")
+	publicKey := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(minisignAlgorithm), keyID...), pub...)) + "\n"
+	sign := func(message []byte) string {
+		sig := ed25519.Sign(priv, message)
+		trustedComment := "timestamp:1634371200"
+		globalSig := ed25519.Sign(priv, append(append([]byte{}, sig...), trustedComment...))
+		return "untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte(minisignAlgorithm), keyID...), sig...)) + "\n" +
+			"trusted comment: " + trustedComment + "\n" +
+			base64.StdEncoding.EncodeToString(globalSig) + "\n"
+	}
+	return publicKey, sign
+}
+
+func TestVerifySignature(t *testing.T) {
+	is := is.New(t)
+
+	publicKey, sign := testMinisignKey(t)
+	message := []byte("xbar.v2.1.0.tar.gz")
+	signature := sign(message)
+	is.NoErr(verifySignature(publicKey, message, []byte(signature)))
+
+	// just the key line works too
+	is.NoErr(verifySignature(strings.Split(publicKey, "\n")[1], message, []byte(signature)))
+
+	err := verifySignature(publicKey, []byte("tampered"), []byte(signature))
+	is.Equal(err.Error(), "invalid signature")
+
+	otherPublicKey, _ := testMinisignKey(t)
+	err = verifySignature(otherPublicKey, message, []byte(signature))
+	is.Equal(err.Error(), "invalid signature")
+
+	lines := strings.Split(signature, "\n")
+	lines[2] = "trusted comment: something else"
+	err = verifySignature(publicKey, message, []byte(strings.Join(lines, "\n")))
+	is.Equal(err.Error(), "invalid trusted comment signature")
+
+	err = verifySignature(publicKey, message, []byte("not a signature"))
+	is.Equal(err.Error(), "malformed signature")
+
+	_, err = parsePublicKey("bm9wZQ==")
+	is.Equal(err.Error(), "not a minisign public key")
+}
+
+func TestUpdateRefusesBadSignature(t *testing.T) {
+	is := is.New(t)
+
+	t.Cleanup(func() {
+		err := os.RemoveAll(filepath.Join("testsignature-testarea"))
+		is.NoErr(err)
+	})
+	appPath := filepath.Join("testsignature-testarea", "xbar.app")
+	is.NoErr(os.MkdirAll(appPath, 0777))
+
+	publicKey, sign := testMinisignKey(t)
+	signature := sign([]byte("something else"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			w.Write([]byte(`{
+				"tag_name": "v2.0.0",
+				"assets": [
+					{"name": "xbar-v2.0.0.tar.gz", "browser_download_url": "` + "http://" + r.Host + `/xbar-v2.0.0.tar.gz"},
+					{"name": "xbar-v2.0.0.tar.gz.minisig", "browser_download_url": "` + "http://" + r.Host + `/xbar-v2.0.0.tar.gz.minisig"}
+				]
+			}`))
+		case "/xbar-v2.0.0.tar.gz":
+			w.Write([]byte("not what was signed"))
+		case "/xbar-v2.0.0.tar.gz.minisig":
+			w.Write([]byte(signature))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	u := Updater{
+		DownloadBytesLimit:          1_000_000,
+		LatestReleaseGitHubEndpoint: server.URL + "/releases/latest",
+		CurrentVersion:              "v1.9.0",
+		Client:                      &http.Client{Timeout: 1 * time.Minute},
+		PublicKey:                   publicKey,
+		SelectAsset: func(release Release, asset Asset) bool {
+			return asset.Name == "xbar-"+release.TagName+".tar.gz"
+		},
+		GetExecutable: func() (string, error) {
+			return filepath.Join(appPath, "Contents", "MacOS", "xbar"), nil
+		},
+	}
+	_, err := u.Update()
+	is.True(err != nil) // bad signature
+	is.True(strings.Contains(err.Error(), "invalid signature"))
+	_, err = os.Stat(appPath)
+	is.NoErr(err) // current app is left alone
+}
@@ -26,6 +26,19 @@ type Updater struct {
 	// LatestReleaseGitHubEndpoint is the URL of the API to get latest release data.
 	// For example, https://api.github.com/repos/matryer/xbar/releases/latest.
 	LatestReleaseGitHubEndpoint string
+	// AppcastURL is the URL of a Sparkle appcast feed to get the
+	// releases from, instead of GitHub, like a feed hosted inside a
+	// company network.
+	// Optional.
+	AppcastURL string
+	// Channel is the Channel to update from. Empty is ChannelStable.
+	Channel Channel
+	// ReleasesGitHubEndpoint is the URL of the API to list the
+	// releases, used for channels other than ChannelStable.
+	// For example, https://api.github.com/repos/matryer/xbar/releases.
+	// Optional: if empty, it is LatestReleaseGitHubEndpoint without
+	// /latest on the end.
+	ReleasesGitHubEndpoint string
 	// Client is the HTTP client to use to access the
 	// API and download the assets.
 	Client *http.Client
@@ -33,6 +46,21 @@ type Updater struct {
 	SelectAsset SelectAssetFunc
 	// DownloadBytesLimit is the maximum number of bytes to download.
 	DownloadBytesLimit int64
+	// Retries is how many times a failed download is retried (resuming
+	// where it dropped, if possible), or zero for three times.
+	Retries int
+	// RetryBackoff is how long to wait before retrying a failed
+	// download, which doubles each time, or zero for two seconds.
+	RetryBackoff time.Duration
+	// PublicKey is the minisign public key releases are signed with.
+	// If set, updates are only installed if the release has a
+	// signature asset (the archive name with .minisig on the end)
+	// made with it.
+	// Optional, but recommended.
+	PublicKey string
+	// KeepPrevious is whether to keep the app that an update replaces,
+	// so Rollback can put it back.
+	KeepPrevious bool
 	// GetExecutable is the function that gets the current
 	// executable. If nil, os.Executable will be used.
 	GetExecutable func() (string, error)
@@ -57,10 +85,17 @@ func (u *Updater) Update() (*Release, error) {
 	if err != nil {
 		return nil, err
 	}
-	hasUpdate := hasUpdate(u.CurrentVersion, latest.TagName)
-	if !hasUpdate {
+	if !u.needsUpdate(latest.TagName) {
 		return nil, nil
 	}
+	// Homebrew (or the user) has to update it
+	managed, err := u.Managed()
+	if err != nil {
+		return nil, err
+	}
+	if managed != nil {
+		return nil, managed
+	}
 	var selectedAsset *Asset
 	for _, asset := range latest.Assets {
 		if u.SelectAsset(*latest, asset) {
@@ -71,7 +106,7 @@ func (u *Updater) Update() (*Release, error) {
 	if selectedAsset == nil {
 		return nil, errors.New("no asset selected, use SelectAssetFunc to select an asset")
 	}
-	err = u.downloadAndReplaceApp(*selectedAsset)
+	err = u.downloadAndReplaceApp(*latest, *selectedAsset)
 	if err != nil {
 		return nil, errors.Wrap(err, "download update")
 	}
@@ -97,8 +132,11 @@ func (u *Updater) Restart() error {
 	return nil
 }
 
-// getLatestRelease gets the latest release.
+// getLatestRelease gets the latest release in the channel.
 func (u *Updater) getLatestRelease() (*Release, error) {
+	if u.AppcastURL != "" || (u.Channel != "" && u.Channel != ChannelStable) {
+		return u.getLatestChannelRelease()
+	}
 	resp, err := u.Client.Get(u.LatestReleaseGitHubEndpoint)
 	if err != nil {
 		return nil, errors.Wrap(err, "get latest release")
@@ -119,14 +157,40 @@ func (u *Updater) getLatestRelease() (*Release, error) {
 	return &latestRelease, nil
 }
 
+// getReleases gets all the releases.
+func (u *Updater) getReleases() ([]Release, error) {
+	if u.AppcastURL != "" {
+		return u.getAppcastReleases()
+	}
+	resp, err := u.Client.Get(u.releasesEndpoint())
+	if err != nil {
+		return nil, errors.Wrap(err, "get releases")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to get releases: got %s", resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, u.DownloadBytesLimit))
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+	var releases []Release
+	err = json.Unmarshal(b, &releases)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal")
+	}
+	return releases, nil
+}
+
 // HasUpdate checks whether there's an update or not.
+// Switching to a more stable Channel counts as an update, so the
+// latest release in that channel can be rolled back to.
 func (u *Updater) HasUpdate() (*Release, bool, error) {
 	latest, err := u.getLatestRelease()
 	if err != nil {
 		return nil, false, err
 	}
-	hasUpdate := hasUpdate(u.CurrentVersion, latest.TagName)
-	return latest, hasUpdate, nil
+	return latest, u.needsUpdate(latest.TagName), nil
 }
 
 // hasUpdate compares the current and latest version strings to
@@ -160,7 +224,7 @@ func hasUpdate(current, latest string) bool {
 	return true
 }
 
-func (u *Updater) downloadAndReplaceApp(asset Asset) error {
+func (u *Updater) downloadAndReplaceApp(release Release, asset Asset) error {
 	filename := path.Base(asset.BrowserDownloadURL)
 	switch {
 	case strings.HasSuffix(filename, ".tar.gz"):
@@ -168,22 +232,26 @@ func (u *Updater) downloadAndReplaceApp(asset Asset) error {
 	default:
 		return errors.Errorf("%s files not supported", filename)
 	}
-	resp, err := u.Client.Get(asset.BrowserDownloadURL)
+	// the download is kept in the same place, so if it drops, the
+	// next attempt can carry on from where it got to
+	dir, err := downloadDir()
 	if err != nil {
+		return errors.Wrap(err, "download directory")
+	}
+	downloadFilename := filepath.Join(dir, filename)
+	if err := u.download(asset.BrowserDownloadURL, downloadFilename, asset.Size); err != nil {
 		return errors.Wrap(err, "download asset")
 	}
-	defer resp.Body.Close()
-	const defaultTempDir = ""
-	f, err := os.CreateTemp(defaultTempDir, "*-"+filename)
-	if err != nil {
-		return errors.Wrap(err, "create temp file")
+	if err := u.verifyChecksum(release, asset, downloadFilename); err != nil {
+		os.Remove(downloadFilename)
+		return errors.Wrap(err, "verify checksum")
 	}
-	_, err = io.Copy(f, io.LimitReader(resp.Body, u.DownloadBytesLimit))
-	if err != nil {
-		f.Close()
-		return errors.Wrap(err, "download asset")
+	if u.PublicKey != "" {
+		if err := u.verifyAsset(release, asset, downloadFilename); err != nil {
+			os.Remove(downloadFilename)
+			return errors.Wrap(err, "verify signature")
+		}
 	}
-	f.Close()
 	executable, err := u.GetExecutable()
 	if err != nil {
 		return errors.Wrap(err, "get executable")
@@ -193,7 +261,12 @@ func (u *Updater) downloadAndReplaceApp(asset Asset) error {
 		return errors.Wrap(err, "find app path")
 	}
 	appPathDir := filepath.Dir(appPath)
-	appPreviousPath := appPath + ".previous"
+	appPreviousPath := appPath + previousAppSuffix
+	// only the version before this one is kept
+	err = os.RemoveAll(appPreviousPath)
+	if err != nil {
+		return errors.Wrap(err, "remove previous")
+	}
 	err = os.Rename(appPath, appPreviousPath)
 	if err != nil {
 		_, statErr := os.Stat(appPath)
@@ -202,10 +275,20 @@ func (u *Updater) downloadAndReplaceApp(asset Asset) error {
 			return errors.Wrap(err, "rename existing app")
 		}
 	}
-	err = archiver.Unarchive(f.Name(), appPathDir)
+	err = archiver.Unarchive(downloadFilename, appPathDir)
 	if err != nil {
+		// put the current app back
+		os.RemoveAll(appPath)
+		os.Rename(appPreviousPath, appPath)
 		return errors.Wrap(err, "unarchive")
 	}
+	err = os.Remove(downloadFilename)
+	if err != nil {
+		return errors.Wrap(err, "remove download")
+	}
+	if u.KeepPrevious {
+		return nil
+	}
 	err = os.RemoveAll(appPreviousPath)
 	if err != nil {
 		return errors.Wrap(err, "remove previous")
@@ -213,17 +296,68 @@ func (u *Updater) downloadAndReplaceApp(asset Asset) error {
 	return nil
 }
 
+// verifyAsset checks the downloaded asset (in filename) was signed
+// with u.PublicKey, using the signature asset in the release (or the
+// appcast signature of the asset).
+func (u *Updater) verifyAsset(release Release, asset Asset, filename string) error {
+	if asset.EdSignature != "" {
+		message, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		return verifyEdSignature(u.PublicKey, message, asset.EdSignature)
+	}
+	var signatureAsset *Asset
+	for i := range release.Assets {
+		if release.Assets[i].Name == asset.Name+signatureExt {
+			signatureAsset = &release.Assets[i]
+			break
+		}
+	}
+	if signatureAsset == nil {
+		return errors.Errorf("%s is not signed", asset.Name)
+	}
+	resp, err := u.Client.Get(signatureAsset.BrowserDownloadURL)
+	if err != nil {
+		return errors.Wrap(err, "download signature")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("download signature: got %s", resp.Status)
+	}
+	signature, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureBytes))
+	if err != nil {
+		return errors.Wrap(err, "download signature")
+	}
+	message, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return verifySignature(u.PublicKey, message, signature)
+}
+
 // Release is a GitHub release.
 type Release struct {
 	TagName string  `json:"tag_name"`
 	Assets  []Asset `json:"assets"`
 	Body    string  `json:"body"`
+	// Prerelease is whether the release is a prerelease, which only
+	// the beta and nightly channels get.
+	Prerelease bool `json:"prerelease"`
+	// Draft is whether the release is unpublished.
+	Draft bool `json:"draft"`
 }
 
 // Asset is a file within a Release on GitHub.
 type Asset struct {
 	Name               string `json:"name"`
 	BrowserDownloadURL string `json:"browser_download_url"`
+	// Size is the size of the asset in bytes, or zero if it isn't
+	// known.
+	Size int64 `json:"size"`
+	// EdSignature is the Ed25519 signature of the asset, from an
+	// appcast (see Updater.AppcastURL).
+	EdSignature string `json:"edSignature,omitempty"`
 }
 
 // appPathFromExecutable gets the .app path from the currently
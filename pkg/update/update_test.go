@@ -2,8 +2,11 @@ package update
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -50,26 +53,29 @@ func TestUpdate(t *testing.T) {
 		is.NoErr(err)
 	})
 
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	header := &tar.Header{
+		Name: "binary-name",
+		Size: 8,
+	}
+	err := tarWriter.WriteHeader(header)
+	is.NoErr(err)
+	n, err := tarWriter.Write([]byte("12345678")) // sample data
+	is.NoErr(err)                                 // tarWriter.Write
+	is.Equal(n, 8)                                // should write eight bytes only
+	is.NoErr(tarWriter.Close())
+	is.NoErr(gzipWriter.Close())
+	sum := sha256.Sum256(archive.Bytes())
+
 	downloadServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		gzipWriter := gzip.NewWriter(w)
-		defer func() {
-			err := gzipWriter.Close()
-			is.NoErr(err)
-		}()
-		tarWriter := tar.NewWriter(gzipWriter)
-		defer func() {
-			err := tarWriter.Close()
-			is.NoErr(err)
-		}()
-		header := &tar.Header{
-			Name: "binary-name",
-			Size: 8,
+		if r.URL.Path == "/checksums.txt" {
+			fmt.Fprintf(w, "%x  xbar-v2.0.0.tar.gz\n", sum)
+			return
 		}
-		err := tarWriter.WriteHeader(header)
+		_, err := w.Write(archive.Bytes())
 		is.NoErr(err)
-		n, err := tarWriter.Write([]byte("12345678")) // sample data
-		is.NoErr(err)                                 // tarWriter.Write
-		is.Equal(n, 8)                                // should write eight bytes only
 	}))
 	t.Cleanup(func() {
 		downloadServer.Close()
@@ -83,6 +89,10 @@ func TestUpdate(t *testing.T) {
 					Name:               "xbar-" + version + ".tar.gz",
 					BrowserDownloadURL: downloadServer.URL + "/xbar-" + version + ".tar.gz",
 				},
+				{
+					Name:               "checksums.txt",
+					BrowserDownloadURL: downloadServer.URL + "/checksums.txt",
+				},
 			},
 		}
 		b, err := json.Marshal(response)
@@ -104,10 +114,14 @@ func TestUpdate(t *testing.T) {
 		GetExecutable: func() (string, error) {
 			return "./testupdate-testarea/xbar.app/Contents/MacOS/xbar", nil
 		},
+		KeepPrevious: true,
 	}
+	is.NoErr(os.MkdirAll("./testupdate-testarea/xbar.app", 0777))
 	release, err := u.Update()
 	is.NoErr(err)
 	is.Equal(release.TagName, "v2.0.0")
+	_, err = os.Stat("./testupdate-testarea/xbar.app.previous")
+	is.NoErr(err) // previous version kept
 }
 
 func TestAppPathFromExecutable(t *testing.T) {
@@ -211,3 +225,74 @@ func TestLocalVersionIsHigher(t *testing.T) {
 	_, err = u.Update()
 	is.NoErr(err)
 }
+
+func TestChannels(t *testing.T) {
+	is := is.New(t)
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/releases/latest":
+			response = Release{TagName: "v2.1.0"}
+		case "/releases":
+			response = []Release{
+				{TagName: "v2.3.0-beta.1", Draft: true},
+				{TagName: "v2.2.0-nightly.20211016", Prerelease: true},
+				{TagName: "v2.2.0-beta.2", Prerelease: true},
+				{TagName: "v2.2.0-beta.1", Prerelease: true},
+				{TagName: "v2.1.0"},
+				{TagName: "v2.0.0"},
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		b, err := json.Marshal(response)
+		is.NoErr(err) // marshal
+		_, err = w.Write(b)
+		is.NoErr(err) // write
+	}))
+	t.Cleanup(func() {
+		apiServer.Close()
+	})
+
+	for _, test := range []struct {
+		channel        Channel
+		currentVersion string
+		latest         string
+		hasUpdate      bool
+	}{
+		{"", "v2.0.0", "v2.1.0", true},
+		{ChannelStable, "v2.1.0", "v2.1.0", false},
+		{ChannelBeta, "v2.1.0", "v2.2.0-beta.2", true},
+		{ChannelBeta, "v2.2.0-beta.2", "v2.2.0-beta.2", false},
+		{ChannelNightly, "v2.2.0-beta.2", "v2.2.0-nightly.20211016", true},
+		{ChannelStable, "v2.2.0-beta.2", "v2.1.0", true},                // roll back
+		{ChannelBeta, "v2.2.0-nightly.20211016", "v2.2.0-beta.2", true}, // roll back
+	} {
+		u := &Updater{
+			CurrentVersion:              test.currentVersion,
+			Channel:                     test.channel,
+			LatestReleaseGitHubEndpoint: apiServer.URL + "/releases/latest",
+			Client:                      &http.Client{Timeout: 10 * time.Second},
+			DownloadBytesLimit:          1_000_000,
+		}
+		latest, hasUpdate, err := u.HasUpdate()
+		is.NoErr(err)
+		is.Equal(latest.TagName, test.latest) // latest
+		is.Equal(hasUpdate, test.hasUpdate)   // hasUpdate
+	}
+}
+
+func TestParseChannel(t *testing.T) {
+	is := is.New(t)
+
+	channel, err := ParseChannel("")
+	is.NoErr(err)
+	is.Equal(channel, ChannelStable)
+	channel, err = ParseChannel("Beta")
+	is.NoErr(err)
+	is.Equal(channel, ChannelBeta)
+	_, err = ParseChannel("canary")
+	is.True(err != nil) // unsupported
+}
@@ -41,47 +41,78 @@ func (d *imageDownloader) DownloadImages(plugins []metadata.Plugin) {
 	wg.Wait()
 }
 
+// downloadImage downloads each of the plugin's images, and points
+// ImageURL and ImageURLs at the copies.
+// The first image is saved next to the plugin (like plugin.1h.sh.png),
+// and the others are numbered (like plugin.1h.sh.2.png).
 func (d *imageDownloader) downloadImage(plugin *metadata.Plugin) error {
-	ext := path.Ext(plugin.ImageURL)
-	imagePath := plugin.Path + ext
+	imageURLs := plugin.ImageURLs
+	if len(imageURLs) == 0 {
+		imageURLs = []string{plugin.ImageURL}
+	}
+	for i, imageURL := range imageURLs {
+		imagePath := plugin.Path
+		if i > 0 {
+			imagePath += fmt.Sprintf(".%d", i+1)
+		}
+		imagePath += path.Ext(imageURL)
+		downloaded, err := d.downloadImageURL(plugin, imageURL, imagePath)
+		if err != nil {
+			return err
+		}
+		if !downloaded {
+			continue
+		}
+		if i == 0 {
+			plugin.ImageURL = "https://xbarapp.com/docs/plugins/" + imagePath
+		}
+		if i < len(plugin.ImageURLs) {
+			plugin.ImageURLs[i] = "https://xbarapp.com/docs/plugins/" + imagePath
+		}
+	}
+	return nil
+}
+
+// downloadImageURL downloads the image to imagePath, returning false
+// (and noting why) if it can't.
+func (d *imageDownloader) downloadImageURL(plugin *metadata.Plugin, imageURL, imagePath string) (bool, error) {
 	_, err := os.Stat(imagePath)
 	if nil == err {
 		// the image is already there, no work to do
-		return nil
+		return false, nil
 	}
-	if plugin.ImageURL == "" {
+	if imageURL == "" {
 		plugin.ProcessingNotes = append(plugin.ProcessingNotes, "missing image URL")
-		return nil
+		return false, nil
 	}
-	resp, err := d.client.Get(plugin.ImageURL)
+	resp, err := d.client.Get(imageURL)
 	if err != nil {
 		plugin.ProcessingNotes = append(plugin.ProcessingNotes, fmt.Sprintf("unable to access image: %s", err))
-		return nil
+		return false, nil
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 && resp.StatusCode >= 400 {
 		plugin.ProcessingNotes = append(plugin.ProcessingNotes, fmt.Sprintf("unable to access image: got %d HTTP status code", resp.StatusCode))
-		return nil
+		return false, nil
 	}
 	fullImagePath := filepath.Join(d.outputDir, "plugins", imagePath)
 	if err := os.MkdirAll(filepath.Dir(fullImagePath), 0777); err != nil {
-		return err
+		return false, err
 	}
 	f, err := os.Create(fullImagePath)
 	if err != nil {
-		return errors.Wrap(err, "create")
+		return false, errors.Wrap(err, "create")
 	}
 	defer f.Close()
 	const mb = 1 << 20 // megabyte
 	if resp.ContentLength > 5*mb {
 		plugin.ProcessingNotes = append(plugin.ProcessingNotes, "image too big, should be less than 5MB")
-		return nil
+		return false, nil
 	}
 	_, err = io.Copy(f, io.LimitReader(resp.Body, 5*mb))
 	if err != nil {
-		return errors.Wrap(err, "copy")
+		return false, errors.Wrap(err, "copy")
 	}
-	plugin.ImageURL = "https://xbarapp.com/docs/plugins/" + imagePath
 	fmt.Print("🌆")
-	return nil
+	return true, nil
 }
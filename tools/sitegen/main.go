@@ -44,6 +44,7 @@ func run(ctx context.Context, args []string) error {
 		skipdata = flags.Bool("skipdata", false, "skip the data - just render the index template")
 		errs     = flags.Bool("errs", false, "print out error details")
 		nodocs   = flags.Bool("nodocs", false, "skip docs generation")
+		licenses = flags.String("licenses", "", "only include plugins with these licenses (comma separated SPDX identifiers, like MIT,Apache-2.0)")
 	)
 	if err := flags.Parse(args[1:]); err != nil {
 		return err
@@ -62,6 +63,9 @@ func run(ctx context.Context, args []string) error {
 	var allPlugins []metadata.Plugin
 	moonCycleIndex := 0
 	eachPlugin := EachFunc(func(plugin metadata.Plugin) {
+		if !hasLicense(plugin, *licenses) {
+			return
+		}
 		categoriesLock.Lock()
 		plugins = append(plugins, plugin)
 		metadata.CategoryEnsurePath(categories, nil, plugin.PathSegments)
@@ -864,6 +868,23 @@ func (g *generator) categoriesToCategory(categories map[string]metadata.Category
 	return cats
 }
 
+// hasLicense gets whether the plugin has one of the licenses (a
+// comma separated list of SPDX identifiers), or true if licenses
+// is empty.
+func hasLicense(plugin metadata.Plugin, licenses string) bool {
+	if licenses == "" {
+		return true
+	}
+	for _, license := range strings.Split(licenses, ",") {
+		for _, pluginLicense := range plugin.Licenses() {
+			if strings.EqualFold(strings.TrimSpace(license), pluginLicense) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func firstSegment(path string) string {
 	return strings.Split(path, "/")[0]
 }
@@ -155,6 +155,12 @@ func (r *RepoReader) loadPluginMetadata(ctx context.Context, gh *github.Client,
 	if err != nil {
 		return plugin, err
 	}
+	for _, diagnostic := range metadata.Lint(path, string(decodedContent)) {
+		if diagnostic.Severity == metadata.SeverityError {
+			return plugin, errors.Errorf("lint: %s", diagnostic)
+		}
+		plugin.ProcessingNotes = append(plugin.ProcessingNotes, diagnostic.String())
+	}
 	plugin.Path = path
 	plugin.DocsPlugin = path + ".html"
 	plugin.DocsCategory = filepath.Dir(path) + ".html"
@@ -0,0 +1,14 @@
+module github.com/matryer/xbar/tools/xbar
+
+go 1.16
+
+replace github.com/matryer/xbar/pkg/metadata => ../../pkg/metadata
+
+replace github.com/matryer/xbar/pkg/plugins => ../../pkg/plugins
+
+require (
+	github.com/matryer/is v1.4.0
+	github.com/matryer/xbar/pkg/metadata v0.0.0-00010101000000-000000000000
+	github.com/matryer/xbar/pkg/plugins v0.0.0-00010101000000-000000000000
+	github.com/pkg/errors v0.9.1
+)
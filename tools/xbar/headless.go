@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/matryer/xbar/pkg/plugins"
+	"github.com/pkg/errors"
+)
+
+// runHeadless runs the plugins without the menu bar (like on a
+// server, or in CI), until it is interrupted.
+func runHeadless(args []string) error {
+	flags := flag.NewFlagSet("headless", flag.ContinueOnError)
+	dir := flags.String("dir", defaultPluginDir, "the folder of plugins to run")
+	addr := flags.String("addr", "127.0.0.1:8739", "the address to serve the plugins' menus on")
+	token := flags.String("token", "", "the token requests must have (as Authorization: Bearer <token>), or empty to make one up")
+	verbose := flags.Bool("verbose", false, "log what the plugins are doing")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	host, _, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return errors.Wrap(err, "addr")
+	}
+	if *token == "" {
+		*token, err = newToken()
+		if err != nil {
+			return errors.Wrap(err, "make token")
+		}
+		log.Printf("headless: token: %s", *token)
+	}
+	pluginsToRun, err := plugins.Dir(*dir)
+	if err != nil {
+		return errors.Wrap(err, "load plugins")
+	}
+	if *verbose {
+		for _, plugin := range pluginsToRun {
+			plugin.Debugf = plugins.DebugfPrefix(filepath.Base(plugin.Command), plugins.DebugfLog)
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s := newHeadlessServer(pluginsToRun, *token)
+	s.localOnly = isLoopback(host)
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: s,
+	}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Close(); err != nil {
+			log.Println("headless: close:", err)
+		}
+	}()
+	go pluginsToRun.Run(ctx)
+	log.Printf("headless: running %d plugins from %s, serving on http://%s/plugins", len(pluginsToRun), *dir, *addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// pluginState is the latest menu of a running plugin.
+type pluginState struct {
+	// Path is the filename of the plugin, in the plugin folder.
+	Path string `json:"path"`
+	// Items are the parsed items of the menu (see plugins.Items), or
+	// nil if the plugin hasn't run yet.
+	Items *json.RawMessage `json:"items"`
+	// Error is why the plugin failed last time it ran, if it did.
+	Error string `json:"error,omitempty"`
+	// RefreshedAt is when the plugin last ran.
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
+
+// headlessServer keeps track of the menus of the running plugins,
+// and serves them over HTTP:
+//
+//	GET  /plugins           - gets the menus of all the plugins
+//	GET  /plugins/{path}    - gets the menu of the plugin
+//	POST /refresh           - refreshes all plugins
+//	POST /refresh/{path}    - refreshes the plugin
+//
+// Requests must have the token, as Authorization: Bearer <token>.
+type headlessServer struct {
+	// plugins are the running plugins, by path.
+	plugins map[string]*plugins.Plugin
+	// token is the token requests must have.
+	token string
+	// localOnly is whether requests must be for localhost (by the
+	// Host header), so web pages can't get at the server with DNS
+	// rebinding. It should be set when it listens on a loopback
+	// address.
+	localOnly bool
+
+	// lock protects states.
+	lock sync.Mutex
+	// states are the menus of the plugins, in the order they run.
+	states []*pluginState
+}
+
+// newHeadlessServer makes a headlessServer that keeps track of the
+// plugins, which should then be run, serving requests that have the
+// token.
+func newHeadlessServer(pluginsToRun plugins.Plugins, token string) *headlessServer {
+	s := &headlessServer{
+		plugins: make(map[string]*plugins.Plugin, len(pluginsToRun)),
+		token:   token,
+		states:  make([]*pluginState, 0, len(pluginsToRun)),
+	}
+	for _, plugin := range pluginsToRun {
+		path := filepath.Base(plugin.Command)
+		s.plugins[path] = plugin
+		s.states = append(s.states, &pluginState{Path: path})
+		plugin.OnRefresh = s.onRefresh
+	}
+	return s
+}
+
+// onRefresh keeps the menu of the plugin that just ran.
+func (s *headlessServer) onRefresh(_ context.Context, p *plugins.Plugin, err error) {
+	b, marshalErr := json.Marshal(p.Items)
+	if marshalErr != nil {
+		log.Println("headless:", p.Command, marshalErr)
+		return
+	}
+	items := json.RawMessage(b)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	state := s.findState(filepath.Base(p.Command))
+	if state == nil {
+		return
+	}
+	state.Items = &items
+	state.Error = ""
+	if err != nil {
+		state.Error = err.Error()
+	}
+	state.RefreshedAt = time.Now()
+}
+
+func (s *headlessServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.localOnly && !isLocalHost(r.Host) {
+		http.Error(w, "use localhost", http.StatusForbidden)
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="xbar"`)
+		http.Error(w, "missing or wrong token", http.StatusUnauthorized)
+		return
+	}
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "plugins" || strings.HasPrefix(path, "plugins/"):
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		s.serveStates(w, strings.TrimPrefix(strings.TrimPrefix(path, "plugins"), "/"))
+	case path == "refresh" || strings.HasPrefix(path, "refresh/"):
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		s.refresh(w, strings.TrimPrefix(strings.TrimPrefix(path, "refresh"), "/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveStates writes the menus of all the plugins, or just the one
+// at the path, as JSON.
+func (s *headlessServer) serveStates(w http.ResponseWriter, path string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var v interface{} = s.states
+	if path != "" {
+		state := s.findState(path)
+		if state == nil {
+			http.Error(w, "plugin "+path+" not found", http.StatusNotFound)
+			return
+		}
+		v = state
+	}
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// findState gets the state of the plugin at the path, or nil if
+// there isn't one.
+// The lock must be held.
+func (s *headlessServer) findState(path string) *pluginState {
+	for _, state := range s.states {
+		if state.Path == path {
+			return state
+		}
+	}
+	return nil
+}
+
+// refresh refreshes all the plugins, or just the one at the path.
+func (s *headlessServer) refresh(w http.ResponseWriter, path string) {
+	if path == "" {
+		for _, plugin := range s.plugins {
+			plugin.RequestRefresh()
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	plugin, ok := s.plugins[path]
+	if !ok {
+		http.Error(w, "plugin "+path+" not found", http.StatusNotFound)
+		return
+	}
+	plugin.RequestRefresh()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// authorized gets whether the request has the token.
+func (s *headlessServer) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// newToken makes a random token.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isLoopback gets whether the host (from an address to listen on) is
+// only reachable from this computer.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isLocalHost gets whether the Host header of a request is for this
+// computer.
+func isLocalHost(hostHeader string) bool {
+	host, _, err := net.SplitHostPort(hostHeader)
+	if err != nil {
+		// no port
+		host = strings.Trim(hostHeader, "[]")
+	}
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
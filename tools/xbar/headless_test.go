@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/matryer/xbar/pkg/plugins"
+)
+
+func TestHeadlessServer(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-headless-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	is.NoErr(ioutil.WriteFile(filepath.Join(dir, "001-hello.1m.sh"), []byte("#!/bin/sh\necho Hello\necho ---\necho World\n"), 0755))
+	is.NoErr(ioutil.WriteFile(filepath.Join(dir, "002-broken.1m.sh"), []byte("#!/bin/sh\nexit 1\n"), 0755))
+	pluginsToRun, err := plugins.Dir(dir)
+	is.NoErr(err)
+	is.Equal(len(pluginsToRun), 2)
+	s := newHeadlessServer(pluginsToRun, "s3cr3t")
+	s.localOnly = true
+	request := func(method, path string) *http.Request {
+		r := httptest.NewRequest(method, path, nil)
+		r.Host = "127.0.0.1:8739"
+		r.Header.Set("Authorization", "Bearer s3cr3t")
+		return r
+	}
+
+	get := func(path string, v interface{}) int {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, request(http.MethodGet, path))
+		if w.Code == http.StatusOK {
+			is.NoErr(json.Unmarshal(w.Body.Bytes(), v))
+		}
+		return w.Code
+	}
+
+	var states []pluginState
+	is.Equal(get("/plugins", &states), http.StatusOK)
+	is.Equal(len(states), 2)
+	is.Equal(states[0].Path, "001-hello.1m.sh")
+	is.Equal(states[0].Items, nil) // not run yet
+
+	for _, plugin := range pluginsToRun {
+		plugin.Refresh(context.Background())
+	}
+	var state pluginState
+	is.Equal(get("/plugins/001-hello.1m.sh", &state), http.StatusOK)
+	is.Equal(state.Error, "")
+	var items plugins.Items
+	is.NoErr(json.Unmarshal(*state.Items, &items))
+	is.Equal(len(items.CycleItems), 1)
+	is.Equal(items.CycleItems[0].Text, "Hello")
+	is.Equal(len(items.ExpandedItems), 1)
+	is.Equal(items.ExpandedItems[0].Text, "World")
+
+	is.Equal(get("/plugins/002-broken.1m.sh", &state), http.StatusOK)
+	is.True(state.Error != "") // failed
+
+	is.Equal(get("/plugins/nope.sh", &state), http.StatusNotFound)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, request(http.MethodPost, "/refresh/001-hello.1m.sh"))
+	is.Equal(w.Code, http.StatusAccepted)
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, request(http.MethodPost, "/refresh/nope.sh"))
+	is.Equal(w.Code, http.StatusNotFound)
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, request(http.MethodPost, "/plugins"))
+	is.Equal(w.Code, http.StatusMethodNotAllowed)
+
+	// without the token
+	r := request(http.MethodGet, "/plugins")
+	r.Header.Del("Authorization")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusUnauthorized)
+	r.Header.Set("Authorization", "Bearer guess")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusUnauthorized)
+
+	// from a web page, with DNS rebinding
+	r = request(http.MethodGet, "/plugins")
+	r.Host = "evil.example.com:8739"
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	is.Equal(w.Code, http.StatusForbidden)
+}
+
+func TestHeadlessHosts(t *testing.T) {
+	is := is.New(t)
+
+	is.True(isLoopback("127.0.0.1"))
+	is.True(isLoopback("::1"))
+	is.True(isLoopback("localhost"))
+	is.True(!isLoopback(""))
+	is.True(!isLoopback("0.0.0.0"))
+	is.True(!isLoopback("192.168.1.2"))
+
+	is.True(isLocalHost("localhost:8739"))
+	is.True(isLocalHost("127.0.0.1:8739"))
+	is.True(isLocalHost("[::1]:8739"))
+	is.True(isLocalHost("localhost"))
+	is.True(!isLocalHost("evil.example.com:8739"))
+	is.True(!isLocalHost("127.0.0.1.evil.example.com"))
+}
@@ -0,0 +1,65 @@
+// Command xbar runs and manages xbar plugins from the command line,
+// without the menu bar app.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// The same places the xbar app keeps things, so the command and the
+// app can be used together.
+var (
+	// supportDir is where xbar keeps its files.
+	supportDir = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", "xbar")
+	// defaultPluginDir is where the xbar app keeps its plugins.
+	defaultPluginDir = filepath.Join(supportDir, "plugins")
+	// installsFile records where installed plugins came from. See
+	// plugins.CheckForUpdates.
+	installsFile = filepath.Join(supportDir, "installs.json")
+	// pluginCacheDir, pluginDataDir and historyDir hold the
+	// state of the plugins, which is removed when they are
+	// uninstalled.
+	pluginCacheDir = filepath.Join(supportDir, "cache", "plugins")
+	pluginDataDir  = filepath.Join(supportDir, "data")
+	historyDir     = filepath.Join(supportDir, "history")
+)
+
+const usage = `usage: xbar <command> [arguments]
+
+commands:
+  headless    run the plugins without the menu bar, serving their menus over HTTP
+  plugin      install, list, remove, enable and disable plugins
+  run         run a plugin once and print the menu it makes
+
+Run xbar <command> -h for the arguments of a command.
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "xbar: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errors.New("missing command")
+	}
+	switch args[0] {
+	case "headless":
+		return runHeadless(args[1:])
+	case "plugin":
+		return runPlugin(args[1:], os.Stdout)
+	case "run":
+		return runRun(args[1:], os.Stdout, os.Stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+	return errors.Errorf("unknown command %q (see xbar help)", args[0])
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/matryer/xbar/pkg/plugins"
+	"github.com/pkg/errors"
+)
+
+// pluginRepositoryURL is where plugins are installed from.
+const pluginRepositoryURL = "https://xbarapp.com/docs/plugins/"
+
+const pluginUsage = `usage: xbar plugin <command> [-dir folder] [arguments]
+
+commands:
+  list                      list the installed plugins
+  install <path>            install a plugin from xbarapp.com, by its path (like Dev/GitHub/github-status.1m.sh)
+  remove <installed path>   remove an installed plugin
+  enable <installed path>   turn a disabled plugin back on
+  disable <installed path>  turn a plugin off, without removing it
+`
+
+// runPlugin manages the installed plugins, with the same code as the
+// xbar app, so plugin setup can be scripted (like in dotfiles).
+// The app picks up the changes when it next refreshes all plugins.
+func runPlugin(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stdout, pluginUsage)
+		return errors.New("missing plugin command")
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("plugin "+command, flag.ContinueOnError)
+	dir := flags.String("dir", defaultPluginDir, "the plugin folder")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	installer := plugins.Installer{
+		Client: &http.Client{
+			Timeout: 1 * time.Minute,
+		},
+		PluginDir:    *dir,
+		CacheDir:     pluginCacheDir,
+		DataDir:      pluginDataDir,
+		HistoryDir:   historyDir,
+		InstallsFile: installsFile,
+	}
+	switch command {
+	case "list":
+		if flags.NArg() != 0 {
+			return errors.New("usage: xbar plugin list")
+		}
+		return listPlugins(stdout, *dir)
+	case "install":
+		if flags.NArg() != 1 {
+			return errors.New("usage: xbar plugin install <path>")
+		}
+		pluginURL, err := pluginJSONURL(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		installedPluginPath, err := installer.Install(pluginURL)
+		if err != nil {
+			return errors.Wrap(err, "install")
+		}
+		fmt.Fprintln(stdout, installedPluginPath)
+		return nil
+	case "remove":
+		if flags.NArg() != 1 {
+			return errors.New("usage: xbar plugin remove <installed path>")
+		}
+		if err := installer.Uninstall(flags.Arg(0)); err != nil {
+			return errors.Wrap(err, "remove")
+		}
+		return nil
+	case "enable", "disable":
+		if flags.NArg() != 1 {
+			return errors.Errorf("usage: xbar plugin %s <installed path>", command)
+		}
+		newPath, err := plugins.SetEnabled(*dir, flags.Arg(0), command == "enable")
+		if err != nil {
+			return errors.Wrap(err, command)
+		}
+		fmt.Fprintln(stdout, newPath)
+		return nil
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, pluginUsage)
+		return nil
+	}
+	return errors.Errorf("unknown plugin command %q (see xbar plugin help)", command)
+}
+
+// listPlugins writes a table of the installed plugins in the
+// folder.
+func listPlugins(w io.Writer, dir string) error {
+	installedPlugins, err := plugins.GetInstalledPlugins(dir)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tENABLED")
+	for _, installedPlugin := range installedPlugins {
+		fmt.Fprintf(tw, "%s\t%v\n", installedPlugin.Path, installedPlugin.Enabled)
+	}
+	return tw.Flush()
+}
+
+// pluginJSONURL gets the URL of the metadata of the plugin to install,
+// from its path in the plugin repository, or a URL.
+func pluginJSONURL(path string) (*url.URL, error) {
+	if strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://") {
+		return url.Parse(path)
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".json")
+	if path == "" || strings.Contains(path, "..") {
+		return nil, errors.Errorf("bad plugin path %q", path)
+	}
+	return url.Parse(pluginRepositoryURL + path + ".json")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRunPlugin(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-plugin-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	// keep xbar's files out of the way
+	oldInstallsFile, oldCacheDir, oldDataDir, oldHistoryDir := installsFile, pluginCacheDir, pluginDataDir, historyDir
+	t.Cleanup(func() {
+		installsFile, pluginCacheDir, pluginDataDir, historyDir = oldInstallsFile, oldCacheDir, oldDataDir, oldHistoryDir
+	})
+	installsFile = filepath.Join(dir, "installs.json")
+	pluginCacheDir = filepath.Join(dir, "cache")
+	pluginDataDir = filepath.Join(dir, "data")
+	historyDir = filepath.Join(dir, "history")
+	pluginDir := filepath.Join(dir, "plugins")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.URL.Path, "/Fun/jokes.1h.sh.json")
+		_, _ = w.Write([]byte(`{"plugin": {
+			"path": "Fun/jokes.1h.sh",
+			"filename": "jokes.1h.sh",
+			"version": "v1.0",
+			"files": [{"path": "Fun/jokes.1h.sh", "filename": "jokes.1h.sh", "content": "#!/bin/sh\necho 'Knock knock'\n"}]
+		}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	xbarPlugin := func(args ...string) (string, error) {
+		var stdout bytes.Buffer
+		err := runPlugin(append(args[:1], append([]string{"-dir", pluginDir}, args[1:]...)...), &stdout)
+		return strings.TrimSpace(stdout.String()), err
+	}
+
+	out, err := xbarPlugin("install", srv.URL+"/Fun/jokes.1h.sh.json")
+	is.NoErr(err)
+	is.Equal(out, "001-jokes.1h.sh")
+	_, err = os.Stat(installsFile)
+	is.NoErr(err) // recorded, to check for updates
+
+	out, err = xbarPlugin("list")
+	is.NoErr(err)
+	is.Equal(out, "PATH             ENABLED\n001-jokes.1h.sh  true")
+
+	out, err = xbarPlugin("disable", "001-jokes.1h.sh")
+	is.NoErr(err)
+	is.Equal(out, "001-jokes.1h.sh.off")
+	out, err = xbarPlugin("enable", "001-jokes.1h.sh.off")
+	is.NoErr(err)
+	is.Equal(out, "001-jokes.1h.sh")
+
+	_, err = xbarPlugin("remove", "001-jokes.1h.sh")
+	is.NoErr(err)
+	out, err = xbarPlugin("list")
+	is.NoErr(err)
+	is.Equal(out, "PATH  ENABLED")
+
+	_, err = xbarPlugin("install")
+	is.True(err != nil) // needs a path
+	_, err = xbarPlugin("nope")
+	is.True(err != nil) // unknown command
+}
+
+func TestPluginJSONURL(t *testing.T) {
+	is := is.New(t)
+
+	u, err := pluginJSONURL("Dev/GitHub/github-status.1m.sh")
+	is.NoErr(err)
+	is.Equal(u.String(), "https://xbarapp.com/docs/plugins/Dev/GitHub/github-status.1m.sh.json")
+	u, err = pluginJSONURL("/Dev/GitHub/github-status.1m.sh.json")
+	is.NoErr(err)
+	is.Equal(u.String(), "https://xbarapp.com/docs/plugins/Dev/GitHub/github-status.1m.sh.json")
+	u, err = pluginJSONURL("http://localhost:8080/plugin.json")
+	is.NoErr(err)
+	is.Equal(u.String(), "http://localhost:8080/plugin.json")
+	_, err = pluginJSONURL("../secrets")
+	is.True(err != nil)
+	_, err = pluginJSONURL("")
+	is.True(err != nil)
+}
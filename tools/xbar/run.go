@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/matryer/xbar/pkg/plugins"
+	"github.com/pkg/errors"
+)
+
+// runResult is what xbar run -json prints.
+type runResult struct {
+	// Path is the plugin that ran.
+	Path string `json:"path"`
+	// Items are the parsed items of the menu (see plugins.Items).
+	Items plugins.Items `json:"items"`
+	// Warnings are problems that didn't stop the plugin from
+	// running, like its output being truncated, and anything it
+	// wrote to stderr.
+	Warnings []string `json:"warnings"`
+	// Error is why the plugin failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// runRun runs a plugin once, and prints the menu it makes, so
+// plugin authors can see how their output is parsed.
+func runRun(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "print the parsed items as JSON")
+	verbose := flags.Bool("verbose", false, "print what the plugin is doing")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: xbar run [-json] [-verbose] <plugin>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("run: missing plugin")
+	}
+	path, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return errors.Wrap(err, "run")
+	}
+	plugin := plugins.NewPlugin(path)
+	plugin.SetStateDirs(pluginCacheDir, pluginDataDir)
+	var warnings []string
+	plugin.OnWarning = func(p *plugins.Plugin, err error) {
+		warnings = append(warnings, err.Error())
+	}
+	if *verbose {
+		plugin.Debugf = func(format string, v ...interface{}) {
+			fmt.Fprintln(stderr, "xbar:", fmt.Sprintf(format, v...))
+		}
+	}
+	var pluginStderr bytes.Buffer
+	plugin.Stderr = &pluginStderr
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	runErr := plugin.RunOnce(ctx)
+	scanner := bufio.NewScanner(&pluginStderr)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			warnings = append(warnings, "stderr: "+line)
+		}
+	}
+	if *asJSON {
+		result := runResult{
+			Path:     path,
+			Items:    plugin.Items,
+			Warnings: warnings,
+		}
+		if result.Warnings == nil {
+			result.Warnings = []string{}
+		}
+		if runErr != nil {
+			result.Error = runErr.Error()
+		}
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		printItems(stdout, plugin.Items)
+		for _, warning := range warnings {
+			fmt.Fprintln(stderr, "warning:", warning)
+		}
+	}
+	if runErr != nil {
+		return errors.Wrap(runErr, "run")
+	}
+	return nil
+}
+
+// printItems prints the menu as indented text, with the parameters
+// of each item that aren't the defaults.
+func printItems(w io.Writer, items plugins.Items) {
+	fmt.Fprintln(w, "menu bar:")
+	for _, item := range items.CycleItems {
+		printItem(w, item, 1)
+	}
+	fmt.Fprintln(w, "menu:")
+	for _, item := range items.ExpandedItems {
+		printItem(w, item, 1)
+	}
+	for i, split := range items.Splits {
+		fmt.Fprintf(w, "split %d:\n", i+1)
+		printItems(&indentWriter{w: w}, split)
+	}
+}
+
+// printItem prints the item and its sub menu, indented to depth.
+func printItem(w io.Writer, item *plugins.Item, depth int) {
+	indent := strings.Repeat("  ", depth)
+	text := item.Text
+	if item.Params.Separator {
+		text = "---"
+	}
+	if params := itemParams(item.Params); params != "" {
+		text += "  [" + params + "]"
+	}
+	fmt.Fprintln(w, indent+text)
+	if item.Alternate != nil {
+		fmt.Fprintf(w, "%s  (alternate) %s\n", indent, item.Alternate.Text)
+	}
+	for _, child := range item.Items {
+		printItem(w, child, depth+1)
+	}
+}
+
+// itemParams describes the params that are different from the
+// defaults, like `color=red href=https://xbarapp.com`.
+func itemParams(params plugins.ItemParams) string {
+	values, err := paramsMap(params)
+	if err != nil {
+		return ""
+	}
+	defaults, err := paramsMap(plugins.DefaultItemParams())
+	if err != nil {
+		return ""
+	}
+	var changed []string
+	for key, value := range values {
+		if key == "separator" {
+			continue
+		}
+		b, _ := json.Marshal(value)
+		d, _ := json.Marshal(defaults[key])
+		if bytes.Equal(b, d) {
+			continue
+		}
+		s := fmt.Sprint(value)
+		if str, ok := value.(string); !ok || strings.ContainsAny(str, " \t\"") {
+			s = string(b)
+		}
+		changed = append(changed, key+"="+s)
+	}
+	sort.Strings(changed)
+	return strings.Join(changed, " ")
+}
+
+// paramsMap gets the params as they appear in JSON.
+func paramsMap(params plugins.ItemParams) (map[string]interface{}, error) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// indentWriter indents every line written to w.
+type indentWriter struct {
+	w io.Writer
+}
+
+func (i *indentWriter) Write(b []byte) (int, error) {
+	lines := strings.SplitAfter(string(b), "\n")
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if _, err := io.WriteString(i.w, "  "+line); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
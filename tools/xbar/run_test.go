@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRunRun(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "xbar-run-test")
+	is.NoErr(err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	oldCacheDir, oldDataDir := pluginCacheDir, pluginDataDir
+	t.Cleanup(func() {
+		pluginCacheDir, pluginDataDir = oldCacheDir, oldDataDir
+	})
+	pluginCacheDir = filepath.Join(dir, "cache")
+	pluginDataDir = filepath.Join(dir, "data")
+	pluginPath := filepath.Join(dir, "hello.1m.sh")
+	err = ioutil.WriteFile(pluginPath, []byte(`#!/bin/sh
+echo "Hello"
+echo "---"
+echo "Open | href=https://xbarapp.com color=red"
+echo "--Sub item"
+echo "careful" >&2
+`), 0755)
+	is.NoErr(err)
+
+	var stdout, stderr bytes.Buffer
+	err = runRun([]string{pluginPath}, &stdout, &stderr)
+	is.NoErr(err)
+	is.Equal(stdout.String(), `menu bar:
+  Hello
+menu:
+  Open  [color=#ff0000 href=https://xbarapp.com]
+    Sub item
+`)
+	is.Equal(strings.TrimSpace(stderr.String()), "warning: stderr: careful")
+
+	stdout.Reset()
+	err = runRun([]string{"-json", pluginPath}, &stdout, ioutil.Discard)
+	is.NoErr(err)
+	var result runResult
+	is.NoErr(json.Unmarshal(stdout.Bytes(), &result))
+	is.Equal(result.Path, pluginPath)
+	is.Equal(len(result.Items.CycleItems), 1)
+	is.Equal(result.Items.ExpandedItems[0].Text, "Open")
+	is.Equal(result.Items.ExpandedItems[0].Items[0].Text, "Sub item")
+	is.Equal(result.Warnings, []string{"stderr: careful"})
+	is.Equal(result.Error, "")
+
+	// failing plugins print the error, and the command fails
+	err = ioutil.WriteFile(pluginPath, []byte("#!/bin/sh\nexit 1\n"), 0755)
+	is.NoErr(err)
+	stdout.Reset()
+	err = runRun([]string{"-json", pluginPath}, &stdout, ioutil.Discard)
+	is.True(err != nil)
+	is.NoErr(json.Unmarshal(stdout.Bytes(), &result))
+	is.True(result.Error != "")
+
+	// metadata and images that can't be used are warnings
+	err = ioutil.WriteFile(pluginPath, []byte(`#!/bin/sh
+# <xbar.cycle>often</xbar.cycle>
+echo "Hello | image=@@@"
+`), 0755)
+	is.NoErr(err)
+	stdout.Reset()
+	err = runRun([]string{"-json", pluginPath}, &stdout, ioutil.Discard)
+	is.NoErr(err)
+	result = runResult{}
+	is.NoErr(json.Unmarshal(stdout.Bytes(), &result))
+	is.Equal(len(result.Warnings), 2)
+	is.True(strings.Contains(result.Warnings[0], "xbar.cycle"))
+	is.True(strings.Contains(result.Warnings[1], "ignoring the image"))
+
+	// streamable plugins show their first menu
+	err = ioutil.WriteFile(pluginPath, []byte(`#!/bin/sh
+# <xbar.streamable>true</xbar.streamable>
+echo "First"
+echo "~~~"
+sleep 60
+`), 0755)
+	is.NoErr(err)
+	stdout.Reset()
+	err = runRun([]string{pluginPath}, &stdout, ioutil.Discard)
+	is.NoErr(err)
+	is.Equal(stdout.String(), "menu bar:\n  First\nmenu:\n")
+
+	err = runRun(nil, &stdout, ioutil.Discard)
+	is.True(err != nil) // missing plugin
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -21,6 +22,14 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	diagnostics := metadata.Lint("stdin", string(input))
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Line == 0 {
+			fmt.Fprintf(os.Stderr, "stdin: %s\n", diagnostic)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "stdin:%s\n", diagnostic)
+	}
 	md, err := metadata.Parse(metadata.DebugfLog, "stdin", string(input))
 	if err != nil {
 		return err
@@ -33,5 +42,10 @@ func run() error {
 	if err := md.Validate(); err != nil {
 		return err
 	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == metadata.SeverityError {
+			return errors.New("metadata has errors")
+		}
+	}
 	return nil
 }